@@ -0,0 +1,218 @@
+package bundle
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/split"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const resourcesSeparator = "---\n"
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Combines all the kubernetes resources in a directory tree into a single multi-document YAML file ordered so that it can be applied in one go
+
+The resources are ordered by their kind: namespaces, custom resource definitions, RBAC, configuration, services and workloads then ingresses. Resources of any other kinds such as custom resources are added last. Resources of the same priority are ordered by their file path.
+`)
+
+	cmdExample = templates.Examples(`
+		# writes the resources in config-root to a single file
+		%s bundle --dir config-root --output all.yaml
+
+		# writes the resources to stdout
+		%s bundle --dir config-root | kubectl apply -f -
+	`)
+
+	// KindOrder the kinds in the order they are added to the bundle
+	KindOrder = []string{
+		// namespaces
+		"Namespace",
+
+		// custom resource definitions
+		"CustomResourceDefinition",
+
+		// RBAC
+		"ServiceAccount",
+		"ClusterRole",
+		"ClusterRoleBinding",
+		"Role",
+		"RoleBinding",
+
+		// configuration
+		"PriorityClass",
+		"StorageClass",
+		"ResourceQuota",
+		"LimitRange",
+		"NetworkPolicy",
+		"PodSecurityPolicy",
+		"ConfigMap",
+		"Secret",
+		"PersistentVolume",
+		"PersistentVolumeClaim",
+
+		// services and workloads
+		"Service",
+		"DaemonSet",
+		"Pod",
+		"ReplicaSet",
+		"Deployment",
+		"StatefulSet",
+		"Job",
+		"CronJob",
+		"HorizontalPodAutoscaler",
+		"PodDisruptionBudget",
+
+		// ingress
+		"Ingress",
+	}
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir       string
+	Output    string
+	Out       io.Writer
+	Resources []Resource
+}
+
+// Resource a resource in the bundle
+type Resource struct {
+	Path     string
+	Kind     string
+	Name     string
+	Priority int
+	Text     string
+}
+
+// NewCmdBundle creates a command object for the command
+func NewCmdBundle() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "bundle",
+		Short:   "Combines all the kubernetes resources in a directory tree into a single multi-document YAML file in apply order",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "", "config-root", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "the file to write the bundle to. Defaults to stdout")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	filterFn, err := o.Filter.ToFilterFn()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create filter")
+	}
+
+	o.Resources = nil
+	err = filepath.Walk(o.Dir, func(path string, info os.FileInfo, err error) error {
+		if info == nil || info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read file %s", path)
+		}
+		for i, doc := range split.Documents(string(data)) {
+			node, err := yaml.Parse(doc)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse resource %d of YAML file %s", i+1, path)
+			}
+			include, err := filterFn(node, path)
+			if err != nil {
+				return errors.Wrapf(err, "failed to evaluate filter on %s", path)
+			}
+			if !include {
+				continue
+			}
+			text, err := node.String()
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal resource %d of YAML file %s", i+1, path)
+			}
+			kind := kyamls.GetKind(node, path)
+			o.Resources = append(o.Resources, Resource{
+				Path:     path,
+				Kind:     kind,
+				Name:     kyamls.GetName(node, path),
+				Priority: KindPriority(kind),
+				Text:     text,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find resources in dir %s", o.Dir)
+	}
+
+	// the walk visits the files in lexical order so a stable sort keeps the resources of each priority in path order
+	sort.SliceStable(o.Resources, func(i, j int) bool {
+		return o.Resources[i].Priority < o.Resources[j].Priority
+	})
+
+	buf := strings.Builder{}
+	for i, r := range o.Resources {
+		if i > 0 {
+			buf.WriteString(resourcesSeparator)
+		}
+		buf.WriteString(r.Text)
+	}
+
+	if o.Output == "" {
+		_, err = fmt.Fprint(o.Out, buf.String())
+		return err
+	}
+	dir := filepath.Dir(o.Output)
+	err = os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	err = ioutil.WriteFile(o.Output, []byte(buf.String()), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", o.Output)
+	}
+	log.Logger().Infof("wrote %d resources to %s", len(o.Resources), info(o.Output))
+	return nil
+}
+
+// KindPriority returns the index of the kind in the KindOrder or the number of known kinds if the kind is not known
+// so that any custom resources are applied last
+func KindPriority(kind string) int {
+	for i, k := range KindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(KindOrder)
+}
@@ -0,0 +1,69 @@
+package bundle_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/bundle"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/split"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestBundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output", "bundle.yaml")
+
+	_, o := bundle.NewCmdBundle()
+	o.Dir = filepath.Join("test_data", "source")
+	o.Output = path
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "failed to load %s", path)
+	t.Logf("%s\n", string(data))
+
+	assert.Equal(t, []string{
+		"Namespace/myapp",
+		"CustomResourceDefinition/widgets.example.com",
+		"ServiceAccount/api",
+		"Role/api",
+		"RoleBinding/api",
+		"ConfigMap/api-config",
+		"Service/api",
+		"Deployment/api",
+		"Ingress/api",
+		"Widget/api",
+	}, bundleKinds(t, string(data)), "resources in the bundle")
+}
+
+func TestBundleFilter(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	_, o := bundle.NewCmdBundle()
+	o.Dir = filepath.Join("test_data", "source")
+	o.Out = buf
+	o.Filter.Kinds = []string{"Deployment", "Namespace", "Service"}
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	assert.Equal(t, []string{
+		"Namespace/myapp",
+		"Service/api",
+		"Deployment/api",
+	}, bundleKinds(t, buf.String()), "resources in the bundle")
+}
+
+func bundleKinds(t *testing.T, text string) []string {
+	var answer []string
+	for i, doc := range split.Documents(text) {
+		node, err := yaml.Parse(doc)
+		require.NoError(t, err, "failed to parse document %d", i+1)
+		answer = append(answer, kyamls.GetKind(node, "")+"/"+kyamls.GetName(node, ""))
+	}
+	return answer
+}
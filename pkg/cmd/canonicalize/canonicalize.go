@@ -0,0 +1,125 @@
+package canonicalize
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Rewrites all kubernetes resources in the given directory tree into a canonical form so that diffs only show real changes
+
+The 'status' of each resource, any 'creationTimestamp: null' and any empty 'metadata' fields are removed and the keys are sorted using the kubernetes field ordering.
+`)
+
+	cmdExample = templates.Examples(`
+		# canonicalizes all the resources in the current directory
+		%s canonicalize
+
+		# canonicalizes all the resources in a directory
+		%s canonicalize --dir config-root
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir string
+}
+
+// NewCmdCanonicalize creates a command object for the command
+func NewCmdCanonicalize() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "canonicalize",
+		Aliases: []string{"canonical"},
+		Short:   "Rewrites all kubernetes resources in the given directory tree into a canonical form so that diffs only show real changes",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		before, err := node.String()
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to marshal YAML")
+		}
+		err = Canonicalize(node)
+		if err != nil {
+			return false, err
+		}
+		after, err := node.String()
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to marshal YAML")
+		}
+		return before != after, nil
+	}
+	return kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+}
+
+// Canonicalize converts the resource into its canonical form by removing the status,
+// any empty metadata fields and sorting the keys
+func Canonicalize(node *yaml.RNode) error {
+	err := node.PipeE(yaml.Clear("status"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove status")
+	}
+
+	metadata, err := node.Pipe(yaml.Get("metadata"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get metadata")
+	}
+	if metadata != nil && metadata.YNode().Kind == yaml.MappingNode {
+		fields, err := metadata.Fields()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get metadata fields")
+		}
+		for _, name := range fields {
+			value := metadata.Field(name).Value
+			if !isEmpty(value.YNode()) {
+				continue
+			}
+			err = metadata.PipeE(yaml.Clear(name))
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove empty metadata.%s", name)
+			}
+		}
+	}
+
+	_, err = filters.FormatFilter{}.Filter([]*yaml.RNode{node})
+	if err != nil {
+		return errors.Wrapf(err, "failed to sort keys")
+	}
+	return nil
+}
+
+func isEmpty(n *yaml.Node) bool {
+	if n == nil {
+		return true
+	}
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(n.Content) == 0
+	case yaml.ScalarNode:
+		return n.Tag == yaml.NodeTagNull || n.Value == ""
+	}
+	return false
+}
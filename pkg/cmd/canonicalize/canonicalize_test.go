@@ -0,0 +1,44 @@
+package canonicalize_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/canonicalize"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "could not create temp dir")
+
+	err = files.CopyDirOverwrite(filepath.Join("test_data", "input"), tmpDir)
+	require.NoError(t, err, "failed to copy test files to %s", tmpDir)
+
+	_, o := canonicalize.NewCmdCanonicalize()
+	o.Dir = tmpDir
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run canonicalize")
+
+	expectedDir := filepath.Join("test_data", "expected")
+	for _, name := range []string{"deployment.yaml", "service.yaml"} {
+		testhelpers.AssertTextFilesEqual(t, filepath.Join(expectedDir, name), filepath.Join(tmpDir, name), "canonical file "+name)
+
+		data, err := ioutil.ReadFile(filepath.Join(tmpDir, name))
+		require.NoError(t, err, "failed to read %s", name)
+		assert.NotContains(t, string(data), "status:", "should have removed the status from %s", name)
+		assert.NotContains(t, string(data), "creationTimestamp", "should have removed the creationTimestamp from %s", name)
+	}
+
+	// lets check the canonical form is stable
+	err = o.Run()
+	require.NoError(t, err, "failed to run canonicalize again")
+	for _, name := range []string{"deployment.yaml", "service.yaml"} {
+		testhelpers.AssertTextFilesEqual(t, filepath.Join(expectedDir, name), filepath.Join(tmpDir, name), "canonical file "+name+" after second run")
+	}
+}
@@ -0,0 +1,166 @@
+package commonannotations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Merges the common annotations from a base file into all kubernetes resources in the given directory tree
+
+The base file is either a kustomization style file with a 'commonAnnotations' map or a plain map of annotation names to values.
+Existing annotations on a resource are only replaced if --overwrite is specified.
+`)
+
+	cmdExample = templates.Examples(`
+		# merges the annotations from a kustomization file into all the resources in the current directory
+		%s common-annotations --file base/kustomization.yaml
+
+		# merges the annotations from a base file replacing any existing values
+		%s common-annotations --file base/annotations.yaml --dir config-root --overwrite
+	`)
+)
+
+// Base the base file of common annotations
+type Base struct {
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+}
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir         string
+	File        string
+	Overwrite   bool
+	Annotations map[string]string
+}
+
+// NewCmdCommonAnnotations creates a command object for the command
+func NewCmdCommonAnnotations() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "common-annotations",
+		Short:   "Merges the common annotations from a base file into all kubernetes resources in the given directory tree",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringVarP(&o.File, "file", "f", "", "the base YAML file containing the common annotations")
+	cmd.Flags().BoolVarP(&o.Overwrite, "overwrite", "", false, "replace the value of annotations which already exist on a resource")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.Annotations != nil {
+		return nil
+	}
+	if o.File == "" {
+		return options.MissingOption("file")
+	}
+	annotations, err := LoadAnnotations(o.File)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load common annotations")
+	}
+	o.Annotations = annotations
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	if len(o.Annotations) == 0 {
+		return nil
+	}
+
+	var keys []string
+	for k := range o.Annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	baseFile := ""
+	if o.File != "" {
+		baseFile, err = filepath.Abs(o.File)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find absolute path of %s", o.File)
+		}
+	}
+
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		if baseFile != "" {
+			p, err := filepath.Abs(path)
+			if err == nil && p == baseFile {
+				return false, nil
+			}
+		}
+		modified := false
+		for _, k := range keys {
+			v := o.Annotations[k]
+			if !o.Overwrite {
+				existing, err := node.Pipe(yaml.GetAnnotation(k))
+				if err != nil {
+					return false, errors.Wrapf(err, "failed to get annotation %s", k)
+				}
+				if existing != nil {
+					continue
+				}
+			}
+			err := node.PipeE(yaml.SetAnnotation(k, v))
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to set annotation %s=%s", k, v)
+			}
+			modified = true
+		}
+		return modified, nil
+	}
+
+	err = kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to merge common annotations into dir %s", o.Dir)
+	}
+	return nil
+}
+
+// LoadAnnotations loads the annotations from either a kustomization style file with
+// a commonAnnotations map or a plain map of annotations
+func LoadAnnotations(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", path)
+	}
+	base := &Base{}
+	err = sigsyaml.Unmarshal(data, base)
+	if err == nil && base.CommonAnnotations != nil {
+		return base.CommonAnnotations, nil
+	}
+
+	annotations := map[string]string{}
+	err = sigsyaml.Unmarshal(data, &annotations)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse file %s as a map of annotations or a file with commonAnnotations", path)
+	}
+	return annotations, nil
+}
@@ -0,0 +1,73 @@
+package commonannotations_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/commonannotations"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommonAnnotations(t *testing.T) {
+	testCases := []struct {
+		name        string
+		file        string
+		overwrite   bool
+		expectedDir string
+	}{
+		{
+			name:        "kustomization",
+			file:        "kustomization.yaml",
+			expectedDir: "expected",
+		},
+		{
+			name:        "plain-map",
+			file:        "annotations.yaml",
+			expectedDir: "expected",
+		},
+		{
+			name:        "overwrite",
+			file:        "kustomization.yaml",
+			overwrite:   true,
+			expectedDir: "expected-overwrite",
+		},
+	}
+
+	for _, tc := range testCases {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "could not create temp dir")
+
+		err = files.CopyDirOverwrite(filepath.Join("test_data", "source"), tmpDir)
+		require.NoError(t, err, "failed to copy source files to %s", tmpDir)
+
+		_, o := commonannotations.NewCmdCommonAnnotations()
+		o.Dir = tmpDir
+		o.File = filepath.Join("test_data", tc.file)
+		o.Overwrite = tc.overwrite
+		err = o.Run()
+		require.NoError(t, err, "failed to run for %s", tc.name)
+
+		for _, name := range []string{"annotated.yaml", "plain.yaml"} {
+			resultFile := filepath.Join(tmpDir, name)
+			expectedFile := filepath.Join("test_data", tc.expectedDir, name)
+
+			result, err := ioutil.ReadFile(resultFile)
+			require.NoError(t, err, "failed to load %s", resultFile)
+			expected, err := ioutil.ReadFile(expectedFile)
+			require.NoError(t, err, "failed to load %s", expectedFile)
+
+			assert.Equal(t, strings.TrimSpace(string(expected)), strings.TrimSpace(string(result)), "for %s file %s", tc.name, name)
+		}
+	}
+}
+
+func TestCommonAnnotationsMissingFile(t *testing.T) {
+	_, o := commonannotations.NewCmdCommonAnnotations()
+	o.Dir = filepath.Join("test_data", "source")
+	err := o.Run()
+	require.Error(t, err, "should fail without a --file")
+}
@@ -0,0 +1,177 @@
+package conftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Validates the kubernetes resources in a directory against the Rego policies in a policy directory using Conftest
+
+The conftest binary plugin is downloaded if no binary is specified. Any policy failures are reported and the command fails
+`)
+
+	cmdExample = templates.Examples(`
+		# validates the resources in the config-root dir against the policies in the policy dir
+		%s conftest
+
+		# validates the resources against the policies of all the packages in a custom policy dir
+		%s conftest --dir config-root --policy my-policies --all-namespaces
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir           string
+	PolicyDir     string
+	ConftestBin   string
+	AllNamespaces bool
+	Failures      []Failure
+	CommandRunner cmdrunner.CommandRunner
+}
+
+// Failure a policy failure of a file
+type Failure struct {
+	Path    string
+	Message string
+}
+
+// Result the conftest JSON output for a file
+type Result struct {
+	Filename  string    `json:"filename"`
+	Namespace string    `json:"namespace"`
+	Failures  []Message `json:"failures"`
+	Warnings  []Message `json:"warnings"`
+}
+
+// Message a failure or warning message
+type Message struct {
+	Msg string `json:"msg"`
+}
+
+// NewCmdConftest creates a command object for the command
+func NewCmdConftest() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "conftest",
+		Short:   "Validates the kubernetes resources in a directory against Rego policies using Conftest",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", "config-root", "the directory to recursively look for the *.yaml or *.yml files to validate")
+	cmd.Flags().StringVarP(&o.PolicyDir, "policy", "p", "policy", "the directory containing the Rego policies")
+	cmd.Flags().BoolVarP(&o.AllNamespaces, "all-namespaces", "", false, "validates against the policies of all the Rego packages rather than just the main package")
+	cmd.Flags().StringVarP(&o.ConftestBin, "bin", "", "", "the 'conftest' binary name to use. If not specified this command will download the jx binary plugin into ~/.jx3/plugins/bin and use that")
+	return cmd, o
+}
+
+// Validate verifies the options
+func (o *Options) Validate() error {
+	if o.Dir == "" {
+		return options.MissingOption("dir")
+	}
+	if o.PolicyDir == "" {
+		return options.MissingOption("policy")
+	}
+	exists, err := files.DirExists(o.PolicyDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if policy dir exists %s", o.PolicyDir)
+	}
+	if !exists {
+		return errors.Errorf("policy dir %s does not exist", o.PolicyDir)
+	}
+	if o.CommandRunner == nil {
+		o.CommandRunner = cmdrunner.QuietCommandRunner
+	}
+	if o.ConftestBin == "" {
+		o.ConftestBin, err = plugins.GetConftestBinary(plugins.ConftestVersion)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	o.Failures = nil
+
+	args := []string{"test", "--policy", o.PolicyDir, "--output", "json"}
+	if o.AllNamespaces {
+		args = append(args, "--all-namespaces")
+	}
+	args = append(args, o.Dir)
+	c := &cmdrunner.Command{
+		Name: o.ConftestBin,
+		Args: args,
+	}
+	// conftest exits with a non zero code if there are failures so parse the output before checking the error
+	text, runErr := o.CommandRunner(c)
+	results, err := ParseResults(text)
+	if err != nil {
+		if runErr != nil {
+			return errors.Wrapf(runErr, "failed to run %s", cmdrunner.CLI(c))
+		}
+		return errors.Wrapf(err, "failed to parse the output of %s", cmdrunner.CLI(c))
+	}
+
+	for i := range results {
+		r := &results[i]
+		for _, w := range r.Warnings {
+			log.Logger().Warnf("file %s: %s", r.Filename, w.Msg)
+		}
+		for _, f := range r.Failures {
+			o.Failures = append(o.Failures, Failure{Path: r.Filename, Message: f.Msg})
+		}
+	}
+	for _, f := range o.Failures {
+		log.Logger().Warnf("file %s: %s", info(f.Path), f.Message)
+	}
+	if len(o.Failures) > 0 {
+		return errors.Errorf("found %d policy failures in dir %s", len(o.Failures), o.Dir)
+	}
+	if runErr != nil {
+		return errors.Wrapf(runErr, "failed to run %s", cmdrunner.CLI(c))
+	}
+	log.Logger().Infof("no policy failures found in dir %s", info(o.Dir))
+	return nil
+}
+
+// ParseResults parses the conftest JSON output
+func ParseResults(text string) ([]Result, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.Errorf("no output")
+	}
+	var results []Result
+	err := json.Unmarshal([]byte(text), &results)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal conftest results")
+	}
+	return results, nil
+}
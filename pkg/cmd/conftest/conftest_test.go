@@ -0,0 +1,89 @@
+package conftest_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/conftest"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner/fakerunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConftest(t *testing.T) {
+	policyDir := filepath.Join("test_data", "policy")
+
+	testCases := []struct {
+		name     string
+		failures []conftest.Failure
+	}{
+		{
+			name: "pass",
+		},
+		{
+			name: "fail",
+			failures: []conftest.Failure{
+				{
+					Path:    "test_data/fail/config-root/deployment.yaml",
+					Message: "Deployment cheese must not run as root",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		dir := filepath.Join("test_data", tc.name, "config-root")
+
+		// fakes the conftest output for the fixture as conftest exits with a non zero code on failures
+		runner := &fakerunner.FakeRunner{
+			CommandRunner: func(c *cmdrunner.Command) (string, error) {
+				data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(dir), "output.json"))
+				if err != nil {
+					return "", err
+				}
+				if len(tc.failures) > 0 {
+					return string(data), errors.New("exit status 1")
+				}
+				return string(data), nil
+			},
+		}
+
+		_, o := conftest.NewCmdConftest()
+		o.ConftestBin = "conftest"
+		o.CommandRunner = runner.Run
+		o.Dir = dir
+		o.PolicyDir = policyDir
+
+		err := o.Run()
+		if len(tc.failures) > 0 {
+			require.Error(t, err, "should fail for %s", tc.name)
+		} else {
+			require.NoError(t, err, "should pass for %s", tc.name)
+		}
+
+		runner.ExpectResults(t,
+			fakerunner.FakeResult{CLI: "conftest test --policy " + policyDir + " --output json " + dir},
+		)
+		assert.Equal(t, tc.failures, o.Failures, "failures for %s", tc.name)
+	}
+}
+
+func TestConftestRunError(t *testing.T) {
+	runner := &fakerunner.FakeRunner{
+		ResultOutput: "Error: running test: load: loading policies: no policies found",
+		ResultError:  errors.New("exit status 1"),
+	}
+
+	_, o := conftest.NewCmdConftest()
+	o.ConftestBin = "conftest"
+	o.CommandRunner = runner.Run
+	o.Dir = filepath.Join("test_data", "pass", "config-root")
+	o.PolicyDir = filepath.Join("test_data", "policy")
+
+	err := o.Run()
+	require.Error(t, err, "should fail if conftest fails without reporting results")
+	assert.Contains(t, err.Error(), "exit status 1")
+}
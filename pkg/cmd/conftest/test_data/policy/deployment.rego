@@ -0,0 +1,8 @@
+package main
+
+deny[msg] {
+  input.kind == "Deployment"
+  not input.spec.template.spec.securityContext.runAsNonRoot
+
+  msg := sprintf("Deployment %s must not run as root", [input.metadata.name])
+}
@@ -2,42 +2,117 @@ package activities
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/jenkins-x/go-scm/scm"
 	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	jxc "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
 	jv1 "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/typed/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/scmhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Options command line arguments and flags
 type Options struct {
 	DryRun                  bool
+	Quiet                   bool
+	Verbose                 bool
 	ReleaseHistoryLimit     int
 	PullRequestHistoryLimit int
 	ReleaseAgeLimit         time.Duration
 	PullRequestAgeLimit     time.Duration
 	PipelineRunAgeLimit     time.Duration
 	ProwJobAgeLimit         time.Duration
+	StuckAgeLimit           time.Duration
+	InclusiveAge            bool
+	KeepLastSuccess         bool
+	CountBuildNumbers       bool
+	GlobalKeepNewest        int
+	Concurrency             int
+	SlowDeleteThreshold     time.Duration
 	Namespace               string
+	LabelSelector           string
+	Repositories            []string
+	ExcludePipelineContexts []string
+	OnlyPipelineContexts    []string
+	Contexts                []string
+	FromFile                string
+	DeleteOrder             string
+	StateFile               string
+	ArchiveBucket           string
+	ArchivePrefix           string
+	ContinueOnError         bool
+	CheckPermissions        bool
+	DeletePods              bool
+	ProtectFinalizers       []string
+	RemoveFinalizers        bool
+	ProtectFromIssues       bool
+	OtelEndpoint            string
+	OtelDeletionSpans       bool
+	PolicyConfigMap         string
+	Config                  string
+	OnlyBetween             string
+	CreatedAfter            string
+	CreatedBefore           string
+	Timezone                string
+	Output                  string
+	JUnitOutput             string
+	PipelineTypeLabel       string
+	Clock                   func() time.Time
+	Sizer                   func(a *v1.PipelineActivity) int
+	Cmd                     *cobra.Command
+	Out                     io.Writer
 	JXClient                jxc.Interface
+	KubeClient              kubernetes.Interface
+	TektonClient            tektonclient.Interface
+	DynamicClient           dynamic.Interface
+	Archiver                Archiver
+	SpanExporter            SpanExporter
+	IssueFinder             IssueFinder
+	ScmFactory              scmhelpers.Factory
+	ContextClientsFactory   ContextClientsFactory
+	ContextReports          []*ContextReport
+	Deleted                 map[string]DeleteReason
+	SlowDeletions           map[string]time.Duration
+	deletedBranches         map[string]string
+	kept                    map[string]keptActivity
+	repoConfig              RepositoryConfig
+	window                  *maintenanceWindow
+	created                 *creationWindow
+	selector                labels.Selector
+	tracer                  *tracer
+	lock                    sync.Mutex
+	openIssues              map[string][]*scm.Issue
 }
 
 var (
 	info = termcolor.ColorInfo
 
 	cmdLong = templates.LongDesc(`
-		Garbage collect the Jenkins X PipelineActivity resources
+		Garbage collect the Jenkins X PipelineActivity resources and completed Tekton PipelineRun and ProwJob resources
 
+Each deleted PipelineActivity is logged with one of the reasons: age_release, age_pr, history_release, history_pr, orphan or stuck
 `)
 
 	cmdExample = templates.Examples(`
@@ -46,6 +121,95 @@ var (
 
 		# dry run mode
 		jx gitops gc pa --dry-run
+
+		# delete completed PipelineRuns after 6 hours
+		jx gitops gc activities --pipelinerun-age 6h
+
+		# delete PipelineActivities which started over a day ago but never completed
+		jx gitops gc activities --stuck-age 24h
+
+		# allow the last successful PipelineActivity of each branch to be deleted by the age and history limits
+		jx gitops gc activities --keep-last-success=false
+
+		# count reruns of the same build number as a single build for the history limits
+		jx gitops gc activities --count-build-numbers
+
+		# always keep the 20 most recently completed PipelineActivities across all repositories
+		jx gitops gc activities --global-keep-newest 20
+
+		# only log the summary and any errors
+		jx gitops gc activities --quiet
+
+		# fail early if the PipelineActivities cannot be deleted in the namespace
+		jx gitops gc activities --check-permissions
+
+		# delete up to 20 PipelineActivities in parallel
+		jx gitops gc activities --concurrency 20
+
+		# write a JSON report of the deleted PipelineActivities grouped by repository and branch
+		jx gitops gc activities --output json
+
+		# write a JUnit report with a test case for each deleted or kept PipelineActivity to chart the trend in CI
+		jx gitops gc activities --junit-output reports/gc-activities.xml
+
+		# use the retention settings from a ConfigMap in the namespace
+		jx gitops gc activities --policy-configmap jx-gc-policy
+
+		# override the retention settings of some repositories or branches using a YAML file such as:
+		#   myorg/myrepo:
+		#     releaseHistoryLimit: 20
+		#   myorg/sandbox/main:
+		#     prAge: 12h
+		jx gitops gc activities --config gc-repositories.yaml
+
+		# only garbage collect between 1am and 5am in London
+		jx gitops gc activities --only-between 01:00-05:00 --timezone Europe/London
+
+		# remove the archive finalizer of any PipelineActivities stuck archiving so they can be deleted
+		jx gitops gc activities --remove-finalizers
+
+		# also delete the pipeline Pods of each deleted PipelineActivity
+		jx gitops gc activities --delete-pods
+
+		# trace each gc run with an OpenTelemetry collector including a span for each deleted PipelineActivity
+		jx gitops gc activities --otel-endpoint http://otel-collector:4318 --otel-deletion-spans
+
+		# keep any PipelineActivity whose build URL is referenced by an open issue of its repository
+		jx gitops gc activities --protect-from-issues --git-server https://github.com
+
+		# simulate the retention settings against the PipelineActivities exported from a cluster
+		kubectl get pipelineactivities -o json > activities.json
+		jx gitops gc activities --from-file activities.json --release-history-limit 3
+
+		# only garbage collect the PipelineActivities of a team in a shared namespace
+		jx gitops gc activities --namespace shared --selector team=myteam --repo myorg/myrepo --dry-run
+
+		# never delete the PipelineActivities of the release and promote pipeline contexts
+		jx gitops gc activities --exclude-pipeline-context release --exclude-pipeline-context promote
+
+		# only garbage collect the PipelineActivities of the lint and preview pipeline contexts
+		jx gitops gc activities --only-pipeline-context lint --only-pipeline-context preview
+
+		# only garbage collect the PipelineActivities created during a bad deployment
+		jx gitops gc activities --created-after 2021-03-01T10:00:00Z --created-before 2021-03-01T12:00:00Z --release-age 1h
+
+		# delete the largest PipelineActivities first to reclaim storage faster
+		jx gitops gc activities --delete-order size-desc
+
+		# classify PipelineActivities as pull requests, batches or releases using a label rather than the branch name
+		jx gitops gc activities --pipeline-type-label jenkins.io/pipelineType
+
+		# only examine the PipelineActivities which completed since the previous run
+		jx gitops gc activities --state-file /data/gc-state.json
+
+		# archive each PipelineActivity to a bucket before deleting it
+		jx gitops gc activities --archive-bucket gs://my-bucket --archive-prefix activities
+
+		# garbage collect the PipelineActivities of each cluster of a fleet
+		jx gitops gc activities --contexts prod-eu,prod-us,staging
+
+		# run the garbage collection on demand over HTTP
+		jx gitops gc activities serve --token $JX_GC_TOKEN
 `)
 )
 
@@ -55,7 +219,8 @@ type buildCounter struct {
 }
 
 type buildsCount struct {
-	cache map[string]*buildCounter
+	cache  map[string]*buildCounter
+	builds map[string]int
 }
 
 // AddBuild adds the build and returns the number of builds for this repo and branch
@@ -76,6 +241,21 @@ func (c *buildsCount) AddBuild(repoAndBranch string, isPR bool) int {
 	return bc.ReleaseCount
 }
 
+// AddBuildNumber adds the build with the build number and returns the number of distinct build numbers for this repo
+// and branch so that reruns of the same build number are only counted once
+func (c *buildsCount) AddBuildNumber(repoAndBranch string, isPR bool, buildNumber int) int {
+	if c.builds == nil {
+		c.builds = map[string]int{}
+	}
+	key := fmt.Sprintf("%s/%t/%d", repoAndBranch, isPR, buildNumber)
+	if count, ok := c.builds[key]; ok {
+		return count
+	}
+	count := c.AddBuild(repoAndBranch, isPR)
+	c.builds[key] = count
+	return count
+}
+
 // NewCmd s a command object for the "step" command
 func NewCmdGCActivities() (*cobra.Command, *Options) {
 	o := &Options{}
@@ -87,54 +267,324 @@ func NewCmdGCActivities() (*cobra.Command, *Options) {
 		Long:    cmdLong,
 		Example: cmdExample,
 		Run: func(cmd *cobra.Command, args []string) {
+			o.Cmd = cmd
 			err := o.Run()
 			helper.CheckErr(err)
 		},
 	}
+	o.AddFlags(cmd)
+	cmd.AddCommand(cobras.SplitCommand(NewCmdGCActivitiesServe()))
+	return cmd, o
+}
+
+// AddFlags adds the garbage collection flags to the command
+func (o *Options) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "d", false, "Dry run mode. If enabled just list the resources that would be removed")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "the namespace to garbage collect the PipelineActivities in. Defaults to the current namespace")
+	cmd.Flags().StringVarP(&o.LabelSelector, "selector", "", "", "the label selector of the PipelineActivities to garbage collect such as team=myteam")
+	cmd.Flags().StringArrayVarP(&o.ExcludePipelineContexts, "exclude-pipeline-context", "", nil, "the pipeline context such as release or promote of the PipelineActivities which are never deleted. Can be specified multiple times")
+	cmd.Flags().StringArrayVarP(&o.OnlyPipelineContexts, "only-pipeline-context", "", nil, "the pipeline context such as lint or preview of the PipelineActivities to garbage collect. Can be specified multiple times")
+	cmd.Flags().StringSliceVarP(&o.Contexts, "contexts", "", nil, "the kubeconfig contexts to garbage collect the PipelineActivities of in turn. The namespace of each context is used unless --namespace is specified. Defaults to the current context")
+	cmd.Flags().StringArrayVarP(&o.Repositories, "repo", "", nil, "the owner/repository of the PipelineActivities to garbage collect. Can be specified multiple times")
 	cmd.Flags().IntVarP(&o.ReleaseHistoryLimit, "release-history-limit", "l", 5, "Maximum number of PipelineActivities to keep around per repository release")
 	cmd.Flags().IntVarP(&o.PullRequestHistoryLimit, "pr-history-limit", "", 2, "Minimum number of PipelineActivities to keep around per repository Pull Request")
 	cmd.Flags().DurationVarP(&o.PullRequestAgeLimit, "pull-request-age", "p", time.Hour*48, "Maximum age to keep PipelineActivities for Pull Requests")
 	cmd.Flags().DurationVarP(&o.ReleaseAgeLimit, "release-age", "r", time.Hour*24*30, "Maximum age to keep PipelineActivities for Releases")
-	cmd.Flags().DurationVarP(&o.PipelineRunAgeLimit, "pipelinerun-age", "", time.Hour*12, "Maximum age to keep completed PipelineRuns for all pipelines")
-	cmd.Flags().DurationVarP(&o.ProwJobAgeLimit, "prowjob-age", "", time.Hour*24*7, "Maximum age to keep completed ProwJobs for all pipelines")
-	return cmd, o
+	cmd.Flags().DurationVarP(&o.PipelineRunAgeLimit, "pipelinerun-age", "", time.Hour*12, "Maximum age to keep completed PipelineRuns for all pipelines. Use 0 to not garbage collect PipelineRuns")
+	cmd.Flags().DurationVarP(&o.ProwJobAgeLimit, "prowjob-age", "", time.Hour*24*7, "Maximum age to keep completed ProwJobs for all pipelines. Use 0 to not garbage collect ProwJobs")
+	cmd.Flags().DurationVarP(&o.StuckAgeLimit, "stuck-age", "", 0, "Maximum age since they started to keep PipelineActivities which never completed such as those whose pipeline crashed. Use 0 to keep them forever")
+	cmd.Flags().StringVarP(&o.PipelineTypeLabel, "pipeline-type-label", "", "", "the label used to classify PipelineActivities as "+PipelineTypePullRequest+", "+PipelineTypeBatch+" or "+PipelineTypeRelease+" such as jenkins.io/pipelineType. PipelineActivities without a recognised value are classified by their branch name")
+	cmd.Flags().BoolVarP(&o.InclusiveAge, "inclusive-age", "", false, "if enabled PipelineActivities whose age is exactly the maximum age are deleted too. By default only PipelineActivities older than the maximum age are deleted")
+	cmd.Flags().BoolVarP(&o.KeepLastSuccess, "keep-last-success", "", true, "if enabled the newest successful PipelineActivity of each repository, branch and context is never deleted regardless of its age or the history limits")
+	cmd.Flags().BoolVarP(&o.CountBuildNumbers, "count-build-numbers", "", false, "if enabled the history limits count the distinct build numbers of each repository and branch so that reruns of the same build number only count once")
+	cmd.Flags().IntVarP(&o.GlobalKeepNewest, "global-keep-newest", "", 0, "the number of the most recently completed PipelineActivities across all repositories which are never deleted regardless of the per repository age and history limits. Disabled if 0")
+	cmd.Flags().BoolVarP(&o.Quiet, "quiet", "q", false, "Quiet mode. If enabled only the final summary and any errors are logged")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "the format of the report of the deleted PipelineActivities. If "+OutputJSON+" the report is written to stdout as JSON instead of logging each PipelineActivity")
+	cmd.Flags().StringVarP(&o.JUnitOutput, "junit-output", "", "", "the file to write a JUnit report to with a test suite per repository and branch and a test case per PipelineActivity. Kept PipelineActivities pass and deleted PipelineActivities are skipped")
+	cmd.Flags().BoolVarP(&o.Verbose, "verbose", "v", false, "Verbose mode. If enabled the PipelineActivities which are kept are logged too")
+	cmd.Flags().StringVarP(&o.ArchiveBucket, "archive-bucket", "", "", "the bucket URL (gs:// or s3://) to upload each PipelineActivity to as JSON before it is deleted")
+	cmd.Flags().StringVarP(&o.ArchivePrefix, "archive-prefix", "", "", "the path prefix of the archived PipelineActivities in the archive bucket")
+	cmd.Flags().StringVarP(&o.PolicyConfigMap, "policy-configmap", "", "", "the name of a ConfigMap in the namespace containing the retention settings. The keys are the names of the age and history limit flags. Flags specified on the command line take precedence")
+	cmd.Flags().StringVarP(&o.Config, "config", "", "", "the YAML file of per repository retention settings keyed by owner/repo or owner/repo/branch with the optional keys releaseHistoryLimit, prHistoryLimit, releaseAge and prAge. Repositories without settings use the flags")
+	cmd.Flags().StringVarP(&o.OnlyBetween, "only-between", "", "", "the HH:MM-HH:MM maintenance window. If specified and the current time is outside the window nothing is deleted")
+	cmd.Flags().StringVarP(&o.CreatedAfter, "created-after", "", "", "the RFC 3339 time such as 2021-01-02T15:04:05Z. If specified only PipelineActivities created at or after this time are garbage collected")
+	cmd.Flags().StringVarP(&o.CreatedBefore, "created-before", "", "", "the RFC 3339 time such as 2021-01-02T15:04:05Z. If specified only PipelineActivities created before this time are garbage collected")
+	cmd.Flags().StringVarP(&o.Timezone, "timezone", "", "UTC", "the timezone of the --only-between maintenance window")
+	cmd.Flags().StringArrayVarP(&o.ProtectFinalizers, "protect-finalizer", "", []string{DefaultProtectFinalizer}, "the finalizers which indicate a PipelineActivity is still being processed such as archived so it is not deleted. Can be specified multiple times")
+	cmd.Flags().BoolVarP(&o.RemoveFinalizers, "remove-finalizers", "", false, "if enabled the --protect-finalizer finalizers are removed from the PipelineActivities so that they can be deleted rather than being skipped")
+	cmd.Flags().BoolVarP(&o.DeletePods, "delete-pods", "", false, "if enabled the pipeline Pods labelled with the build identifier of each deleted PipelineActivity are deleted too")
+	cmd.Flags().StringVarP(&o.OtelEndpoint, "otel-endpoint", "", "", "the OTLP/HTTP endpoint of an OpenTelemetry collector to export a span of the gc run to. The path defaults to /v1/traces")
+	cmd.Flags().BoolVarP(&o.OtelDeletionSpans, "otel-deletion-spans", "", false, "if enabled a child span is exported for each deleted PipelineActivity when using --otel-endpoint")
+	cmd.Flags().BoolVarP(&o.ProtectFromIssues, "protect-from-issues", "", false, "if enabled PipelineActivities whose build URL is referenced by an open issue of their repository are not deleted")
+	cmd.Flags().StringVarP(&o.FromFile, "from-file", "", "", "the JSON or YAML file of PipelineActivities to use instead of the cluster. Implies --dry-run so the PipelineActivities which would be deleted are just logged")
+	cmd.Flags().StringVarP(&o.StateFile, "state-file", "", "", "the file to store the high-water mark of the completion time of the evaluated PipelineActivities. If specified PipelineActivities completed at or before the mark are skipped so only newer PipelineActivities are examined. Run without this flag periodically to apply the age limits to the skipped PipelineActivities")
+	cmd.Flags().StringVarP(&o.DeleteOrder, "delete-order", "", DeleteOrderCompleted, "the order the PipelineActivities are deleted in. Use "+DeleteOrderSizeDesc+" to delete the largest PipelineActivities first to reclaim storage faster. Values: "+strings.Join(DeleteOrders, ", "))
+	cmd.Flags().DurationVarP(&o.SlowDeleteThreshold, "slow-delete-threshold", "", 0, "if specified a warning is logged for each PipelineActivity which takes longer than this duration to delete which may indicate problems with the API server")
+	cmd.Flags().BoolVarP(&o.ContinueOnError, "continue-on-error", "", false, "if enabled PipelineActivities are still deleted if they could not be archived")
+	cmd.Flags().BoolVarP(&o.CheckPermissions, "check-permissions", "", false, "if enabled a SelfSubjectAccessReview is used to verify PipelineActivities can be deleted in the namespace before anything is deleted")
+	cmd.Flags().IntVarP(&o.Concurrency, "concurrency", "", 5, "the maximum number of PipelineActivities to delete in parallel. Use 1 to delete the PipelineActivities strictly in the --delete-order. A dry run always logs the PipelineActivities in order")
+	o.ScmFactory.AddFlags(cmd)
 }
 
-// Run implements this command
-func (o *Options) Run() error {
+// Validate verifies the options and lazily creates any clients
+func (o *Options) Validate() error {
+	if o.Quiet && o.Verbose {
+		return errors.Errorf("cannot use both --quiet and --verbose")
+	}
+	if o.Output != "" {
+		if stringhelpers.StringArrayIndex(Outputs, o.Output) < 0 {
+			return options.InvalidOption("output", o.Output, Outputs)
+		}
+		if o.Verbose {
+			return errors.Errorf("cannot use both --output and --verbose")
+		}
+		o.Quiet = true
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if o.Concurrency < 1 {
+		return errors.Errorf("--concurrency must be at least 1 but was %d", o.Concurrency)
+	}
+	if o.DeleteOrder == "" {
+		o.DeleteOrder = DeleteOrderCompleted
+	}
+	if stringhelpers.StringArrayIndex(DeleteOrders, o.DeleteOrder) < 0 {
+		return options.InvalidOption("delete-order", o.DeleteOrder, DeleteOrders)
+	}
+	if o.OnlyBetween != "" {
+		var err error
+		o.window, err = parseMaintenanceWindow(o.OnlyBetween, o.Timezone)
+		if err != nil {
+			return errors.Wrapf(err, "invalid --only-between")
+		}
+	}
+	if o.Config != "" && o.repoConfig == nil {
+		var err error
+		o.repoConfig, err = LoadRepositoryConfig(o.Config)
+		if err != nil {
+			return errors.Wrapf(err, "invalid --config")
+		}
+	}
+	if o.LabelSelector != "" {
+		var err error
+		o.selector, err = labels.Parse(o.LabelSelector)
+		if err != nil {
+			return errors.Wrapf(err, "invalid --selector %s", o.LabelSelector)
+		}
+	}
+	if o.CreatedAfter != "" || o.CreatedBefore != "" {
+		var err error
+		o.created, err = parseCreationWindow(o.CreatedAfter, o.CreatedBefore)
+		if err != nil {
+			return err
+		}
+	}
+	if o.OtelEndpoint != "" && o.SpanExporter == nil {
+		_, err := tracesURL(o.OtelEndpoint)
+		if err != nil {
+			return errors.Wrapf(err, "invalid --otel-endpoint")
+		}
+		o.SpanExporter = &OTLPExporter{Endpoint: o.OtelEndpoint}
+	}
+	if o.ProtectFromIssues && o.IssueFinder == nil {
+		scmClient, err := o.ScmFactory.Create()
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the git provider client for --protect-from-issues")
+		}
+		o.IssueFinder = &ScmIssueFinder{ScmClient: scmClient}
+	}
+	if o.ArchiveBucket != "" && o.Archiver == nil {
+		err := ValidateBucketURL(o.ArchiveBucket)
+		if err != nil {
+			return errors.Wrapf(err, "invalid --archive-bucket")
+		}
+		o.Archiver = &CLIArchiver{}
+	}
+	if len(o.Contexts) > 0 {
+		// the clients of each context are created when it is garbage collected
+		return o.validateContexts()
+	}
+	if o.FromFile != "" {
+		if o.DeletePods {
+			return errors.Errorf("cannot use --delete-pods with --from-file")
+		}
+		o.DryRun = true
+		return nil
+	}
 	var err error
+	if o.DeletePods {
+		o.KubeClient, err = kube.LazyCreateKubeClient(o.KubeClient)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create kube client")
+		}
+	}
 	o.JXClient, o.Namespace, err = jxclient.LazyCreateJXClientAndNamespace(o.JXClient, o.Namespace)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create jx client")
 	}
+	if o.PipelineRunAgeLimit > 0 {
+		o.TektonClient, err = LazyCreateTektonClient(o.TektonClient)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create tekton client")
+		}
+	}
+	if o.ProwJobAgeLimit > 0 {
+		o.DynamicClient, err = kube.LazyCreateDynamicClient(o.DynamicClient)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create dynamic client")
+		}
+	}
+	return nil
+}
+
+// Run implements this command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	ctx, cancel := signalContext()
+	defer cancel()
+	if len(o.Contexts) > 0 {
+		return o.collectContexts(ctx)
+	}
+	summary, err := o.Collect(ctx)
+	if err != nil || summary.Skipped {
+		return err
+	}
+	err = o.writeJUnitReport()
+	if err != nil {
+		return err
+	}
+	return o.writeReport(summary.Kept)
+}
 
+// Collect performs a single garbage collection of the PipelineActivities returning the summary
+func (o *Options) Collect(ctx context.Context) (*Summary, error) {
+	summary := &Summary{DryRun: o.DryRun}
+	o.Deleted = nil
+	o.SlowDeletions = nil
+	o.deletedBranches = nil
+	o.kept = nil
+	now := o.now()
+	if o.window != nil && !o.window.Contains(now) {
+		log.Logger().Infof("not garbage collecting PipelineActivities as the time %s is outside of the maintenance window %s", now.In(o.window.location).Format("15:04"), o.window.String())
+		summary.Skipped = true
+		return summary, nil
+	}
+
+	var err error
+	if o.SpanExporter == nil {
+		summary.Deleted, summary.Kept, err = o.gcActivities(ctx, now)
+		summary.Activities = o.Deleted
+		summary.SlowDeletions = len(o.SlowDeletions)
+		return summary, err
+	}
+
+	o.tracer = newTracer()
+	o.tracer.startRun(time.Now())
+	summary.Deleted, summary.Kept, err = o.gcActivities(ctx, now)
+	summary.Activities = o.Deleted
+	summary.SlowDeletions = len(o.SlowDeletions)
+	o.tracer.run.Attributes["gc.namespace"] = o.Namespace
+	o.tracer.run.Attributes["gc.dry_run"] = o.DryRun
+	o.tracer.run.Attributes["gc.deleted"] = summary.Deleted
+	o.tracer.run.Attributes["gc.kept"] = summary.Kept
+	o.tracer.run.Attributes["gc.slow_deletions"] = summary.SlowDeletions
+	spans := o.tracer.endRun(time.Now(), err)
+	exportErr := o.SpanExporter.ExportSpans(ctx, spans)
+	if exportErr != nil {
+		log.Logger().Warnf("failed to export OpenTelemetry spans: %s", exportErr.Error())
+	}
+	return summary, err
+}
+
+// gcActivities garbage collects the PipelineActivities returning the number deleted and kept
+func (o *Options) gcActivities(ctx context.Context, now time.Time) (int, int, error) {
 	client := o.JXClient
 	currentNs := o.Namespace
-	ctx := context.TODO()
+	o.openIssues = nil
 
-	// cannot use field selectors like `spec.kind=Preview` on CRDs so list all environments
-	activityInterface := client.JenkinsV1().PipelineActivities(currentNs)
-	activities, err := activityInterface.List(ctx, metav1.ListOptions{})
+	restorePolicy := o.copyPolicy()
+	defer restorePolicy()
+	err := o.loadPolicy(ctx)
 	if err != nil {
-		return err
+		return 0, 0, errors.Wrapf(err, "failed to load retention policy")
+	}
+
+	err = o.checkDeletePermission(ctx, currentNs)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var activityInterface jv1.PipelineActivityInterface
+	var items []v1.PipelineActivity
+	if o.FromFile != "" {
+		items, err = LoadActivitiesFile(o.FromFile)
+		if err != nil {
+			return 0, 0, err
+		}
+	} else {
+		// cannot use field selectors like `spec.kind=Preview` on CRDs so list all environments
+		activityInterface = client.JenkinsV1().PipelineActivities(currentNs)
+		activities, err := activityInterface.List(ctx, metav1.ListOptions{LabelSelector: o.LabelSelector})
+		if err != nil {
+			return 0, 0, err
+		}
+		items = activities.Items
 	}
-	if len(activities.Items) == 0 {
+	if len(items) == 0 {
 		// no preview environments found so lets return gracefully
 		log.Logger().Debug("no activities found")
-		return nil
+		o.logSummary(0, 0)
+		return 0, 0, o.gcPipelineResources(ctx, currentNs, now)
 	}
 
-	now := time.Now()
 	counters := &buildsCount{}
+	deleted := 0
+	kept := 0
+
+	var state *State
+	if o.StateFile != "" {
+		state, err = LoadState(o.StateFile)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
 
 	var completedActivities []v1.PipelineActivity
+	var stuckActivities []v1.PipelineActivity
+	evaluated := 0
+	matched := 0
 
-	// Filter out running activities
-	for _, a := range activities.Items {
-		if a.Spec.CompletedTimestamp != nil {
-			completedActivities = append(completedActivities, a)
+	// Filter out activities which do not match the filters, running activities, those created outside of the creation window
+	// and those evaluated by a previous run
+	for _, a := range items {
+		if !o.matchesFilters(&a) {
+			continue
+		}
+		matched++
+		if o.created != nil && !o.created.Contains(&a) {
+			continue
+		}
+		if a.Spec.CompletedTimestamp == nil {
+			if o.isStuck(&a, now) {
+				stuckActivities = append(stuckActivities, a)
+			}
+			continue
+		}
+		if state.isEvaluated(a.Spec.CompletedTimestamp.Time) {
+			evaluated++
+			continue
 		}
+		completedActivities = append(completedActivities, a)
+	}
+	if o.hasFilters() {
+		log.Logger().Infof("matched %d of %d PipelineActivities using the selector %s, repositories %s, pipeline contexts %s and excluded pipeline contexts %s", matched, len(items), info(o.LabelSelector), info(strings.Join(o.Repositories, ", ")), info(strings.Join(o.OnlyPipelineContexts, ", ")), info(strings.Join(o.ExcludePipelineContexts, ", ")))
+	}
+	if evaluated > 0 {
+		log.Logger().Infof("skipped %d PipelineActivities completed at or before %s which were evaluated by a previous run", evaluated, state.LastCompletedTimestamp.Format(time.RFC3339))
 	}
 
 	// Sort with newest created activities first
@@ -142,63 +592,209 @@ func (o *Options) Run() error {
 		return !completedActivities[i].Spec.CompletedTimestamp.Before(completedActivities[j].Spec.CompletedTimestamp)
 	})
 
-	//
-	for _, a := range completedActivities {
+	var candidates []deletion
+	successes := map[string]bool{}
+	for i, a := range completedActivities {
 		activity := a
-		branchName := a.BranchName()
-		isPR, isBatch := o.isPullRequestOrBatchBranch(branchName)
-		maxAge, revisionHistory := o.ageAndHistoryLimits(isPR, isBatch)
-		// lets remove activities that are too old
-		if activity.Spec.CompletedTimestamp != nil && activity.Spec.CompletedTimestamp.Add(maxAge).Before(now) {
-
-			err = o.deleteActivity(ctx, activityInterface, &activity)
-			if err != nil {
-				return err
+		reason := o.deleteReason(&activity, now, counters)
+		lastSuccess := o.isLastSuccess(&activity, successes)
+		if reason != "" && i < o.GlobalKeepNewest {
+			if o.Verbose {
+				log.Logger().Infof("keeping PipelineActivity %s as it is one of the %d newest across all repositories", info(activity.Name), o.GlobalKeepNewest)
 			}
+			o.recordKept(&activity, KeepReasonGlobalNewest)
+			kept++
 			continue
 		}
-
-		repoBranchAndContext := activity.RepositoryOwner() + "/" + activity.RepositoryName() + "/" + activity.BranchName() + "/" + activity.Spec.Context
-		c := counters.AddBuild(repoBranchAndContext, isPR)
-		if c > revisionHistory && a.Spec.CompletedTimestamp != nil {
-			err = o.deleteActivity(ctx, activityInterface, &activity)
-			if err != nil {
-				return err
+		if reason != "" && lastSuccess {
+			if o.Verbose {
+				log.Logger().Infof("keeping PipelineActivity %s as it is the last successful build of %s", info(activity.Name), repoBranchAndContext(&activity))
+			}
+			o.recordKept(&activity, KeepReasonLastSuccess)
+			kept++
+			continue
+		}
+		if reason == "" {
+			o.recordKept(&activity, KeepReasonLimits)
+			kept++
+			if o.Verbose {
+				log.Logger().Infof("keeping PipelineActivity %s", info(activity.Name))
 			}
 			continue
 		}
+
+		referenced, err := o.isReferencedByOpenIssue(ctx, &activity)
+		if err != nil {
+			return deleted, kept, err
+		}
+		if referenced {
+			o.recordKept(&activity, KeepReasonOpenIssue)
+			kept++
+			continue
+		}
+		if o.isProtectedByFinalizer(&activity) {
+			o.recordKept(&activity, KeepReasonFinalizer)
+			kept++
+			continue
+		}
+		candidates = append(candidates, deletion{activity: activity, reason: reason})
+	}
+	for _, a := range stuckActivities {
+		activity := a
+		referenced, err := o.isReferencedByOpenIssue(ctx, &activity)
+		if err != nil {
+			return deleted, kept, err
+		}
+		if referenced {
+			o.recordKept(&activity, KeepReasonOpenIssue)
+			kept++
+			continue
+		}
+		if o.isProtectedByFinalizer(&activity) {
+			o.recordKept(&activity, KeepReasonFinalizer)
+			kept++
+			continue
+		}
+		candidates = append(candidates, deletion{activity: activity, reason: DeleteReasonStuck})
+	}
+	o.sortDeletions(candidates)
+
+	removed, archiveFailures, err := o.deleteCandidates(ctx, activityInterface, candidates)
+	deleted += removed
+	kept += archiveFailures
+	if err != nil {
+		return deleted, kept, err
 	}
 
-	// Clean up completed PipelineRuns
-	/*
-		err = o.gcPipelineRuns(currentNs)
+	o.logSummary(deleted, kept)
+	if archiveFailures > 0 {
+		return deleted, kept, errors.Errorf("failed to archive %d PipelineActivities so they were not deleted", archiveFailures)
+	}
+
+	// completed activities are immutable so lets remember the newest one so the next run can skip them
+	if state != nil && !o.DryRun && len(completedActivities) > 0 {
+		state.LastCompletedTimestamp = completedActivities[0].Spec.CompletedTimestamp.Time
+		err = SaveState(o.StateFile, state)
 		if err != nil {
-			return err
+			return deleted, kept, errors.Wrapf(err, "failed to save the state")
 		}
-	*/
+	}
 
-	return nil
+	// Clean up completed PipelineRuns and ProwJobs
+	err = o.gcPipelineResources(ctx, currentNs, now)
+	if err != nil {
+		return deleted, kept, err
+	}
+	return deleted, kept, nil
+}
+
+// gcPipelineResources deletes the completed PipelineRuns and ProwJobs which are older than their age limits
+func (o *Options) gcPipelineResources(ctx context.Context, ns string, now time.Time) error {
+	err := o.gcPipelineRuns(ctx, ns, now)
+	if err != nil {
+		return err
+	}
+	return o.gcProwJobs(ctx, ns, now)
 }
 
-func (o *Options) deleteActivity(ctx context.Context, activityInterface jv1.PipelineActivityInterface, a *v1.PipelineActivity) error {
+func (o *Options) logSummary(deleted, kept int) {
+	prefix := ""
+	if o.DryRun {
+		prefix = "would have "
+	}
+	log.Logger().Infof("%sdeleted %d PipelineActivities and kept %d", prefix, deleted, kept)
+}
+
+// deleteActivity archives and deletes the activity returning false if it was not deleted as it could not be archived
+func (o *Options) deleteActivity(ctx context.Context, activityInterface jv1.PipelineActivityInterface, a *v1.PipelineActivity, reason DeleteReason) (bool, error) {
 	prefix := ""
 	if o.DryRun {
 		prefix = "not "
 	}
-	log.Logger().Infof("%sdeleting PipelineActivity %s", prefix, info(a.Name))
+	if !o.Quiet {
+		log.Logger().Infof("%sdeleting PipelineActivity %s reason: %s", prefix, info(a.Name), reason)
+	}
 	if o.DryRun {
-		return nil
+		o.recordDeletion(a, reason)
+		return true, o.deleteActivityPods(ctx, a)
+	}
+	err := o.archiveActivity(ctx, a)
+	if err != nil {
+		if !o.ContinueOnError {
+			log.Logger().Warnf("not deleting PipelineActivity %s: %s", a.Name, err.Error())
+			o.recordKept(a, KeepReasonArchiveFailed)
+			return false, nil
+		}
+		log.Logger().Warnf("deleting PipelineActivity %s even though it was not archived: %s", a.Name, err.Error())
+	}
+	if o.RemoveFinalizers {
+		err = o.removeProtectingFinalizers(ctx, activityInterface, a)
+		if err != nil {
+			return true, err
+		}
+	}
+	o.recordDeletion(a, reason)
+	start := time.Now()
+	err = activityInterface.Delete(ctx, a.Name, *metav1.NewDeleteOptions(0))
+	o.checkSlowDeletion(a, time.Since(start))
+	if err != nil {
+		return true, err
 	}
-	return activityInterface.Delete(ctx, a.Name, *metav1.NewDeleteOptions(0))
+	return true, o.deleteActivityPods(ctx, a)
 }
 
-func (o *Options) ageAndHistoryLimits(isPR, isBatch bool) (time.Duration, int) {
+// checkSlowDeletion warns if the deletion of the activity took longer than the --slow-delete-threshold
+func (o *Options) checkSlowDeletion(a *v1.PipelineActivity, duration time.Duration) {
+	if o.SlowDeleteThreshold <= 0 || duration <= o.SlowDeleteThreshold {
+		return
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	log.Logger().Warnf("deleting PipelineActivity %s took %s which exceeds the threshold of %s which may indicate problems with the API server", info(a.Name), duration.String(), o.SlowDeleteThreshold.String())
+	if o.SlowDeletions == nil {
+		o.SlowDeletions = map[string]time.Duration{}
+	}
+	o.SlowDeletions[a.Name] = duration
+}
+
+func (o *Options) recordDeletion(a *v1.PipelineActivity, reason DeleteReason) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.Deleted == nil {
+		o.Deleted = map[string]DeleteReason{}
+	}
+	o.Deleted[a.Name] = reason
+	o.recordBranch(a)
+}
+
+// ageAndHistoryLimits returns the limits of the activity using any settings of its repository in the --config file
+// falling back to the flags
+func (o *Options) ageAndHistoryLimits(activity *v1.PipelineActivity, isPR, isBatch bool) (time.Duration, int) {
 	maxAge := o.ReleaseAgeLimit
 	revisionLimit := o.ReleaseHistoryLimit
 	if isPR || isBatch {
 		maxAge = o.PullRequestAgeLimit
 		revisionLimit = o.PullRequestHistoryLimit
 	}
+	limits := o.repoConfig.limitsFor(activity)
+	if limits == nil {
+		return maxAge, revisionLimit
+	}
+	if isPR || isBatch {
+		if limits.PullRequestAge != "" {
+			maxAge = limits.pullRequestAge
+		}
+		if limits.PullRequestHistoryLimit != nil {
+			revisionLimit = *limits.PullRequestHistoryLimit
+		}
+		return maxAge, revisionLimit
+	}
+	if limits.ReleaseAge != "" {
+		maxAge = limits.releaseAge
+	}
+	if limits.ReleaseHistoryLimit != nil {
+		revisionLimit = *limits.ReleaseHistoryLimit
+	}
 	return maxAge, revisionLimit
 }
 
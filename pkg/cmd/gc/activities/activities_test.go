@@ -10,8 +10,12 @@ import (
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
 	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestGCPipelineActivities(t *testing.T) {
@@ -122,6 +126,8 @@ func TestGCPipelineActivities(t *testing.T) {
 
 	_, o := activities.NewCmdGCActivities()
 	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
 	o.JXClient = jxClient
 
 	err := o.Run()
@@ -140,3 +146,98 @@ func TestGCPipelineActivities(t *testing.T) {
 	}
 	assert.Len(t, verifier, 2, "Both PR and Batch builds should've been verified")
 }
+
+func TestGCPipelineActivitiesQuiet(t *testing.T) {
+	ns := "jx"
+	nowMinusThirtyOneDays := time.Now().AddDate(0, 0, -31)
+	nowMinusOneDay := time.Now().AddDate(0, 0, -1)
+
+	newActivities := func() []runtime.Object {
+		return []runtime.Object{
+			&v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "old",
+					Namespace: ns,
+					Labels: map[string]string{
+						v1.LabelBranch: "master",
+					},
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/project/master",
+					CompletedTimestamp: &metav1.Time{Time: nowMinusThirtyOneDays},
+				},
+			},
+			&v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "new",
+					Namespace: ns,
+					Labels: map[string]string{
+						v1.LabelBranch: "master",
+					},
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/project/master",
+					CompletedTimestamp: &metav1.Time{Time: nowMinusOneDay},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		quiet       bool
+		activities  []runtime.Object
+		expectItems bool
+		summary     string
+	}{
+		{
+			name:        "default",
+			activities:  newActivities(),
+			expectItems: true,
+			summary:     "deleted 1 PipelineActivities and kept 1",
+		},
+		{
+			name:       "quiet",
+			quiet:      true,
+			activities: newActivities(),
+			summary:    "deleted 1 PipelineActivities and kept 1",
+		},
+		{
+			name:    "quiet-no-activities",
+			quiet:   true,
+			summary: "deleted 0 PipelineActivities and kept 0",
+		},
+	}
+
+	for _, tc := range testCases {
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(tc.activities...)
+		o.Quiet = tc.quiet
+
+		var err error
+		output := log.CaptureOutput(func() {
+			err = o.Run()
+		})
+		require.NoError(t, err, "for test %s", tc.name)
+
+		if tc.expectItems {
+			assert.Contains(t, output, "deleting PipelineActivity", "should log each deletion for test %s", tc.name)
+		} else {
+			assert.NotContains(t, output, "deleting PipelineActivity", "should not log each deletion for test %s", tc.name)
+		}
+		assert.Contains(t, output, tc.summary, "should log the summary for test %s", tc.name)
+	}
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.Quiet = true
+	o.Verbose = true
+	err := o.Run()
+	require.Error(t, err, "should not allow both quiet and verbose")
+}
@@ -0,0 +1,100 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/pkg/errors"
+)
+
+// Archiver stores the data of a PipelineActivity in object storage before it is deleted
+type Archiver interface {
+	// Archive uploads the data to the given key in the bucket URL
+	Archive(ctx context.Context, bucketURL, key string, data []byte) error
+}
+
+// CLIArchiver uploads to object storage via the cloud provider CLI: 'gsutil' for gs:// and 'aws' for s3:// buckets
+type CLIArchiver struct {
+	CommandRunner cmdrunner.CommandRunner
+}
+
+// ValidateBucketURL returns an error if the bucket URL is not supported by the CLIArchiver
+func ValidateBucketURL(bucketURL string) error {
+	if strings.HasPrefix(bucketURL, "gs://") || strings.HasPrefix(bucketURL, "s3://") {
+		return nil
+	}
+	return errors.Errorf("unsupported archive bucket %s should start with gs:// or s3://", bucketURL)
+}
+
+// Archive uploads the data to the bucket
+func (a *CLIArchiver) Archive(ctx context.Context, bucketURL, key string, data []byte) error {
+	err := ValidateBucketURL(bucketURL)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile("", "jx-gc-activity-*.json")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary file")
+	}
+	fileName := f.Name()
+	f.Close()
+	defer os.Remove(fileName)
+
+	err = ioutil.WriteFile(fileName, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", fileName)
+	}
+
+	dest := strings.TrimSuffix(bucketURL, "/") + "/" + key
+	c := &cmdrunner.Command{
+		Name: "gsutil",
+		Args: []string{"cp", fileName, dest},
+	}
+	if strings.HasPrefix(bucketURL, "s3://") {
+		c = &cmdrunner.Command{
+			Name: "aws",
+			Args: []string{"s3", "cp", fileName, dest},
+		}
+	}
+	commandRunner := a.CommandRunner
+	if commandRunner == nil {
+		commandRunner = cmdrunner.QuietCommandRunner
+	}
+	_, err = commandRunner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to run %s", c.CLI())
+	}
+	return nil
+}
+
+// archiveKey returns the key in the bucket for the activity
+func (o *Options) archiveKey(a *v1.PipelineActivity) string {
+	return path.Join(o.ArchivePrefix, a.Namespace, a.Name+".json")
+}
+
+// archiveActivity uploads the activity to the archive bucket if one is configured
+func (o *Options) archiveActivity(ctx context.Context, a *v1.PipelineActivity) error {
+	if o.ArchiveBucket == "" {
+		return nil
+	}
+	archived := a.DeepCopy()
+	archived.APIVersion = "jenkins.io/v1"
+	archived.Kind = "PipelineActivity"
+	data, err := json.Marshal(archived)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal PipelineActivity %s to JSON", a.Name)
+	}
+	key := o.archiveKey(a)
+	err = o.Archiver.Archive(ctx, o.ArchiveBucket, key, data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to archive PipelineActivity %s to %s", a.Name, key)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner/fakerunner"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeArchiver struct {
+	fail     bool
+	archived map[string][]byte
+	lock     sync.Mutex
+}
+
+func (a *fakeArchiver) Archive(ctx context.Context, bucketURL, key string, data []byte) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.fail {
+		return errors.Errorf("bucket %s is not available", bucketURL)
+	}
+	if a.archived == nil {
+		a.archived = map[string][]byte{}
+	}
+	a.archived[bucketURL+"/"+key] = data
+	return nil
+}
+
+func TestGCPipelineActivitiesArchive(t *testing.T) {
+	ctx := context.TODO()
+	ns := "jx"
+
+	testCases := []struct {
+		name            string
+		fail            bool
+		continueOnError bool
+		expectError     bool
+		expectDeleted   bool
+	}{
+		{
+			name:          "archived",
+			expectDeleted: true,
+		},
+		{
+			name:        "archive-fails",
+			fail:        true,
+			expectError: true,
+		},
+		{
+			name:            "archive-fails-continue-on-error",
+			fail:            true,
+			continueOnError: true,
+			expectDeleted:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		jxClient := jxfake.NewSimpleClientset(
+			&v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "old",
+					Namespace: ns,
+					Labels: map[string]string{
+						v1.LabelBranch: "master",
+					},
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/project/master",
+					CompletedTimestamp: &metav1.Time{Time: time.Now().AddDate(0, 0, -31)},
+				},
+			},
+		)
+		archiver := &fakeArchiver{fail: tc.fail}
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.ArchiveBucket = "gs://my-bucket"
+		o.ArchivePrefix = "activities"
+		o.ContinueOnError = tc.continueOnError
+		o.Archiver = archiver
+
+		err := o.Run()
+		if tc.expectError {
+			require.Error(t, err, "for test %s", tc.name)
+		} else {
+			require.NoError(t, err, "for test %s", tc.name)
+		}
+
+		list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(ctx, metav1.ListOptions{})
+		require.NoError(t, err, "failed to list activities for test %s", tc.name)
+		if tc.expectDeleted {
+			assert.Empty(t, list.Items, "should have deleted the activity for test %s", tc.name)
+		} else {
+			assert.Len(t, list.Items, 1, "should not have deleted the activity for test %s", tc.name)
+		}
+
+		if tc.fail {
+			assert.Empty(t, archiver.archived, "should not have archived for test %s", tc.name)
+			continue
+		}
+		data := archiver.archived["gs://my-bucket/activities/jx/old.json"]
+		require.NotEmpty(t, data, "should have archived the activity for test %s", tc.name)
+
+		pa := &v1.PipelineActivity{}
+		err = json.Unmarshal(data, pa)
+		require.NoError(t, err, "failed to unmarshal archived activity for test %s", tc.name)
+		assert.Equal(t, "old", pa.Name, "archived activity name for test %s", tc.name)
+		assert.Equal(t, "PipelineActivity", pa.Kind, "archived activity kind for test %s", tc.name)
+	}
+}
+
+func TestCLIArchiver(t *testing.T) {
+	ctx := context.TODO()
+	for _, bucket := range []string{"gs://my-bucket", "s3://my-bucket/"} {
+		runner := &fakerunner.FakeRunner{}
+		archiver := &activities.CLIArchiver{CommandRunner: runner.Run}
+
+		err := archiver.Archive(ctx, bucket, "activities/jx/old.json", []byte("{}"))
+		require.NoError(t, err, "failed to archive to %s", bucket)
+		require.Len(t, runner.OrderedCommands, 1, "commands for %s", bucket)
+
+		c := runner.OrderedCommands[0]
+		args := c.Args
+		require.NotEmpty(t, args, "args for %s", bucket)
+		assert.Equal(t, strings.TrimSuffix(bucket, "/")+"/activities/jx/old.json", args[len(args)-1], "destination for %s", bucket)
+		if strings.HasPrefix(bucket, "s3://") {
+			assert.Equal(t, "aws", c.Name)
+		} else {
+			assert.Equal(t, "gsutil", c.Name)
+		}
+	}
+
+	err := activities.ValidateBucketURL("https://my-bucket")
+	assert.Error(t, err, "should not support https buckets")
+}
@@ -0,0 +1,83 @@
+// +build unit
+
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGCPipelineActivitiesCountBuildNumbers(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newActivity := func(name, build string, age time.Duration) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "myorg/myrepo/master",
+				Build:              build,
+				CompletedTimestamp: &metav1.Time{Time: now.Add(-age)},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		countBuildNumbers bool
+		expected          map[string]activities.DeleteReason
+	}{
+		{
+			name: "count-activities",
+			expected: map[string]activities.DeleteReason{
+				"build-2":     activities.DeleteReasonHistoryRelease,
+				"build-1":     activities.DeleteReasonHistoryRelease,
+				"build-other": activities.DeleteReasonHistoryRelease,
+			},
+		},
+		{
+			name:              "count-build-numbers",
+			countBuildNumbers: true,
+			expected: map[string]activities.DeleteReason{
+				"build-1":     activities.DeleteReasonHistoryRelease,
+				"build-other": activities.DeleteReasonHistoryRelease,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		objects := []runtime.Object{
+			newActivity("build-3-rerun", "3", time.Hour),
+			newActivity("build-3", "3", 2*time.Hour),
+			newActivity("build-2", "2", 3*time.Hour),
+			newActivity("build-1", "1", 4*time.Hour),
+			newActivity("build-other", "not-a-number", 5*time.Hour),
+		}
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(objects...)
+		o.ReleaseHistoryLimit = 2
+		o.KeepLastSuccess = false
+		o.CountBuildNumbers = tc.countBuildNumbers
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		assert.Equal(t, tc.expected, o.Deleted, "deleted activities for %s", tc.name)
+	}
+}
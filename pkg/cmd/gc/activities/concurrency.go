@@ -0,0 +1,100 @@
+package activities
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	jv1 "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/typed/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// signalContext returns a context which is cancelled when an interrupt or terminate signal is received
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case s := <-signals:
+			log.Logger().Infof("received signal %s so shutting down", s.String())
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// deleteCandidates deletes the candidates using up to --concurrency workers returning the number deleted and the number
+// which were not deleted as they could not be archived. The first error cancels the remaining deletions.
+// A dry run uses a single worker so the candidates are logged in order
+func (o *Options) deleteCandidates(ctx context.Context, activityInterface jv1.PipelineActivityInterface, candidates []deletion) (int, int, error) {
+	workers := o.Concurrency
+	if workers < 1 || o.DryRun {
+		workers = 1
+	}
+	if workers > len(candidates) {
+		workers = len(candidates)
+	}
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lock sync.Mutex
+	var firstErr error
+	deleted := 0
+	archiveFailures := 0
+
+	jobs := make(chan *deletion)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range jobs {
+				if workerCtx.Err() != nil {
+					continue
+				}
+				start := time.Now()
+				removed, err := o.deleteActivity(workerCtx, activityInterface, &d.activity, d.reason)
+				o.traceDeletion(&d.activity, d.reason, start, removed, err)
+
+				lock.Lock()
+				switch {
+				case err != nil:
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				case removed:
+					deleted++
+				default:
+					archiveFailures++
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+queue:
+	for i := range candidates {
+		select {
+		case jobs <- &candidates[i]:
+		case <-workerCtx.Done():
+			break queue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr == nil && ctx.Err() != nil {
+		firstErr = errors.Wrapf(ctx.Err(), "cancelled after deleting %d PipelineActivities", deleted)
+	}
+	return deleted, archiveFailures, firstErr
+}
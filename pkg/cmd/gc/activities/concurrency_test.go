@@ -0,0 +1,187 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newConcurrencyActivities creates old release activities named release-1 to release-count from newest to oldest
+func newConcurrencyActivities(ns string, count int) []runtime.Object {
+	completed := time.Now().AddDate(0, 0, -60)
+	var answer []runtime.Object
+	for i := 1; i <= count; i++ {
+		answer = append(answer, &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("release-%d", i),
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           fmt.Sprintf("org/repo-%d/master", i),
+				Status:             v1.ActivityStatusTypeFailed,
+				CompletedTimestamp: &metav1.Time{Time: completed.Add(time.Duration(-i) * time.Minute)},
+			},
+		})
+	}
+	return answer
+}
+
+// inFlightArchiver records the most archives in flight at once
+type inFlightArchiver struct {
+	lock        sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (a *inFlightArchiver) Archive(ctx context.Context, bucketURL, key string, data []byte) error {
+	a.lock.Lock()
+	a.inFlight++
+	if a.inFlight > a.maxInFlight {
+		a.maxInFlight = a.inFlight
+	}
+	a.lock.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	a.lock.Lock()
+	a.inFlight--
+	a.lock.Unlock()
+	return nil
+}
+
+func TestGCPipelineActivitiesConcurrency(t *testing.T) {
+	ns := "jx"
+	count := 20
+	concurrency := 4
+
+	jxClient := jxfake.NewSimpleClientset(newConcurrencyActivities(ns, count)...)
+
+	// the fake clientset serialises its actions so lets track the parallelism via the archiver
+	archiver := &inFlightArchiver{}
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	o.ArchiveBucket = "gs://my-bucket"
+	o.Archiver = archiver
+	o.Concurrency = concurrency
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	assert.Len(t, o.Deleted, count, "deleted activities")
+	assert.True(t, archiver.maxInFlight > 1, "should delete in parallel but the most deletions in flight was %d", archiver.maxInFlight)
+	assert.True(t, archiver.maxInFlight <= concurrency, "should delete at most %d in parallel but was %d", concurrency, archiver.maxInFlight)
+
+	list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err, "failed to list activities")
+	assert.Empty(t, list.Items, "remaining activities")
+}
+
+func TestGCPipelineActivitiesConcurrencyFirstError(t *testing.T) {
+	ns := "jx"
+	count := 20
+
+	jxClient := jxfake.NewSimpleClientset(newConcurrencyActivities(ns, count)...)
+	jxClient.PrependReactor("delete", "pipelineactivities", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.DeleteAction).GetName() == "release-2" {
+			return true, nil, errors.New("the server is on fire")
+		}
+		time.Sleep(20 * time.Millisecond)
+		return false, nil, nil
+	})
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	o.Concurrency = 2
+
+	err := o.Run()
+	require.Error(t, err, "should fail when a deletion fails")
+	assert.Contains(t, err.Error(), "the server is on fire")
+
+	list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err, "failed to list activities")
+	assert.NotEmpty(t, list.Items, "the remaining deletions should be cancelled after the first error")
+}
+
+func TestGCPipelineActivitiesConcurrencyCancelled(t *testing.T) {
+	ns := "jx"
+	count := 5
+
+	jxClient := jxfake.NewSimpleClientset(newConcurrencyActivities(ns, count)...)
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	err := o.Validate()
+	require.NoError(t, err, "failed to validate")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = o.Collect(ctx)
+	require.Error(t, err, "should fail when cancelled")
+	assert.Contains(t, err.Error(), "cancelled")
+
+	list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err, "failed to list activities")
+	assert.Len(t, list.Items, count, "no activities should be deleted once cancelled")
+}
+
+func TestGCPipelineActivitiesConcurrencyDryRunOrder(t *testing.T) {
+	ns := "jx"
+	count := 10
+	recorder := &spanRecorder{}
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset(newConcurrencyActivities(ns, count)...)
+	o.DryRun = true
+	o.Concurrency = 5
+	o.SpanExporter = recorder
+	o.OtelDeletionSpans = true
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	// the deletion spans are recorded in the order the activities are deleted after the run span
+	require.Len(t, recorder.spans, count+1, "spans")
+	for i := 1; i <= count; i++ {
+		assert.Equal(t, fmt.Sprintf("release-%d", i), recorder.spans[i].Attributes["gc.activity"], "activity of deletion span %d", i)
+	}
+}
+
+func TestGCPipelineActivitiesInvalidConcurrency(t *testing.T) {
+	_, o := activities.NewCmdGCActivities()
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.Concurrency = 0
+
+	err := o.Validate()
+	require.Error(t, err, "should fail for an invalid concurrency")
+}
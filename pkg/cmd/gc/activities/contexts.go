@@ -0,0 +1,167 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	jxc "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ContextClients the clients and default namespace of a kubeconfig context
+type ContextClients struct {
+	Namespace     string
+	JXClient      jxc.Interface
+	KubeClient    kubernetes.Interface
+	TektonClient  tektonclient.Interface
+	DynamicClient dynamic.Interface
+}
+
+// ContextClientsFactory creates the clients of a kubeconfig context
+type ContextClientsFactory func(kubeContext string) (*ContextClients, error)
+
+// ContextReport the report of the garbage collection of a kubeconfig context
+type ContextReport struct {
+	// Context the name of the kubeconfig context
+	Context string `json:"context"`
+
+	// Namespace the namespace the PipelineActivities were garbage collected in
+	Namespace string `json:"namespace"`
+
+	// Error the error if the garbage collection of the context failed
+	Error string `json:"error,omitempty"`
+
+	*Report
+}
+
+// NewContextClients creates the clients of the kubeconfig context using the default kubeconfig loading rules
+func NewContextClients(kubeContext string) (*ContextClients, error) {
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	)
+	cfg, err := config.ClientConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the kubernetes config of context %s", kubeContext)
+	}
+	ns, _, err := config.Namespace()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the namespace of context %s", kubeContext)
+	}
+	c := &ContextClients{Namespace: ns}
+	c.JXClient, err = jxc.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create jx client for context %s", kubeContext)
+	}
+	c.KubeClient, err = kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create kube client for context %s", kubeContext)
+	}
+	c.TektonClient, err = tektonclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create tekton client for context %s", kubeContext)
+	}
+	c.DynamicClient, err = dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create dynamic client for context %s", kubeContext)
+	}
+	return c, nil
+}
+
+// validateContexts verifies the options which cannot be used when garbage collecting multiple kubeconfig contexts
+func (o *Options) validateContexts() error {
+	if o.FromFile != "" {
+		return errors.Errorf("cannot use --from-file with --contexts")
+	}
+	if o.JUnitOutput != "" {
+		return errors.Errorf("cannot use --junit-output with --contexts")
+	}
+	if o.StateFile != "" {
+		return errors.Errorf("cannot use --state-file with --contexts")
+	}
+	if o.ContextClientsFactory == nil {
+		o.ContextClientsFactory = NewContextClients
+	}
+	return nil
+}
+
+// collectContexts garbage collects the PipelineActivities of each kubeconfig context in turn. A failure of one context
+// does not stop the other contexts being garbage collected but is returned once they have all been processed
+func (o *Options) collectContexts(ctx context.Context) error {
+	namespace := o.Namespace
+
+	o.ContextReports = nil
+	total := &Report{DryRun: o.DryRun}
+	var failed []string
+	for _, kubeContext := range o.Contexts {
+		r, err := o.collectContext(ctx, kubeContext, namespace)
+		if err != nil {
+			log.Logger().Errorf("failed to garbage collect PipelineActivities in context %s: %s", info(kubeContext), err.Error())
+			failed = append(failed, kubeContext)
+			r.Error = err.Error()
+		}
+		o.ContextReports = append(o.ContextReports, r)
+		if r.Report == nil {
+			continue
+		}
+		if o.Output != OutputJSON {
+			log.Logger().Infof("context %s: %s", info(kubeContext), r.Report.String())
+		}
+		total.Deleted += r.Deleted
+		total.PullRequests += r.PullRequests
+		total.Releases += r.Releases
+		total.Orphans += r.Orphans
+		total.Stuck += r.Stuck
+		total.Kept += r.Kept
+	}
+	o.Namespace = namespace
+
+	if o.Output == OutputJSON {
+		data, err := json.MarshalIndent(o.ContextReports, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal the report to JSON")
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		if err != nil {
+			return errors.Wrapf(err, "failed to write the report")
+		}
+	} else {
+		log.Logger().Infof("%d contexts: %s", len(o.Contexts), total.String())
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to garbage collect PipelineActivities in contexts: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// collectContext garbage collects the PipelineActivities of the kubeconfig context
+func (o *Options) collectContext(ctx context.Context, kubeContext, namespace string) (*ContextReport, error) {
+	r := &ContextReport{Context: kubeContext}
+	clients, err := o.ContextClientsFactory(kubeContext)
+	if err != nil {
+		return r, err
+	}
+	o.JXClient = clients.JXClient
+	o.KubeClient = clients.KubeClient
+	o.TektonClient = clients.TektonClient
+	o.DynamicClient = clients.DynamicClient
+	o.Namespace = namespace
+	if o.Namespace == "" {
+		o.Namespace = clients.Namespace
+	}
+	r.Namespace = o.Namespace
+
+	summary, err := o.Collect(ctx)
+	if err != nil || summary.Skipped {
+		return r, err
+	}
+	r.Report = o.createReport(summary.Kept)
+	return r, nil
+}
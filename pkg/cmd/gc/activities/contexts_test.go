@@ -0,0 +1,144 @@
+// +build unit
+
+package activities_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGCPipelineActivitiesContexts(t *testing.T) {
+	ctx := context.TODO()
+	now := time.Now()
+
+	newActivities := func(ns string) []runtime.Object {
+		var answer []runtime.Object
+		for i := 1; i <= 3; i++ {
+			answer = append(answer, &v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("release-%d", i),
+					Namespace: ns,
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/repo/master",
+					CompletedTimestamp: &metav1.Time{Time: now.Add(time.Duration(-i) * time.Hour)},
+				},
+			})
+		}
+		return answer
+	}
+	newPolicy := func(ns string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "jx-gc-policy",
+				Namespace: ns,
+			},
+			Data: data,
+		}
+	}
+
+	// the policy of prod limits the release history which should not be used for staging
+	clients := map[string]*activities.ContextClients{
+		"prod": {
+			Namespace:     "jx",
+			JXClient:      jxfake.NewSimpleClientset(newActivities("jx")...),
+			KubeClient:    fake.NewSimpleClientset(newPolicy("jx", map[string]string{"release-history-limit": "1"})),
+			TektonClient:  tektonfake.NewSimpleClientset(),
+			DynamicClient: newFakeDynamicClient(),
+		},
+		"staging": {
+			Namespace:     "jx-staging",
+			JXClient:      jxfake.NewSimpleClientset(newActivities("jx-staging")...),
+			KubeClient:    fake.NewSimpleClientset(newPolicy("jx-staging", map[string]string{"pull-request-age": "24h"})),
+			TektonClient:  tektonfake.NewSimpleClientset(),
+			DynamicClient: newFakeDynamicClient(),
+		},
+	}
+
+	out := &bytes.Buffer{}
+	_, o := activities.NewCmdGCActivities()
+	o.Contexts = []string{"prod", "broken", "staging"}
+	o.PolicyConfigMap = "jx-gc-policy"
+	o.Output = activities.OutputJSON
+	o.Out = out
+	o.ContextClientsFactory = func(kubeContext string) (*activities.ContextClients, error) {
+		c := clients[kubeContext]
+		if c == nil {
+			return nil, errors.Errorf("context %s does not exist", kubeContext)
+		}
+		return c, nil
+	}
+	err := o.Run()
+	require.Error(t, err, "should fail as the broken context does not exist")
+	assert.Contains(t, err.Error(), "failed to garbage collect PipelineActivities in contexts: broken", "error")
+
+	t.Logf("%s\n", out.String())
+	var reports []*activities.ContextReport
+	err = json.Unmarshal(out.Bytes(), &reports)
+	require.NoError(t, err, "failed to parse the JSON report")
+	require.Len(t, reports, 3, "context reports")
+
+	prod := reports[0]
+	assert.Equal(t, "prod", prod.Context, "context")
+	assert.Equal(t, "jx", prod.Namespace, "namespace of prod")
+	require.NotNil(t, prod.Report, "report of prod")
+	assert.Equal(t, 2, prod.Deleted, "deleted in prod")
+	assert.Equal(t, 1, prod.Kept, "kept in prod")
+
+	broken := reports[1]
+	assert.Equal(t, "broken", broken.Context, "context")
+	assert.Contains(t, broken.Error, "context broken does not exist", "error of broken")
+	assert.Nil(t, broken.Report, "report of broken")
+
+	staging := reports[2]
+	assert.Equal(t, "staging", staging.Context, "context")
+	assert.Equal(t, "jx-staging", staging.Namespace, "namespace of staging")
+	require.NotNil(t, staging.Report, "report of staging")
+	assert.Equal(t, 0, staging.Deleted, "deleted in staging")
+	assert.Equal(t, 3, staging.Kept, "kept in staging")
+
+	for name, ns := range map[string]string{"prod": "jx", "staging": "jx-staging"} {
+		list, err := clients[name].JXClient.JenkinsV1().PipelineActivities(ns).List(ctx, metav1.ListOptions{})
+		require.NoError(t, err, "failed to list PipelineActivities of %s", name)
+		assert.Len(t, list.Items, 3-reportFor(reports, name).Deleted, "remaining PipelineActivities of %s", name)
+	}
+}
+
+func TestGCPipelineActivitiesContextsInvalidOptions(t *testing.T) {
+	_, o := activities.NewCmdGCActivities()
+	o.Contexts = []string{"prod"}
+	o.JUnitOutput = "report.xml"
+	err := o.Validate()
+	require.Error(t, err, "should not support --junit-output with --contexts")
+
+	_, o = activities.NewCmdGCActivities()
+	o.Contexts = []string{"prod"}
+	o.StateFile = "state.json"
+	err = o.Validate()
+	require.Error(t, err, "should not support --state-file with --contexts")
+}
+
+func reportFor(reports []*activities.ContextReport, kubeContext string) *activities.ContextReport {
+	for _, r := range reports {
+		if r.Context == kubeContext {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package activities
+
+import (
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+)
+
+// creationWindow the range of creation times of the PipelineActivities to garbage collect.
+// The start is inclusive and the end is exclusive. A zero time means the range is unbounded
+type creationWindow struct {
+	after  time.Time
+	before time.Time
+}
+
+// parseCreationWindow parses the RFC 3339 times of the --created-after and --created-before flags
+func parseCreationWindow(createdAfter, createdBefore string) (*creationWindow, error) {
+	w := &creationWindow{}
+	var err error
+	if createdAfter != "" {
+		w.after, err = time.Parse(time.RFC3339, createdAfter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid --created-after %s should be an RFC 3339 time such as 2021-01-02T15:04:05Z", createdAfter)
+		}
+	}
+	if createdBefore != "" {
+		w.before, err = time.Parse(time.RFC3339, createdBefore)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid --created-before %s should be an RFC 3339 time such as 2021-01-02T15:04:05Z", createdBefore)
+		}
+	}
+	if !w.after.IsZero() && !w.before.IsZero() && !w.after.Before(w.before) {
+		return nil, errors.Errorf("--created-after %s must be before --created-before %s", createdAfter, createdBefore)
+	}
+	return w, nil
+}
+
+// Contains returns true if the activity was created within the window
+func (w *creationWindow) Contains(a *v1.PipelineActivity) bool {
+	created := a.CreationTimestamp.Time
+	if !w.after.IsZero() && created.Before(w.after) {
+		return false
+	}
+	if !w.before.IsZero() && !created.Before(w.before) {
+		return false
+	}
+	return true
+}
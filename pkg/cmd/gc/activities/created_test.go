@@ -0,0 +1,121 @@
+// +build unit
+
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGCPipelineActivitiesCreationWindow(t *testing.T) {
+	ns := "jx"
+	start := time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	created := map[string]time.Time{
+		"before-window": start.Add(-time.Second),
+		"at-start":      start,
+		"inside":        start.Add(time.Hour),
+		"at-end":        end,
+		"after-window":  end.Add(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		createdAfter  string
+		createdBefore string
+		expected      []string
+	}{
+		{
+			name:          "window",
+			createdAfter:  "2021-03-01T10:00:00Z",
+			createdBefore: "2021-03-01T12:00:00Z",
+			expected:      []string{"at-start", "inside"},
+		},
+		{
+			name:         "after-only",
+			createdAfter: "2021-03-01T12:00:00Z",
+			expected:     []string{"at-end", "after-window"},
+		},
+		{
+			name:          "before-only",
+			createdBefore: "2021-03-01T10:00:00Z",
+			expected:      []string{"before-window"},
+		},
+	}
+
+	for _, tc := range testCases {
+		var objects []runtime.Object
+		i := 0
+		for name, creationTime := range created {
+			i++
+			objects = append(objects, &v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         ns,
+					CreationTimestamp: metav1.Time{Time: creationTime},
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/repo/PR-1",
+					CompletedTimestamp: &metav1.Time{Time: time.Now().AddDate(0, 0, -3).Add(time.Duration(i) * time.Minute)},
+				},
+			})
+		}
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(objects...)
+		o.CreatedAfter = tc.createdAfter
+		o.CreatedBefore = tc.createdBefore
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		var deleted []string
+		for name := range o.Deleted {
+			deleted = append(deleted, name)
+		}
+		assert.ElementsMatch(t, tc.expected, deleted, "deleted activities for %s", tc.name)
+	}
+}
+
+func TestGCPipelineActivitiesInvalidCreationWindow(t *testing.T) {
+	testCases := []struct {
+		createdAfter  string
+		createdBefore string
+	}{
+		{
+			createdAfter: "yesterday",
+		},
+		{
+			createdBefore: "2021-03-01",
+		},
+		{
+			createdAfter:  "2021-03-01T12:00:00Z",
+			createdBefore: "2021-03-01T12:00:00Z",
+		},
+	}
+	for _, tc := range testCases {
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = "jx"
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset()
+		o.CreatedAfter = tc.createdAfter
+		o.CreatedBefore = tc.createdBefore
+
+		err := o.Run()
+		require.Error(t, err, "should fail for --created-after %s --created-before %s", tc.createdAfter, tc.createdBefore)
+	}
+}
@@ -0,0 +1,41 @@
+package activities
+
+import (
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// matchesFilters returns true if the activity matches the --selector, --repo, --only-pipeline-context and --exclude-pipeline-context filters
+func (o *Options) matchesFilters(a *v1.PipelineActivity) bool {
+	// the selector is passed to the List call when using the cluster so only needs checking for activities loaded from a file
+	if o.FromFile != "" && o.selector != nil && !o.selector.Matches(labels.Set(a.Labels)) {
+		return false
+	}
+	if len(o.OnlyPipelineContexts) > 0 && !containsFold(o.OnlyPipelineContexts, a.Spec.Context) {
+		return false
+	}
+	if containsFold(o.ExcludePipelineContexts, a.Spec.Context) {
+		return false
+	}
+	if len(o.Repositories) == 0 {
+		return true
+	}
+	return containsFold(o.Repositories, a.RepositoryOwner()+"/"+a.RepositoryName())
+}
+
+// hasFilters returns true if the activities are filtered by label, repository or context
+func (o *Options) hasFilters() bool {
+	return o.LabelSelector != "" || len(o.Repositories) > 0 || len(o.OnlyPipelineContexts) > 0 || len(o.ExcludePipelineContexts) > 0
+}
+
+// containsFold returns true if the values contain the text ignoring case
+func containsFold(values []string, text string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, text) {
+			return true
+		}
+	}
+	return false
+}
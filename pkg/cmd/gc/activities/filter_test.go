@@ -0,0 +1,194 @@
+//go:build unit
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGCPipelineActivitiesFilters(t *testing.T) {
+	ns := "shared"
+	completed := time.Now().AddDate(0, 0, -3)
+
+	newActivity := func(name, pipeline, team string) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels: map[string]string{
+					"team": team,
+				},
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           pipeline,
+				CompletedTimestamp: &metav1.Time{Time: completed},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		selector     string
+		repositories []string
+		dryRun       bool
+		expected     []string
+	}{
+		{
+			name:     "selector",
+			selector: "team=red",
+			expected: []string{"red-app", "red-lib"},
+		},
+		{
+			name:         "repo",
+			repositories: []string{"myorg/blue-app", "MyOrg/Red-Lib"},
+			expected:     []string{"blue-app", "red-lib"},
+		},
+		{
+			name:         "selector-and-repo",
+			selector:     "team=red",
+			repositories: []string{"myorg/red-app"},
+			expected:     []string{"red-app"},
+		},
+		{
+			name:     "dry-run",
+			selector: "team=blue",
+			dryRun:   true,
+			expected: []string{"blue-app"},
+		},
+	}
+
+	for _, tc := range testCases {
+		objects := []runtime.Object{
+			newActivity("red-app", "myorg/red-app/PR-1", "red"),
+			newActivity("red-lib", "myorg/red-lib/PR-1", "red"),
+			newActivity("blue-app", "myorg/blue-app/PR-1", "blue"),
+		}
+		jxClient := jxfake.NewSimpleClientset(objects...)
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.LabelSelector = tc.selector
+		o.Repositories = tc.repositories
+		o.DryRun = tc.dryRun
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		var deleted []string
+		for name := range o.Deleted {
+			deleted = append(deleted, name)
+		}
+		assert.ElementsMatch(t, tc.expected, deleted, "deleted activities for %s", tc.name)
+
+		list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(t, err)
+		remaining := len(objects) - len(tc.expected)
+		if tc.dryRun {
+			remaining = len(objects)
+		}
+		assert.Len(t, list.Items, remaining, "remaining activities for %s", tc.name)
+	}
+
+	_, o := activities.NewCmdGCActivities()
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.Namespace = ns
+	o.LabelSelector = "team in (red"
+	err := o.Run()
+	require.Error(t, err, "should fail for an invalid selector")
+}
+
+func TestGCPipelineActivitiesContextFilters(t *testing.T) {
+	ns := "jx"
+	completed := time.Now().AddDate(0, 0, -3)
+
+	newActivity := func(name, pipelineContext string) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "myorg/myapp/PR-1",
+				Context:            pipelineContext,
+				CompletedTimestamp: &metav1.Time{Time: completed},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name                    string
+		excludePipelineContexts []string
+		onlyPipelineContexts    []string
+		expected                []string
+	}{
+		{
+			name:     "no-filters",
+			expected: []string{"release", "promote", "lint", "preview", "none"},
+		},
+		{
+			name:                    "exclude-pipeline-context",
+			excludePipelineContexts: []string{"release", "Promote"},
+			expected:                []string{"lint", "preview", "none"},
+		},
+		{
+			name:                 "only-pipeline-context",
+			onlyPipelineContexts: []string{"lint", "preview"},
+			expected:             []string{"lint", "preview"},
+		},
+		{
+			name:                    "only-and-exclude-pipeline-context",
+			onlyPipelineContexts:    []string{"lint", "preview"},
+			excludePipelineContexts: []string{"preview"},
+			expected:                []string{"lint"},
+		},
+	}
+
+	for _, tc := range testCases {
+		jxClient := jxfake.NewSimpleClientset(
+			newActivity("release", "release"),
+			newActivity("promote", "promote"),
+			newActivity("lint", "lint"),
+			newActivity("preview", "preview"),
+			newActivity("none", ""),
+		)
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.KeepLastSuccess = false
+		o.ExcludePipelineContexts = tc.excludePipelineContexts
+		o.OnlyPipelineContexts = tc.onlyPipelineContexts
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		var deleted []string
+		for name := range o.Deleted {
+			deleted = append(deleted, name)
+		}
+		assert.ElementsMatch(t, tc.expected, deleted, "deleted activities for %s", tc.name)
+
+		list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, list.Items, 5-len(tc.expected), "remaining activities for %s", tc.name)
+	}
+}
@@ -0,0 +1,70 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jv1 "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/typed/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultProtectFinalizer the finalizer added to a PipelineActivity while it is being archived
+const DefaultProtectFinalizer = "jenkins.io/archive"
+
+// protectingFinalizers returns the --protect-finalizer finalizers of the activity
+func (o *Options) protectingFinalizers(a *v1.PipelineActivity) []string {
+	var answer []string
+	for _, f := range a.Finalizers {
+		if stringhelpers.StringArrayIndex(o.ProtectFinalizers, f) >= 0 {
+			answer = append(answer, f)
+		}
+	}
+	return answer
+}
+
+// isProtectedByFinalizer returns true if the activity has a protective finalizer so it should not be deleted yet. If
+// --remove-finalizers is enabled the finalizers are removed when the activity is deleted instead
+func (o *Options) isProtectedByFinalizer(a *v1.PipelineActivity) bool {
+	finalizers := o.protectingFinalizers(a)
+	if len(finalizers) == 0 || o.RemoveFinalizers {
+		return false
+	}
+	if !o.Quiet {
+		log.Logger().Infof("not deleting PipelineActivity %s as it has the finalizers %v", info(a.Name), finalizers)
+	}
+	return true
+}
+
+// removeProtectingFinalizers removes the protective finalizers of the activity so that it can be deleted
+func (o *Options) removeProtectingFinalizers(ctx context.Context, activityInterface jv1.PipelineActivityInterface, a *v1.PipelineActivity) error {
+	finalizers := o.protectingFinalizers(a)
+	if len(finalizers) == 0 {
+		return nil
+	}
+	remaining := []string{}
+	for _, f := range a.Finalizers {
+		if stringhelpers.StringArrayIndex(finalizers, f) < 0 {
+			remaining = append(remaining, f)
+		}
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers": remaining,
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the finalizers patch")
+	}
+	_, err = activityInterface.Patch(ctx, a.Name, types.MergePatchType, data, metav1.PatchOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove the finalizers %v of PipelineActivity %s", finalizers, a.Name)
+	}
+	log.Logger().Infof("removed the finalizers %v of PipelineActivity %s", finalizers, info(a.Name))
+	return nil
+}
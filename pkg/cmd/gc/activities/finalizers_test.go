@@ -0,0 +1,101 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGCPipelineActivitiesProtectFinalizers(t *testing.T) {
+	ns := "jx"
+	old := time.Now().AddDate(0, 0, -40)
+
+	newActivity := func(name string, finalizers ...string) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       name,
+				Namespace:  ns,
+				Finalizers: finalizers,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "myorg/myrepo/master",
+				CompletedTimestamp: &metav1.Time{Time: old},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		removeFinalizers bool
+		expectedDeleted  []string
+		expectedKept     []string
+		expectedPatched  []string
+	}{
+		{
+			name:            "protect",
+			expectedDeleted: []string{"no-finalizer", "other-finalizer"},
+			expectedKept:    []string{"archiving"},
+		},
+		{
+			name:             "remove-finalizers",
+			removeFinalizers: true,
+			expectedDeleted:  []string{"archiving", "no-finalizer", "other-finalizer"},
+			expectedPatched:  []string{"archiving"},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.KeepLastSuccess = false
+		o.ReleaseHistoryLimit = 0
+		o.RemoveFinalizers = tc.removeFinalizers
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		jxClient := jxfake.NewSimpleClientset(
+			newActivity("archiving", activities.DefaultProtectFinalizer, "example.com/other"),
+			newActivity("no-finalizer"),
+			newActivity("other-finalizer", "example.com/other"),
+		)
+		o.JXClient = jxClient
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		var deleted []string
+		for name := range o.Deleted {
+			deleted = append(deleted, name)
+		}
+		assert.ElementsMatch(t, tc.expectedDeleted, deleted, "deleted for %s", tc.name)
+
+		list, err := o.JXClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(t, err, "failed to list PipelineActivities for %s", tc.name)
+		var remaining []string
+		for i := range list.Items {
+			a := &list.Items[i]
+			remaining = append(remaining, a.Name)
+			assert.Contains(t, a.Finalizers, activities.DefaultProtectFinalizer, "finalizers of %s for %s", a.Name, tc.name)
+		}
+		assert.ElementsMatch(t, tc.expectedKept, remaining, "remaining for %s", tc.name)
+
+		var patched []string
+		for _, action := range jxClient.Actions() {
+			if patch, ok := action.(k8stesting.PatchAction); ok {
+				patched = append(patched, patch.GetName())
+				assert.JSONEq(t, `{"metadata":{"finalizers":["example.com/other"]}}`, string(patch.GetPatch()), "patch of %s for %s", patch.GetName(), tc.name)
+			}
+		}
+		assert.ElementsMatch(t, tc.expectedPatched, patched, "patched for %s", tc.name)
+	}
+}
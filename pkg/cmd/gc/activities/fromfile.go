@@ -0,0 +1,36 @@
+package activities
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadActivitiesFile loads the PipelineActivities from a JSON or YAML file containing either a list resource
+// such as the output of 'kubectl get pipelineactivities -o json' or an array of PipelineActivities
+func LoadActivitiesFile(fileName string) ([]v1.PipelineActivity, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file %s", fileName)
+	}
+	data = bytes.TrimSpace(data)
+
+	// an array of activities is a sequence in YAML or an array in JSON
+	if bytes.HasPrefix(data, []byte("[")) || bytes.HasPrefix(data, []byte("-")) {
+		var activities []v1.PipelineActivity
+		err = yaml.Unmarshal(data, &activities)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal PipelineActivities in file %s", fileName)
+		}
+		return activities, nil
+	}
+	list := &v1.PipelineActivityList{}
+	err = yaml.Unmarshal(data, list)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal PipelineActivityList in file %s", fileName)
+	}
+	return list.Items, nil
+}
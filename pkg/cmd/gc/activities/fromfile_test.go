@@ -0,0 +1,61 @@
+// +build unit
+
+package activities_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCPipelineActivitiesFromFile(t *testing.T) {
+	now := time.Date(2021, time.June, 10, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		file     string
+		expected map[string]activities.DeleteReason
+	}{
+		{
+			file: "activities.json",
+			expected: map[string]activities.DeleteReason{
+				"master-1": activities.DeleteReasonHistoryRelease,
+				"master-2": activities.DeleteReasonHistoryRelease,
+				"pr-1":     activities.DeleteReasonAgePR,
+			},
+		},
+		{
+			file: "activities.yaml",
+			expected: map[string]activities.DeleteReason{
+				"old-release": activities.DeleteReasonAgeRelease,
+				"orphan":      activities.DeleteReasonOrphan,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, o := activities.NewCmdGCActivities()
+		o.FromFile = filepath.Join("test_data", tc.file)
+		o.Clock = func() time.Time {
+			return now
+		}
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run for %s", tc.file)
+		assert.True(t, o.DryRun, "should be a dry run for %s", tc.file)
+		assert.Nil(t, o.JXClient, "should not create a jx client for %s", tc.file)
+		assert.Equal(t, tc.expected, o.Deleted, "deleted activities for %s", tc.file)
+	}
+}
+
+func TestGCPipelineActivitiesFromFileDeletePods(t *testing.T) {
+	_, o := activities.NewCmdGCActivities()
+	o.FromFile = filepath.Join("test_data", "activities.json")
+	o.DeletePods = true
+
+	err := o.Run()
+	require.Error(t, err, "should not support --delete-pods with --from-file")
+}
@@ -0,0 +1,86 @@
+// +build unit
+
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGCPipelineActivitiesGlobalKeepNewest(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newActivity := func(name, pipeline string, age time.Duration) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           pipeline,
+				Status:             v1.ActivityStatusTypeFailed,
+				CompletedTimestamp: &metav1.Time{Time: now.Add(-age)},
+			},
+		}
+	}
+	day := 24 * time.Hour
+
+	testCases := []struct {
+		name       string
+		keepNewest int
+		expected   []string
+	}{
+		{
+			name:     "disabled",
+			expected: []string{"a-1", "a-2", "a-3", "b-1", "b-2", "c-1"},
+		},
+		{
+			name:       "keep-newest-3",
+			keepNewest: 3,
+			expected:   []string{"a-2", "b-2", "c-1"},
+		},
+		{
+			name:       "keep-more-than-exist",
+			keepNewest: 10,
+		},
+	}
+
+	for _, tc := range testCases {
+		// all the activities are older than the release age so the per repository rules would delete them all
+		objects := []runtime.Object{
+			newActivity("a-3", "myorg/a/master", 8*day),
+			newActivity("b-1", "myorg/b/master", 9*day),
+			newActivity("a-1", "myorg/a/master", 10*day),
+			newActivity("a-2", "myorg/a/master", 11*day),
+			newActivity("b-2", "myorg/b/master", 12*day),
+			newActivity("c-1", "myorg/c/master", 13*day),
+		}
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(objects...)
+		o.ReleaseAgeLimit = 7 * day
+		o.GlobalKeepNewest = tc.keepNewest
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		var deleted []string
+		for name := range o.Deleted {
+			deleted = append(deleted, name)
+		}
+		assert.ElementsMatch(t, tc.expected, deleted, "deleted activities for %s", tc.name)
+	}
+}
@@ -0,0 +1,85 @@
+package activities
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// IssueFinder finds the open issues of a repository
+type IssueFinder interface {
+	// OpenIssues returns the open issues of the repository
+	OpenIssues(ctx context.Context, owner, repository string) ([]*scm.Issue, error)
+}
+
+// ScmIssueFinder finds the open issues of a repository using the git provider
+type ScmIssueFinder struct {
+	ScmClient *scm.Client
+	PageSize  int
+}
+
+// OpenIssues returns all the pages of open issues of the repository
+func (f *ScmIssueFinder) OpenIssues(ctx context.Context, owner, repository string) ([]*scm.Issue, error) {
+	pageSize := f.PageSize
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	fullName := scm.Join(owner, repository)
+	var answer []*scm.Issue
+	for page := 1; ; page++ {
+		issues, _, err := f.ScmClient.Issues.List(ctx, fullName, scm.IssueListOptions{Page: page, Size: pageSize, Open: true})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list open issues of %s", fullName)
+		}
+		answer = append(answer, issues...)
+		if len(issues) < pageSize {
+			return answer, nil
+		}
+	}
+}
+
+// isReferencedByOpenIssue returns true if the build URL or build logs URL of the activity is in the title or body
+// of an open issue of its repository. The open issues of each repository are only queried once per gc run
+func (o *Options) isReferencedByOpenIssue(ctx context.Context, a *v1.PipelineActivity) (bool, error) {
+	if !o.ProtectFromIssues {
+		return false, nil
+	}
+	var urls []string
+	for _, u := range []string{a.Spec.BuildURL, a.Spec.BuildLogsURL} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	owner := a.RepositoryOwner()
+	repository := a.RepositoryName()
+	if len(urls) == 0 || owner == "" || repository == "" {
+		return false, nil
+	}
+
+	fullName := scm.Join(owner, repository)
+	if o.openIssues == nil {
+		o.openIssues = map[string][]*scm.Issue{}
+	}
+	issues, ok := o.openIssues[fullName]
+	if !ok {
+		var err error
+		issues, err = o.IssueFinder.OpenIssues(ctx, owner, repository)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to find open issues of %s", fullName)
+		}
+		o.openIssues[fullName] = issues
+	}
+	for _, issue := range issues {
+		for _, u := range urls {
+			if strings.Contains(issue.Body, u) || strings.Contains(issue.Title, u) {
+				log.Logger().Infof("keeping PipelineActivity %s as it is referenced by open issue %s", info(a.Name), info(issue.Link))
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
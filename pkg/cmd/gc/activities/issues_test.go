@@ -0,0 +1,98 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	"github.com/jenkins-x/go-scm/scm"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeIssueFinder returns the open issues for each repository
+type fakeIssueFinder struct {
+	issues  map[string][]*scm.Issue
+	queries []string
+}
+
+func (f *fakeIssueFinder) OpenIssues(ctx context.Context, owner, repository string) ([]*scm.Issue, error) {
+	fullName := scm.Join(owner, repository)
+	f.queries = append(f.queries, fullName)
+	return f.issues[fullName], nil
+}
+
+func TestGCPipelineActivitiesProtectFromIssues(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newActivity := func(name, owner, repository, buildURL string) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           owner + "/" + repository + "/master",
+				GitOwner:           owner,
+				GitRepository:      repository,
+				GitBranch:          "master",
+				BuildURL:           buildURL,
+				CompletedTimestamp: &metav1.Time{Time: now.AddDate(0, 0, -60)},
+			},
+		}
+	}
+
+	finder := &fakeIssueFinder{
+		issues: map[string][]*scm.Issue{
+			"org/repo": {
+				{
+					Number: 1,
+					Title:  "unrelated issue",
+					Body:   "nothing to see here",
+				},
+				{
+					Number: 2,
+					Title:  "release pipeline is failing",
+					Body:   "see the failed build at https://dashboard.acme.com/org/repo/master/2 for details",
+					Link:   "https://github.com/org/repo/issues/2",
+				},
+			},
+		},
+	}
+
+	jxClient := jxfake.NewSimpleClientset(
+		newActivity("referenced", "org", "repo", "https://dashboard.acme.com/org/repo/master/2"),
+		newActivity("not-referenced", "org", "repo", "https://dashboard.acme.com/org/repo/master/1"),
+		newActivity("other-repo", "org", "another", "https://dashboard.acme.com/org/repo/master/2"),
+		newActivity("no-url", "org", "repo", ""),
+	)
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	o.ProtectFromIssues = true
+	o.IssueFinder = finder
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	assert.NotContains(t, o.Deleted, "referenced", "should keep the activity referenced by an open issue")
+	assert.Contains(t, o.Deleted, "not-referenced", "should delete the activity which is not referenced")
+	assert.Contains(t, o.Deleted, "other-repo", "should only check the issues of the repository of the activity")
+	assert.Contains(t, o.Deleted, "no-url", "should delete the activity without a build URL")
+
+	assert.ElementsMatch(t, []string{"org/repo", "org/another"}, finder.queries, "should only query the issues of each repository once")
+
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).Get(context.TODO(), "referenced", metav1.GetOptions{})
+	require.NoError(t, err, "should not have deleted the referenced activity")
+}
@@ -0,0 +1,166 @@
+package activities
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+// KeepReason the reason a PipelineActivity was not deleted
+type KeepReason string
+
+const (
+	// KeepReasonLimits the PipelineActivity is within the age and history limits
+	KeepReasonLimits KeepReason = "within_limits"
+
+	// KeepReasonGlobalNewest the PipelineActivity is one of the --global-keep-newest PipelineActivities
+	KeepReasonGlobalNewest KeepReason = "global_newest"
+
+	// KeepReasonLastSuccess the PipelineActivity is the last successful build of its branch
+	KeepReasonLastSuccess KeepReason = "last_success"
+
+	// KeepReasonOpenIssue the PipelineActivity is referenced by an open issue
+	KeepReasonOpenIssue KeepReason = "open_issue"
+
+	// KeepReasonFinalizer the PipelineActivity has a protective finalizer
+	KeepReasonFinalizer KeepReason = "finalizer"
+
+	// KeepReasonArchiveFailed the PipelineActivity could not be archived
+	KeepReasonArchiveFailed KeepReason = "archive_failed"
+)
+
+// JUnitTestSuites the root element of a JUnit report
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite the decisions of the PipelineActivities of a branch
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase the decision for a PipelineActivity
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitSkipped marks a deleted PipelineActivity
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type keptActivity struct {
+	branch string
+	reason KeepReason
+}
+
+// recordKept records the reason the activity was kept for the JUnit report
+func (o *Options) recordKept(a *v1.PipelineActivity, reason KeepReason) {
+	if o.JUnitOutput == "" {
+		return
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if o.kept == nil {
+		o.kept = map[string]keptActivity{}
+	}
+	o.kept[a.Name] = keptActivity{branch: reportBranch(a), reason: reason}
+}
+
+// createJUnitReport creates a JUnit report with a test suite per branch and a test case per PipelineActivity. Kept
+// PipelineActivities pass and deleted PipelineActivities are skipped so that CI servers can chart the trend of each
+func (o *Options) createJUnitReport() *JUnitTestSuites {
+	suites := map[string]*JUnitTestSuite{}
+	suiteFor := func(branch string) *JUnitTestSuite {
+		s := suites[branch]
+		if s == nil {
+			s = &JUnitTestSuite{Name: branch}
+			suites[branch] = s
+		}
+		return s
+	}
+
+	prefix := "deleted"
+	if o.DryRun {
+		prefix = "would have deleted"
+	}
+	for name, reason := range o.Deleted {
+		branch := o.deletedBranches[name]
+		s := suiteFor(branch)
+		s.Skipped++
+		s.Cases = append(s.Cases, JUnitTestCase{
+			Name:      name,
+			ClassName: branch,
+			Skipped:   &JUnitSkipped{Message: fmt.Sprintf("%s: %s", prefix, reason)},
+		})
+	}
+	for name, k := range o.kept {
+		s := suiteFor(k.branch)
+		s.Cases = append(s.Cases, JUnitTestCase{
+			Name:      name,
+			ClassName: k.branch,
+			SystemOut: fmt.Sprintf("kept: %s", k.reason),
+		})
+	}
+
+	r := &JUnitTestSuites{Name: "gc-activities"}
+	var branches []string
+	for branch := range suites {
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	for _, branch := range branches {
+		s := suites[branch]
+		sort.Slice(s.Cases, func(i, j int) bool {
+			return s.Cases[i].Name < s.Cases[j].Name
+		})
+		s.Tests = len(s.Cases)
+		r.Tests += s.Tests
+		r.Skipped += s.Skipped
+		r.Suites = append(r.Suites, *s)
+	}
+	return r
+}
+
+// writeJUnitReport writes the JUnit report of the last garbage collection to the --junit-output file
+func (o *Options) writeJUnitReport() error {
+	if o.JUnitOutput == "" {
+		return nil
+	}
+	data, err := xml.MarshalIndent(o.createJUnitReport(), "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the JUnit report")
+	}
+	dir := filepath.Dir(o.JUnitOutput)
+	err = os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	data = append([]byte(xml.Header), data...)
+	err = ioutil.WriteFile(o.JUnitOutput, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", o.JUnitOutput)
+	}
+	log.Logger().Debugf("wrote the JUnit report to %s", o.JUnitOutput)
+	return nil
+}
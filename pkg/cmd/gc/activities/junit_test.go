@@ -0,0 +1,96 @@
+// +build unit
+
+package activities_test
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGCPipelineActivitiesJUnitReport(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newActivity := func(name, pipeline string, completed time.Time) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           pipeline,
+				CompletedTimestamp: &metav1.Time{Time: completed},
+			},
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "reports", "gc-activities.xml")
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.DryRun = true
+	o.KeepLastSuccess = false
+	o.JUnitOutput = path
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset(
+		newActivity("pr-1", "myorg/myrepo/PR-1", now.AddDate(0, 0, -5)),
+		newActivity("release-1", "myorg/myrepo/master", now.AddDate(0, 0, -40)),
+		newActivity("release-2", "myorg/myrepo/master", now.Add(-time.Hour)),
+		newActivity("orphan", "", now.AddDate(0, 0, -40)),
+	)
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "failed to load %s", path)
+	t.Logf("%s\n", string(data))
+
+	report := &activities.JUnitTestSuites{}
+	err = xml.Unmarshal(data, report)
+	require.NoError(t, err, "failed to parse the JUnit report %s", path)
+
+	assert.Equal(t, "testsuites", report.XMLName.Local, "root element")
+	assert.Equal(t, 4, report.Tests, "tests")
+	assert.Equal(t, 3, report.Skipped, "skipped")
+	assert.Equal(t, 0, report.Failures, "failures")
+
+	require.Len(t, report.Suites, 3, "test suites")
+	names := []string{}
+	for _, s := range report.Suites {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"myorg/myrepo/PR-1", "myorg/myrepo/master", "orphan"}, names, "test suite names")
+
+	master := report.Suites[1]
+	assert.Equal(t, 2, master.Tests, "tests of %s", master.Name)
+	assert.Equal(t, 1, master.Skipped, "skipped of %s", master.Name)
+	require.Len(t, master.Cases, 2, "test cases of %s", master.Name)
+
+	deleted := master.Cases[0]
+	assert.Equal(t, "release-1", deleted.Name, "deleted test case")
+	assert.Equal(t, "myorg/myrepo/master", deleted.ClassName, "deleted test case classname")
+	require.NotNil(t, deleted.Skipped, "deleted test case should be skipped")
+	assert.Equal(t, "would have deleted: age_release", deleted.Skipped.Message, "deleted test case message")
+
+	kept := master.Cases[1]
+	assert.Equal(t, "release-2", kept.Name, "kept test case")
+	assert.Nil(t, kept.Skipped, "kept test case should pass")
+	assert.Equal(t, "kept: "+string(activities.KeepReasonLimits), kept.SystemOut, "kept test case output")
+
+	pr := report.Suites[0].Cases
+	require.Len(t, pr, 1, "test cases of the pull request")
+	require.NotNil(t, pr[0].Skipped, "pull request test case should be skipped")
+	assert.Equal(t, "would have deleted: age_pr", pr[0].Skipped.Message, "pull request test case message")
+}
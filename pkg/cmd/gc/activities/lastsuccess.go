@@ -0,0 +1,28 @@
+package activities
+
+import (
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+)
+
+// repoBranchAndContext returns the key of the repository, branch and context of the activity
+func repoBranchAndContext(activity *v1.PipelineActivity) string {
+	return activity.RepositoryOwner() + "/" + activity.RepositoryName() + "/" + activity.BranchName() + "/" + activity.Spec.Context
+}
+
+// isLastSuccess returns true if --keep-last-success is enabled and the activity is the newest successful activity of its
+// repository, branch and context. The activities must be passed newest first so the successes map records the keys
+// whose newest successful activity has already been found
+func (o *Options) isLastSuccess(activity *v1.PipelineActivity, successes map[string]bool) bool {
+	if !o.KeepLastSuccess || activity.Spec.Status != v1.ActivityStatusTypeSucceeded {
+		return false
+	}
+	if activity.RepositoryOwner() == "" || activity.RepositoryName() == "" {
+		return false
+	}
+	key := repoBranchAndContext(activity)
+	if successes[key] {
+		return false
+	}
+	successes[key] = true
+	return true
+}
@@ -0,0 +1,83 @@
+// +build unit
+
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGCPipelineActivitiesKeepLastSuccess(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newActivity := func(name, pipeline string, status v1.ActivityStatusType, age time.Duration) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           pipeline,
+				Status:             status,
+				CompletedTimestamp: &metav1.Time{Time: now.Add(-age)},
+			},
+		}
+	}
+	day := 24 * time.Hour
+
+	testCases := []struct {
+		name            string
+		keepLastSuccess bool
+		expected        []string
+	}{
+		{
+			name:            "keep-last-success",
+			keepLastSuccess: true,
+			expected:        []string{"old-failed", "older-success", "other-older-success"},
+		},
+		{
+			name:     "disabled",
+			expected: []string{"old-failed", "old-success", "older-success", "other-older-success"},
+		},
+	}
+
+	for _, tc := range testCases {
+		objects := []runtime.Object{
+			// the last success of a release branch is older than the release age
+			newActivity("old-failed", "myorg/myrepo/master", v1.ActivityStatusTypeFailed, 10*day),
+			newActivity("old-success", "myorg/myrepo/master", v1.ActivityStatusTypeSucceeded, 11*day),
+			newActivity("older-success", "myorg/myrepo/master", v1.ActivityStatusTypeSucceeded, 12*day),
+
+			// the last success of another repository is recent so its older successes are not protected
+			newActivity("other-success", "myorg/other/master", v1.ActivityStatusTypeSucceeded, time.Hour),
+			newActivity("other-older-success", "myorg/other/master", v1.ActivityStatusTypeSucceeded, 12*day),
+		}
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(objects...)
+		o.ReleaseAgeLimit = 7 * day
+		o.KeepLastSuccess = tc.keepLastSuccess
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		var deleted []string
+		for name := range o.Deleted {
+			deleted = append(deleted, name)
+		}
+		assert.ElementsMatch(t, tc.expected, deleted, "deleted activities for %s", tc.name)
+	}
+}
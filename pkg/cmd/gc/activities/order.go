@@ -0,0 +1,54 @@
+package activities
+
+import (
+	"encoding/json"
+	"sort"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+)
+
+const (
+	// DeleteOrderCompleted deletes the PipelineActivities in the order of their completion time with the newest first
+	DeleteOrderCompleted = "completed"
+
+	// DeleteOrderSizeDesc deletes the PipelineActivities with the largest serialized size first
+	DeleteOrderSizeDesc = "size-desc"
+)
+
+// DeleteOrders the supported values of --delete-order
+var DeleteOrders = []string{DeleteOrderCompleted, DeleteOrderSizeDesc}
+
+// deletion a PipelineActivity which is to be deleted along with the reason
+type deletion struct {
+	activity v1.PipelineActivity
+	reason   DeleteReason
+}
+
+// sortDeletions sorts the activities to be deleted by the --delete-order. The deletions are already
+// ordered by completion time so this order is kept for activities of the same size
+func (o *Options) sortDeletions(deletions []deletion) {
+	if o.DeleteOrder != DeleteOrderSizeDesc {
+		return
+	}
+	sizer := o.Sizer
+	if sizer == nil {
+		sizer = ActivitySize
+	}
+	sizes := map[string]int{}
+	for i := range deletions {
+		a := &deletions[i].activity
+		sizes[a.Name] = sizer(a)
+	}
+	sort.SliceStable(deletions, func(i, j int) bool {
+		return sizes[deletions[i].activity.Name] > sizes[deletions[j].activity.Name]
+	})
+}
+
+// ActivitySize returns the size of the activity when serialized to JSON
+func ActivitySize(a *v1.PipelineActivity) int {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
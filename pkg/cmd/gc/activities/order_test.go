@@ -0,0 +1,131 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGCPipelineActivitiesDeleteOrder(t *testing.T) {
+	ctx := context.TODO()
+	ns := "jx"
+	now := time.Now()
+
+	sizes := map[string]int{
+		"a": 10,
+		"b": 300,
+		"c": 50,
+		"d": 300,
+		"e": 1000,
+	}
+
+	testCases := []struct {
+		deleteOrder string
+		expected    []string
+	}{
+		{
+			deleteOrder: activities.DeleteOrderCompleted,
+			expected:    []string{"a", "b", "c", "d"},
+		},
+		{
+			deleteOrder: activities.DeleteOrderSizeDesc,
+			expected:    []string{"b", "d", "c", "a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		jxClient := jxfake.NewSimpleClientset()
+		// the activities are completed in reverse alphabetical order so 'a' is the newest
+		for i, name := range []string{"a", "b", "c", "d"} {
+			_, err := jxClient.JenkinsV1().PipelineActivities(ns).Create(ctx, &v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns,
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/repo/PR-1",
+					CompletedTimestamp: &metav1.Time{Time: now.AddDate(0, 0, -3-i)},
+				},
+			}, metav1.CreateOptions{})
+			require.NoError(t, err, "failed to create activity %s", name)
+		}
+
+		// the largest activity is still running so must not be deleted
+		_, err := jxClient.JenkinsV1().PipelineActivities(ns).Create(ctx, &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "e",
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline: "org/repo/PR-2",
+			},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err, "failed to create activity e")
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.Concurrency = 1
+		o.DeleteOrder = tc.deleteOrder
+		o.Sizer = func(a *v1.PipelineActivity) int {
+			return sizes[a.Name]
+		}
+
+		err = o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.deleteOrder)
+
+		var deleted []string
+		for _, action := range jxClient.Actions() {
+			if d, ok := action.(k8stesting.DeleteAction); ok {
+				deleted = append(deleted, d.GetName())
+			}
+		}
+		assert.Equal(t, tc.expected, deleted, "deletion order for %s", tc.deleteOrder)
+	}
+}
+
+func TestGCPipelineActivitiesInvalidDeleteOrder(t *testing.T) {
+	_, o := activities.NewCmdGCActivities()
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.Namespace = "jx"
+	o.DeleteOrder = "random"
+
+	err := o.Run()
+	require.Error(t, err, "should fail for an invalid --delete-order")
+}
+
+func TestActivitySize(t *testing.T) {
+	small := &v1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "small",
+		},
+	}
+	large := small.DeepCopy()
+	large.Spec.Steps = []v1.PipelineActivityStep{
+		{
+			Kind: v1.ActivityStepKindTypeStage,
+			Stage: &v1.StageActivityStep{
+				CoreActivityStep: v1.CoreActivityStep{
+					Name:        "build",
+					Description: "a step which makes the activity larger",
+				},
+			},
+		},
+	}
+	assert.True(t, activities.ActivitySize(large) > activities.ActivitySize(small), "a larger activity should have a larger size")
+}
@@ -0,0 +1,44 @@
+package activities
+
+import (
+	"context"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/pkg/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkDeletePermission verifies the current user can delete PipelineActivities in the namespace using a
+// SelfSubjectAccessReview so that we fail before deleting anything rather than part of the way through
+func (o *Options) checkDeletePermission(ctx context.Context, ns string) error {
+	if !o.CheckPermissions || o.FromFile != "" {
+		return nil
+	}
+	var err error
+	o.KubeClient, err = kube.LazyCreateKubeClient(o.KubeClient)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create kube client")
+	}
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: ns,
+				Verb:      "delete",
+				Group:     "jenkins.io",
+				Resource:  "pipelineactivities",
+			},
+		},
+	}
+	review, err = o.KubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to check permission to delete PipelineActivities in namespace %s", ns)
+	}
+	if !review.Status.Allowed {
+		if review.Status.Reason != "" {
+			return errors.Errorf("not permitted to delete PipelineActivities in namespace %s: %s", ns, review.Status.Reason)
+		}
+		return errors.Errorf("not permitted to delete PipelineActivities in namespace %s", ns)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGCPipelineActivitiesCheckPermissions(t *testing.T) {
+	ns := "jx"
+	completed := metav1.NewTime(time.Now().Add(-time.Hour * 24 * 60))
+
+	testCases := []struct {
+		name    string
+		allowed bool
+		reason  string
+	}{
+		{
+			name:    "allowed",
+			allowed: true,
+		},
+		{
+			name:   "denied",
+			reason: "RBAC: access denied",
+		},
+	}
+
+	for _, tc := range testCases {
+		activity := &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "old",
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "myorg/myrepo/master",
+				CompletedTimestamp: &completed,
+			},
+		}
+
+		var reviews []*authorizationv1.SelfSubjectAccessReview
+		kubeClient := fake.NewSimpleClientset()
+		kubeClient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			reviews = append(reviews, review)
+			result := review.DeepCopy()
+			result.Status.Allowed = tc.allowed
+			result.Status.Reason = tc.reason
+			return true, result, nil
+		})
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.CheckPermissions = true
+		o.KeepLastSuccess = false
+		o.KubeClient = kubeClient
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(activity)
+
+		err := o.Run()
+
+		require.Len(t, reviews, 1, "SelfSubjectAccessReviews for %s", tc.name)
+		attrs := reviews[0].Spec.ResourceAttributes
+		require.NotNil(t, attrs, "ResourceAttributes for %s", tc.name)
+		assert.Equal(t, ns, attrs.Namespace, "namespace for %s", tc.name)
+		assert.Equal(t, "delete", attrs.Verb, "verb for %s", tc.name)
+		assert.Equal(t, "jenkins.io", attrs.Group, "group for %s", tc.name)
+		assert.Equal(t, "pipelineactivities", attrs.Resource, "resource for %s", tc.name)
+
+		if !tc.allowed {
+			require.Error(t, err, "should fail for %s", tc.name)
+			assert.Contains(t, err.Error(), "not permitted to delete PipelineActivities in namespace jx: RBAC: access denied")
+			assert.Empty(t, o.Deleted, "deleted activities for %s", tc.name)
+
+			_, err = o.JXClient.JenkinsV1().PipelineActivities(ns).Get(context.TODO(), "old", metav1.GetOptions{})
+			assert.NoError(t, err, "should not have deleted the PipelineActivity for %s", tc.name)
+			continue
+		}
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+		assert.Equal(t, map[string]activities.DeleteReason{"old": activities.DeleteReasonAgeRelease}, o.Deleted, "deleted activities for %s", tc.name)
+	}
+}
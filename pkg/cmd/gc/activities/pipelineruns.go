@@ -0,0 +1,95 @@
+package activities
+
+import (
+	"context"
+	"time"
+
+	"github.com/jenkins-x/jx-kube-client/v3/pkg/kubeclient"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+)
+
+// CompletedPipelineRunReasons the reasons of the Succeeded condition of a PipelineRun which has completed
+var CompletedPipelineRunReasons = []string{
+	v1beta1.PipelineRunReasonSuccessful.String(),
+	v1beta1.PipelineRunReasonFailed.String(),
+	v1beta1.PipelineRunReasonCompleted.String(),
+}
+
+// LazyCreateTektonClient lazily creates the tekton client if its not defined
+func LazyCreateTektonClient(client tektonclient.Interface) (tektonclient.Interface, error) {
+	if client != nil {
+		return client, nil
+	}
+	f := kubeclient.NewFactory()
+	cfg, err := f.CreateKubeConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get kubernetes config")
+	}
+	client, err = tektonclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "error building tekton clientset")
+	}
+	return client, nil
+}
+
+// gcPipelineRuns deletes the completed PipelineRuns which are older than the --pipelinerun-age
+func (o *Options) gcPipelineRuns(ctx context.Context, ns string, now time.Time) error {
+	if o.PipelineRunAgeLimit <= 0 || o.FromFile != "" {
+		return nil
+	}
+	pipelineRunInterface := o.TektonClient.TektonV1beta1().PipelineRuns(ns)
+	pipelineRuns, err := pipelineRunInterface.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list PipelineRuns in namespace %s", ns)
+	}
+	prefix := ""
+	if o.DryRun {
+		prefix = "not "
+	}
+	deleted := 0
+	for i := range pipelineRuns.Items {
+		pr := &pipelineRuns.Items[i]
+		if !isCompletedPipelineRun(pr) || !o.isTooOld(pr.Status.CompletionTime.Time, o.PipelineRunAgeLimit, now) {
+			continue
+		}
+		if !o.Quiet {
+			log.Logger().Infof("%sdeleting PipelineRun %s", prefix, info(pr.Name))
+		}
+		deleted++
+		if o.DryRun {
+			continue
+		}
+		err = pipelineRunInterface.Delete(ctx, pr.Name, *metav1.NewDeleteOptions(0))
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete PipelineRun %s", pr.Name)
+		}
+	}
+	summary := "deleted"
+	if o.DryRun {
+		summary = "would have deleted"
+	}
+	log.Logger().Infof("%s %d PipelineRuns", summary, deleted)
+	return nil
+}
+
+// isCompletedPipelineRun returns true if the PipelineRun has succeeded, failed or completed
+func isCompletedPipelineRun(pr *v1beta1.PipelineRun) bool {
+	if pr.Status.CompletionTime == nil {
+		return false
+	}
+	c := pr.Status.GetCondition(apis.ConditionSucceeded)
+	if c == nil {
+		return false
+	}
+	for _, reason := range CompletedPipelineRunReasons {
+		if c.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
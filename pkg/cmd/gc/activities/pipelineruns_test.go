@@ -0,0 +1,84 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1beta1 "knative.dev/pkg/apis/duck/v1beta1"
+)
+
+func TestGCPipelineRuns(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newPipelineRun := func(name, reason string, completed *time.Time) *v1beta1.PipelineRun {
+		pr := &v1beta1.PipelineRun{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Status: v1beta1.PipelineRunStatus{
+				Status: duckv1beta1.Status{
+					Conditions: duckv1beta1.Conditions{
+						{
+							Type:   apis.ConditionSucceeded,
+							Status: corev1.ConditionTrue,
+							Reason: reason,
+						},
+					},
+				},
+			},
+		}
+		if completed != nil {
+			pr.Status.CompletionTime = &metav1.Time{Time: *completed}
+		}
+		return pr
+	}
+	old := now.Add(-13 * time.Hour)
+	recent := now.Add(-time.Hour)
+
+	for _, dryRun := range []bool{false, true} {
+		tektonClient := tektonfake.NewSimpleClientset(
+			newPipelineRun("old-succeeded", "Succeeded", &old),
+			newPipelineRun("old-failed", "Failed", &old),
+			newPipelineRun("old-completed", "Completed", &old),
+			newPipelineRun("old-cancelled", "Cancelled", &old),
+			newPipelineRun("recent-succeeded", "Succeeded", &recent),
+			newPipelineRun("running", "Running", nil),
+		)
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.DryRun = dryRun
+		o.JXClient = jxfake.NewSimpleClientset()
+		o.TektonClient = tektonClient
+		o.DynamicClient = newFakeDynamicClient()
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command with dry run %v", dryRun)
+
+		list, err := tektonClient.TektonV1beta1().PipelineRuns(ns).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(t, err)
+		var remaining []string
+		for i := range list.Items {
+			remaining = append(remaining, list.Items[i].Name)
+		}
+		if dryRun {
+			assert.Len(t, remaining, 6, "should not delete any PipelineRuns in dry run mode")
+			continue
+		}
+		assert.ElementsMatch(t, []string{"old-cancelled", "recent-succeeded", "running"}, remaining, "remaining PipelineRuns")
+	}
+}
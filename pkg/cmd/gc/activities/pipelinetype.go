@@ -0,0 +1,34 @@
+package activities
+
+import (
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+)
+
+const (
+	// PipelineTypePullRequest the label value of a pull request pipeline
+	PipelineTypePullRequest = "pullrequest"
+
+	// PipelineTypeBatch the label value of a batch pipeline
+	PipelineTypeBatch = "batch"
+
+	// PipelineTypeRelease the label value of a release pipeline
+	PipelineTypeRelease = "release"
+)
+
+// isPullRequestOrBatch returns whether the activity is for a pull request or batch. If --pipeline-type-label is
+// specified and the activity has a recognised value for the label it is used, otherwise the branch name is used
+func (o *Options) isPullRequestOrBatch(activity *v1.PipelineActivity) (bool, bool) {
+	if o.PipelineTypeLabel != "" && activity.Labels != nil {
+		switch strings.ToLower(activity.Labels[o.PipelineTypeLabel]) {
+		case PipelineTypePullRequest, "pr":
+			return true, false
+		case PipelineTypeBatch:
+			return false, true
+		case PipelineTypeRelease:
+			return false, false
+		}
+	}
+	return o.isPullRequestOrBatchBranch(activity.BranchName())
+}
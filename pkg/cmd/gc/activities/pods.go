@@ -0,0 +1,57 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BuildIDLabels the labels on a PipelineActivity and its pipeline Pods which identify the build
+var BuildIDLabels = []string{"lighthouse.jenkins-x.io/buildNum", "buildID"}
+
+// deleteActivityPods deletes the Pods of the pipeline of the given activity which are labelled with its build identifier
+// if --delete-pods is enabled
+func (o *Options) deleteActivityPods(ctx context.Context, a *v1.PipelineActivity) error {
+	if !o.DeletePods {
+		return nil
+	}
+	podInterface := o.KubeClient.CoreV1().Pods(a.Namespace)
+	prefix := ""
+	if o.DryRun {
+		prefix = "not "
+	}
+	found := false
+	for _, label := range BuildIDLabels {
+		buildID := a.Labels[label]
+		if buildID == "" {
+			continue
+		}
+		found = true
+		selector := fmt.Sprintf("%s=%s", label, buildID)
+		pods, err := podInterface.List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list Pods in namespace %s with selector %s", a.Namespace, selector)
+		}
+		for i := range pods.Items {
+			name := pods.Items[i].Name
+			if !o.Quiet {
+				log.Logger().Infof("%sdeleting Pod %s of PipelineActivity %s", prefix, info(name), info(a.Name))
+			}
+			if o.DryRun {
+				continue
+			}
+			err = podInterface.Delete(ctx, name, *metav1.NewDeleteOptions(0))
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete Pod %s of PipelineActivity %s", name, a.Name)
+			}
+		}
+	}
+	if !found {
+		log.Logger().Debugf("PipelineActivity %s has no build identifier label so not deleting its Pods", a.Name)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGCPipelineActivitiesDeletePods(t *testing.T) {
+	ctx := context.TODO()
+	ns := "jx"
+	now := time.Now()
+	buildLabel := "lighthouse.jenkins-x.io/buildNum"
+
+	newActivity := func(name, buildID string, age time.Duration) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels: map[string]string{
+					buildLabel: buildID,
+				},
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "org/repo/master",
+				CompletedTimestamp: &metav1.Time{Time: now.Add(-age)},
+			},
+		}
+	}
+	newPod := func(name, buildID string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels: map[string]string{
+					buildLabel: buildID,
+				},
+			},
+		}
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		jxClient := jxfake.NewSimpleClientset(
+			newActivity("old", "1001", time.Hour*24*60),
+			newActivity("new", "1002", time.Hour),
+		)
+		kubeClient := fake.NewSimpleClientset(
+			newPod("old-build-pod", "1001"),
+			newPod("old-build-pod-2", "1001"),
+			newPod("new-build-pod", "1002"),
+			newPod("other-pod", "999"),
+		)
+
+		_, o := activities.NewCmdGCActivities()
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.KubeClient = kubeClient
+		o.Namespace = ns
+		o.DeletePods = true
+		o.DryRun = dryRun
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run gc with dry run %v", dryRun)
+
+		pods, err := kubeClient.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+
+		var names []string
+		for _, p := range pods.Items {
+			names = append(names, p.Name)
+		}
+		if dryRun {
+			assert.ElementsMatch(t, []string{"old-build-pod", "old-build-pod-2", "new-build-pod", "other-pod"}, names, "remaining pods for dry run")
+		} else {
+			assert.ElementsMatch(t, []string{"new-build-pod", "other-pod"}, names, "remaining pods")
+		}
+	}
+}
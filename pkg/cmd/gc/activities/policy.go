@@ -0,0 +1,109 @@
+package activities
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type policyField struct {
+	name     string
+	intValue *int
+	duration *time.Duration
+}
+
+// policyFields the retention settings which can be specified in the policy ConfigMap. The keys are the flag names
+func (o *Options) policyFields() []policyField {
+	return []policyField{
+		{name: "release-history-limit", intValue: &o.ReleaseHistoryLimit},
+		{name: "pr-history-limit", intValue: &o.PullRequestHistoryLimit},
+		{name: "release-age", duration: &o.ReleaseAgeLimit},
+		{name: "pull-request-age", duration: &o.PullRequestAgeLimit},
+		{name: "pipelinerun-age", duration: &o.PipelineRunAgeLimit},
+		{name: "prowjob-age", duration: &o.ProwJobAgeLimit},
+	}
+}
+
+// copyPolicy takes a copy of the retention settings returning a function which restores them. The policy ConfigMap is
+// applied for a single run so that each run, such as those of the serve command, starts from the command line flags
+func (o *Options) copyPolicy() func() {
+	fields := o.policyFields()
+	ints := make([]int, len(fields))
+	durations := make([]time.Duration, len(fields))
+	for i, f := range fields {
+		if f.intValue != nil {
+			ints[i] = *f.intValue
+		} else {
+			durations[i] = *f.duration
+		}
+	}
+	return func() {
+		for i, f := range fields {
+			if f.intValue != nil {
+				*f.intValue = ints[i]
+			} else {
+				*f.duration = durations[i]
+			}
+		}
+	}
+}
+
+// loadPolicy loads the retention settings from the policy ConfigMap if one is configured.
+// Any flags specified on the command line take precedence over the ConfigMap
+func (o *Options) loadPolicy(ctx context.Context) error {
+	if o.PolicyConfigMap == "" {
+		return nil
+	}
+	var err error
+	o.KubeClient, err = kube.LazyCreateKubeClient(o.KubeClient)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create kube client")
+	}
+	cm, err := o.KubeClient.CoreV1().ConfigMaps(o.Namespace).Get(ctx, o.PolicyConfigMap, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to load policy ConfigMap %s in namespace %s", o.PolicyConfigMap, o.Namespace)
+	}
+
+	fields := map[string]policyField{}
+	for _, f := range o.policyFields() {
+		fields[f.name] = f
+	}
+	for k, v := range cm.Data {
+		f, ok := fields[k]
+		if !ok {
+			log.Logger().Warnf("ignoring unknown key %s in policy ConfigMap %s", k, o.PolicyConfigMap)
+			continue
+		}
+		if o.FlagChanged(k) {
+			continue
+		}
+		if f.intValue != nil {
+			*f.intValue, err = strconv.Atoi(v)
+			if err != nil {
+				return errors.Wrapf(err, "invalid number %s for key %s in policy ConfigMap %s", v, k, o.PolicyConfigMap)
+			}
+			continue
+		}
+		*f.duration, err = time.ParseDuration(v)
+		if err != nil {
+			return errors.Wrapf(err, "invalid duration %s for key %s in policy ConfigMap %s", v, k, o.PolicyConfigMap)
+		}
+	}
+	return nil
+}
+
+// FlagChanged returns true if the given flag was supplied on the command line
+func (o *Options) FlagChanged(name string) bool {
+	if o.Cmd != nil {
+		f := o.Cmd.Flag(name)
+		if f != nil {
+			return f.Changed
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGCPipelineActivitiesPolicyConfigMap(t *testing.T) {
+	ctx := context.TODO()
+	ns := "jx"
+	now := time.Now()
+
+	newActivities := func() []runtime.Object {
+		var answer []runtime.Object
+		for i := 1; i <= 4; i++ {
+			answer = append(answer, &v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("release-%d", i),
+					Namespace: ns,
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/repo/master",
+					CompletedTimestamp: &metav1.Time{Time: now.Add(time.Duration(-i) * time.Hour)},
+				},
+			})
+		}
+		return answer
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jx-gc-policy",
+			Namespace: ns,
+		},
+		Data: map[string]string{
+			"release-history-limit": "1",
+			"pull-request-age":      "24h",
+		},
+	}
+
+	testCases := []struct {
+		name                 string
+		flags                map[string]string
+		expectedHistoryLimit int
+		expectedRemaining    int
+	}{
+		{
+			name:                 "configmap",
+			expectedHistoryLimit: 5,
+			expectedRemaining:    1,
+		},
+		{
+			name: "explicit-flag",
+			flags: map[string]string{
+				"release-history-limit": "3",
+			},
+			expectedHistoryLimit: 3,
+			expectedRemaining:    3,
+		},
+	}
+
+	for _, tc := range testCases {
+		jxClient := jxfake.NewSimpleClientset(newActivities()...)
+
+		cmd, o := activities.NewCmdGCActivities()
+		o.Cmd = cmd
+		for k, v := range tc.flags {
+			err := cmd.Flags().Set(k, v)
+			require.NoError(t, err, "failed to set flag %s for %s", k, tc.name)
+		}
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.KubeClient = fake.NewSimpleClientset(configMap)
+		o.PolicyConfigMap = configMap.Name
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run for %s", tc.name)
+
+		// the policy is only applied to the run so the options keep the values of the flags
+		assert.Equal(t, tc.expectedHistoryLimit, o.ReleaseHistoryLimit, "release history limit for %s", tc.name)
+		assert.Equal(t, 48*time.Hour, o.PullRequestAgeLimit, "pull request age for %s", tc.name)
+		assert.Equal(t, 30*24*time.Hour, o.ReleaseAgeLimit, "release age should keep its default for %s", tc.name)
+
+		list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(ctx, metav1.ListOptions{})
+		require.NoError(t, err, "failed to list activities for %s", tc.name)
+		assert.Len(t, list.Items, tc.expectedRemaining, "remaining activities for %s", tc.name)
+	}
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.KubeClient = fake.NewSimpleClientset()
+	o.PolicyConfigMap = "does-not-exist"
+	err := o.Run()
+	require.Error(t, err, "should fail if the policy ConfigMap does not exist")
+}
+
+func TestGCPipelineActivitiesPolicyConfigMapEachRun(t *testing.T) {
+	ctx := context.TODO()
+	ns := "jx"
+	now := time.Now()
+
+	var objects []runtime.Object
+	for i := 1; i <= 8; i++ {
+		objects = append(objects, &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("release-%d", i),
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "org/repo/master",
+				CompletedTimestamp: &metav1.Time{Time: now.Add(time.Duration(-i) * time.Hour)},
+			},
+		})
+	}
+	jxClient := jxfake.NewSimpleClientset(objects...)
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jx-gc-policy",
+			Namespace: ns,
+		},
+		Data: map[string]string{
+			"release-history-limit": "7",
+		},
+	}
+	kubeClient := fake.NewSimpleClientset(configMap)
+
+	cmd, o := activities.NewCmdGCActivities()
+	o.Cmd = cmd
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	o.KubeClient = kubeClient
+	o.PolicyConfigMap = configMap.Name
+
+	summary, err := o.Collect(ctx)
+	require.NoError(t, err, "failed to run the first garbage collection")
+	assert.Equal(t, 1, summary.Deleted, "the first run should use the history limit of the policy")
+	assert.Equal(t, 5, o.ReleaseHistoryLimit, "the options should keep the value of the flag")
+
+	// lets remove the key from the policy so that the next run reverts to the default of the flag
+	configMap.Data = map[string]string{}
+	_, err = kubeClient.CoreV1().ConfigMaps(ns).Update(ctx, configMap, metav1.UpdateOptions{})
+	require.NoError(t, err, "failed to update the policy ConfigMap")
+
+	summary, err = o.Collect(ctx)
+	require.NoError(t, err, "failed to run the second garbage collection")
+	assert.Equal(t, 2, summary.Deleted, "the second run should use the history limit of the flag")
+
+	list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err, "failed to list activities")
+	assert.Len(t, list.Items, 5, "remaining activities")
+}
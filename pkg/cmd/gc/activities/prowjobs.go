@@ -0,0 +1,81 @@
+package activities
+
+import (
+	"context"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// ProwJobResource the resource of the ProwJobs created by lighthouse
+	ProwJobResource = schema.GroupVersionResource{Group: "prow.k8s.io", Version: "v1", Resource: "prowjobs"}
+
+	// CompletedProwJobStates the states of a ProwJob which has completed
+	CompletedProwJobStates = []string{"success", "failure", "error", "aborted"}
+)
+
+// gcProwJobs deletes the completed ProwJobs whose completion time is older than the --prowjob-age
+func (o *Options) gcProwJobs(ctx context.Context, ns string, now time.Time) error {
+	if o.ProwJobAgeLimit <= 0 || o.FromFile != "" {
+		return nil
+	}
+	prowJobInterface := o.DynamicClient.Resource(ProwJobResource).Namespace(ns)
+	prowJobs, err := prowJobInterface.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list ProwJobs in namespace %s", ns)
+	}
+	prefix := ""
+	if o.DryRun {
+		prefix = "not "
+	}
+	deleted := 0
+	for i := range prowJobs.Items {
+		pj := &prowJobs.Items[i]
+		completed, ok := prowJobCompletionTime(pj)
+		if !ok || !o.isTooOld(completed, o.ProwJobAgeLimit, now) {
+			continue
+		}
+		name := pj.GetName()
+		if !o.Quiet {
+			log.Logger().Infof("%sdeleting ProwJob %s", prefix, info(name))
+		}
+		deleted++
+		if o.DryRun {
+			continue
+		}
+		err = prowJobInterface.Delete(ctx, name, *metav1.NewDeleteOptions(0))
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete ProwJob %s", name)
+		}
+	}
+	summary := "deleted"
+	if o.DryRun {
+		summary = "would have deleted"
+	}
+	log.Logger().Infof("%s %d ProwJobs", summary, deleted)
+	return nil
+}
+
+// prowJobCompletionTime returns the completion time of the ProwJob if it is in a completed state
+func prowJobCompletionTime(pj *unstructured.Unstructured) (time.Time, bool) {
+	state, _, _ := unstructured.NestedString(pj.Object, "status", "state")
+	if stringhelpers.StringArrayIndex(CompletedProwJobStates, state) < 0 {
+		return time.Time{}, false
+	}
+	value, _, _ := unstructured.NestedString(pj.Object, "status", "completionTime")
+	if value == "" {
+		return time.Time{}, false
+	}
+	completed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Logger().Warnf("ignoring ProwJob %s with invalid completionTime %s", pj.GetName(), value)
+		return time.Time{}, false
+	}
+	return completed, true
+}
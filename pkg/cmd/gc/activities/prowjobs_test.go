@@ -0,0 +1,89 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedyn "k8s.io/client-go/dynamic/fake"
+)
+
+// newFakeDynamicClient creates a fake dynamic client which can list ProwJobs
+func newFakeDynamicClient(objects ...runtime.Object) *fakedyn.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{
+		activities.ProwJobResource: "ProwJobList",
+	}
+	return fakedyn.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func TestGCProwJobs(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newProwJob := func(name, state string, completed time.Time) *unstructured.Unstructured {
+		status := map[string]interface{}{
+			"state": state,
+		}
+		if !completed.IsZero() {
+			status["completionTime"] = completed.UTC().Format(time.RFC3339)
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "prow.k8s.io/v1",
+				"kind":       "ProwJob",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": ns,
+				},
+				"status": status,
+			},
+		}
+	}
+	old := now.AddDate(0, 0, -8)
+	recent := now.Add(-time.Hour)
+
+	for _, dryRun := range []bool{false, true} {
+		dynamicClient := newFakeDynamicClient(
+			newProwJob("old-success", "success", old),
+			newProwJob("old-failure", "failure", old),
+			newProwJob("old-error", "error", old),
+			newProwJob("old-aborted", "aborted", old),
+			newProwJob("old-pending", "pending", old),
+			newProwJob("triggered", "triggered", time.Time{}),
+			newProwJob("recent-success", "success", recent),
+		)
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.DryRun = dryRun
+		o.JXClient = jxfake.NewSimpleClientset()
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = dynamicClient
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command with dry run %v", dryRun)
+
+		list, err := dynamicClient.Resource(activities.ProwJobResource).Namespace(ns).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(t, err)
+		var remaining []string
+		for i := range list.Items {
+			remaining = append(remaining, list.Items[i].GetName())
+		}
+		if dryRun {
+			assert.Len(t, remaining, 7, "should not delete any ProwJobs in dry run mode")
+			continue
+		}
+		assert.ElementsMatch(t, []string{"old-pending", "triggered", "recent-success"}, remaining, "remaining ProwJobs")
+	}
+}
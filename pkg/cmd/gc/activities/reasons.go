@@ -0,0 +1,104 @@
+package activities
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+)
+
+// DeleteReason the stable reason code for why a PipelineActivity is deleted which is used
+// in the logs so that downstream audit systems can rely on it
+type DeleteReason string
+
+const (
+	// DeleteReasonAgeRelease the release activity is older than the release age limit
+	DeleteReasonAgeRelease DeleteReason = "age_release"
+
+	// DeleteReasonAgePR the pull request or batch activity is older than the pull request age limit
+	DeleteReasonAgePR DeleteReason = "age_pr"
+
+	// DeleteReasonHistoryRelease the release activity exceeds the release history limit of its repository, branch and context
+	DeleteReasonHistoryRelease DeleteReason = "history_release"
+
+	// DeleteReasonHistoryPR the pull request or batch activity exceeds the pull request history limit of its repository, branch and context
+	DeleteReasonHistoryPR DeleteReason = "history_pr"
+
+	// DeleteReasonOrphan the activity is not associated with a repository and exceeds the age or history limit
+	DeleteReasonOrphan DeleteReason = "orphan"
+
+	// DeleteReasonStuck the activity never completed and was started longer ago than the stuck age limit
+	DeleteReasonStuck DeleteReason = "stuck"
+)
+
+var (
+	// DeleteReasons all the reasons a PipelineActivity can be deleted
+	DeleteReasons = []DeleteReason{
+		DeleteReasonAgeRelease,
+		DeleteReasonAgePR,
+		DeleteReasonHistoryRelease,
+		DeleteReasonHistoryPR,
+		DeleteReasonOrphan,
+		DeleteReasonStuck,
+	}
+)
+
+// deleteReason returns the reason the activity should be deleted or an empty string if it should be kept
+func (o *Options) deleteReason(activity *v1.PipelineActivity, now time.Time, counters *buildsCount) DeleteReason {
+	if activity.Spec.CompletedTimestamp == nil {
+		return ""
+	}
+	isPR, isBatch := o.isPullRequestOrBatch(activity)
+	maxAge, revisionHistory := o.ageAndHistoryLimits(activity, isPR, isBatch)
+	orphan := activity.RepositoryOwner() == "" || activity.RepositoryName() == ""
+
+	// lets remove activities that are too old
+	if o.isTooOld(activity.Spec.CompletedTimestamp.Time, maxAge, now) {
+		switch {
+		case orphan:
+			return DeleteReasonOrphan
+		case isPR || isBatch:
+			return DeleteReasonAgePR
+		default:
+			return DeleteReasonAgeRelease
+		}
+	}
+
+	c := o.countBuild(counters, activity, isPR)
+	if c > revisionHistory {
+		switch {
+		case orphan:
+			return DeleteReasonOrphan
+		case isPR || isBatch:
+			return DeleteReasonHistoryPR
+		default:
+			return DeleteReasonHistoryRelease
+		}
+	}
+	return ""
+}
+
+// isTooOld returns true if the completed time is older than the maximum age. The boundary where the age is exactly
+// the maximum age is only included if --inclusive-age is enabled
+func (o *Options) isTooOld(completed time.Time, maxAge time.Duration, now time.Time) bool {
+	expires := completed.Add(maxAge)
+	if o.InclusiveAge {
+		return !expires.After(now)
+	}
+	return expires.Before(now)
+}
+
+// countBuild adds the activity to the counters returning the number of builds of its repository and branch. If
+// --count-build-numbers is enabled activities with the same build number are counted once
+func (o *Options) countBuild(counters *buildsCount, activity *v1.PipelineActivity, isPR bool) int {
+	key := repoBranchAndContext(activity)
+	if !o.CountBuildNumbers {
+		return counters.AddBuild(key, isPR)
+	}
+	buildNumber, err := strconv.Atoi(strings.TrimSpace(activity.Spec.Build))
+	if err != nil {
+		return counters.AddBuild(key, isPR)
+	}
+	return counters.AddBuildNumber(key, isPR, buildNumber)
+}
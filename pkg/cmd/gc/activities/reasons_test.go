@@ -0,0 +1,170 @@
+// +build unit
+
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGCPipelineActivitiesDeleteReasons(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newActivity := func(name, pipeline string, completed time.Time) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           pipeline,
+				CompletedTimestamp: &metav1.Time{Time: completed},
+			},
+		}
+	}
+
+	jxClient := jxfake.NewSimpleClientset(
+		newActivity("old-release", "org/repo/master", now.AddDate(0, 0, -31)),
+		newActivity("old-pr", "org/repo/PR-1", now.AddDate(0, 0, -3)),
+		newActivity("old-batch", "org/repo/batch", now.AddDate(0, 0, -3)),
+		newActivity("orphan", "", now.AddDate(0, 0, -31)),
+
+		// the release history limit is 1 so only the newest is kept
+		newActivity("release-1", "org/another/master", now.Add(-3*time.Hour)),
+		newActivity("release-2", "org/another/master", now.Add(-2*time.Hour)),
+
+		// the pull request history limit is 1 so only the newest is kept
+		newActivity("pr-1", "org/another/PR-2", now.Add(-3*time.Hour)),
+		newActivity("pr-2", "org/another/PR-2", now.Add(-2*time.Hour)),
+	)
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	o.ReleaseHistoryLimit = 1
+	o.PullRequestHistoryLimit = 1
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	expected := map[string]activities.DeleteReason{
+		"old-release": activities.DeleteReasonAgeRelease,
+		"old-pr":      activities.DeleteReasonAgePR,
+		"old-batch":   activities.DeleteReasonAgePR,
+		"orphan":      activities.DeleteReasonOrphan,
+		"release-1":   activities.DeleteReasonHistoryRelease,
+		"pr-1":        activities.DeleteReasonHistoryPR,
+	}
+	assert.Equal(t, expected, o.Deleted, "delete reasons")
+
+	for _, reason := range o.Deleted {
+		assert.Contains(t, activities.DeleteReasons, reason, "reason %s should be documented", reason)
+	}
+}
+
+func TestGCPipelineActivitiesInclusiveAge(t *testing.T) {
+	ns := "jx"
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	maxAge := time.Hour * 24 * 30
+
+	for _, inclusive := range []bool{false, true} {
+		jxClient := jxfake.NewSimpleClientset(
+			&v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "boundary",
+					Namespace: ns,
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/repo/master",
+					CompletedTimestamp: &metav1.Time{Time: now.Add(-maxAge)},
+				},
+			},
+		)
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.ReleaseAgeLimit = maxAge
+		o.InclusiveAge = inclusive
+		o.Clock = func() time.Time {
+			return now
+		}
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command with inclusive age %v", inclusive)
+
+		if inclusive {
+			assert.Equal(t, activities.DeleteReasonAgeRelease, o.Deleted["boundary"], "should delete the activity at the boundary when inclusive")
+		} else {
+			assert.Empty(t, o.Deleted, "should keep the activity at the boundary by default")
+		}
+	}
+}
+
+func TestGCPipelineActivitiesPipelineTypeLabel(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+	label := "jenkins.io/pipelineType"
+
+	newActivity := func(name, pipeline, pipelineType string, completed time.Time) *v1.PipelineActivity {
+		a := &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           pipeline,
+				CompletedTimestamp: &metav1.Time{Time: completed},
+			},
+		}
+		if pipelineType != "" {
+			a.Labels = map[string]string{label: pipelineType}
+		}
+		return a
+	}
+
+	jxClient := jxfake.NewSimpleClientset(
+		// labelled as pull requests so the pull request age limit is used despite the branch names
+		newActivity("labelled-pr", "org/repo/feature", "pullrequest", now.AddDate(0, 0, -3)),
+		newActivity("labelled-batch", "org/repo/merge-queue", "batch", now.AddDate(0, 0, -3)),
+
+		// labelled as a release so the release age limit is used despite the PR branch name
+		newActivity("labelled-release", "org/repo/PR-5", "release", now.AddDate(0, 0, -3)),
+
+		// unknown or missing labels fall back to the branch name
+		newActivity("unknown-label", "org/repo/PR-6", "something", now.AddDate(0, 0, -3)),
+		newActivity("unlabelled-pr", "org/repo/PR-7", "", now.AddDate(0, 0, -3)),
+		newActivity("unlabelled-release", "org/repo/master", "", now.AddDate(0, 0, -3)),
+	)
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	o.PipelineTypeLabel = label
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	expected := map[string]activities.DeleteReason{
+		"labelled-pr":    activities.DeleteReasonAgePR,
+		"labelled-batch": activities.DeleteReasonAgePR,
+		"unknown-label":  activities.DeleteReasonAgePR,
+		"unlabelled-pr":  activities.DeleteReasonAgePR,
+	}
+	assert.Equal(t, expected, o.Deleted, "deleted activities")
+}
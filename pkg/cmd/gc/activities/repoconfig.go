@@ -0,0 +1,79 @@
+package activities
+
+import (
+	"io/ioutil"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// RepositoryLimits the retention settings of a repository which override the global flags.
+// Any settings which are not specified fall back to the global flags
+type RepositoryLimits struct {
+	ReleaseHistoryLimit     *int   `json:"releaseHistoryLimit,omitempty"`
+	PullRequestHistoryLimit *int   `json:"prHistoryLimit,omitempty"`
+	ReleaseAge              string `json:"releaseAge,omitempty"`
+	PullRequestAge          string `json:"prAge,omitempty"`
+
+	releaseAge     time.Duration
+	pullRequestAge time.Duration
+}
+
+// RepositoryConfig the per repository retention settings loaded from the --config file.
+// The keys are owner/repo or owner/repo/branch where the branch specific settings take precedence
+type RepositoryConfig map[string]*RepositoryLimits
+
+// LoadRepositoryConfig loads the per repository retention settings from the given YAML file
+func LoadRepositoryConfig(path string) (RepositoryConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", path)
+	}
+	config := RepositoryConfig{}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse repository config file %s", path)
+	}
+	for k, l := range config {
+		if l == nil {
+			return nil, errors.Errorf("no settings for %s in repository config file %s", k, path)
+		}
+		if l.ReleaseAge != "" {
+			l.releaseAge, err = time.ParseDuration(l.ReleaseAge)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid releaseAge %s for %s in repository config file %s", l.ReleaseAge, k, path)
+			}
+		}
+		if l.PullRequestAge != "" {
+			l.pullRequestAge, err = time.ParseDuration(l.PullRequestAge)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid prAge %s for %s in repository config file %s", l.PullRequestAge, k, path)
+			}
+		}
+	}
+	return config, nil
+}
+
+// limitsFor returns the settings of the branch of the activity's repository falling back to those of the repository.
+// Returns nil if the repository has no settings
+func (c RepositoryConfig) limitsFor(activity *v1.PipelineActivity) *RepositoryLimits {
+	if len(c) == 0 {
+		return nil
+	}
+	repo := strings.ToLower(activity.RepositoryOwner() + "/" + activity.RepositoryName())
+	branch := activity.BranchName()
+	var repoLimits *RepositoryLimits
+	for k, l := range c {
+		key := strings.ToLower(k)
+		if branch != "" && key == repo+"/"+strings.ToLower(branch) {
+			return l
+		}
+		if key == repo {
+			repoLimits = l
+		}
+	}
+	return repoLimits
+}
@@ -0,0 +1,72 @@
+// +build unit
+
+package activities_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGCPipelineActivitiesRepositoryConfig(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	var objects []runtime.Object
+	for _, pipeline := range []string{"myorg/big/master", "myorg/plain/master", "myorg/sandbox/main", "myorg/sandbox/other"} {
+		for i := 1; i <= 4; i++ {
+			objects = append(objects, &v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-%d", filepath.Base(filepath.Dir(pipeline))+"-"+filepath.Base(pipeline), i),
+					Namespace: ns,
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           pipeline,
+					Build:              fmt.Sprintf("%d", i),
+					CompletedTimestamp: &metav1.Time{Time: now.Add(-time.Duration(5-i) * 10 * time.Minute)},
+				},
+			})
+		}
+	}
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset(objects...)
+	o.ReleaseHistoryLimit = 2
+	o.Config = filepath.Join("test_data", "repoconfig.yaml")
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	var deleted []string
+	for name := range o.Deleted {
+		deleted = append(deleted, name)
+	}
+	// myorg/big keeps all 4 due to its override, myorg/plain has no override so uses the flag,
+	// the main branch of myorg/sandbox keeps only 1 whereas its other branch uses the flag
+	assert.ElementsMatch(t, []string{"plain-master-1", "plain-master-2", "sandbox-main-1", "sandbox-main-2", "sandbox-main-3", "sandbox-other-1", "sandbox-other-2"}, deleted)
+	assert.Equal(t, activities.DeleteReasonHistoryRelease, o.Deleted["sandbox-main-3"])
+}
+
+func TestLoadRepositoryConfigInvalid(t *testing.T) {
+	_, o := activities.NewCmdGCActivities()
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.Namespace = "jx"
+	o.Config = filepath.Join("test_data", "does-not-exist.yaml")
+	err := o.Run()
+	require.Error(t, err, "should fail for a missing config file")
+}
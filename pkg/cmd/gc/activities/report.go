@@ -0,0 +1,128 @@
+package activities
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OutputJSON writes the report of the garbage collection to the output as JSON
+	OutputJSON = "json"
+)
+
+// Outputs the supported values of --output
+var Outputs = []string{OutputJSON}
+
+// Report the report of the PipelineActivities deleted by a garbage collection
+type Report struct {
+	// DryRun whether the PipelineActivities were only logged rather than deleted
+	DryRun bool `json:"dryRun"`
+
+	// Deleted the number of deleted PipelineActivities
+	Deleted int `json:"deleted"`
+
+	// PullRequests the number of deleted pull request and batch PipelineActivities
+	PullRequests int `json:"pullRequests"`
+
+	// Releases the number of deleted release PipelineActivities
+	Releases int `json:"releases"`
+
+	// Orphans the number of deleted PipelineActivities without a repository
+	Orphans int `json:"orphans"`
+
+	// Stuck the number of deleted PipelineActivities which never completed
+	Stuck int `json:"stuck"`
+
+	// Kept the number of kept PipelineActivities
+	Kept int `json:"kept"`
+
+	// Branches the names of the deleted PipelineActivities indexed by owner/repo/branch. PipelineActivities without a
+	// repository are indexed by orphan
+	Branches map[string][]string `json:"branches,omitempty"`
+}
+
+// String returns the summary line of the report
+func (r *Report) String() string {
+	prefix := ""
+	if r.DryRun {
+		prefix = "would have "
+	}
+	counts := []string{fmt.Sprintf("%d PR", r.PullRequests), fmt.Sprintf("%d release", r.Releases)}
+	if r.Orphans > 0 {
+		counts = append(counts, fmt.Sprintf("%d orphan", r.Orphans))
+	}
+	if r.Stuck > 0 {
+		counts = append(counts, fmt.Sprintf("%d stuck", r.Stuck))
+	}
+	return fmt.Sprintf("%sdeleted %d activities (%s), kept %d", prefix, r.Deleted, strings.Join(counts, ", "), r.Kept)
+}
+
+// recordBranch records the owner/repo/branch of the deleted activity for the report
+func (o *Options) recordBranch(a *v1.PipelineActivity) {
+	if o.deletedBranches == nil {
+		o.deletedBranches = map[string]string{}
+	}
+	o.deletedBranches[a.Name] = reportBranch(a)
+}
+
+// reportBranch returns the owner/repo/branch of the activity or orphan if it has no repository
+func reportBranch(a *v1.PipelineActivity) string {
+	if a.RepositoryOwner() != "" && a.RepositoryName() != "" {
+		return a.RepositoryOwner() + "/" + a.RepositoryName() + "/" + a.BranchName()
+	}
+	return string(DeleteReasonOrphan)
+}
+
+// createReport creates the report of the deleted activities of the last garbage collection
+func (o *Options) createReport(kept int) *Report {
+	r := &Report{
+		DryRun: o.DryRun,
+		Kept:   kept,
+	}
+	for name, reason := range o.Deleted {
+		r.Deleted++
+		switch reason {
+		case DeleteReasonAgePR, DeleteReasonHistoryPR:
+			r.PullRequests++
+		case DeleteReasonOrphan:
+			r.Orphans++
+		case DeleteReasonStuck:
+			r.Stuck++
+		default:
+			r.Releases++
+		}
+		if r.Branches == nil {
+			r.Branches = map[string][]string{}
+		}
+		branch := o.deletedBranches[name]
+		r.Branches[branch] = append(r.Branches[branch], name)
+	}
+	for _, names := range r.Branches {
+		sort.Strings(names)
+	}
+	return r
+}
+
+// writeReport logs the summary line of the report or writes it to the output as JSON if --output json is specified
+func (o *Options) writeReport(kept int) error {
+	r := o.createReport(kept)
+	if o.Output != OutputJSON {
+		log.Logger().Info(r.String())
+		return nil
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the report to JSON")
+	}
+	_, err = fmt.Fprintln(o.Out, string(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the report")
+	}
+	return nil
+}
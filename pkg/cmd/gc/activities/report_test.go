@@ -0,0 +1,95 @@
+// +build unit
+
+package activities_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGCPipelineActivitiesReport(t *testing.T) {
+	ns := "jx"
+	old := time.Now().AddDate(0, 0, -40)
+
+	newActivity := func(name, pipeline string) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           pipeline,
+				CompletedTimestamp: &metav1.Time{Time: old},
+			},
+		}
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		out := &bytes.Buffer{}
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.DryRun = dryRun
+		o.KeepLastSuccess = false
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(
+			newActivity("pr-1", "myorg/myrepo/PR-1"),
+			newActivity("pr-2", "myorg/myrepo/PR-1"),
+			newActivity("release-1", "myorg/myrepo/master"),
+			newActivity("other-release-1", "myorg/other/main"),
+			newActivity("orphan", ""),
+		)
+		o.Output = activities.OutputJSON
+		o.Out = out
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command with dry run %v", dryRun)
+
+		report := &activities.Report{}
+		err = json.Unmarshal(out.Bytes(), report)
+		require.NoError(t, err, "failed to parse the report %s", out.String())
+
+		assert.Equal(t, dryRun, report.DryRun, "dryRun")
+		assert.Equal(t, 5, report.Deleted, "deleted")
+		assert.Equal(t, 2, report.PullRequests, "pullRequests")
+		assert.Equal(t, 2, report.Releases, "releases")
+		assert.Equal(t, 1, report.Orphans, "orphans")
+		assert.Equal(t, 0, report.Kept, "kept")
+		assert.Equal(t, map[string][]string{
+			"myorg/myrepo/PR-1":   {"pr-1", "pr-2"},
+			"myorg/myrepo/master": {"release-1"},
+			"myorg/other/main":    {"other-release-1"},
+			"orphan":              {"orphan"},
+		}, report.Branches, "branches")
+	}
+}
+
+func TestReportString(t *testing.T) {
+	r := &activities.Report{Deleted: 42, PullRequests: 18, Releases: 24, Kept: 130}
+	assert.Equal(t, "deleted 42 activities (18 PR, 24 release), kept 130", r.String())
+
+	r = &activities.Report{DryRun: true, Deleted: 3, PullRequests: 1, Releases: 1, Orphans: 1, Kept: 2}
+	assert.Equal(t, "would have deleted 3 activities (1 PR, 1 release, 1 orphan), kept 2", r.String())
+
+	r = &activities.Report{Deleted: 2, Releases: 1, Stuck: 1}
+	assert.Equal(t, "deleted 2 activities (0 PR, 1 release, 1 stuck), kept 0", r.String())
+}
+
+func TestGCPipelineActivitiesInvalidOutput(t *testing.T) {
+	_, o := activities.NewCmdGCActivities()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.Namespace = "jx"
+	o.Output = "yaml"
+	err := o.Run()
+	require.Error(t, err, "should fail for an invalid output")
+}
@@ -0,0 +1,200 @@
+package activities
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// TokenEnvVar the environment variable used for the shared token if --token is not specified
+const TokenEnvVar = "JX_GC_TOKEN"
+
+var (
+	serveLong = templates.LongDesc(`
+		Runs an HTTP server which garbage collects the Jenkins X PipelineActivity resources on demand
+
+Each POST request runs the garbage collection and returns the summary as JSON. Requests must use the shared token in an 'Authorization: Bearer $token' header.
+The token defaults to the $` + TokenEnvVar + ` environment variable.
+`)
+
+	serveExample = templates.Examples(`
+		# runs the garbage collection server
+		jx gitops gc activities serve --address :8080
+
+		# triggers the garbage collection
+		curl -X POST -H "Authorization: Bearer $JX_GC_TOKEN" http://localhost:8080/
+`)
+)
+
+// Summary the results of a garbage collection
+type Summary struct {
+	// DryRun whether the PipelineActivities were only logged rather than deleted
+	DryRun bool `json:"dryRun"`
+
+	// Skipped whether the garbage collection was skipped as it was outside the maintenance window
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Deleted the number of deleted PipelineActivities
+	Deleted int `json:"deleted"`
+
+	// Kept the number of kept PipelineActivities
+	Kept int `json:"kept"`
+
+	// SlowDeletions the number of PipelineActivities which took longer than the --slow-delete-threshold to delete
+	SlowDeletions int `json:"slowDeletions,omitempty"`
+
+	// Activities the reason each PipelineActivity was deleted indexed by name
+	Activities map[string]DeleteReason `json:"activities,omitempty"`
+
+	// Error the error if the garbage collection failed
+	Error string `json:"error,omitempty"`
+}
+
+// ServeOptions the options for running the garbage collection server
+type ServeOptions struct {
+	Options
+	Address         string
+	Token           string
+	ShutdownTimeout time.Duration
+	lock            sync.Mutex
+}
+
+// NewCmdGCActivitiesServe creates a command object for the command
+func NewCmdGCActivitiesServe() (*cobra.Command, *ServeOptions) {
+	o := &ServeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "serve",
+		Short:   "Runs an HTTP server which garbage collects PipelineActivity resources on demand",
+		Long:    serveLong,
+		Example: serveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Cmd = cmd
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Address, "address", "", ":8080", "the address the HTTP server listens on")
+	cmd.Flags().StringVarP(&o.Token, "token", "", "", "the shared token which requests must use as a bearer token. Defaults to the $"+TokenEnvVar+" environment variable")
+	cmd.Flags().DurationVarP(&o.ShutdownTimeout, "shutdown-timeout", "", 30*time.Second, "the maximum time to wait for a running garbage collection to complete on shutdown")
+	o.AddFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *ServeOptions) Validate() error {
+	if o.Token == "" {
+		o.Token = os.Getenv(TokenEnvVar)
+		if o.Token == "" {
+			return options.MissingOption("token")
+		}
+	}
+	if o.FromFile != "" {
+		return errors.Errorf("cannot use --from-file when serving")
+	}
+	if len(o.Contexts) > 0 {
+		return errors.Errorf("cannot use --contexts when serving")
+	}
+	return o.Options.Validate()
+}
+
+// Run runs the server until it receives an interrupt or terminate signal
+func (o *ServeOptions) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	listener, err := net.Listen("tcp", o.Address)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s", o.Address)
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	return o.Serve(ctx, listener)
+}
+
+// Serve serves HTTP requests on the listener until the context is done then shuts down gracefully
+func (o *ServeOptions) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler: o.Handler(),
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+	log.Logger().Infof("garbage collecting PipelineActivities on POST requests to %s", info(listener.Addr().String()))
+
+	select {
+	case err := <-errs:
+		return errors.Wrapf(err, "failed to serve HTTP requests")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), o.ShutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to shut down the HTTP server")
+	}
+	log.Logger().Infof("shut down the HTTP server")
+	return nil
+}
+
+// Handler returns the HTTP handler which runs the garbage collection on POST requests
+func (o *ServeOptions) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		if !o.authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		// lets only run one garbage collection at a time
+		o.lock.Lock()
+		summary, err := o.Collect(r.Context())
+		o.lock.Unlock()
+
+		status := http.StatusOK
+		if err != nil {
+			log.Logger().Warnf("failed to garbage collect PipelineActivities: %s", err.Error())
+			status = http.StatusInternalServerError
+			if summary == nil {
+				summary = &Summary{DryRun: o.DryRun}
+			}
+			summary.Error = err.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		err = json.NewEncoder(w).Encode(summary)
+		if err != nil {
+			log.Logger().Warnf("failed to write the summary: %s", err.Error())
+		}
+	})
+}
+
+func (o *ServeOptions) authorized(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(header, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(o.Token)) == 1
+}
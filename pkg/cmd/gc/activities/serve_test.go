@@ -0,0 +1,155 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGCPipelineActivitiesServe(t *testing.T) {
+	ns := "jx"
+	token := "my-secret-token"
+
+	_, o := activities.NewCmdGCActivitiesServe()
+	o.Namespace = ns
+	o.Token = token
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset(
+		&v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "old-pr",
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "org/repo/PR-1",
+				CompletedTimestamp: &metav1.Time{Time: time.Now().AddDate(0, 0, -3)},
+			},
+		},
+		&v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "new-pr",
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "org/repo/PR-1",
+				CompletedTimestamp: &metav1.Time{Time: time.Now()},
+			},
+		},
+	)
+	err := o.Validate()
+	require.NoError(t, err, "failed to validate options")
+
+	server := httptest.NewServer(o.Handler())
+	defer server.Close()
+
+	testCases := []struct {
+		name           string
+		method         string
+		token          string
+		expectedStatus int
+	}{
+		{
+			name:           "get",
+			method:         http.MethodGet,
+			token:          token,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "no-token",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "wrong-token",
+			method:         http.MethodPost,
+			token:          "wrong",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, server.URL, nil)
+		require.NoError(t, err, "failed to create request for %s", tc.name)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "failed to send request for %s", tc.name)
+		resp.Body.Close()
+		assert.Equal(t, tc.expectedStatus, resp.StatusCode, "status for %s", tc.name)
+	}
+
+	list, err := o.JXClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, list.Items, 2, "unauthorized requests should not delete any PipelineActivities")
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "failed to send request")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	summary := &activities.Summary{}
+	err = json.NewDecoder(resp.Body).Decode(summary)
+	require.NoError(t, err, "failed to parse the summary")
+	assert.Equal(t, 1, summary.Deleted, "deleted")
+	assert.Equal(t, 1, summary.Kept, "kept")
+	assert.Equal(t, activities.DeleteReasonAgePR, summary.Activities["old-pr"])
+
+	list, err = o.JXClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "new-pr", list.Items[0].Name)
+}
+
+func TestGCPipelineActivitiesServeShutdown(t *testing.T) {
+	_, o := activities.NewCmdGCActivitiesServe()
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.Namespace = "jx"
+	o.Token = "my-secret-token"
+	o.ShutdownTimeout = 5 * time.Second
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "failed to listen")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- o.Serve(ctx, listener)
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, "http://"+listener.Addr().String(), nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer my-secret-token")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "failed to send request")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	select {
+	case err = <-errs:
+		require.NoError(t, err, "failed to shut down")
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the server to shut down")
+	}
+}
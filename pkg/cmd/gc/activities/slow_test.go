@@ -0,0 +1,72 @@
+// +build unit
+
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGCPipelineActivitiesSlowDeletes(t *testing.T) {
+	ns := "jx"
+	completed := time.Now().AddDate(0, 0, -3)
+
+	newActivity := func(name string) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "org/" + name + "/PR-1",
+				CompletedTimestamp: &metav1.Time{Time: completed},
+			},
+		}
+	}
+
+	jxClient := jxfake.NewSimpleClientset(newActivity("slow"), newActivity("fast"))
+
+	// lets delay the deletion of one of the activities
+	jxClient.PrependReactor("delete", "pipelineactivities", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.DeleteAction).GetName() == "slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return false, nil, nil
+	})
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	o.Concurrency = 1
+	o.SlowDeleteThreshold = 50 * time.Millisecond
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	assert.Len(t, o.Deleted, 2, "deleted activities")
+	require.Len(t, o.SlowDeletions, 1, "slow deletions")
+	assert.True(t, o.SlowDeletions["slow"] >= 100*time.Millisecond, "slow deletion took %s", o.SlowDeletions["slow"])
+
+	// no warnings without a threshold
+	jxClient = jxfake.NewSimpleClientset(newActivity("slow"))
+	_, o = activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxClient
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+	assert.Empty(t, o.SlowDeletions, "slow deletions without a threshold")
+}
@@ -0,0 +1,63 @@
+package activities
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/pkg/errors"
+)
+
+// State the incremental state of the garbage collection which is stored in the --state-file between runs
+type State struct {
+	// LastCompletedTimestamp the high-water mark of the completion time of the evaluated PipelineActivities.
+	// PipelineActivities completed at or before this time were evaluated by a previous run
+	LastCompletedTimestamp time.Time `json:"lastCompletedTimestamp"`
+}
+
+// LoadState loads the state from the file returning an empty state if the file does not exist
+func LoadState(path string) (*State, error) {
+	state := &State{}
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if !exists {
+		return state, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load file %s", path)
+	}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse state file %s", path)
+	}
+	return state, nil
+}
+
+// SaveState saves the state to the file
+func SaveState(path string, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal state to JSON")
+	}
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	err = ioutil.WriteFile(path, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", path)
+	}
+	return nil
+}
+
+// isEvaluated returns true if the activity completed at or before the high-water mark of the state so was evaluated by a previous run
+func (s *State) isEvaluated(completed time.Time) bool {
+	return s != nil && !s.LastCompletedTimestamp.IsZero() && !completed.After(s.LastCompletedTimestamp)
+}
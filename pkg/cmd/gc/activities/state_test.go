@@ -0,0 +1,98 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGCPipelineActivitiesStateFile(t *testing.T) {
+	ns := "jx"
+	now := time.Date(2021, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	newActivity := func(name string, completed time.Time) *v1.PipelineActivity {
+		return &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "org/repo/PR-" + name,
+				CompletedTimestamp: &metav1.Time{Time: completed},
+			},
+		}
+	}
+
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create temp dir")
+	stateFile := filepath.Join(tmpDir, "state", "gc.json")
+
+	jxClient := jxfake.NewSimpleClientset(
+		newActivity("old", now.AddDate(0, 0, -3)),
+		newActivity("recent", now.Add(-time.Hour)),
+	)
+
+	run := func(dryRun bool, clock time.Time) *activities.Options {
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.StateFile = stateFile
+		o.DryRun = dryRun
+		o.Clock = func() time.Time {
+			return clock
+		}
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command")
+		return o
+	}
+
+	// a dry run does not store the state
+	o := run(true, now)
+	assert.Equal(t, map[string]activities.DeleteReason{"old": activities.DeleteReasonAgePR}, o.Deleted, "dry run deleted activities")
+	exists, err := files.FileExists(stateFile)
+	require.NoError(t, err)
+	assert.False(t, exists, "should not have saved the state on a dry run")
+
+	o = run(false, now)
+	assert.Equal(t, map[string]activities.DeleteReason{"old": activities.DeleteReasonAgePR}, o.Deleted, "first run deleted activities")
+
+	state, err := activities.LoadState(stateFile)
+	require.NoError(t, err, "failed to load state")
+	assert.True(t, now.Add(-time.Hour).Equal(state.LastCompletedTimestamp), "high-water mark after the first run was %s", state.LastCompletedTimestamp)
+
+	// the second run is 3 days later so all the activities are too old but only the new ones are examined
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).Create(context.TODO(), newActivity("late-arrival", now.Add(-2*time.Hour)), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = jxClient.JenkinsV1().PipelineActivities(ns).Create(context.TODO(), newActivity("newer", now.Add(time.Hour)), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	o = run(false, now.AddDate(0, 0, 3))
+	assert.Equal(t, map[string]activities.DeleteReason{"newer": activities.DeleteReasonAgePR}, o.Deleted, "second run deleted activities")
+
+	list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	var names []string
+	for i := range list.Items {
+		names = append(names, list.Items[i].Name)
+	}
+	assert.ElementsMatch(t, []string{"recent", "late-arrival"}, names, "remaining activities")
+
+	state, err = activities.LoadState(stateFile)
+	require.NoError(t, err, "failed to load state")
+	assert.True(t, now.Add(time.Hour).Equal(state.LastCompletedTimestamp), "high-water mark after the second run was %s", state.LastCompletedTimestamp)
+}
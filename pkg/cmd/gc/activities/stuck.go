@@ -0,0 +1,16 @@
+package activities
+
+import (
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+)
+
+// isStuck returns true if --stuck-age is specified and the activity never completed but was started longer ago than
+// the stuck age. Activities without a start time are never stuck as their age is unknown
+func (o *Options) isStuck(activity *v1.PipelineActivity, now time.Time) bool {
+	if o.StuckAgeLimit <= 0 || activity.Spec.CompletedTimestamp != nil || activity.Spec.StartedTimestamp == nil {
+		return false
+	}
+	return o.isTooOld(activity.Spec.StartedTimestamp.Time, o.StuckAgeLimit, now)
+}
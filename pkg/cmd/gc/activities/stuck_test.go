@@ -0,0 +1,80 @@
+// +build unit
+
+package activities_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGCPipelineActivitiesStuck(t *testing.T) {
+	ns := "jx"
+	now := time.Now()
+
+	newActivity := func(name string, started *time.Time) *v1.PipelineActivity {
+		a := &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline: "myorg/myrepo/master",
+				Status:   v1.ActivityStatusTypeRunning,
+			},
+		}
+		if started != nil {
+			a.Spec.StartedTimestamp = &metav1.Time{Time: *started}
+		}
+		return a
+	}
+	at := func(age time.Duration) *time.Time {
+		t := now.Add(-age)
+		return &t
+	}
+
+	testCases := []struct {
+		name     string
+		stuckAge time.Duration
+		expected map[string]activities.DeleteReason
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:     "stuck-age",
+			stuckAge: 24 * time.Hour,
+			expected: map[string]activities.DeleteReason{
+				"stuck": activities.DeleteReasonStuck,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		objects := []runtime.Object{
+			newActivity("stuck", at(72*time.Hour)),
+			newActivity("running", at(time.Hour)),
+			newActivity("no-timestamps", nil),
+		}
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(objects...)
+		o.StuckAgeLimit = tc.stuckAge
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		assert.Equal(t, tc.expected, o.Deleted, "deleted activities for %s", tc.name)
+	}
+}
@@ -0,0 +1,246 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/httphelpers"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TracerName the name of the instrumentation scope of the exported spans
+	TracerName = "jx-gitops/gc/activities"
+
+	// RunSpanName the name of the span of a gc run
+	RunSpanName = "gc activities"
+
+	// DeleteSpanName the name of the child span of each deleted PipelineActivity
+	DeleteSpanName = "delete PipelineActivity"
+)
+
+// Span a completed span of a gc run
+type Span struct {
+	Name         string
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]interface{}
+	Error        string
+}
+
+// SpanExporter exports the completed spans of a gc run
+type SpanExporter interface {
+	// ExportSpans exports the spans
+	ExportSpans(ctx context.Context, spans []*Span) error
+}
+
+// OTLPExporter exports spans to an OpenTelemetry collector using the OTLP/HTTP JSON protocol
+type OTLPExporter struct {
+	Endpoint    string
+	ServiceName string
+	Client      *http.Client
+}
+
+// tracer records the spans of a gc run
+type tracer struct {
+	traceID string
+	run     *Span
+	spans   []*Span
+}
+
+func newTracer() *tracer {
+	return &tracer{traceID: randomHex(16)}
+}
+
+// startRun starts the span of the gc run
+func (t *tracer) startRun(start time.Time) {
+	t.run = &Span{
+		Name:       RunSpanName,
+		TraceID:    t.traceID,
+		SpanID:     randomHex(8),
+		Start:      start,
+		Attributes: map[string]interface{}{},
+	}
+}
+
+// addChild adds a completed child span of the gc run
+func (t *tracer) addChild(name string, start, end time.Time, attributes map[string]interface{}, err error) {
+	span := &Span{
+		Name:         name,
+		TraceID:      t.traceID,
+		SpanID:       randomHex(8),
+		ParentSpanID: t.run.SpanID,
+		Start:        start,
+		End:          end,
+		Attributes:   attributes,
+	}
+	if err != nil {
+		span.Error = err.Error()
+	}
+	t.spans = append(t.spans, span)
+}
+
+// endRun completes the span of the gc run and returns all the spans with the run span first
+func (t *tracer) endRun(end time.Time, err error) []*Span {
+	t.run.End = end
+	t.run.Attributes["gc.duration_ms"] = end.Sub(t.run.Start).Milliseconds()
+	if err != nil {
+		t.run.Error = err.Error()
+	}
+	return append([]*Span{t.run}, t.spans...)
+}
+
+// traceDeletion records a child span for the deleted activity if deletion spans are enabled
+func (o *Options) traceDeletion(a *v1.PipelineActivity, reason DeleteReason, start time.Time, removed bool, err error) {
+	if o.tracer == nil || !o.OtelDeletionSpans {
+		return
+	}
+	attributes := map[string]interface{}{
+		"gc.activity":  a.Name,
+		"gc.reason":    string(reason),
+		"gc.deleted":   removed,
+		"gc.dry_run":   o.DryRun,
+		"gc.namespace": a.Namespace,
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.tracer.addChild(DeleteSpanName, start, time.Now(), attributes, err)
+}
+
+// ExportSpans posts the spans to the OTLP/HTTP traces endpoint of the collector
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	endpoint, err := tracesURL(e.Endpoint)
+	if err != nil {
+		return err
+	}
+	serviceName := e.ServiceName
+	if serviceName == "" {
+		serviceName = "jx-gitops"
+	}
+	var otlpSpans []map[string]interface{}
+	for _, s := range spans {
+		otlpSpans = append(otlpSpans, toOTLPSpan(s))
+	}
+	body := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": toOTLPAttributes(map[string]interface{}{"service.name": serviceName}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": TracerName},
+						"spans": otlpSpans,
+					},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal spans")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request for %s", endpoint)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = httphelpers.GetClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to export spans to %s", endpoint)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("failed to export spans to %s got status %d: %s", endpoint, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// tracesURL returns the OTLP/HTTP traces URL of the endpoint defaulting the path to /v1/traces
+func tracesURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errors.Errorf("invalid OpenTelemetry endpoint %s should be a http or https URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return u.String(), nil
+}
+
+func toOTLPSpan(s *Span) map[string]interface{} {
+	answer := map[string]interface{}{
+		"traceId":           s.TraceID,
+		"spanId":            s.SpanID,
+		"name":              s.Name,
+		"kind":              1,
+		"startTimeUnixNano": strconv.FormatInt(s.Start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.End.UnixNano(), 10),
+		"attributes":        toOTLPAttributes(s.Attributes),
+	}
+	if s.ParentSpanID != "" {
+		answer["parentSpanId"] = s.ParentSpanID
+	}
+	if s.Error != "" {
+		answer["status"] = map[string]interface{}{"code": 2, "message": s.Error}
+	}
+	return answer
+}
+
+func toOTLPAttributes(attributes map[string]interface{}) []interface{} {
+	var keys []string
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var answer []interface{}
+	for _, k := range keys {
+		var value map[string]interface{}
+		switch v := attributes[k].(type) {
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprintf("%v", v)}
+		}
+		answer = append(answer, map[string]interface{}{"key": k, "value": value})
+	}
+	return answer
+}
+
+func randomHex(n int) string {
+	data := make([]byte, n)
+	_, err := rand.Read(data)
+	if err != nil {
+		// lets fall back to the time so we still have a non zero identifier
+		return strings.Repeat("0", 2*n-16) + fmt.Sprintf("%016x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(data)
+}
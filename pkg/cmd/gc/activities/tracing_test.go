@@ -0,0 +1,146 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// spanRecorder records the exported spans in memory
+type spanRecorder struct {
+	spans []*activities.Span
+}
+
+func (r *spanRecorder) ExportSpans(ctx context.Context, spans []*activities.Span) error {
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func newTracingActivities(ns string) []runtime.Object {
+	now := time.Now()
+	var answer []runtime.Object
+	for i := 1; i <= 3; i++ {
+		answer = append(answer, &v1.PipelineActivity{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("release-%d", i),
+				Namespace: ns,
+			},
+			Spec: v1.PipelineActivitySpec{
+				Pipeline:           "org/repo/master",
+				CompletedTimestamp: &metav1.Time{Time: now.Add(time.Duration(-i) * time.Hour)},
+			},
+		})
+	}
+	return answer
+}
+
+func TestGCPipelineActivitiesTracing(t *testing.T) {
+	ns := "jx"
+	for _, deletionSpans := range []bool{false, true} {
+		recorder := &spanRecorder{}
+
+		_, o := activities.NewCmdGCActivities()
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxfake.NewSimpleClientset(newTracingActivities(ns)...)
+		o.Namespace = ns
+		o.ReleaseHistoryLimit = 1
+		o.SpanExporter = recorder
+		o.OtelDeletionSpans = deletionSpans
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run gc")
+
+		expectedSpans := 1
+		if deletionSpans {
+			expectedSpans = 3
+		}
+		require.Len(t, recorder.spans, expectedSpans, "spans with deletion spans %v", deletionSpans)
+
+		run := recorder.spans[0]
+		assert.Equal(t, activities.RunSpanName, run.Name, "run span name")
+		assert.Empty(t, run.ParentSpanID, "run span should not have a parent")
+		assert.Len(t, run.TraceID, 32, "trace ID")
+		assert.Equal(t, 2, run.Attributes["gc.deleted"], "deleted attribute")
+		assert.Equal(t, 1, run.Attributes["gc.kept"], "kept attribute")
+		assert.Equal(t, ns, run.Attributes["gc.namespace"], "namespace attribute")
+		assert.Equal(t, false, run.Attributes["gc.dry_run"], "dry run attribute")
+		assert.Contains(t, run.Attributes, "gc.duration_ms", "duration attribute")
+		assert.False(t, run.End.Before(run.Start), "run span should end after it starts")
+
+		var deleted []string
+		for _, child := range recorder.spans[1:] {
+			assert.Equal(t, activities.DeleteSpanName, child.Name, "child span name")
+			assert.Equal(t, run.SpanID, child.ParentSpanID, "child span parent")
+			assert.Equal(t, run.TraceID, child.TraceID, "child span trace")
+			assert.Equal(t, string(activities.DeleteReasonHistoryRelease), child.Attributes["gc.reason"], "child span reason")
+			assert.Equal(t, true, child.Attributes["gc.deleted"], "child span deleted")
+			deleted = append(deleted, child.Attributes["gc.activity"].(string))
+		}
+		if deletionSpans {
+			assert.Equal(t, []string{"release-2", "release-3"}, deleted, "deleted activity spans")
+		}
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]interface{}
+	path := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err, "failed to read request body")
+		err = json.Unmarshal(data, &body)
+		require.NoError(t, err, "failed to parse request body %s", string(data))
+	}))
+	defer server.Close()
+
+	recorder := &spanRecorder{}
+	_, o := activities.NewCmdGCActivities()
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset(newTracingActivities("jx")...)
+	o.Namespace = "jx"
+	o.ReleaseHistoryLimit = 1
+	o.SpanExporter = recorder
+	err := o.Run()
+	require.NoError(t, err, "failed to run gc")
+
+	exporter := &activities.OTLPExporter{Endpoint: server.URL}
+	err = exporter.ExportSpans(context.TODO(), recorder.spans)
+	require.NoError(t, err, "failed to export spans")
+
+	assert.Equal(t, "/v1/traces", path, "request path")
+	resourceSpans := body["resourceSpans"].([]interface{})
+	require.Len(t, resourceSpans, 1, "resourceSpans")
+	scopeSpans := resourceSpans[0].(map[string]interface{})["scopeSpans"].([]interface{})
+	require.Len(t, scopeSpans, 1, "scopeSpans")
+	spans := scopeSpans[0].(map[string]interface{})["spans"].([]interface{})
+	require.Len(t, spans, 1, "spans")
+	span := spans[0].(map[string]interface{})
+	assert.Equal(t, activities.RunSpanName, span["name"], "span name")
+
+	attributes := map[string]interface{}{}
+	for _, a := range span["attributes"].([]interface{}) {
+		m := a.(map[string]interface{})
+		attributes[m["key"].(string)] = m["value"]
+	}
+	assert.Equal(t, map[string]interface{}{"intValue": "2"}, attributes["gc.deleted"], "deleted attribute")
+	assert.Equal(t, map[string]interface{}{"boolValue": false}, attributes["gc.dry_run"], "dry run attribute")
+}
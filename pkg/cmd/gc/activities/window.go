@@ -0,0 +1,73 @@
+package activities
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maintenanceWindow the time of day range in which garbage collection is allowed
+type maintenanceWindow struct {
+	start    time.Duration
+	end      time.Duration
+	location *time.Location
+}
+
+// parseMaintenanceWindow parses a window of the form 'HH:MM-HH:MM' in the given timezone
+func parseMaintenanceWindow(text, timezone string) (*maintenanceWindow, error) {
+	paths := strings.Split(text, "-")
+	if len(paths) != 2 {
+		return nil, errors.Errorf("invalid window %s should be of the form HH:MM-HH:MM", text)
+	}
+	start, err := parseTimeOfDay(paths[0])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid start of window %s", text)
+	}
+	end, err := parseTimeOfDay(paths[1])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid end of window %s", text)
+	}
+	location := time.UTC
+	if timezone != "" {
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid timezone %s", timezone)
+		}
+	}
+	return &maintenanceWindow{start: start, end: end, location: location}, nil
+}
+
+func parseTimeOfDay(text string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse time %s should be of the form HH:MM", text)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains returns true if the time is inside the window. If the end is before the start the window spans midnight
+func (w *maintenanceWindow) Contains(t time.Time) bool {
+	t = t.In(w.location)
+	tod := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.start <= w.end {
+		return tod >= w.start && tod < w.end
+	}
+	return tod >= w.start || tod < w.end
+}
+
+// String returns a description of the window
+func (w *maintenanceWindow) String() string {
+	format := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%s-%s %s", format(w.start), format(w.end), w.location.String())
+}
+
+func (o *Options) now() time.Time {
+	if o.Clock != nil {
+		return o.Clock()
+	}
+	return time.Now()
+}
@@ -0,0 +1,120 @@
+// +build unit
+
+package activities_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc/activities"
+	v1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGCPipelineActivitiesMaintenanceWindow(t *testing.T) {
+	ctx := context.TODO()
+	ns := "jx"
+
+	testCases := []struct {
+		name          string
+		window        string
+		timezone      string
+		now           time.Time
+		expectDeleted bool
+	}{
+		{
+			name:          "in-window",
+			window:        "01:00-05:00",
+			now:           time.Date(2021, 3, 1, 3, 30, 0, 0, time.UTC),
+			expectDeleted: true,
+		},
+		{
+			name:   "before-window",
+			window: "01:00-05:00",
+			now:    time.Date(2021, 3, 1, 0, 59, 0, 0, time.UTC),
+		},
+		{
+			name:   "end-of-window",
+			window: "01:00-05:00",
+			now:    time.Date(2021, 3, 1, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "spans-midnight",
+			window:        "22:00-02:00",
+			now:           time.Date(2021, 3, 1, 23, 15, 0, 0, time.UTC),
+			expectDeleted: true,
+		},
+		{
+			name:   "outside-spans-midnight",
+			window: "22:00-02:00",
+			now:    time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "timezone",
+			window:        "01:00-05:00",
+			timezone:      "America/New_York",
+			now:           time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC),
+			expectDeleted: true,
+		},
+		{
+			name:     "outside-timezone",
+			window:   "01:00-05:00",
+			timezone: "America/New_York",
+			now:      time.Date(2021, 3, 1, 3, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		jxClient := jxfake.NewSimpleClientset(
+			&v1.PipelineActivity{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "old",
+					Namespace: ns,
+				},
+				Spec: v1.PipelineActivitySpec{
+					Pipeline:           "org/repo/master",
+					CompletedTimestamp: &metav1.Time{Time: tc.now.AddDate(0, 0, -31)},
+				},
+			},
+		)
+
+		_, o := activities.NewCmdGCActivities()
+		o.Namespace = ns
+		o.TektonClient = tektonfake.NewSimpleClientset()
+		o.DynamicClient = newFakeDynamicClient()
+		o.JXClient = jxClient
+		o.OnlyBetween = tc.window
+		if tc.timezone != "" {
+			o.Timezone = tc.timezone
+		}
+		now := tc.now
+		o.Clock = func() time.Time {
+			return now
+		}
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run for %s", tc.name)
+
+		list, err := jxClient.JenkinsV1().PipelineActivities(ns).List(ctx, metav1.ListOptions{})
+		require.NoError(t, err, "failed to list activities for %s", tc.name)
+		if tc.expectDeleted {
+			assert.Empty(t, list.Items, "should have deleted the activity for %s", tc.name)
+		} else {
+			assert.Len(t, list.Items, 1, "should not have deleted the activity for %s", tc.name)
+		}
+	}
+
+	_, o := activities.NewCmdGCActivities()
+	o.Namespace = ns
+	o.TektonClient = tektonfake.NewSimpleClientset()
+	o.DynamicClient = newFakeDynamicClient()
+	o.JXClient = jxfake.NewSimpleClientset()
+	o.OnlyBetween = "1am-5am"
+	err := o.Run()
+	require.Error(t, err, "should fail for an invalid window")
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/chart/loader"
 )
 
 var (
@@ -22,20 +23,27 @@ var (
 
 	cmdLong = templates.LongDesc(`
 		Builds and lints any helm charts
+
+If --update-dependencies is specified then 'helm dependency update' is run for any chart with dependencies in its Chart.yaml or requirements.yaml before it is linted so that charts whose subcharts are not vendored can be built
 `)
 
 	cmdExample = templates.Examples(`
 		# generates the resources from a helm chart
 		%s step helm template
+
+		# downloads the dependencies of the charts using the repositories of a private repository config before building them
+		%s helm build --update-dependencies --repository-config repositories.yaml
 	`)
 )
 
 // Options the options for the command
 type Options struct {
-	UseHelmPlugin bool
-	HelmBinary    string
-	ChartsDir     string
-	CommandRunner cmdrunner.CommandRunner
+	UseHelmPlugin      bool
+	UpdateDependencies bool
+	HelmBinary         string
+	ChartsDir          string
+	RepositoryConfig   string
+	CommandRunner      cmdrunner.CommandRunner
 }
 
 // NewCmdHelmBuild creates a command object for the command
@@ -46,7 +54,7 @@ func NewCmdHelmBuild() (*cobra.Command, *Options) {
 		Use:     "build",
 		Short:   "Builds and lints any helm charts",
 		Long:    cmdLong,
-		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName),
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
 		Run: func(cmd *cobra.Command, args []string) {
 			err := o.Run()
 			helper.CheckErr(err)
@@ -54,6 +62,8 @@ func NewCmdHelmBuild() (*cobra.Command, *Options) {
 	}
 	cmd.Flags().StringVarP(&o.ChartsDir, "charts-dir", "c", "charts", "the directory to look for helm charts to release")
 	cmd.Flags().StringVarP(&o.HelmBinary, "binary", "n", "", "specifies the helm binary location to use. If not specified defaults to 'helm' on the $PATH")
+	cmd.Flags().BoolVarP(&o.UpdateDependencies, "update-dependencies", "", false, "runs 'helm dependency update' for any chart with dependencies before building it")
+	cmd.Flags().StringVarP(&o.RepositoryConfig, "repository-config", "", "", "the helm repository config file used to resolve the dependencies of the charts such as private repositories")
 	cmd.Flags().BoolVarP(&o.UseHelmPlugin, "use-helm-plugin", "", false, "uses the jx binary plugin for helm rather than whatever helm is on the $PATH")
 	return cmd, o
 }
@@ -75,6 +85,14 @@ func (o *Options) Validate() error {
 			o.HelmBinary = "helm"
 		}
 	}
+	if o.RepositoryConfig != "" {
+		// helm is run in the chart dir so lets use an absolute path
+		path, err := filepath.Abs(o.RepositoryConfig)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the absolute path of %s", o.RepositoryConfig)
+		}
+		o.RepositoryConfig = path
+	}
 	return nil
 }
 
@@ -116,6 +134,13 @@ func (o *Options) Run() error {
 
 		log.Logger().Infof("building chart %s", info(name))
 
+		if o.UpdateDependencies {
+			err = o.updateDependencies(chartDir)
+			if err != nil {
+				return errors.Wrapf(err, "failed to update dependencies of chart %s", name)
+			}
+		}
+
 		c := &cmdrunner.Command{
 			Dir:  chartDir,
 			Name: o.HelmBinary,
@@ -129,7 +154,7 @@ func (o *Options) Run() error {
 		c = &cmdrunner.Command{
 			Dir:  chartDir,
 			Name: o.HelmBinary,
-			Args: []string{"dependency", "build", "."},
+			Args: o.dependencyArgs("build"),
 		}
 		_, err = o.CommandRunner(c)
 		if err != nil {
@@ -151,3 +176,34 @@ func (o *Options) Run() error {
 	log.Logger().Infof("built %d charts from the charts dir: %s", count, dir)
 	return nil
 }
+
+// updateDependencies runs 'helm dependency update' in the chart dir if the chart has any dependencies
+func (o *Options) updateDependencies(chartDir string) error {
+	ch, err := loader.LoadDir(chartDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load chart %s", chartDir)
+	}
+	if len(ch.Metadata.Dependencies) == 0 {
+		log.Logger().Debugf("no dependencies to update for chart %s", chartDir)
+		return nil
+	}
+	c := &cmdrunner.Command{
+		Dir:  chartDir,
+		Name: o.HelmBinary,
+		Args: o.dependencyArgs("update"),
+	}
+	_, err = o.CommandRunner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to run %s", c.CLI())
+	}
+	return nil
+}
+
+// dependencyArgs returns the arguments of the 'helm dependency' command using the --repository-config if specified
+func (o *Options) dependencyArgs(command string) []string {
+	args := []string{"dependency", command, "."}
+	if o.RepositoryConfig != "" {
+		args = append(args, "--repository-config", o.RepositoryConfig)
+	}
+	return args
+}
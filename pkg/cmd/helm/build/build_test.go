@@ -40,3 +40,51 @@ func TestStepHelmBuild(t *testing.T) {
 		}
 	}
 }
+
+func TestStepHelmBuildUpdateDependencies(t *testing.T) {
+	chartsDir := filepath.Join("test_data", "has_dependencies", "charts")
+	repoConfig, err := filepath.Abs(filepath.Join("test_data", "repositories.yaml"))
+	require.NoError(t, err, "failed to find the absolute path of the repository config")
+
+	runner := &fakerunner.FakeRunner{}
+	_, o := build.NewCmdHelmBuild()
+	o.HelmBinary = "helm"
+	o.CommandRunner = runner.Run
+	o.ChartsDir = chartsDir
+	o.UpdateDependencies = true
+	o.RepositoryConfig = filepath.Join("test_data", "repositories.yaml")
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	var commands []string
+	for _, c := range runner.OrderedCommands {
+		commands = append(commands, filepath.Base(c.Dir)+": "+c.CLI())
+	}
+	assert.Equal(t, []string{
+		"legacy: helm dependency update . --repository-config " + repoConfig,
+		"legacy: helm lint",
+		"legacy: helm dependency build . --repository-config " + repoConfig,
+		"legacy: helm package .",
+		"plain: helm lint",
+		"plain: helm dependency build . --repository-config " + repoConfig,
+		"plain: helm package .",
+		"umbrella: helm dependency update . --repository-config " + repoConfig,
+		"umbrella: helm lint",
+		"umbrella: helm dependency build . --repository-config " + repoConfig,
+		"umbrella: helm package .",
+	}, commands, "commands")
+
+	runner = &fakerunner.FakeRunner{}
+	_, o = build.NewCmdHelmBuild()
+	o.HelmBinary = "helm"
+	o.CommandRunner = runner.Run
+	o.ChartsDir = chartsDir
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	for _, c := range runner.OrderedCommands {
+		assert.NotContains(t, c.CLI(), "dependency update", "should not update dependencies by default")
+	}
+}
@@ -0,0 +1,65 @@
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"sigs.k8s.io/yaml"
+)
+
+// ComputeValues returns the values of the chart in the dir merged with the values files, the --set values and the default
+// values of the enabled subcharts in the same way as 'helm template' so that the overrides of subchart values are visible
+func ComputeValues(chartDir string, valuesFiles, setValues []string) (map[string]interface{}, error) {
+	ch, err := loader.Load(chartDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load chart %s", chartDir)
+	}
+	vo := &values.Options{
+		ValueFiles: valuesFiles,
+		Values:     setValues,
+	}
+	vals, err := vo.MergeValues(getter.Providers{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to merge the values")
+	}
+	err = chartutil.ProcessDependencies(ch, vals)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to process the dependencies of chart %s", chartDir)
+	}
+	answer, err := chartutil.CoalesceValues(ch, vals)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compute the values of chart %s", chartDir)
+	}
+	return answer, nil
+}
+
+// writeComputedValues writes the computed values of the chart for the release to the --computed-values-dir
+func (o *TemplateOptions) writeComputedValues(name, chartDir string) error {
+	vals, err := ComputeValues(chartDir, o.valuesFiles, o.SetValues)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(vals)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the computed values of chart %s", chartDir)
+	}
+	err = os.MkdirAll(o.ComputedValuesDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", o.ComputedValuesDir)
+	}
+	path := filepath.Join(o.ComputedValuesDir, name+".yaml")
+	err = ioutil.WriteFile(path, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", path)
+	}
+	log.Logger().Infof("wrote the computed values of release %s to %s", name, path)
+	return nil
+}
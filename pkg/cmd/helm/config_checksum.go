@@ -0,0 +1,239 @@
+package helm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ConfigChecksumAnnotation the pod template annotation containing the checksum of the ConfigMaps and Secrets referenced by a workload
+const ConfigChecksumAnnotation = "jenkins-x.io/config-checksum"
+
+// podTemplatePaths the paths of the pod template of each workload kind
+var podTemplatePaths = map[string][]string{
+	"Deployment":  {"spec", "template"},
+	"StatefulSet": {"spec", "template"},
+	"DaemonSet":   {"spec", "template"},
+	"ReplicaSet":  {"spec", "template"},
+	"Job":         {"spec", "template"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template"},
+}
+
+type configResource struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Data       map[string]string `json:"data,omitempty"`
+	BinaryData map[string]string `json:"binaryData,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+// AddConfigChecksums annotates the pod template of each workload in the directory tree with a checksum of the
+// ConfigMaps and Secrets in the same directory tree which it references so that the workload is rolled out when they change
+func AddConfigChecksums(dir string) error {
+	docs := map[string][]*yaml.RNode{}
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if info == nil || info.IsDir() {
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read file %s", path)
+		}
+		nodes, err := (&kio.ByteReader{Reader: bytes.NewReader(data), OmitReaderAnnotations: true}).Read()
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse YAML file %s", path)
+		}
+		docs[path] = nodes
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the resources in %s", dir)
+	}
+
+	// lets hash the contents of the ConfigMaps and Secrets
+	hashes := map[string]string{}
+	for _, path := range paths {
+		for _, node := range docs[path] {
+			kind := kyamls.GetKind(node, path)
+			if kind != "ConfigMap" && kind != "Secret" {
+				continue
+			}
+			data, err := node.MarshalJSON()
+			if err != nil {
+				return errors.Wrapf(err, "failed to convert %s to JSON", path)
+			}
+			r := &configResource{}
+			err = json.Unmarshal(data, r)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse %s in %s", kind, path)
+			}
+			data, err = json.Marshal(r)
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal %s in %s", kind, path)
+			}
+			hashes[kind+"/"+r.Metadata.Name] = fmt.Sprintf("%x", sha256.Sum256(data))
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	for _, path := range paths {
+		modified := false
+		for _, node := range docs[path] {
+			changed, err := addConfigChecksum(node, path, hashes)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add config checksum in %s", path)
+			}
+			if changed {
+				modified = true
+			}
+		}
+		if !modified {
+			continue
+		}
+		buf := &bytes.Buffer{}
+		err = kio.ByteWriter{Writer: buf}.Write(docs[path])
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal YAML for %s", path)
+		}
+		err = ioutil.WriteFile(path, buf.Bytes(), files.DefaultFileWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", path)
+		}
+	}
+	return nil
+}
+
+// addConfigChecksum annotates the pod template of the workload if it references any of the ConfigMaps or Secrets
+func addConfigChecksum(node *yaml.RNode, path string, hashes map[string]string) (bool, error) {
+	kind := kyamls.GetKind(node, path)
+	templatePath := podTemplatePaths[kind]
+	if templatePath == nil {
+		return false, nil
+	}
+	data, err := node.MarshalJSON()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to convert to JSON")
+	}
+	m := map[string]interface{}{}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse JSON")
+	}
+	templateMap, found, err := unstructured.NestedMap(m, templatePath...)
+	if err != nil || !found {
+		return false, err
+	}
+	template := &corev1.PodTemplateSpec{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(templateMap, template)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse the pod template of %s %s", kind, kyamls.GetName(node, path))
+	}
+
+	var lines []string
+	for _, ref := range ConfigReferences(&template.Spec) {
+		h := hashes[ref]
+		if h != "" {
+			lines = append(lines, ref+"="+h)
+		}
+	}
+	if len(lines) == 0 {
+		return false, nil
+	}
+	sort.Strings(lines)
+	checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(lines, "\n"))))
+
+	annotationsPath := append(append([]string{}, templatePath...), "metadata", "annotations")
+	_, err = node.Pipe(
+		yaml.PathGetter{Path: annotationsPath, Create: yaml.MappingNode},
+		yaml.FieldSetter{Name: ConfigChecksumAnnotation, Value: yaml.NewScalarRNode(checksum)})
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to set annotation %s", ConfigChecksumAnnotation)
+	}
+	return true, nil
+}
+
+// ConfigReferences returns the unique ConfigMap/$name and Secret/$name references of the pod spec
+func ConfigReferences(spec *corev1.PodSpec) []string {
+	refs := map[string]bool{}
+	add := func(kind, name string) {
+		if name != "" {
+			refs[kind+"/"+name] = true
+		}
+	}
+	for i := range spec.Volumes {
+		v := &spec.Volumes[i]
+		if v.ConfigMap != nil {
+			add("ConfigMap", v.ConfigMap.Name)
+		}
+		if v.Secret != nil {
+			add("Secret", v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for j := range v.Projected.Sources {
+				s := &v.Projected.Sources[j]
+				if s.ConfigMap != nil {
+					add("ConfigMap", s.ConfigMap.Name)
+				}
+				if s.Secret != nil {
+					add("Secret", s.Secret.Name)
+				}
+			}
+		}
+	}
+	var containers []corev1.Container
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for i := range containers {
+		c := &containers[i]
+		for _, e := range c.EnvFrom {
+			if e.ConfigMapRef != nil {
+				add("ConfigMap", e.ConfigMapRef.Name)
+			}
+			if e.SecretRef != nil {
+				add("Secret", e.SecretRef.Name)
+			}
+		}
+		for _, e := range c.Env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			if e.ValueFrom.ConfigMapKeyRef != nil {
+				add("ConfigMap", e.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if e.ValueFrom.SecretKeyRef != nil {
+				add("Secret", e.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	var answer []string
+	for ref := range refs {
+		answer = append(answer, ref)
+	}
+	sort.Strings(answer)
+	return answer
+}
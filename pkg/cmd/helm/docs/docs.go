@@ -0,0 +1,212 @@
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Generates the Markdown documentation of the configuration of a chart from the comments in its values.yaml file
+
+The description of each value is taken from the comment above it. Comments starting with '# --' are preferred and if a map
+has a '# --' comment it is documented as a single value rather than documenting each of its keys.
+`)
+
+	cmdExample = templates.Examples(`
+		# displays the documentation of the chart
+		%s helm docs --chart charts/myapp
+
+		# generates the README.md of the chart
+		%s helm docs --chart charts/myapp --output-file charts/myapp/README.md
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Chart    string
+	OutFile  string
+	Values   []*Value
+	Markdown string
+}
+
+// Value the documentation of a value of the chart
+type Value struct {
+	Key         string
+	Type        string
+	Default     string
+	Description string
+}
+
+// NewCmdHelmDocs creates a command object for the command
+func NewCmdHelmDocs() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "docs",
+		Short:   "Generates the Markdown documentation of the configuration of a chart from the comments in its values.yaml file",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Chart, "chart", "c", ".", "the directory of the chart")
+	cmd.Flags().StringVarP(&o.OutFile, "output-file", "o", "", "the file to write the Markdown to. If not specified the Markdown is written to the console")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	valuesFile := filepath.Join(o.Chart, "values.yaml")
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", valuesFile)
+	}
+	node, err := yaml.Parse(string(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse YAML file %s", valuesFile)
+	}
+	o.Values = nil
+	err = o.addValues(node.YNode(), "", "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to document values in %s", valuesFile)
+	}
+	sort.Slice(o.Values, func(i, j int) bool {
+		return o.Values[i].Key < o.Values[j].Key
+	})
+
+	chartFile := filepath.Join(o.Chart, "Chart.yaml")
+	metadata := &chart.Metadata{}
+	err = yaml2s.LoadFile(chartFile, metadata)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", chartFile)
+	}
+	o.Markdown = o.toMarkdown(metadata)
+
+	if o.OutFile == "" {
+		fmt.Fprint(os.Stdout, o.Markdown)
+		return nil
+	}
+	err = ioutil.WriteFile(o.OutFile, []byte(o.Markdown), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", o.OutFile)
+	}
+	log.Logger().Infof("generated the documentation of chart %s to %s", info(o.Chart), info(o.OutFile))
+	return nil
+}
+
+func (o *Options) addValues(node *yaml.Node, key, comment string) error {
+	description, explicit := parseComment(comment)
+	if node.Kind == yaml.MappingNode && len(node.Content) > 0 && !explicit {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			childKey := k.Value
+			if key != "" {
+				childKey = key + "." + k.Value
+			}
+			err := o.addValues(node.Content[i+1], childKey, k.HeadComment)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if key == "" {
+		return nil
+	}
+
+	var value interface{}
+	err := node.Decode(&value)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode value of %s", key)
+	}
+	defaultValue, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal value of %s", key)
+	}
+	o.Values = append(o.Values, &Value{
+		Key:         key,
+		Type:        valueType(node),
+		Default:     string(defaultValue),
+		Description: description,
+	})
+	return nil
+}
+
+// parseComment returns the description from the comment and whether it used the explicit '# --' prefix
+func parseComment(comment string) (string, bool) {
+	explicit := false
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#"))
+		if strings.HasPrefix(line, "--") {
+			explicit = true
+			lines = nil
+			line = strings.TrimSpace(strings.TrimPrefix(line, "--"))
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " "), explicit
+}
+
+func valueType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "list"
+	}
+	switch node.ShortTag() {
+	case "!!int":
+		return "int"
+	case "!!float":
+		return "float"
+	case "!!bool":
+		return "bool"
+	}
+	return "string"
+}
+
+func (o *Options) toMarkdown(metadata *chart.Metadata) string {
+	buf := strings.Builder{}
+	if metadata.Name != "" {
+		buf.WriteString("# " + metadata.Name + "\n\n")
+	}
+	if metadata.Description != "" {
+		buf.WriteString(metadata.Description + "\n\n")
+	}
+	buf.WriteString("## Values\n\n")
+	buf.WriteString("| Key | Type | Default | Description |\n")
+	buf.WriteString("|-----|------|---------|-------------|\n")
+	for _, v := range o.Values {
+		buf.WriteString(fmt.Sprintf("| %s | %s | `%s` | %s |\n", v.Key, v.Type, escapeMarkdown(v.Default), escapeMarkdown(v.Description)))
+	}
+	return buf.String()
+}
+
+func escapeMarkdown(text string) string {
+	return strings.ReplaceAll(text, "|", "\\|")
+}
@@ -0,0 +1,25 @@
+package docs_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/docs"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmDocs(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	_, o := docs.NewCmdHelmDocs()
+	o.Chart = filepath.Join("test_data", "mychart")
+	o.OutFile = filepath.Join(tmpDir, "README.md")
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", "expected.md"), o.OutFile, "generated docs")
+}
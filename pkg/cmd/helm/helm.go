@@ -2,9 +2,15 @@ package helm
 
 import (
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/build"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/docs"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/escape"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/initchart"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/mirror"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/release"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/schema"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/tree"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/values"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/verifyappversion"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/spf13/cobra"
@@ -23,9 +29,16 @@ func NewCmdHelm() *cobra.Command {
 		},
 	}
 	command.AddCommand(cobras.SplitCommand(NewCmdHelmTemplate()))
+	command.AddCommand(cobras.SplitCommand(NewCmdHelmToKustomize()))
 	command.AddCommand(cobras.SplitCommand(build.NewCmdHelmBuild()))
+	command.AddCommand(cobras.SplitCommand(docs.NewCmdHelmDocs()))
 	command.AddCommand(cobras.SplitCommand(escape.NewCmdEscape()))
+	command.AddCommand(cobras.SplitCommand(initchart.NewCmdHelmInitChart()))
 	command.AddCommand(cobras.SplitCommand(mirror.NewCmdMirror()))
 	command.AddCommand(cobras.SplitCommand(release.NewCmdHelmRelease()))
+	command.AddCommand(cobras.SplitCommand(tree.NewCmdHelmTree()))
+	command.AddCommand(cobras.SplitCommand(verifyappversion.NewCmdHelmVerifyAppVersion()))
+	command.AddCommand(schema.NewCmdSchema())
+	command.AddCommand(values.NewCmdValues())
 	return command
 }
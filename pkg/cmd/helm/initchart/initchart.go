@@ -0,0 +1,146 @@
+package initchart
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Creates a minimal Chart.yaml and values.yaml for a directory of templates
+
+The directory is either the chart directory containing a 'templates' directory or the 'templates' directory itself. The chart name defaults to the name of the chart directory.
+`)
+
+	cmdExample = templates.Examples(`
+		# creates the Chart.yaml and values.yaml for the templates in charts/myapp/templates
+		%s helm init-chart --dir charts/myapp
+
+		# creates the Chart.yaml with a specific version
+		%s helm init-chart --dir charts/myapp --version 1.0.0 --app-version 2.3.4
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir         string
+	Name        string
+	Version     string
+	AppVersion  string
+	Description string
+	Overwrite   bool
+	Metadata    *chart.Metadata
+}
+
+// NewCmdHelmInitChart creates a command object for the command
+func NewCmdHelmInitChart() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "init-chart",
+		Short:   "Creates a minimal Chart.yaml and values.yaml for a directory of templates",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the chart directory containing the templates directory or the templates directory itself")
+	cmd.Flags().StringVarP(&o.Name, "name", "n", "", "the name of the chart. Defaults to the name of the chart directory")
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "0.1.0", "the version of the chart")
+	cmd.Flags().StringVarP(&o.AppVersion, "app-version", "", "", "the version of the application in the chart")
+	cmd.Flags().StringVarP(&o.Description, "description", "", "", "the description of the chart. Defaults to a description using the chart name")
+	cmd.Flags().BoolVarP(&o.Overwrite, "overwrite", "", false, "overwrites any existing Chart.yaml. An existing values.yaml is never overwritten")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	dir, err := filepath.Abs(o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the absolute path of %s", o.Dir)
+	}
+	chartDir := dir
+	templatesDir := filepath.Join(dir, "templates")
+	if filepath.Base(dir) == "templates" {
+		chartDir = filepath.Dir(dir)
+		templatesDir = dir
+	}
+	exists, err := files.DirExists(templatesDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if dir exists %s", templatesDir)
+	}
+	if !exists {
+		return errors.Errorf("there is no templates directory at %s", templatesDir)
+	}
+
+	chartFile := filepath.Join(chartDir, "Chart.yaml")
+	exists, err = files.FileExists(chartFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if file exists %s", chartFile)
+	}
+	if exists && !o.Overwrite {
+		return errors.Errorf("the chart file %s already exists. Use --overwrite to replace it", chartFile)
+	}
+
+	name := o.Name
+	if name == "" {
+		name = filepath.Base(chartDir)
+	}
+	description := o.Description
+	if description == "" {
+		description = fmt.Sprintf("A Helm chart for %s", name)
+	}
+	o.Metadata = &chart.Metadata{
+		APIVersion:  chart.APIVersionV2,
+		Name:        name,
+		Version:     o.Version,
+		AppVersion:  o.AppVersion,
+		Description: description,
+		Type:        "application",
+	}
+	err = o.Metadata.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "invalid chart metadata")
+	}
+	data, err := yaml.Marshal(o.Metadata)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the chart metadata")
+	}
+	err = ioutil.WriteFile(chartFile, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", chartFile)
+	}
+	log.Logger().Infof("created chart file %s", info(chartFile))
+
+	valuesFile := filepath.Join(chartDir, "values.yaml")
+	exists, err = files.FileExists(valuesFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if file exists %s", valuesFile)
+	}
+	if exists {
+		return nil
+	}
+	text := fmt.Sprintf("# default values for the %s chart\n", name)
+	err = ioutil.WriteFile(valuesFile, []byte(text), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", valuesFile)
+	}
+	log.Logger().Infof("created values file %s", info(valuesFile))
+	return nil
+}
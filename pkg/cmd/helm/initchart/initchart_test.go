@@ -0,0 +1,69 @@
+package initchart_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/initchart"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+func TestHelmInitChart(t *testing.T) {
+	for _, templatesDir := range []bool{false, true} {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "failed to create tmp dir")
+
+		chartDir := filepath.Join(tmpDir, "myapp")
+		err = files.CopyDirOverwrite(filepath.Join("test_data", "myapp"), chartDir)
+		require.NoError(t, err, "failed to copy test data")
+
+		_, o := initchart.NewCmdHelmInitChart()
+		o.Dir = chartDir
+		if templatesDir {
+			o.Dir = filepath.Join(chartDir, "templates")
+		}
+		o.Version = "1.0.0"
+		o.AppVersion = "2.3.4"
+		err = o.Run()
+		require.NoError(t, err, "failed to run for dir %s", o.Dir)
+
+		testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", "expected", "Chart.yaml"), filepath.Join(chartDir, "Chart.yaml"), "generated Chart.yaml")
+		assert.FileExists(t, filepath.Join(chartDir, "values.yaml"))
+
+		// lets check helm can load the generated chart
+		c, err := loader.Load(chartDir)
+		require.NoError(t, err, "failed to load the generated chart")
+		assert.Equal(t, "myapp", c.Name(), "chart name")
+		assert.Equal(t, "1.0.0", c.Metadata.Version, "chart version")
+		assert.Len(t, c.Templates, 1, "chart templates")
+
+		// lets not overwrite the chart by default
+		_, o = initchart.NewCmdHelmInitChart()
+		o.Dir = chartDir
+		err = o.Run()
+		require.Error(t, err, "should not overwrite the Chart.yaml")
+
+		_, o = initchart.NewCmdHelmInitChart()
+		o.Dir = chartDir
+		o.Name = "another"
+		o.Overwrite = true
+		err = o.Run()
+		require.NoError(t, err, "failed to overwrite the Chart.yaml")
+		assert.Equal(t, "another", o.Metadata.Name, "chart name")
+	}
+}
+
+func TestHelmInitChartNoTemplates(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	_, o := initchart.NewCmdHelmInitChart()
+	o.Dir = tmpDir
+	err = o.Run()
+	require.Error(t, err, "should fail when there is no templates dir")
+}
@@ -0,0 +1,114 @@
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OCIPrefix the prefix of charts stored in an OCI registry
+	OCIPrefix = "oci://"
+
+	// RegistryUsernameEnv the environment variable used for the OCI registry user name if --registry-username is not specified
+	RegistryUsernameEnv = "HELM_REGISTRY_USERNAME"
+
+	// RegistryPasswordEnv the environment variable used for the OCI registry password if --registry-password is not specified
+	RegistryPasswordEnv = "HELM_REGISTRY_PASSWORD"
+)
+
+// IsOCIChart returns true if the chart is a reference to a chart in an OCI registry such as 'oci://registry.example.com/charts/mychart'
+func IsOCIChart(chart string) bool {
+	return strings.HasPrefix(chart, OCIPrefix)
+}
+
+// SplitOCIChart splits the OCI chart reference into the reference without a tag, the registry host and the tag if the
+// reference has one. Helm stores a chart version containing '+' as a tag with '_' so the tag is converted back to a version
+func SplitOCIChart(chart string) (string, string, string) {
+	ref := strings.TrimPrefix(chart, OCIPrefix)
+	host := ref
+	i := strings.Index(ref, "/")
+	if i > 0 {
+		host = ref[0:i]
+	}
+	tag := ""
+	j := strings.LastIndex(ref, ":")
+	if j > i && i > 0 {
+		tag = strings.ReplaceAll(ref[j+1:], "_", "+")
+		ref = ref[0:j]
+	}
+	return OCIPrefix + ref, host, tag
+}
+
+// pullOCIChart logs into the OCI registry if there are credentials then pulls the chart into the dir returning the
+// local chart directory so that it is templated in the same way as a local chart
+func (o *TemplateOptions) pullOCIChart(bin, chart, dir string) (string, error) {
+	ref, host, tag := SplitOCIChart(chart)
+	version := strings.ReplaceAll(o.Version, "_", "+")
+	if tag != "" {
+		if version != "" && version != tag {
+			return "", errors.Errorf("the --version %s does not match the tag %s of the OCI chart %s", o.Version, tag, chart)
+		}
+		version = tag
+	}
+
+	username := o.RegistryUsername
+	if username == "" {
+		username = os.Getenv(RegistryUsernameEnv)
+	}
+	password := o.RegistryPassword
+	if password == "" {
+		password = os.Getenv(RegistryPasswordEnv)
+	}
+	if username != "" {
+		c := &cmdrunner.Command{
+			Name: bin,
+			Args: []string{"registry", "login", host, "--username", username, "--password-stdin"},
+			In:   strings.NewReader(password),
+		}
+		_, err := o.CommandRunner(c)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to login to the OCI registry %s", host)
+		}
+		log.Logger().Debugf("logged into the OCI registry %s", host)
+	}
+
+	args := []string{"pull", ref, "--untar", "--untardir", dir}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+	c := &cmdrunner.Command{
+		Name: bin,
+		Args: args,
+	}
+	_, err := o.CommandRunner(c)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to run %s", c.CLI())
+	}
+
+	// lets find the chart in the dir as helm untars the chart into a directory of the chart name
+	fileSlice, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read dir %s", dir)
+	}
+	for _, f := range fileSlice {
+		if !f.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		exists, err := files.FileExists(filepath.Join(path, "Chart.yaml"))
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to check for Chart.yaml in %s", path)
+		}
+		if exists {
+			return path, nil
+		}
+	}
+	return "", errors.Errorf("no chart was pulled from %s into %s", chart, dir)
+}
@@ -0,0 +1,78 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+const (
+	// OutputFormatYAML generates the resources as YAML files
+	OutputFormatYAML = "yaml"
+
+	// OutputFormatJSON generates the resources as JSON files
+	OutputFormatJSON = "json"
+)
+
+// OutputFormats the supported output formats of the generated resources
+var OutputFormats = []string{OutputFormatYAML, OutputFormatJSON}
+
+// ConvertToJSON converts every YAML file in the directory tree into a JSON file. A file containing
+// multiple YAML documents is converted into a JSON array of the documents
+func ConvertToJSON(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if info == nil || info.IsDir() {
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read file %s", path)
+		}
+		nodes, err := (&kio.ByteReader{Reader: bytes.NewReader(data), OmitReaderAnnotations: true}).Read()
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse YAML file %s", path)
+		}
+		var docs []json.RawMessage
+		for _, node := range nodes {
+			doc, err := node.MarshalJSON()
+			if err != nil {
+				return errors.Wrapf(err, "failed to convert %s to JSON", path)
+			}
+			docs = append(docs, doc)
+		}
+
+		var output interface{} = docs
+		switch len(docs) {
+		case 0:
+			output = []json.RawMessage{}
+		case 1:
+			output = docs[0]
+		}
+		jsonData, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal JSON for %s", path)
+		}
+
+		jsonFile := strings.TrimSuffix(path, ext) + ".json"
+		err = ioutil.WriteFile(jsonFile, append(jsonData, '\n'), files.DefaultFileWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", jsonFile)
+		}
+		err = os.Remove(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove file %s", path)
+		}
+		return nil
+	})
+}
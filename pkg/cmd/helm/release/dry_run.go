@@ -0,0 +1,104 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	manifestHeader = "MANIFEST:\n"
+	notesHeader    = "\nNOTES:\n"
+)
+
+// DryRunRelease performs a 'helm upgrade --install --dry-run' of the chart and either prints the manifests it would
+// install or, with --diff, the changes compared to the manifests of the deployed release
+func (o *Options) DryRunRelease(chartDir, name string) error {
+	c := &cmdrunner.Command{
+		Dir:  chartDir,
+		Name: o.HelmBinary,
+		Args: []string{"dependency", "build", "."},
+	}
+	_, err := o.CommandRunner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build dependencies")
+	}
+
+	c = &cmdrunner.Command{
+		Dir:  chartDir,
+		Name: o.HelmBinary,
+		Args: []string{"upgrade", "--install", name, ".", "--namespace", o.ReleaseNamespace, "--dry-run"},
+	}
+	text, err := o.CommandRunner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to dry run the release %s", name)
+	}
+	manifest := DryRunManifest(text)
+
+	if !o.Diff {
+		fmt.Fprintf(o.Out, "# release %s in namespace %s\n%s\n", name, o.ReleaseNamespace, manifest)
+		return nil
+	}
+
+	current, err := o.deployedManifest(chartDir, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the manifest of the deployed release %s", name)
+	}
+	var lines []string
+	if current != "" {
+		lines = difflib.SplitLines(current)
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        lines,
+		B:        difflib.SplitLines(manifest),
+		FromFile: name + " (deployed)",
+		ToFile:   name + " (dry run)",
+		Context:  3,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to diff the release %s", name)
+	}
+	if diff == "" {
+		log.Logger().Infof("no changes to release %s", info(name))
+		return nil
+	}
+	o.Changed = append(o.Changed, name)
+	fmt.Fprint(o.Out, diff)
+	return nil
+}
+
+// DryRunManifest returns the manifests in the output of a 'helm upgrade --install --dry-run' without the release
+// details, hooks and notes
+func DryRunManifest(text string) string {
+	idx := strings.Index(text, manifestHeader)
+	if idx >= 0 {
+		text = text[idx+len(manifestHeader):]
+	}
+	idx = strings.Index(text, notesHeader)
+	if idx >= 0 {
+		text = text[:idx]
+	}
+	return strings.TrimSpace(text)
+}
+
+// deployedManifest returns the manifest of the deployed release or an empty string if it has not been installed
+func (o *Options) deployedManifest(chartDir, name string) (string, error) {
+	c := &cmdrunner.Command{
+		Dir:  chartDir,
+		Name: o.HelmBinary,
+		Args: []string{"get", "manifest", name, "--namespace", o.ReleaseNamespace},
+	}
+	text, err := o.CommandRunner(c)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			log.Logger().Infof("release %s is not installed in namespace %s", info(name), info(o.ReleaseNamespace))
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(text), nil
+}
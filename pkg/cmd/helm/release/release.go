@@ -3,12 +3,14 @@ package release
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/ghpages"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/gittags"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/variablefinders"
@@ -33,6 +35,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// DiffExitCode the exit code used with --detailed-exitcode when --diff detects changes
+const DiffExitCode = 2
+
 var (
 	info = termcolor.ColorInfo
 
@@ -43,6 +48,18 @@ var (
 	cmdExample = templates.Examples(`
 		# generates the resources from a helm chart
 		%s step helm template
+
+		# releases the charts then creates and pushes a git tag for the version
+		%s helm release --git-tag
+
+		# prints the manifests the charts would install without releasing them
+		%s helm release --dry-run
+
+		# shows the changes to the deployed releases and exits with code 2 if there are any
+		%s helm release --diff --detailed-exitcode
+
+		# releases the charts to GitHub Pages along with a SPDX SBOM of each chart
+		%s helm release --pages --sbom --sbom-format spdx
 	`)
 
 	defaultReadMe = `
@@ -71,6 +88,7 @@ You can have a look at the underlying charts YAML at: [index.yaml](index.yaml)
 type Options struct {
 	UseHelmPlugin        bool
 	NoRelease            bool
+	GitTag               bool
 	ChartOCI             bool
 	ChartPages           bool
 	NoOCILogin           bool
@@ -84,6 +102,8 @@ type Options struct {
 	RepositoryPassword   string
 	GithubPagesBranch    string
 	GithubPagesURL       string
+	GitTagPrefix         string
+	GitRemote            string
 	Version              string
 	VersionFile          string
 	Namespace            string
@@ -94,6 +114,15 @@ type Options struct {
 	CommandRunner        cmdrunner.CommandRunner
 	Requirements         *jxcore.RequirementsConfig
 	GitHubPagesDir       string
+	DryRun               bool
+	Diff                 bool
+	DetailedExitCode     bool
+	ReleaseNamespace     string
+	Out                  io.Writer
+	Changed              []string
+	SBOM                 bool
+	SBOMFormat           string
+	SBOMDir              string
 }
 
 // NewCmdHelmRelease creates a command object for the command
@@ -104,10 +133,13 @@ func NewCmdHelmRelease() (*cobra.Command, *Options) {
 		Use:     "release",
 		Short:   "Performs a release of all the charts in the charts folder",
 		Long:    cmdLong,
-		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName),
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName),
 		Run: func(cmd *cobra.Command, args []string) {
 			err := o.Run()
 			helper.CheckErr(err)
+			if o.DetailedExitCode && len(o.Changed) > 0 {
+				os.Exit(DiffExitCode)
+			}
 		},
 	}
 	cmd.Flags().StringVarP(&o.Dir, "dir", "", ".", "the root directory to look for .jx/requirements.yaml")
@@ -126,6 +158,16 @@ func NewCmdHelmRelease() (*cobra.Command, *Options) {
 	cmd.Flags().BoolVarP(&o.Artifactory, "artifactory", "", false, "use artifactory mode for publishing the chart which involves using an artifactory header and -T for pushing the chart")
 	cmd.Flags().BoolVarP(&o.NoOCILogin, "no-oci-login", "", false, "disables using the 'helm registry login' command when using OCI")
 	cmd.Flags().BoolVarP(&o.NoRelease, "no-release", "", false, "disables publishing the release. Useful for a Pull Request pipeline")
+	cmd.Flags().BoolVarP(&o.GitTag, "git-tag", "", false, "creates an annotated git tag of the released version in the --dir and pushes it after publishing the charts")
+	cmd.Flags().StringVarP(&o.GitTagPrefix, "git-tag-prefix", "", "v", "the prefix of the git tag created with --git-tag")
+	cmd.Flags().StringVarP(&o.GitRemote, "git-remote", "", "origin", "the git remote to push the tag created with --git-tag to")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "prints the manifests a 'helm upgrade --install --dry-run' of each chart would install rather than releasing the charts")
+	cmd.Flags().BoolVarP(&o.Diff, "diff", "", false, "prints the changes between the deployed releases and a 'helm upgrade --install --dry-run' of each chart rather than releasing the charts")
+	cmd.Flags().BoolVarP(&o.DetailedExitCode, "detailed-exitcode", "", false, fmt.Sprintf("exits with code %d if --diff detects any changes", DiffExitCode))
+	cmd.Flags().StringVarP(&o.ReleaseNamespace, "release-namespace", "", "", "the namespace of the releases used by --dry-run and --diff. Defaults to the --namespace")
+	cmd.Flags().BoolVarP(&o.SBOM, "sbom", "", false, "generates a SBOM of the dependencies and images of each chart which is published alongside the chart. Only supported for GitHub Pages and Artifactory repositories")
+	cmd.Flags().StringVarP(&o.SBOMFormat, "sbom-format", "", SBOMFormatCycloneDX, fmt.Sprintf("the format of the SBOM generated by --sbom. Possible values: %s", strings.Join(SBOMFormats, ", ")))
+	cmd.Flags().StringVarP(&o.SBOMDir, "sbom-dir", "", "", "the directory the SBOMs generated by --sbom are written to. Defaults to a temporary directory which is removed after the release")
 	cmd.Flags().BoolVarP(&o.UseHelmPlugin, "use-helm-plugin", "", false, "uses the jx binary plugin for helm rather than whatever helm is on the $PATH")
 	return cmd, o
 }
@@ -135,6 +177,20 @@ func (o *Options) Validate() error {
 	if o.CommandRunner == nil {
 		o.CommandRunner = cmdrunner.QuietCommandRunner
 	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if o.DetailedExitCode && !o.Diff {
+		return errors.Errorf("--detailed-exitcode can only be used with --diff")
+	}
+	if o.SBOM {
+		if o.SBOMFormat == "" {
+			o.SBOMFormat = SBOMFormatCycloneDX
+		}
+		if stringhelpers.StringArrayIndex(SBOMFormats, o.SBOMFormat) < 0 {
+			return options.InvalidOption("sbom-format", o.SBOMFormat, SBOMFormats)
+		}
+	}
 	if !o.Artifactory && os.Getenv("ARTIFACTORY_CHART_REPOSITORY") == "true" {
 		o.Artifactory = true
 	}
@@ -177,6 +233,9 @@ func (o *Options) Validate() error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to create kube client")
 	}
+	if o.ReleaseNamespace == "" {
+		o.ReleaseNamespace = o.Namespace
+	}
 
 	// lets find the version
 	if o.Version == "" {
@@ -222,6 +281,9 @@ func (o *Options) Validate() error {
 			o.ContainerRegistryOrg = requirements.Cluster.DockerRegistryOrg
 		}
 	}
+	if o.SBOM && !o.ChartPages && (o.ChartOCI || !o.Artifactory) {
+		return errors.Errorf("--sbom can only be used with GitHub Pages and Artifactory chart repositories")
+	}
 	return nil
 }
 
@@ -244,6 +306,17 @@ func (o *Options) Run() error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to read dir %s", dir)
 	}
+	o.Changed = nil
+	if o.SBOM && o.SBOMDir == "" && !o.DryRun && !o.Diff {
+		o.SBOMDir, err = ioutil.TempDir("", "jx-sbom-")
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the SBOM dir")
+		}
+		defer func(dir string) {
+			os.RemoveAll(dir)
+			o.SBOMDir = ""
+		}(o.SBOMDir)
+	}
 	count := 0
 	for _, f := range fileSlice {
 		if !f.IsDir() {
@@ -261,6 +334,15 @@ func (o *Options) Run() error {
 			continue
 		}
 
+		if o.DryRun || o.Diff {
+			err = o.DryRunRelease(chartDir, name)
+			if err != nil {
+				return errors.Wrapf(err, "failed to dry run the release of chart in dir %s", chartDir)
+			}
+			count++
+			continue
+		}
+
 		log.Logger().Infof("releasing chart %s", info(name))
 
 		// find the repository URL
@@ -290,7 +372,46 @@ func (o *Options) Run() error {
 		count++
 	}
 
+	if o.DryRun || o.Diff {
+		log.Logger().Infof("dry run %d charts from the charts dir: %s", count, dir)
+		if o.Diff && len(o.Changed) > 0 {
+			log.Logger().Infof("detected changes in releases: %s", info(strings.Join(o.Changed, ", ")))
+		}
+		return nil
+	}
+
 	log.Logger().Infof("released %d charts from the charts dir: %s", count, dir)
+
+	if o.GitTag && !o.NoRelease && count > 0 {
+		err = o.TagRelease()
+		if err != nil {
+			return errors.Wrapf(err, "failed to tag the release")
+		}
+	}
+	return nil
+}
+
+// TagRelease creates an annotated git tag of the released version and pushes it
+func (o *Options) TagRelease() error {
+	tag := o.GitTagPrefix + o.Version
+	exists, err := gittags.HasTag(o.GitClient, o.Dir, tag)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Logger().Infof("not creating git tag %s as it already exists", info(tag))
+	} else {
+		err = gittags.CreateAnnotatedTag(o.GitClient, o.Dir, tag, fmt.Sprintf("release %s", o.Version))
+		if err != nil {
+			return err
+		}
+		log.Logger().Infof("created git tag %s", info(tag))
+	}
+	err = gittags.PushTag(o.GitClient, o.Dir, o.GitRemote, tag)
+	if err != nil {
+		return err
+	}
+	log.Logger().Infof("pushed git tag %s to %s", info(tag), o.GitRemote)
 	return nil
 }
 
@@ -352,6 +473,11 @@ func (o *Options) ChartPageRegistry(repoURL, chartDir, name string) error {
 		return errors.Wrapf(err, "failed to package chart")
 	}
 
+	sbomPath, err := o.CreateSBOM(chartDir, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the SBOM")
+	}
+
 	if o.NoRelease {
 		log.Logger().Infof("disabling the chart publish")
 		return nil
@@ -412,13 +538,19 @@ func (o *Options) ChartPageRegistry(repoURL, chartDir, name string) error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to read chart dir %s", chartDir)
 	}
+	var paths []string
 	for _, f := range fs {
 		name := f.Name()
 		if f.IsDir() || !strings.HasSuffix(name, ".tgz") {
 			continue
 		}
-		path := filepath.Join(chartDir, name)
-		tofile := filepath.Join(o.GitHubPagesDir, name)
+		paths = append(paths, filepath.Join(chartDir, name))
+	}
+	if sbomPath != "" {
+		paths = append(paths, sbomPath)
+	}
+	for _, path := range paths {
+		tofile := filepath.Join(o.GitHubPagesDir, filepath.Base(path))
 
 		err = files.CopyFile(path, tofile)
 		if err != nil {
@@ -489,6 +621,14 @@ func (o *Options) BasicRegistry(repoURL, chartDir, name string) error {
 		return errors.Wrapf(err, "failed to package chart")
 	}
 
+	sbomPath, err := o.CreateSBOM(chartDir, name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the SBOM")
+	}
+	if sbomPath != "" && strings.HasPrefix(repoURL, "gs:") {
+		return errors.Errorf("--sbom cannot be used with the Google Cloud Storage chart repository %s", repoURL)
+	}
+
 	if o.NoRelease {
 		log.Logger().Infof("disabling the chart publish")
 		return nil
@@ -503,6 +643,13 @@ func (o *Options) BasicRegistry(repoURL, chartDir, name string) error {
 	if err != nil {
 		return errors.Wrapf(err, "failed to publish")
 	}
+
+	if sbomPath != "" {
+		_, err = o.CommandRunner(o.createArtifactoryUploadCommand(repoURL, filepath.Dir(sbomPath), filepath.Base(sbomPath), password))
+		if err != nil {
+			return errors.Wrapf(err, "failed to publish the SBOM")
+		}
+	}
 	return nil
 }
 
@@ -552,22 +699,7 @@ func (o *Options) createPublishCommand(repoURL, name, chartDir, username, passwo
 	}
 
 	if o.Artifactory {
-		// lets try detect the git repository name
-		url := stringhelpers.UrlJoin(repoURL, tarFile)
-
-		repoName := os.Getenv("REPO_NAME")
-		if repoName != "" {
-			url = stringhelpers.UrlJoin(repoURL, repoName, tarFile)
-		}
-
-		apiKey := "X-JFrog-Art-Api:" + password
-
-		return &cmdrunner.Command{
-			Dir:  chartDir,
-			Name: "curl",
-			// lets hide progress bars (-s) and enable show errors (-S)
-			Args: []string{"--fail", "-sS", "-H", apiKey, "-T", tarFile, url},
-		}, nil
+		return o.createArtifactoryUploadCommand(repoURL, chartDir, tarFile, password), nil
 	}
 	userSecret := username + ":" + password
 
@@ -581,6 +713,25 @@ func (o *Options) createPublishCommand(repoURL, name, chartDir, username, passwo
 	}, nil
 }
 
+func (o *Options) createArtifactoryUploadCommand(repoURL, chartDir, fileName, password string) *cmdrunner.Command {
+	// lets try detect the git repository name
+	url := stringhelpers.UrlJoin(repoURL, fileName)
+
+	repoName := os.Getenv("REPO_NAME")
+	if repoName != "" {
+		url = stringhelpers.UrlJoin(repoURL, repoName, fileName)
+	}
+
+	apiKey := "X-JFrog-Art-Api:" + password
+
+	return &cmdrunner.Command{
+		Dir:  chartDir,
+		Name: "curl",
+		// lets hide progress bars (-s) and enable show errors (-S)
+		Args: []string{"--fail", "-sS", "-H", apiKey, "-T", fileName, url},
+	}
+}
+
 func (o *Options) findChartRepositoryUserPassword() (string, string, error) {
 	userName := o.RepositoryUsername
 	password := o.RepositoryPassword
@@ -1,7 +1,11 @@
 package release_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/fakerunners"
@@ -9,8 +13,16 @@ import (
 
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/release"
 	jxfake "github.com/jenkins-x/jx-api/v4/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner/fakerunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kube/jxenv"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -110,3 +122,403 @@ func TestStepHelmReleaseWithArtifactory(t *testing.T) {
 		t.Logf("ran: %s\n", c.CLI())
 	}
 }
+
+func TestStepHelmReleaseGitTag(t *testing.T) {
+	g := cli.NewCLIClient("", nil)
+
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	remoteDir := filepath.Join(tmpDir, "remote.git")
+	_, err = g.Command(tmpDir, "init", "--bare", remoteDir)
+	require.NoError(t, err, "failed to create the bare remote repository")
+
+	dir := filepath.Join(tmpDir, "repo")
+	_, err = g.Command(tmpDir, "clone", remoteDir, dir)
+	require.NoError(t, err, "failed to clone the remote repository")
+	_, err = g.Command(dir, "config", "user.name", "jx-gitops")
+	require.NoError(t, err, "failed to configure git user name")
+	_, err = g.Command(dir, "config", "user.email", "jx-gitops@example.com")
+	require.NoError(t, err, "failed to configure git user email")
+
+	chartsDir := filepath.Join(dir, "charts")
+	err = files.CopyDirOverwrite(filepath.Join("test_data", "charts"), chartsDir)
+	require.NoError(t, err, "failed to copy charts")
+	_, err = gitclient.AddAndCommitFiles(g, dir, "initial commit")
+	require.NoError(t, err, "failed to commit")
+
+	// lets use git for real but fake helm
+	runner := &fakerunner.FakeRunner{
+		CommandRunner: func(c *cmdrunner.Command) (string, error) {
+			if c.Name == "git" {
+				return cmdrunner.QuietCommandRunner(c)
+			}
+			return "fake " + c.CLI(), nil
+		},
+	}
+
+	ns := "jx"
+	devEnv := jxenv.CreateDefaultDevEnvironment(ns)
+	devEnv.Namespace = ns
+	devEnv.Spec.Source.URL = remoteDir
+	requirements := jxcore.NewRequirementsConfig()
+	requirements.Spec.Cluster.ChartRepository = "http://bucketrepo/bucketrepo/charts/"
+	data, err := yaml.Marshal(requirements)
+	require.NoError(t, err, "failed to marshal requirements")
+	devEnv.Spec.TeamSettings.BootRequirements = string(data)
+
+	_, o := release.NewCmdHelmRelease()
+	o.HelmBinary = "helm"
+	o.CommandRunner = runner.Run
+	o.Dir = dir
+	o.ChartsDir = chartsDir
+	o.JXClient = jxfake.NewSimpleClientset(devEnv)
+	o.KubeClient = fake.NewSimpleClientset()
+	o.Namespace = ns
+	o.Version = "1.2.3"
+	o.RepositoryURL = "http://bucketrepo/bucketrepo/charts/"
+	o.RepositoryUsername = "myuser"
+	o.RepositoryPassword = "mypwd"
+	o.GitTag = true
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	for _, repoDir := range []string{dir, remoteDir} {
+		tags, err := g.Command(repoDir, "tag", "--list")
+		require.NoError(t, err, "failed to list tags in %s", repoDir)
+		assert.Equal(t, "v1.2.3", strings.TrimSpace(tags), "tags in %s", repoDir)
+	}
+	message, err := g.Command(dir, "tag", "--list", "--format=%(contents:subject)", "v1.2.3")
+	require.NoError(t, err, "failed to get the tag message")
+	assert.Equal(t, "release 1.2.3", strings.TrimSpace(message), "tag message")
+
+	// releasing the same version again should not fail as the tag exists
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command again")
+}
+
+func TestStepHelmReleaseDiff(t *testing.T) {
+	dryRunOutput := `Release "myapp" has been upgraded. Happy Helming!
+NAME: myapp
+NAMESPACE: jx
+STATUS: pending-upgrade
+REVISION: 2
+HOOKS:
+MANIFEST:
+---
+# Source: myapp/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+spec:
+  ports:
+  - port: 8080
+
+NOTES:
+Get the application URL
+`
+	deployed := `---
+# Source: myapp/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: myapp
+spec:
+  ports:
+  - port: 80
+`
+	testCases := []struct {
+		name     string
+		diff     bool
+		deployed string
+		changed  []string
+		expected []string
+	}{
+		{
+			name:     "dry-run",
+			expected: []string{"# release myapp in namespace jx", "kind: Service", "  - port: 8080"},
+		},
+		{
+			name:     "diff",
+			diff:     true,
+			deployed: deployed,
+			changed:  []string{"myapp"},
+			expected: []string{"--- myapp (deployed)", "+++ myapp (dry run)", "-  - port: 80\n", "+  - port: 8080\n"},
+		},
+		{
+			name:     "no-changes",
+			diff:     true,
+			deployed: strings.Replace(deployed, "port: 80", "port: 8080", 1),
+		},
+		{
+			name:     "not-installed",
+			diff:     true,
+			changed:  []string{"myapp"},
+			expected: []string{"+kind: Service\n"},
+		},
+	}
+	// lets use a local repository for the dev environment
+	remoteDir := filepath.Join(t.TempDir(), "remote.git")
+	_, err := cli.NewCLIClient("", nil).Command(filepath.Dir(remoteDir), "init", "--bare", remoteDir)
+	require.NoError(t, err, "failed to create the bare remote repository")
+
+	ns := "jx"
+	devEnv := jxenv.CreateDefaultDevEnvironment(ns)
+	devEnv.Namespace = ns
+	devEnv.Spec.Source.URL = remoteDir
+	requirements := jxcore.NewRequirementsConfig()
+	data, err := yaml.Marshal(requirements)
+	require.NoError(t, err, "failed to marshal requirements")
+	devEnv.Spec.TeamSettings.BootRequirements = string(data)
+
+	for _, tc := range testCases {
+		runner := &fakerunner.FakeRunner{
+			CommandRunner: func(c *cmdrunner.Command) (string, error) {
+				if c.Name == "git" {
+					return cmdrunner.QuietCommandRunner(c)
+				}
+				if len(c.Args) > 0 && c.Args[0] == "upgrade" {
+					return dryRunOutput, nil
+				}
+				if len(c.Args) > 1 && c.Args[0] == "get" && c.Args[1] == "manifest" {
+					if tc.deployed == "" {
+						return "", errors.New("Error: release: not found")
+					}
+					return tc.deployed, nil
+				}
+				return "fake " + c.CLI(), nil
+			},
+		}
+
+		out := &bytes.Buffer{}
+		_, o := release.NewCmdHelmRelease()
+		o.HelmBinary = "helm"
+		o.CommandRunner = runner.Run
+		o.ChartsDir = filepath.Join("test_data", "charts")
+		o.JXClient = jxfake.NewSimpleClientset(devEnv)
+		o.KubeClient = fake.NewSimpleClientset()
+		o.Namespace = ns
+		o.Version = "1.2.3"
+		o.DryRun = true
+		o.Diff = tc.diff
+		o.DetailedExitCode = tc.diff
+		o.Out = out
+
+		err = o.Run()
+		require.NoError(t, err, "failed to run the command for %s", tc.name)
+
+		text := out.String()
+		t.Logf("%s output:\n%s\n", tc.name, text)
+		assert.Equal(t, tc.changed, o.Changed, "changed releases for %s", tc.name)
+		for _, e := range tc.expected {
+			assert.Contains(t, text, e, "output for %s", tc.name)
+		}
+		assert.NotContains(t, text, "NOTES:", "output for %s", tc.name)
+		if len(tc.expected) == 0 {
+			assert.Empty(t, text, "output for %s", tc.name)
+		}
+
+		var commands []string
+		for _, c := range runner.OrderedCommands {
+			if c.Name == "git" {
+				continue
+			}
+			commands = append(commands, c.CLI())
+		}
+		expectedCommands := []string{"helm dependency build .", "helm upgrade --install myapp . --namespace jx --dry-run"}
+		if tc.diff {
+			expectedCommands = append(expectedCommands, "helm get manifest myapp --namespace jx")
+		}
+		assert.Equal(t, expectedCommands, commands, "commands for %s", tc.name)
+	}
+}
+
+func TestStepHelmReleaseDetailedExitCodeRequiresDiff(t *testing.T) {
+	_, o := release.NewCmdHelmRelease()
+	o.DetailedExitCode = true
+	err := o.Validate()
+	require.Error(t, err, "should fail to use --detailed-exitcode without --diff")
+}
+
+func TestStepHelmReleaseSBOM(t *testing.T) {
+	rendered := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: myapp
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox@sha256:0123456789abcdef
+      containers:
+      - name: myapp
+        image: ghcr.io/myorg/myapp:1.2.3
+      - name: sidecar
+        image: ghcr.io/myorg/myapp:1.2.3
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: myapp-postgresql
+spec:
+  template:
+    spec:
+      containers:
+      - name: postgresql
+        image: docker.io/bitnami/postgresql:11.11.0
+`
+	testCases := []struct {
+		format    string
+		extension string
+	}{
+		{
+			format:    release.SBOMFormatCycloneDX,
+			extension: ".cdx.json",
+		},
+		{
+			format:    release.SBOMFormatSPDX,
+			extension: ".spdx.json",
+		},
+	}
+	for _, tc := range testCases {
+		chartsDir := t.TempDir()
+		err := files.CopyDirOverwrite(filepath.Join("test_data", "sbom"), chartsDir)
+		require.NoError(t, err, "failed to copy charts to %s", chartsDir)
+
+		runner := &fakerunner.FakeRunner{
+			CommandRunner: func(c *cmdrunner.Command) (string, error) {
+				if len(c.Args) > 0 && c.Args[0] == "template" {
+					return rendered, nil
+				}
+				return "fake " + c.CLI(), nil
+			},
+		}
+
+		_, o := release.NewCmdHelmRelease()
+		o.HelmBinary = "helm"
+		o.CommandRunner = runner.Run
+		o.ChartsDir = chartsDir
+		o.Version = "1.2.3"
+		o.RepositoryURL = "https://artifactory.example.com/charts"
+		o.RepositoryUsername = "myuser"
+		o.RepositoryPassword = "mypwd"
+		o.Artifactory = true
+		o.SBOM = true
+		o.SBOMFormat = tc.format
+		o.SBOMDir = t.TempDir()
+
+		// lets release the chart directly to avoid loading the requirements from the dev environment
+		err = o.BasicRegistry(o.RepositoryURL, filepath.Join(chartsDir, "myapp"), "myapp")
+		require.NoError(t, err, "failed to release with %s SBOM", tc.format)
+
+		sbomFile := "myapp-1.2.3" + tc.extension
+		assert.NoFileExists(t, filepath.Join(chartsDir, "myapp", sbomFile), "should not write the %s SBOM into the chart dir", tc.format)
+		path := filepath.Join(o.SBOMDir, sbomFile)
+		require.FileExists(t, path, "should have generated the %s SBOM", tc.format)
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err, "failed to load %s", path)
+		t.Logf("%s SBOM:\n%s\n", tc.format, string(data))
+
+		var purls []string
+		switch tc.format {
+		case release.SBOMFormatSPDX:
+			doc := &release.SPDXDocument{}
+			err = json.Unmarshal(data, doc)
+			require.NoError(t, err, "failed to parse %s", path)
+			assert.Equal(t, "SPDX-2.2", doc.SPDXVersion, "spdxVersion")
+			assert.Equal(t, "myapp-1.2.3", doc.Name, "name")
+			for _, p := range doc.Packages {
+				require.Len(t, p.ExternalRefs, 1, "external refs of %s", p.Name)
+				purls = append(purls, p.ExternalRefs[0].ReferenceLocator)
+			}
+			require.Len(t, doc.Relationships, 6, "relationships")
+			assert.Equal(t, "DESCRIBES", doc.Relationships[0].RelationshipType, "first relationship")
+			assert.Equal(t, "SPDXRef-application-myapp-1.2.3", doc.Relationships[0].RelatedSPDXElement, "described package")
+			assert.Equal(t, "DEPENDS_ON", doc.Relationships[1].RelationshipType, "dependency relationship")
+		default:
+			bom := &release.CycloneDXBOM{}
+			err = json.Unmarshal(data, bom)
+			require.NoError(t, err, "failed to parse %s", path)
+			assert.Equal(t, "CycloneDX", bom.BOMFormat, "bomFormat")
+			assert.Equal(t, "pkg:helm/myapp@1.2.3", bom.Metadata.Component.PURL, "chart purl")
+			purls = append(purls, bom.Metadata.Component.PURL)
+			for _, c := range bom.Components {
+				purls = append(purls, c.PURL)
+			}
+			require.Len(t, bom.Dependencies, 1, "dependencies")
+			assert.Len(t, bom.Dependencies[0].DependsOn, 5, "dependencies of the chart")
+		}
+		assert.Equal(t, []string{
+			"pkg:helm/myapp@1.2.3",
+			"pkg:helm/postgresql@10.3.11",
+			"pkg:helm/redis@14.1.0",
+			"pkg:docker/busybox@sha256%3A0123456789abcdef",
+			"pkg:docker/bitnami/postgresql@11.11.0?repository_url=docker.io",
+			"pkg:docker/myorg/myapp@1.2.3?repository_url=ghcr.io",
+		}, purls, "package URLs in the %s SBOM", tc.format)
+
+		uploaded := false
+		for _, c := range runner.OrderedCommands {
+			if c.Name == "curl" && stringhelpers.StringArrayIndex(c.Args, sbomFile) >= 0 {
+				uploaded = true
+				assert.Equal(t, "https://artifactory.example.com/charts/"+sbomFile, c.Args[len(c.Args)-1], "SBOM upload URL")
+				assert.Equal(t, o.SBOMDir, c.Dir, "SBOM upload dir")
+			}
+		}
+		assert.True(t, uploaded, "should have uploaded the %s SBOM", tc.format)
+	}
+}
+
+func TestStepHelmReleaseInvalidSBOMFormat(t *testing.T) {
+	_, o := release.NewCmdHelmRelease()
+	o.SBOM = true
+	o.SBOMFormat = "cheese"
+	err := o.Validate()
+	require.Error(t, err, "should fail with an invalid --sbom-format")
+}
+
+func TestStepHelmReleaseSBOMUnsupportedRepository(t *testing.T) {
+	testCases := []struct {
+		name      string
+		chartKind jxcore.ChartRepositoryType
+	}{
+		{
+			name: "chartmuseum",
+		},
+		{
+			name:      "oci",
+			chartKind: jxcore.ChartRepositoryTypeOCI,
+		},
+	}
+	for _, tc := range testCases {
+		ns := "jx"
+		devEnv := jxenv.CreateDefaultDevEnvironment(ns)
+		devEnv.Namespace = ns
+		devEnv.Spec.Source.URL = "https://github.com/jx3-gitops-repositories/jx3-kubernetes.git"
+
+		requirements := jxcore.NewRequirementsConfig()
+		requirements.Spec.Cluster.ChartRepository = "http://bucketrepo/bucketrepo/charts/"
+		requirements.Spec.Cluster.ChartKind = tc.chartKind
+		data, err := yaml.Marshal(requirements)
+		require.NoError(t, err, "failed to marshal requirements for %s", tc.name)
+		devEnv.Spec.TeamSettings.BootRequirements = string(data)
+
+		runner := fakerunners.NewFakeRunnerWithGitClone()
+		_, o := release.NewCmdHelmRelease()
+		o.HelmBinary = "helm"
+		o.CommandRunner = runner.Run
+		o.ChartsDir = filepath.Join("test_data", "charts")
+		o.JXClient = jxfake.NewSimpleClientset(devEnv)
+		o.KubeClient = fake.NewSimpleClientset()
+		o.Namespace = ns
+		o.Version = "1.2.3"
+		o.SBOM = true
+
+		err = o.Run()
+		require.Error(t, err, "should fail to release with --sbom to a %s repository", tc.name)
+		assert.Contains(t, err.Error(), "--sbom can only be used with GitHub Pages and Artifactory chart repositories", "error for %s", tc.name)
+	}
+}
@@ -0,0 +1,354 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/images"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pborman/uuid"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+const (
+	// SBOMFormatCycloneDX the CycloneDX JSON SBOM format
+	SBOMFormatCycloneDX = "cyclonedx"
+
+	// SBOMFormatSPDX the SPDX JSON SBOM format
+	SBOMFormatSPDX = "spdx"
+
+	sbomTool = "jx-gitops"
+)
+
+var (
+	// SBOMFormats the supported SBOM formats
+	SBOMFormats = []string{SBOMFormatCycloneDX, SBOMFormatSPDX}
+
+	// SBOMExtensions the file extension of the SBOM of each format
+	SBOMExtensions = map[string]string{
+		SBOMFormatCycloneDX: ".cdx.json",
+		SBOMFormatSPDX:      ".spdx.json",
+	}
+
+	invalidSPDXIDChars = regexp.MustCompile(`[^a-zA-Z0-9.\-]+`)
+)
+
+// SBOMComponent a chart, chart dependency or container image in the SBOM
+type SBOMComponent struct {
+	Type    string
+	Name    string
+	Version string
+	PURL    string
+}
+
+// CycloneDXBOM a CycloneDX bill of materials
+type CycloneDXBOM struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     CycloneDXMetadata     `json:"metadata"`
+	Components   []CycloneDXComponent  `json:"components"`
+	Dependencies []CycloneDXDependency `json:"dependencies"`
+}
+
+// CycloneDXMetadata the metadata of a CycloneDX bill of materials
+type CycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []CycloneDXTool    `json:"tools"`
+	Component CycloneDXComponent `json:"component"`
+}
+
+// CycloneDXTool the tool which created a CycloneDX bill of materials
+type CycloneDXTool struct {
+	Name string `json:"name"`
+}
+
+// CycloneDXComponent a component of a CycloneDX bill of materials
+type CycloneDXComponent struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl"`
+}
+
+// CycloneDXDependency the components a component of a CycloneDX bill of materials depends on
+type CycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// SPDXDocument a SPDX document
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+// SPDXCreationInfo the creation details of a SPDX document
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// SPDXPackage a package of a SPDX document
+type SPDXPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs"`
+}
+
+// SPDXExternalRef an external reference of a SPDX package such as its package URL
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// SPDXRelationship a relationship between the elements of a SPDX document
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// CreateSBOM generates the SBOM of the chart dependencies and the images of the resources rendered by the chart into the
+// --sbom-dir returning the path of the SBOM file or an empty string if --sbom is not enabled
+func (o *Options) CreateSBOM(chartDir, name string) (string, error) {
+	if !o.SBOM {
+		return "", nil
+	}
+	ch, err := loader.Load(chartDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load chart %s", chartDir)
+	}
+	chart := SBOMComponent{
+		Type:    "application",
+		Name:    name,
+		Version: o.Version,
+		PURL:    ChartPURL(name, o.Version),
+	}
+
+	var components []SBOMComponent
+	for _, d := range ch.Metadata.Dependencies {
+		components = append(components, SBOMComponent{
+			Type:    "application",
+			Name:    d.Name,
+			Version: d.Version,
+			PURL:    ChartPURL(d.Name, d.Version),
+		})
+	}
+	imageNames, err := o.chartImages(chartDir, name)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the images of chart %s", name)
+	}
+	for _, image := range imageNames {
+		imageName, version := SplitImageVersion(image)
+		components = append(components, SBOMComponent{
+			Type:    "container",
+			Name:    imageName,
+			Version: version,
+			PURL:    ImagePURL(image),
+		})
+	}
+
+	var data []byte
+	switch o.SBOMFormat {
+	case SBOMFormatSPDX:
+		data, err = json.MarshalIndent(CreateSPDXDocument(chart, components, time.Now()), "", "  ")
+	default:
+		data, err = json.MarshalIndent(CreateCycloneDXBOM(chart, components, time.Now()), "", "  ")
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal the SBOM of chart %s", name)
+	}
+	if o.SBOMDir == "" {
+		return "", options.MissingOption("sbom-dir")
+	}
+	err = os.MkdirAll(o.SBOMDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create dir %s", o.SBOMDir)
+	}
+	path := filepath.Join(o.SBOMDir, name+"-"+o.Version+SBOMExtensions[o.SBOMFormat])
+	err = ioutil.WriteFile(path, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to save file %s", path)
+	}
+	log.Logger().Infof("generated the %s SBOM of chart %s with %d components at %s", o.SBOMFormat, info(name), len(components), path)
+	return path, nil
+}
+
+// chartImages returns the sorted unique images of the resources rendered by the chart
+func (o *Options) chartImages(chartDir, name string) ([]string, error) {
+	c := &cmdrunner.Command{
+		Dir:  chartDir,
+		Name: o.HelmBinary,
+		Args: []string{"template", name, "."},
+	}
+	text, err := o.CommandRunner(c)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to template chart")
+	}
+	reader := &kio.ByteReader{Reader: strings.NewReader(text), OmitReaderAnnotations: true}
+	nodes, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the output of helm template")
+	}
+	m := map[string]bool{}
+	for _, node := range nodes {
+		images.ForEachImage(node.YNode(), func(_, image string) {
+			if image != "" {
+				m[image] = true
+			}
+		})
+	}
+	var answer []string
+	for image := range m {
+		answer = append(answer, image)
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
+// CreateCycloneDXBOM creates a CycloneDX bill of materials of the chart and its components
+func CreateCycloneDXBOM(chart SBOMComponent, components []SBOMComponent, now time.Time) *CycloneDXBOM {
+	toComponent := func(c SBOMComponent) CycloneDXComponent {
+		return CycloneDXComponent{
+			Type:    c.Type,
+			BOMRef:  c.PURL,
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.PURL,
+		}
+	}
+	bom := &CycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: "urn:uuid:" + uuid.New(),
+		Version:      1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: now.UTC().Format(time.RFC3339),
+			Tools:     []CycloneDXTool{{Name: sbomTool}},
+			Component: toComponent(chart),
+		},
+		Components: []CycloneDXComponent{},
+	}
+	dependency := CycloneDXDependency{Ref: chart.PURL, DependsOn: []string{}}
+	for _, c := range components {
+		bom.Components = append(bom.Components, toComponent(c))
+		dependency.DependsOn = append(dependency.DependsOn, c.PURL)
+	}
+	bom.Dependencies = []CycloneDXDependency{dependency}
+	return bom
+}
+
+// CreateSPDXDocument creates a SPDX document describing the chart which depends on its components
+func CreateSPDXDocument(chart SBOMComponent, components []SBOMComponent, now time.Time) *SPDXDocument {
+	toPackage := func(c SBOMComponent) SPDXPackage {
+		return SPDXPackage{
+			SPDXID:           SPDXID(c),
+			Name:             c.Name,
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+			ExternalRefs: []SPDXExternalRef{
+				{
+					ReferenceCategory: "PACKAGE-MANAGER",
+					ReferenceType:     "purl",
+					ReferenceLocator:  c.PURL,
+				},
+			},
+		}
+	}
+	name := chart.Name + "-" + chart.Version
+	doc := &SPDXDocument{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("https://jenkins-x.io/spdx/%s-%s", name, uuid.New()),
+		CreationInfo: SPDXCreationInfo{
+			Created:  now.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + sbomTool},
+		},
+		Packages: []SPDXPackage{toPackage(chart)},
+		Relationships: []SPDXRelationship{
+			{
+				SPDXElementID:      "SPDXRef-DOCUMENT",
+				RelationshipType:   "DESCRIBES",
+				RelatedSPDXElement: SPDXID(chart),
+			},
+		},
+	}
+	for _, c := range components {
+		doc.Packages = append(doc.Packages, toPackage(c))
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{
+			SPDXElementID:      SPDXID(chart),
+			RelationshipType:   "DEPENDS_ON",
+			RelatedSPDXElement: SPDXID(c),
+		})
+	}
+	return doc
+}
+
+// SPDXID returns the SPDX identifier of the component which may only contain letters, numbers, '.' and '-'
+func SPDXID(c SBOMComponent) string {
+	return "SPDXRef-" + c.Type + "-" + strings.Trim(invalidSPDXIDChars.ReplaceAllString(c.Name+"-"+c.Version, "-"), "-")
+}
+
+// ChartPURL returns the package URL of the helm chart
+func ChartPURL(name, version string) string {
+	answer := "pkg:helm/" + name
+	if version != "" {
+		answer += "@" + url.QueryEscape(version)
+	}
+	return answer
+}
+
+// ImagePURL returns the package URL of the container image using the registry as the repository_url qualifier
+func ImagePURL(image string) string {
+	name, version := SplitImageVersion(image)
+	registry := ""
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		registry = parts[0]
+		name = parts[1]
+	}
+	answer := "pkg:docker/" + name
+	if version != "" {
+		answer += "@" + url.QueryEscape(version)
+	}
+	if registry != "" {
+		answer += "?repository_url=" + url.QueryEscape(registry)
+	}
+	return answer
+}
+
+// SplitImageVersion splits the image into the name and the digest or tag
+func SplitImageVersion(image string) (string, string) {
+	idx := strings.Index(image, "@")
+	if idx >= 0 {
+		return image[:idx], image[idx+1:]
+	}
+	return images.SplitImageTag(image)
+}
@@ -0,0 +1,196 @@
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// SchemaVersion the JSON schema version of the generated schemas
+	SchemaVersion = "http://json-schema.org/draft-07/schema#"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Generates the values.schema.json file of a chart by inferring the types of the values in its values.yaml file
+
+Maps become objects with properties, lists become arrays whose items are inferred from all of the list elements and null values can have any type.
+`)
+
+	cmdExample = templates.Examples(`
+		# generates the values.schema.json file of the chart in the current directory
+		%s helm schema generate
+
+		# generates the values.schema.json file of a chart
+		%s helm schema generate --chart charts/myapp
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Chart   string
+	OutFile string
+	Schema  *Schema
+}
+
+// Schema a JSON schema of a value
+type Schema struct {
+	Schema     string             `json:"$schema,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+}
+
+// NewCmdSchemaGenerate creates a command object for the command
+func NewCmdSchemaGenerate() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "generate",
+		Aliases: []string{"gen"},
+		Short:   "Generates the values.schema.json file of a chart by inferring the types of the values in its values.yaml file",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Chart, "chart", "c", ".", "the directory of the chart")
+	cmd.Flags().StringVarP(&o.OutFile, "output-file", "o", "", "the schema file to generate. Defaults to values.schema.json in the chart directory")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	valuesFile := filepath.Join(o.Chart, "values.yaml")
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", valuesFile)
+	}
+	node, err := yaml.Parse(string(data))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse YAML file %s", valuesFile)
+	}
+
+	o.Schema = InferSchema(node.YNode())
+	if o.Schema.Type == "" {
+		// an empty values.yaml file
+		o.Schema.Type = "object"
+	}
+	o.Schema.Schema = SchemaVersion
+
+	data, err = json.MarshalIndent(o.Schema, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal schema to JSON")
+	}
+	outFile := o.OutFile
+	if outFile == "" {
+		outFile = filepath.Join(o.Chart, "values.schema.json")
+	}
+	err = ioutil.WriteFile(outFile, append(data, '\n'), files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", outFile)
+	}
+	log.Logger().Infof("generated the schema of chart %s to %s", info(o.Chart), info(outFile))
+	return nil
+}
+
+// InferSchema infers the schema of the YAML node from its values
+func InferSchema(node *yaml.Node) *Schema {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return &Schema{}
+		}
+		return InferSchema(node.Content[0])
+	case yaml.AliasNode:
+		return InferSchema(node.Alias)
+	case yaml.MappingNode:
+		answer := &Schema{Type: "object"}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if answer.Properties == nil {
+				answer.Properties = map[string]*Schema{}
+			}
+			answer.Properties[node.Content[i].Value] = InferSchema(node.Content[i+1])
+		}
+		return answer
+	case yaml.SequenceNode:
+		answer := &Schema{Type: "array"}
+		for i, child := range node.Content {
+			s := InferSchema(child)
+			if i == 0 {
+				answer.Items = s
+			} else {
+				answer.Items = mergeSchemas(answer.Items, s)
+			}
+		}
+		return answer
+	}
+	switch node.ShortTag() {
+	case yaml.NodeTagInt:
+		return &Schema{Type: "integer"}
+	case yaml.NodeTagFloat:
+		return &Schema{Type: "number"}
+	case yaml.NodeTagBool:
+		return &Schema{Type: "boolean"}
+	case yaml.NodeTagNull:
+		return &Schema{}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+// mergeSchemas merges the schemas of two list elements so that the result accepts both of them
+func mergeSchemas(a, b *Schema) *Schema {
+	switch {
+	case a.Type == "":
+		return b
+	case b.Type == "":
+		return a
+	case a.Type == "integer" && b.Type == "number", a.Type == "number" && b.Type == "integer":
+		return &Schema{Type: "number"}
+	case a.Type != b.Type:
+		return &Schema{}
+	}
+	answer := &Schema{Type: a.Type}
+	switch a.Type {
+	case "object":
+		for _, s := range []*Schema{a, b} {
+			for k, v := range s.Properties {
+				if answer.Properties == nil {
+					answer.Properties = map[string]*Schema{}
+				}
+				existing := answer.Properties[k]
+				if existing != nil {
+					v = mergeSchemas(existing, v)
+				}
+				answer.Properties[k] = v
+			}
+		}
+	case "array":
+		switch {
+		case a.Items == nil:
+			answer.Items = b.Items
+		case b.Items == nil:
+			answer.Items = a.Items
+		default:
+			answer.Items = mergeSchemas(a.Items, b.Items)
+		}
+	}
+	return answer
+}
@@ -0,0 +1,48 @@
+package generate_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/schema/generate"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmSchemaGenerate(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	_, o := generate.NewCmdSchemaGenerate()
+	o.Chart = filepath.Join("test_data", "mychart")
+	o.OutFile = filepath.Join(tmpDir, "values.schema.json")
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", "expected.json"), o.OutFile, "generated schema")
+
+	data, err := ioutil.ReadFile(o.OutFile)
+	require.NoError(t, err, "failed to read %s", o.OutFile)
+	schema := &generate.Schema{}
+	err = json.Unmarshal(data, schema)
+	require.NoError(t, err, "failed to parse %s", o.OutFile)
+
+	assert.Equal(t, "integer", schema.Properties["replicaCount"].Type, "replicaCount")
+	assert.Equal(t, "string", schema.Properties["image"].Properties["tag"].Type, "image.tag")
+	assert.Equal(t, "number", schema.Properties["resources"].Properties["limits"].Properties["cpu"].Type, "resources.limits.cpu")
+	assert.Equal(t, "", schema.Properties["serviceAccount"].Properties["name"].Type, "null values can have any type")
+
+	ports := schema.Properties["ports"]
+	assert.Equal(t, "array", ports.Type, "ports")
+	require.NotNil(t, ports.Items, "ports items")
+	assert.Equal(t, "object", ports.Items.Type, "ports items")
+	assert.Equal(t, "integer", ports.Items.Properties["port"].Type, "ports items port")
+	assert.Equal(t, "string", ports.Items.Properties["protocol"].Type, "properties are merged from all the list elements")
+
+	assert.Nil(t, schema.Properties["imagePullSecrets"].Items, "empty list items")
+	assert.Equal(t, "", schema.Properties["args"].Items.Type, "mixed list items can have any type")
+	assert.Equal(t, "number", schema.Properties["env"].Properties["weights"].Items.Type, "integer and number list items")
+}
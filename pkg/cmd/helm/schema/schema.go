@@ -0,0 +1,24 @@
+package schema
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/schema/generate"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdSchema creates the new command
+func NewCmdSchema() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "schema",
+		Short: "Commands for working with the values.schema.json files of helm charts",
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(generate.NewCmdSchemaGenerate()))
+	return command
+}
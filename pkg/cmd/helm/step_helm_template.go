@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/move"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/split"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
@@ -24,34 +28,75 @@ import (
 var (
 	helmTemplateLong = templates.LongDesc(`
 		Generate the kubernetes resources from a helm chart
+
+The chart can be a local directory, a chart in the --repository or a chart in an OCI registry such as 'oci://registry.example.com/charts/mychart'. OCI charts are pulled using the --version or the tag of the reference and then templated in the same way as a local chart. If a --registry-username is specified or the $` + RegistryUsernameEnv + ` environment variable is set then the command logs into the registry first using the --registry-password or $` + RegistryPasswordEnv + `
+
+If --show-computed-values is specified then the values of each release are also written to the --computed-values-dir after merging the chart values, the values files, the --set values and the default values of the enabled subcharts so that any overrides of subchart values are visible
 `)
 
 	helmTemplateExample = templates.Examples(`
 		# generates the resources from a helm chart
 		%s step helm template
+
+		# generates the resources for every chart in a directory using 4 charts in parallel
+		%s step helm template --charts-dir charts --concurrency 4
+
+		# generates the resources into the namespaces and cluster directories of the config-root like 'helmfile move'
+		%s step helm template --namespace jx --config-root config-root
+
+		# generates the resources as JSON files
+		%s step helm template --output-format json
+
+		# rolls out the workloads when their generated ConfigMaps or Secrets change
+		%s step helm template --config-checksum
+
+		# generates the resources using a values file downloaded from a URL
+		%s step helm template --values https://acme.com/values.yaml --values-auth-header "Authorization: Bearer $TOKEN"
+
+		# generates the resources from a chart in an OCI registry
+		%s step helm template --name myapp --chart oci://registry.example.com/charts/myapp --version 1.2.3
+
+		# overrides the image tag of the chart after applying the values file
+		%s step helm template --name myapp --values values.yaml --set image.tag=1.2.3
+
+		# also writes the merged values of the chart and its subcharts to computed-values/myapp.yaml
+		%s step helm template --name myapp --values values.yaml --show-computed-values
 	`)
 )
 
 // HelmTemplateOptions the options for the command
 type TemplateOptions struct {
-	OutDir           string
-	HelmBinary       string
-	ReleaseName      string
-	Namespace        string
-	Chart            string
-	ValuesFiles      []string
-	DefaultDomain    string
-	GitCommitMessage string
-	Version          string
-	Repository       string
-	BatchMode        bool
-	DoGitCommit      bool
-	NoSplit          bool
-	NoExtSecrets     bool
-	IncludeCRDs      bool
-	CheckExists      bool
-	Gitter           gitclient.Interface
-	CommandRunner    cmdrunner.CommandRunner
+	OutDir             string
+	HelmBinary         string
+	ReleaseName        string
+	Namespace          string
+	Chart              string
+	ValuesFiles        []string
+	SetValues          []string
+	ValuesAuthHeader   string
+	DefaultDomain      string
+	GitCommitMessage   string
+	Version            string
+	Repository         string
+	RegistryUsername   string
+	RegistryPassword   string
+	ChartsDir          string
+	ConfigRoot         string
+	OutputFormat       string
+	ComputedValuesDir  string
+	ConfigChecksum     bool
+	ShowComputedValues bool
+	Concurrency        int
+	BatchMode          bool
+	DoGitCommit        bool
+	NoSplit            bool
+	NoExtSecrets       bool
+	IncludeCRDs        bool
+	CheckExists        bool
+	Gitter             gitclient.Interface
+	CommandRunner      cmdrunner.CommandRunner
+	valuesFiles        []string
+	stagingDir         string
 }
 
 // NewCmdHelmTemplate creates a command object for the command
@@ -62,7 +107,7 @@ func NewCmdHelmTemplate() (*cobra.Command, *TemplateOptions) {
 		Use:     "template",
 		Short:   "Generate the kubernetes resources from a helm chart",
 		Long:    helmTemplateLong,
-		Example: fmt.Sprintf(helmTemplateExample, rootcmd.BinaryName),
+		Example: fmt.Sprintf(helmTemplateExample, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName),
 		Run: func(cmd *cobra.Command, args []string) {
 			err := o.Run()
 			helper.CheckErr(err)
@@ -71,11 +116,22 @@ func NewCmdHelmTemplate() (*cobra.Command, *TemplateOptions) {
 	cmd.Flags().StringVarP(&o.OutDir, "output-dir", "o", "", "the output directory to generate the templates to. Defaults to charts/$name/resources")
 	cmd.Flags().StringVarP(&o.ReleaseName, "name", "n", "", "the name of the helm release to template. Defaults to $APP_NAME if not specified")
 	cmd.Flags().StringVarP(&o.Namespace, "namespace", "", "", "specifies the namespace to use to generate the templates in")
-	cmd.Flags().StringVarP(&o.Chart, "chart", "c", "", "the chart name to template. Defaults to 'charts/$name'")
-	cmd.Flags().StringArrayVarP(&o.ValuesFiles, "values", "f", nil, "the helm values.yaml file used to template values in the generated template")
-	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "the version of the helm chart to use. If not specified then the latest one is used")
+	cmd.Flags().StringVarP(&o.Chart, "chart", "c", "", "the chart name to template. Can be an oci:// reference to a chart in an OCI registry. Defaults to 'charts/$name'")
+	cmd.Flags().StringArrayVarP(&o.ValuesFiles, "values", "f", nil, "the helm values.yaml file used to template values in the generated template. Can be a http or https URL")
+	cmd.Flags().StringArrayVarP(&o.SetValues, "set", "", nil, "the key=value pairs passed to helm template to override values. Can be specified multiple times and overrides the --values files in the same way as helm")
+	cmd.Flags().StringVarP(&o.ValuesAuthHeader, "values-auth-header", "", "", "the 'name: value' HTTP header used to authenticate when downloading values files from URLs")
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "the version of the helm chart to use. For OCI charts this is the tag of the chart. If not specified then the latest one is used")
 	cmd.Flags().StringVarP(&o.Repository, "repository", "r", "", "the helm chart repository to locate the chart")
+	cmd.Flags().StringVarP(&o.RegistryUsername, "registry-username", "", "", "the user name to login to the OCI registry of an oci:// chart. Defaults to $"+RegistryUsernameEnv)
+	cmd.Flags().StringVarP(&o.RegistryPassword, "registry-password", "", "", "the password to login to the OCI registry of an oci:// chart. Defaults to $"+RegistryPasswordEnv)
 	cmd.Flags().StringVarP(&o.GitCommitMessage, "commit-message", "", "chore: generated kubernetes resources from helm chart", "the git commit message used")
+	cmd.Flags().StringVarP(&o.ChartsDir, "charts-dir", "", "", "if specified every chart in this directory is templated using the chart directory name as the release name")
+	cmd.Flags().StringVarP(&o.ConfigRoot, "config-root", "", "", "if specified the resources are moved into the namespaces, cluster and customresourcedefinitions directories of this config root directory in the same way as 'helmfile move'")
+	cmd.Flags().StringVarP(&o.OutputFormat, "output-format", "", OutputFormatYAML, "the format of the generated resources: "+strings.Join(OutputFormats, ", ")+". Files with multiple resources are converted to a JSON array when using json")
+	cmd.Flags().BoolVarP(&o.ConfigChecksum, "config-checksum", "", false, "if enabled the pod template of each workload is annotated with "+ConfigChecksumAnnotation+" containing a checksum of the generated ConfigMaps and Secrets it references so that it is rolled out when they change")
+	cmd.Flags().BoolVarP(&o.ShowComputedValues, "show-computed-values", "", false, "if enabled the values of each release merged with the values files and the default values of its subcharts are written to the --computed-values-dir")
+	cmd.Flags().StringVarP(&o.ComputedValuesDir, "computed-values-dir", "", "computed-values", "the directory to write the computed values of each release to as $name.yaml when using --show-computed-values")
+	cmd.Flags().IntVarP(&o.Concurrency, "concurrency", "", 1, "the number of charts to template in parallel when using --charts-dir")
 
 	o.AddFlags(cmd)
 	return cmd, o
@@ -104,6 +160,44 @@ func (o *TemplateOptions) Run() error {
 		}
 	}
 
+	valuesCacheDir, err := ioutil.TempDir("", "jx-values-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary directory for values files")
+	}
+	defer os.RemoveAll(valuesCacheDir)
+
+	o.valuesFiles, err = o.resolveValuesFiles(valuesCacheDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download values files")
+	}
+
+	switch o.OutputFormat {
+	case "", OutputFormatYAML, OutputFormatJSON:
+	default:
+		return options.InvalidOption("output-format", o.OutputFormat, OutputFormats)
+	}
+
+	if o.ConfigRoot != "" {
+		if o.OutputFormat == OutputFormatJSON {
+			return errors.Errorf("cannot use --output-format json with --config-root")
+		}
+		if o.Namespace == "" {
+			return options.MissingOption("namespace")
+		}
+		if o.NoSplit {
+			return errors.Errorf("cannot use --no-split with --config-root")
+		}
+		o.stagingDir, err = ioutil.TempDir("", "jx-helm-template-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create temporary directory for the generated resources")
+		}
+		defer os.RemoveAll(o.stagingDir)
+	}
+
+	if o.ChartsDir != "" {
+		return o.templateChartsDir(bin)
+	}
+
 	name := o.ReleaseName
 	if name == "" {
 		name = os.Getenv("APP_NAME")
@@ -119,7 +213,11 @@ func (o *TemplateOptions) Run() error {
 		chart = filepath.Join("charts", name)
 	}
 
-	if o.Repository == "" {
+	ociChart := IsOCIChart(chart)
+	if ociChart && o.Repository != "" {
+		return errors.Errorf("cannot use --repository with the OCI chart %s", chart)
+	}
+	if o.Repository == "" && !ociChart {
 		exists, err := files.DirExists(chart)
 		if err != nil {
 			return errors.Wrapf(err, "failed to check if dir exists %s", chart)
@@ -135,8 +233,121 @@ func (o *TemplateOptions) Run() error {
 	outDir := o.OutDir
 	if outDir == "" {
 		outDir = filepath.Join(chart, "resources")
+		if ociChart {
+			outDir = filepath.Join("charts", name, "resources")
+		}
+	}
+	if o.stagingDir != "" {
+		chartName := filepath.Base(chart)
+		if ociChart {
+			ref, _, _ := SplitOCIChart(chart)
+			chartName = path.Base(ref)
+		}
+		outDir = filepath.Join(o.stagingDir, o.Namespace, name, chartName)
+	}
+	err = o.templateChart(bin, name, chart, outDir)
+	if err != nil {
+		return err
+	}
+	if o.stagingDir != "" {
+		err = o.moveToConfigRoot()
+		if err != nil {
+			return err
+		}
+		outDir = o.ConfigRoot
+	}
+	if !o.DoGitCommit {
+		return nil
+	}
+	log.Logger().Infof("performing git commit: %s", o.GitCommitMessage)
+	return o.GitCommit(outDir, o.GitCommitMessage)
+}
+
+// templateChartsDir templates every chart in the charts dir using a pool of workers.
+// The results are reported in the order of the chart names so that the output is deterministic
+func (o *TemplateOptions) templateChartsDir(bin string) error {
+	if o.Repository != "" {
+		return errors.Errorf("cannot use --repository with --charts-dir")
+	}
+	fileSlice, err := ioutil.ReadDir(o.ChartsDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read charts dir %s", o.ChartsDir)
+	}
+	var names []string
+	for _, f := range fileSlice {
+		if !f.IsDir() {
+			continue
+		}
+		exists, err := files.FileExists(filepath.Join(o.ChartsDir, f.Name(), "Chart.yaml"))
+		if err != nil {
+			return errors.Wrapf(err, "failed to check for Chart.yaml in %s", f.Name())
+		}
+		if exists {
+			names = append(names, f.Name())
+		}
+	}
+
+	concurrency := o.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
 	}
-	err = os.MkdirAll(outDir, files.DefaultDirWritePermissions)
+	results := make([]error, len(names))
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = o.templateChart(bin, names[i], filepath.Join(o.ChartsDir, names[i]), o.chartOutDir(names[i]))
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, name := range names {
+		if results[i] != nil {
+			return errors.Wrapf(results[i], "failed to template chart %s", name)
+		}
+		log.Logger().Infof("templated chart %s to %s", name, o.chartOutDir(name))
+	}
+	log.Logger().Infof("templated %d charts from the charts dir: %s", len(names), o.ChartsDir)
+
+	outDir := o.OutDir
+	if outDir == "" {
+		outDir = o.ChartsDir
+	}
+	if o.stagingDir != "" {
+		err = o.moveToConfigRoot()
+		if err != nil {
+			return err
+		}
+		outDir = o.ConfigRoot
+	}
+	if !o.DoGitCommit {
+		return nil
+	}
+	log.Logger().Infof("performing git commit: %s", o.GitCommitMessage)
+	return o.GitCommit(outDir, o.GitCommitMessage)
+}
+
+func (o *TemplateOptions) chartOutDir(name string) string {
+	if o.stagingDir != "" {
+		return filepath.Join(o.stagingDir, o.Namespace, name, name)
+	}
+	if o.OutDir != "" {
+		return filepath.Join(o.OutDir, name)
+	}
+	return filepath.Join(o.ChartsDir, name, "resources")
+}
+
+// templateChart templates the chart for the release name into the output directory
+func (o *TemplateOptions) templateChart(bin, name, chart, outDir string) error {
+	err := os.MkdirAll(outDir, files.DefaultDirWritePermissions)
 	if err != nil {
 		return errors.Wrapf(err, "failed to ensure output directory exists %s", outDir)
 	}
@@ -147,7 +358,21 @@ func (o *TemplateOptions) Run() error {
 	}
 
 	tmpChartDir := ""
-	if o.Repository != "" {
+	version := o.Version
+	if IsOCIChart(chart) {
+		tmpChartDir, err = ioutil.TempDir("", "jx-oci-chart-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create temporary chart directory")
+		}
+		defer os.RemoveAll(tmpChartDir)
+
+		chart, err = o.pullOCIChart(bin, chart, tmpChartDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to pull the OCI chart")
+		}
+		// the version has been resolved when pulling the chart
+		version = ""
+	} else if o.Repository != "" {
 		tmpChartDir, err = ioutil.TempDir("", "")
 		if err != nil {
 			return errors.Wrap(err, "failed to create temporary chart directory")
@@ -173,12 +398,26 @@ func (o *TemplateOptions) Run() error {
 		}
 	}
 
+	if o.ShowComputedValues {
+		chartDir := chart
+		if o.Repository != "" {
+			chartDir = filepath.Join(tmpChartDir, name)
+		}
+		err = o.writeComputedValues(name, chartDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to compute the values of release %s", name)
+		}
+	}
+
 	cmdDir := ""
 
 	args := []string{"template", "--output-dir", tmpDir}
-	for _, valuesFile := range o.ValuesFiles {
+	for _, valuesFile := range o.valuesFiles {
 		args = append(args, "--values", valuesFile)
 	}
+	for _, value := range o.SetValues {
+		args = append(args, "--set", value)
+	}
 
 	if o.Repository != "" {
 		args = append(args, "--repo", o.Repository)
@@ -187,8 +426,8 @@ func (o *TemplateOptions) Run() error {
 	if o.Namespace != "" {
 		args = append(args, "--namespace", o.Namespace)
 	}
-	if o.Version != "" {
-		args = append(args, "--version", o.Version)
+	if version != "" {
+		args = append(args, "--version", version)
 	}
 	if o.IncludeCRDs {
 		args = append(args, "--include-crds")
@@ -198,8 +437,11 @@ func (o *TemplateOptions) Run() error {
 		Name: bin,
 		Args: args,
 		Dir:  cmdDir,
-		Out:  os.Stdout,
-		Err:  os.Stderr,
+	}
+	if o.ChartsDir == "" {
+		// when templating charts in parallel lets avoid interleaving the output
+		c.Out = os.Stdout
+		c.Err = os.Stderr
 	}
 	results, err := o.CommandRunner(c)
 	if err != nil {
@@ -271,11 +513,33 @@ func (o *TemplateOptions) Run() error {
 			return errors.Wrapf(err, "failed to split YAML files at %s", outDir)
 		}
 	}
-	if !o.DoGitCommit {
-		return nil
+	if o.ConfigChecksum {
+		err = AddConfigChecksums(outDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add config checksums to the generated resources at %s", outDir)
+		}
 	}
-	log.Logger().Infof("performing git commit: %s", o.GitCommitMessage)
-	return o.GitCommit(outDir, o.GitCommitMessage)
+	if o.OutputFormat == OutputFormatJSON {
+		err = ConvertToJSON(outDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to convert the generated resources at %s to JSON", outDir)
+		}
+	}
+	return nil
+}
+
+// moveToConfigRoot moves the generated resources from the staging directory into the config root directory structure
+func (o *TemplateOptions) moveToConfigRoot() error {
+	mo := &move.Options{
+		Dir:                    o.stagingDir,
+		OutputDir:              o.ConfigRoot,
+		DirIncludesReleaseName: true,
+	}
+	err := mo.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to move the generated resources to %s", o.ConfigRoot)
+	}
+	return nil
 }
 
 func (o *TemplateOptions) GitCommit(outDir string, commitMessage string) error {
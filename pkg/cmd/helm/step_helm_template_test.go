@@ -1,16 +1,35 @@
 package helm_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/move"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner/fakerunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/getter"
+	appsv1 "k8s.io/api/apps/v1"
 )
 
 func TestStepHelmTemplate(t *testing.T) {
@@ -63,3 +82,717 @@ func HasHelmBinary(t *testing.T, helmBin string) bool {
 	t.Logf("found helm version %s", version)
 	return true
 }
+
+func TestStepHelmTemplateChartsDir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	delays := map[string]time.Duration{
+		"alpha": 300 * time.Millisecond,
+		"beta":  100 * time.Millisecond,
+		"gamma": 0,
+	}
+
+	lock := sync.Mutex{}
+	var templated []string
+	_, o := helm.NewCmdHelmTemplate()
+	o.HelmBinary = "helm"
+	o.ChartsDir = filepath.Join("test_data", "charts-dir")
+	o.OutDir = tmpDir
+	o.Concurrency = 3
+	o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+		// lets fake out helm template by generating a ConfigMap for the release
+		require.Equal(t, "template", c.Args[0], "command %s", c.CLI())
+		outDir := c.Args[2]
+		name := c.Args[len(c.Args)-2]
+		time.Sleep(delays[name])
+
+		lock.Lock()
+		templated = append(templated, name)
+		lock.Unlock()
+
+		dir := filepath.Join(outDir, name, "templates")
+		err := os.MkdirAll(dir, files.DefaultDirWritePermissions)
+		if err != nil {
+			return "", err
+		}
+		text := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n"
+		return "", ioutil.WriteFile(filepath.Join(dir, "configmap.yaml"), []byte(text), files.DefaultFileWritePermissions)
+	}
+
+	output := log.CaptureOutput(func() {
+		err = o.Run()
+	})
+	require.NoError(t, err, "failed to run the command")
+
+	assert.ElementsMatch(t, []string{"alpha", "beta", "gamma"}, templated, "templated charts")
+	for name := range delays {
+		path := filepath.Join(tmpDir, name, "configmap.yaml")
+		require.FileExists(t, path)
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err, "failed to read %s", path)
+		assert.Contains(t, string(data), "name: "+name, "generated resource for %s", name)
+	}
+	assert.NoDirExists(t, filepath.Join(tmpDir, "not-a-chart"))
+
+	// lets verify the results are reported in the order of the charts
+	alphaIdx := strings.Index(output, "templated chart alpha")
+	betaIdx := strings.Index(output, "templated chart beta")
+	gammaIdx := strings.Index(output, "templated chart gamma")
+	assert.True(t, alphaIdx >= 0 && alphaIdx < betaIdx && betaIdx < gammaIdx, "should report charts in order but got: %s", output)
+}
+
+func TestStepHelmTemplateValuesURL(t *testing.T) {
+	lock := sync.Mutex{}
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mytoken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		lock.Lock()
+		downloads++
+		lock.Unlock()
+		switch r.URL.Path {
+		case "/values.yaml":
+			fmt.Fprint(w, "replicaCount: 3\n")
+		case "/invalid.yaml":
+			fmt.Fprint(w, "<html>not yaml: [</html>")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name        string
+		path        string
+		authHeader  string
+		expectError bool
+	}{
+		{
+			name:       "valid",
+			path:       "/values.yaml",
+			authHeader: "Authorization: Bearer mytoken",
+		},
+		{
+			name:        "unauthorized",
+			path:        "/values.yaml",
+			expectError: true,
+		},
+		{
+			name:        "invalid-yaml",
+			path:        "/invalid.yaml",
+			authHeader:  "Authorization: Bearer mytoken",
+			expectError: true,
+		},
+		{
+			name:        "missing",
+			path:        "/missing.yaml",
+			authHeader:  "Authorization: Bearer mytoken",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "failed to create tmp dir")
+
+		downloads = 0
+		var valuesFiles []string
+		_, o := helm.NewCmdHelmTemplate()
+		o.HelmBinary = "helm"
+		o.ChartsDir = filepath.Join("test_data", "charts-dir")
+		o.OutDir = tmpDir
+		o.Concurrency = 2
+		o.ValuesFiles = []string{server.URL + tc.path}
+		o.ValuesAuthHeader = tc.authHeader
+		o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+			outDir := c.Args[2]
+			name := c.Args[len(c.Args)-2]
+
+			lock.Lock()
+			for i, arg := range c.Args {
+				if arg == "--values" {
+					data, err := ioutil.ReadFile(c.Args[i+1])
+					if err != nil {
+						lock.Unlock()
+						return "", err
+					}
+					valuesFiles = append(valuesFiles, string(data))
+				}
+			}
+			lock.Unlock()
+
+			dir := filepath.Join(outDir, name, "templates")
+			err := os.MkdirAll(dir, files.DefaultDirWritePermissions)
+			if err != nil {
+				return "", err
+			}
+			text := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n"
+			return "", ioutil.WriteFile(filepath.Join(dir, "configmap.yaml"), []byte(text), files.DefaultFileWritePermissions)
+		}
+
+		err = o.Run()
+		if tc.expectError {
+			require.Error(t, err, "should fail for %s", tc.name)
+			assert.Empty(t, valuesFiles, "should not have templated for %s", tc.name)
+			continue
+		}
+		require.NoError(t, err, "failed to run for %s", tc.name)
+
+		assert.Equal(t, 1, downloads, "should only download the values file once for %s", tc.name)
+		require.Len(t, valuesFiles, 3, "should pass the values file to every chart for %s", tc.name)
+		for _, v := range valuesFiles {
+			assert.Equal(t, "replicaCount: 3\n", v, "downloaded values for %s", tc.name)
+		}
+	}
+}
+
+func TestStepHelmTemplateConfigRoot(t *testing.T) {
+	// lets fake out helm template by generating namespaced, cluster and CRD resources for the release
+	fakeHelm := func(c *cmdrunner.Command) (string, error) {
+		require.Equal(t, "template", c.Args[0], "command %s", c.CLI())
+		outDir := c.Args[2]
+		name := c.Args[len(c.Args)-2]
+		generated := map[string]string{
+			filepath.Join("templates", "deployment.yaml"):  "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: " + name + "\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: " + name + "\n",
+			filepath.Join("templates", "clusterrole.yaml"): "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: " + name + "\n",
+			filepath.Join("crds", "widgets.yaml"):          "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: widgets.acme.com\n",
+		}
+		for path, text := range generated {
+			path = filepath.Join(outDir, name, path)
+			err := os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions)
+			if err != nil {
+				return "", err
+			}
+			err = ioutil.WriteFile(path, []byte(text), files.DefaultFileWritePermissions)
+			if err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+	}
+
+	name := "mychart"
+	ns := "jx"
+
+	// lets generate the expected output using helm template then helmfile move
+	templateDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+	expectedDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	_, o := helm.NewCmdHelmTemplate()
+	o.HelmBinary = "helm"
+	o.ReleaseName = name
+	o.Namespace = ns
+	o.Chart = filepath.Join("test_data", name)
+	o.OutDir = filepath.Join(templateDir, ns, name)
+	o.CommandRunner = fakeHelm
+	err = o.Run()
+	require.NoError(t, err, "failed to run helm template")
+
+	mo := &move.Options{
+		Dir:       templateDir,
+		OutputDir: expectedDir,
+	}
+	err = mo.Run()
+	require.NoError(t, err, "failed to run helmfile move")
+
+	// now lets generate the config root in one step
+	configRoot, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	_, o = helm.NewCmdHelmTemplate()
+	o.HelmBinary = "helm"
+	o.ReleaseName = name
+	o.Namespace = ns
+	o.Chart = filepath.Join("test_data", name)
+	o.ConfigRoot = configRoot
+	o.CommandRunner = fakeHelm
+	err = o.Run()
+	require.NoError(t, err, "failed to run helm template with --config-root")
+
+	expectedFiles := relativeFiles(t, expectedDir)
+	assert.Equal(t, expectedFiles, relativeFiles(t, configRoot), "generated files")
+	for _, f := range expectedFiles {
+		testhelpers.AssertTextFilesEqual(t, filepath.Join(expectedDir, f), filepath.Join(configRoot, f), f)
+	}
+	assert.Contains(t, expectedFiles, filepath.Join("namespaces", ns, name, "deployment.yaml"), "should have a namespaced Deployment")
+	assert.Contains(t, expectedFiles, filepath.Join("cluster", "resources", ns, name, "clusterrole.yaml"), "should have a cluster ClusterRole")
+	assert.Contains(t, expectedFiles, filepath.Join("customresourcedefinitions", ns, name, "widgets.yaml"), "should have a CRD")
+}
+
+func TestStepHelmTemplateOutputFormatJSON(t *testing.T) {
+	// lets fake out helm template by generating a file containing multiple resources
+	fakeHelm := func(c *cmdrunner.Command) (string, error) {
+		outDir := c.Args[2]
+		name := c.Args[len(c.Args)-2]
+		dir := filepath.Join(outDir, name, "templates")
+		err := os.MkdirAll(dir, files.DefaultDirWritePermissions)
+		if err != nil {
+			return "", err
+		}
+		text := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: " + name + "\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: " + name + "\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n"
+		return "", ioutil.WriteFile(filepath.Join(dir, "all.yaml"), []byte(text), files.DefaultFileWritePermissions)
+	}
+
+	name := "mychart"
+	testCases := []struct {
+		name          string
+		noSplit       bool
+		expectedKinds map[string][]string
+	}{
+		{
+			name: "split",
+			expectedKinds: map[string][]string{
+				"all.json":  {"Deployment"},
+				"all2.json": {"Service"},
+				"all3.json": {"ConfigMap"},
+			},
+		},
+		{
+			name:    "no-split",
+			noSplit: true,
+			expectedKinds: map[string][]string{
+				"all.json": {"Deployment", "Service", "ConfigMap"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "failed to create tmp dir")
+
+		_, o := helm.NewCmdHelmTemplate()
+		o.HelmBinary = "helm"
+		o.ReleaseName = name
+		o.Chart = filepath.Join("test_data", name)
+		o.OutDir = tmpDir
+		o.NoSplit = tc.noSplit
+		o.OutputFormat = helm.OutputFormatJSON
+		o.CommandRunner = fakeHelm
+		err = o.Run()
+		require.NoError(t, err, "failed to run helm template for %s", tc.name)
+
+		var expectedFiles []string
+		for f := range tc.expectedKinds {
+			expectedFiles = append(expectedFiles, f)
+		}
+		assert.ElementsMatch(t, expectedFiles, relativeFiles(t, tmpDir), "generated files for %s", tc.name)
+
+		for f, expectedKinds := range tc.expectedKinds {
+			data, err := ioutil.ReadFile(filepath.Join(tmpDir, f))
+			require.NoError(t, err, "failed to read %s for %s", f, tc.name)
+
+			var resources []map[string]interface{}
+			if len(expectedKinds) == 1 {
+				resource := map[string]interface{}{}
+				err = json.Unmarshal(data, &resource)
+				resources = append(resources, resource)
+			} else {
+				err = json.Unmarshal(data, &resources)
+			}
+			require.NoError(t, err, "invalid JSON in %s for %s", f, tc.name)
+
+			var kinds []string
+			for _, r := range resources {
+				kinds = append(kinds, fmt.Sprintf("%v", r["kind"]))
+			}
+			assert.Equal(t, expectedKinds, kinds, "kinds in %s for %s", f, tc.name)
+		}
+	}
+
+	_, o := helm.NewCmdHelmTemplate()
+	o.HelmBinary = "helm"
+	o.ReleaseName = name
+	o.Chart = filepath.Join("test_data", name)
+	o.OutputFormat = "xml"
+	o.CommandRunner = fakeHelm
+	err := o.Run()
+	require.Error(t, err, "should fail for an invalid output format")
+}
+
+func TestStepHelmTemplateConfigChecksum(t *testing.T) {
+	greeting := "hello"
+
+	// lets fake out helm template by generating workloads and the config they reference
+	fakeHelm := func(c *cmdrunner.Command) (string, error) {
+		outDir := c.Args[2]
+		name := c.Args[len(c.Args)-2]
+		dir := filepath.Join(outDir, name, "templates")
+		err := os.MkdirAll(dir, files.DefaultDirWritePermissions)
+		if err != nil {
+			return "", err
+		}
+		text := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:1.0.0
+        envFrom:
+        - configMapRef:
+            name: web-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: other
+spec:
+  template:
+    spec:
+      containers:
+      - name: other
+        image: other:1.0.0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  greeting: ` + greeting + `
+`
+		return "", ioutil.WriteFile(filepath.Join(dir, "all.yaml"), []byte(text), files.DefaultFileWritePermissions)
+	}
+
+	name := "mychart"
+	templateChecksums := func() map[string]string {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "failed to create tmp dir")
+
+		_, o := helm.NewCmdHelmTemplate()
+		o.HelmBinary = "helm"
+		o.ReleaseName = name
+		o.Chart = filepath.Join("test_data", name)
+		o.OutDir = tmpDir
+		o.ConfigChecksum = true
+		o.CommandRunner = fakeHelm
+		err = o.Run()
+		require.NoError(t, err, "failed to run helm template")
+
+		answer := map[string]string{}
+		for _, f := range relativeFiles(t, tmpDir) {
+			deploy := &appsv1.Deployment{}
+			err = yamls.LoadFile(filepath.Join(tmpDir, f), deploy)
+			require.NoError(t, err, "failed to load %s", f)
+			if deploy.Kind == "Deployment" {
+				answer[deploy.Name] = deploy.Spec.Template.Annotations[helm.ConfigChecksumAnnotation]
+			}
+		}
+		return answer
+	}
+
+	first := templateChecksums()
+	require.NotEmpty(t, first["web"], "should have annotated the Deployment referencing the ConfigMap")
+	assert.Empty(t, first["other"], "should not annotate the Deployment which does not reference any config")
+
+	assert.Equal(t, first, templateChecksums(), "the checksums should be stable when the config does not change")
+
+	greeting = "goodbye"
+	second := templateChecksums()
+	require.NotEmpty(t, second["web"], "should have annotated the Deployment referencing the ConfigMap")
+	assert.NotEqual(t, first["web"], second["web"], "the checksum should change when the ConfigMap changes")
+}
+
+// relativeFiles returns the sorted relative paths of all the files in the dir
+func relativeFiles(t *testing.T, dir string) []string {
+	var answer []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if info == nil || info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		answer = append(answer, rel)
+		return nil
+	})
+	require.NoError(t, err, "failed to walk dir %s", dir)
+	return answer
+}
+
+func TestSplitOCIChart(t *testing.T) {
+	testCases := []struct {
+		chart string
+		ref   string
+		host  string
+		tag   string
+	}{
+		{
+			chart: "oci://registry.example.com/charts/mychart",
+			ref:   "oci://registry.example.com/charts/mychart",
+			host:  "registry.example.com",
+		},
+		{
+			chart: "oci://registry.example.com:5000/charts/mychart:1.2.3",
+			ref:   "oci://registry.example.com:5000/charts/mychart",
+			host:  "registry.example.com:5000",
+			tag:   "1.2.3",
+		},
+		{
+			chart: "oci://registry.example.com:5000/mychart:1.2.3_build.4",
+			ref:   "oci://registry.example.com:5000/mychart",
+			host:  "registry.example.com:5000",
+			tag:   "1.2.3+build.4",
+		},
+	}
+	for _, tc := range testCases {
+		ref, host, tag := helm.SplitOCIChart(tc.chart)
+		assert.Equal(t, tc.ref, ref, "ref of %s", tc.chart)
+		assert.Equal(t, tc.host, host, "host of %s", tc.chart)
+		assert.Equal(t, tc.tag, tag, "tag of %s", tc.chart)
+	}
+}
+
+func TestStepHelmTemplateOCI(t *testing.T) {
+	name := "mychart"
+	ociChart := "oci://registry.example.com/charts/" + name
+
+	var commands []*cmdrunner.Command
+	fakeHelm := func(c *cmdrunner.Command) (string, error) {
+		commands = append(commands, c)
+		switch c.Args[0] {
+		case "registry":
+			return "", nil
+		case "pull":
+			// lets fake out helm pull by copying the local chart into the untar dir
+			dir := c.Args[len(c.Args)-1]
+			if c.Args[len(c.Args)-2] == "--version" {
+				dir = c.Args[len(c.Args)-3]
+			}
+			return "", files.CopyDirOverwrite(filepath.Join("test_data", name), filepath.Join(dir, name))
+		}
+		require.Equal(t, "template", c.Args[0], "command %s", c.CLI())
+		chart := c.Args[len(c.Args)-1]
+		require.FileExists(t, filepath.Join(chart, "Chart.yaml"), "should template a local chart")
+		outDir := c.Args[2]
+		dir := filepath.Join(outDir, c.Args[len(c.Args)-2], "templates")
+		err := os.MkdirAll(dir, files.DefaultDirWritePermissions)
+		if err != nil {
+			return "", err
+		}
+		text := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + filepath.Base(chart) + "\n"
+		return "", ioutil.WriteFile(filepath.Join(dir, "configmap.yaml"), []byte(text), files.DefaultFileWritePermissions)
+	}
+
+	expectedDir := t.TempDir()
+	_, o := helm.NewCmdHelmTemplate()
+	o.HelmBinary = "helm"
+	o.ReleaseName = name
+	o.Chart = filepath.Join("test_data", name)
+	o.OutDir = expectedDir
+	o.CommandRunner = fakeHelm
+	err := o.Run()
+	require.NoError(t, err, "failed to run helm template on the local chart")
+
+	commands = nil
+	outDir := t.TempDir()
+	_, o = helm.NewCmdHelmTemplate()
+	o.HelmBinary = "helm"
+	o.ReleaseName = name
+	o.Chart = ociChart
+	o.Version = "1.2.3_build.4"
+	o.RegistryUsername = "myuser"
+	o.RegistryPassword = "mypassword"
+	o.OutDir = outDir
+	o.CommandRunner = fakeHelm
+	err = o.Run()
+	require.NoError(t, err, "failed to run helm template on the OCI chart")
+
+	require.Len(t, commands, 3, "commands")
+	assert.Equal(t, "helm registry login registry.example.com --username myuser --password-stdin", commands[0].CLI(), "login command")
+	require.NotNil(t, commands[0].In, "should pass the password on stdin")
+	password, err := ioutil.ReadAll(commands[0].In)
+	require.NoError(t, err, "failed to read stdin")
+	assert.Equal(t, "mypassword", string(password), "password")
+	assert.Equal(t, []string{"pull", ociChart, "--untar", "--untardir"}, commands[1].Args[0:4], "pull command")
+	assert.Equal(t, []string{"--version", "1.2.3+build.4"}, commands[1].Args[5:], "pull version")
+	assert.NotContains(t, commands[2].Args, "--version", "template command")
+
+	expectedFiles := relativeFiles(t, expectedDir)
+	assert.Equal(t, expectedFiles, relativeFiles(t, outDir), "generated files")
+	for _, f := range expectedFiles {
+		testhelpers.AssertTextFilesEqual(t, filepath.Join(expectedDir, f), filepath.Join(outDir, f), f)
+	}
+
+	_, o = helm.NewCmdHelmTemplate()
+	o.HelmBinary = "helm"
+	o.ReleaseName = name
+	o.Chart = ociChart + ":1.0.0"
+	o.Version = "2.0.0"
+	o.OutDir = t.TempDir()
+	o.CommandRunner = fakeHelm
+	err = o.Run()
+	require.Error(t, err, "should fail if the --version does not match the tag")
+}
+
+func TestStepHelmTemplateShowComputedValues(t *testing.T) {
+	name := "parent"
+	outDir := t.TempDir()
+	valuesDir := t.TempDir()
+
+	_, o := helm.NewCmdHelmTemplate()
+	o.HelmBinary = "helm"
+	o.ReleaseName = name
+	o.Chart = filepath.Join("test_data", name)
+	o.ValuesFiles = []string{filepath.Join("test_data", "parent-values.yaml")}
+	o.OutDir = outDir
+	o.ShowComputedValues = true
+	o.ComputedValuesDir = valuesDir
+	o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+		// lets fake out helm template as only the computed values are verified
+		require.Equal(t, "template", c.Args[0], "command %s", c.CLI())
+		dir := filepath.Join(c.Args[2], name, "templates")
+		err := os.MkdirAll(dir, files.DefaultDirWritePermissions)
+		if err != nil {
+			return "", err
+		}
+		text := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n"
+		return "", ioutil.WriteFile(filepath.Join(dir, "configmap.yaml"), []byte(text), files.DefaultFileWritePermissions)
+	}
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	path := filepath.Join(valuesDir, name+".yaml")
+	require.FileExists(t, path)
+	computed := map[string]interface{}{}
+	err = yamls.LoadFile(path, &computed)
+	require.NoError(t, err, "failed to load %s", path)
+	t.Logf("computed values: %#v\n", computed)
+
+	assert.Equal(t, "2.0.0", lookupValue(computed, "image", "tag"), "values file should override the chart values")
+	assert.Equal(t, "example.com", lookupValue(computed, "global", "domain"), "chart global value")
+
+	assert.Equal(t, float64(2), lookupValue(computed, "child", "replicas"), "parent chart should override the subchart values")
+	assert.Equal(t, float64(9000), lookupValue(computed, "child", "port"), "values file should override the subchart values")
+	assert.Equal(t, "example.com", lookupValue(computed, "child", "global", "domain"), "subchart should inherit the global values")
+
+	assert.Equal(t, false, lookupValue(computed, "other", "enabled"), "parent chart should disable the subchart")
+}
+
+func lookupValue(m map[string]interface{}, path ...string) interface{} {
+	var answer interface{} = m
+	for _, p := range path {
+		child, ok := answer.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		answer = child[p]
+	}
+	return answer
+}
+
+func TestStepHelmTemplateSetValues(t *testing.T) {
+	name := "parent"
+	testCases := []struct {
+		setValues []string
+		expected  string
+	}{
+		{
+			expected: `tag: "2.0.0"`,
+		},
+		{
+			setValues: []string{"image.tag=3.0.0"},
+			expected:  `tag: "3.0.0"`,
+		},
+		{
+			setValues: []string{"image.tag=3.0.0", "image.tag=4.0.0"},
+			expected:  `tag: "4.0.0"`,
+		},
+	}
+	for _, tc := range testCases {
+		outDir := t.TempDir()
+
+		var commands []*cmdrunner.Command
+		_, o := helm.NewCmdHelmTemplate()
+		o.HelmBinary = "helm"
+		o.ReleaseName = name
+		o.Chart = filepath.Join("test_data", name)
+		o.ValuesFiles = []string{filepath.Join("test_data", "parent-values.yaml")}
+		o.SetValues = tc.setValues
+		o.OutDir = outDir
+		o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+			commands = append(commands, c)
+			return renderChart(c.Args)
+		}
+		err := o.Run()
+		require.NoError(t, err, "failed to run the command with --set %v", tc.setValues)
+
+		require.Len(t, commands, 1, "commands")
+		args := commands[0].CLI()
+		valuesIdx := strings.Index(args, "--values")
+		for _, value := range tc.setValues {
+			setIdx := strings.Index(args, "--set "+value)
+			assert.True(t, valuesIdx >= 0 && setIdx > valuesIdx, "should pass --set %s after the --values files: %s", value, args)
+		}
+
+		path := filepath.Join(outDir, "configmap.yaml")
+		require.FileExists(t, path)
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err, "failed to load %s", path)
+		assert.Contains(t, string(data), tc.expected, "rendered ConfigMap with --set %v", tc.setValues)
+	}
+}
+
+// renderChart fakes out 'helm template --output-dir dir [flags] name chart' by rendering the templates of the chart
+// using the helm library
+func renderChart(args []string) (string, error) {
+	if len(args) < 5 || args[0] != "template" || args[1] != "--output-dir" {
+		return "", fmt.Errorf("unexpected helm command %v", args)
+	}
+	outDir := args[2]
+	name, chartDir := args[len(args)-2], args[len(args)-1]
+	vo := &values.Options{}
+	for i := 3; i < len(args)-2; i++ {
+		switch args[i] {
+		case "--values":
+			i++
+			vo.ValueFiles = append(vo.ValueFiles, args[i])
+		case "--set":
+			i++
+			vo.Values = append(vo.Values, args[i])
+		}
+	}
+	ch, err := loader.Load(chartDir)
+	if err != nil {
+		return "", err
+	}
+	vals, err := vo.MergeValues(getter.Providers{})
+	if err != nil {
+		return "", err
+	}
+	err = chartutil.ProcessDependencies(ch, vals)
+	if err != nil {
+		return "", err
+	}
+	renderValues, err := chartutil.ToRenderValues(ch, vals, chartutil.ReleaseOptions{Name: name, Namespace: "default"}, chartutil.DefaultCapabilities)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := engine.Render(ch, renderValues)
+	if err != nil {
+		return "", err
+	}
+	for path, text := range rendered {
+		if strings.TrimSpace(text) == "" || !strings.HasSuffix(path, ".yaml") {
+			continue
+		}
+		path = filepath.Join(outDir, path)
+		err = os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions)
+		if err != nil {
+			return "", err
+		}
+		err = ioutil.WriteFile(path, []byte(text), files.DefaultFileWritePermissions)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,129 @@
+package helm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/kustomizes"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+var (
+	helmToKustomizeLong = templates.LongDesc(`
+		Generates a kustomize base from the resources rendered by a helm chart
+
+The chart is templated into the output directory with one resource per file and a kustomization.yaml file is generated which lists all of the resources.
+This gives teams moving from helm to kustomize a starting point which they can then customise with overlays.
+`)
+
+	helmToKustomizeExample = templates.Examples(`
+		# generates a kustomize base from a local chart
+		%s helm to-kustomize --chart charts/myapp --name myapp --output-dir kustomize/base
+
+		# generates a kustomize base from a chart in a repository
+		%s helm to-kustomize --repository https://charts.bitnami.com/bitnami --name nginx --version 8.5.2 --namespace web
+	`)
+)
+
+// ToKustomizeOptions the options for the command
+type ToKustomizeOptions struct {
+	TemplateOptions
+	Overwrite     bool
+	Kustomization *types.Kustomization
+}
+
+// NewCmdHelmToKustomize creates a command object for the command
+func NewCmdHelmToKustomize() (*cobra.Command, *ToKustomizeOptions) {
+	o := &ToKustomizeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "to-kustomize",
+		Short:   "Generates a kustomize base from the resources rendered by a helm chart",
+		Long:    helmToKustomizeLong,
+		Example: fmt.Sprintf(helmToKustomizeExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.OutDir, "output-dir", "o", filepath.Join("kustomize", "base"), "the directory to generate the kustomize base into")
+	cmd.Flags().StringVarP(&o.ReleaseName, "name", "n", "", "the name of the helm release to template. Defaults to $APP_NAME if not specified")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "", "", "the namespace to template the chart in. If specified it is also the namespace of the kustomization")
+	cmd.Flags().StringVarP(&o.Chart, "chart", "c", "", "the chart name to template. Defaults to 'charts/$name'")
+	cmd.Flags().StringArrayVarP(&o.ValuesFiles, "values", "f", nil, "the helm values.yaml file used to template the chart. Can be a http or https URL")
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "the version of the helm chart to use. If not specified then the latest one is used")
+	cmd.Flags().StringVarP(&o.Repository, "repository", "r", "", "the helm chart repository to locate the chart")
+	cmd.Flags().BoolVarP(&o.IncludeCRDs, "include-crds", "", true, "if CRDs should be included in the output")
+	cmd.Flags().BoolVarP(&o.Overwrite, "overwrite", "", false, "if enabled any existing files in the output directory are removed first")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *ToKustomizeOptions) Run() error {
+	if o.OutDir == "" {
+		o.OutDir = filepath.Join("kustomize", "base")
+	}
+	fileSlice, err := ioutil.ReadDir(o.OutDir)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to read dir %s", o.OutDir)
+	}
+	if len(fileSlice) > 0 {
+		if !o.Overwrite {
+			return errors.Errorf("the output directory %s is not empty. Use --overwrite to replace it", o.OutDir)
+		}
+		err = os.RemoveAll(o.OutDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove dir %s", o.OutDir)
+		}
+	}
+
+	o.ChartsDir = ""
+	o.ConfigRoot = ""
+	o.NoSplit = false
+	o.DoGitCommit = false
+	err = o.TemplateOptions.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to template the chart")
+	}
+
+	var resources []string
+	err = filepath.Walk(o.OutDir, func(path string, info os.FileInfo, err error) error {
+		if info == nil || info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+		rel, err := filepath.Rel(o.OutDir, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to calculate relative path of %s from %s", path, o.OutDir)
+		}
+		resources = append(resources, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find the generated resources in %s", o.OutDir)
+	}
+	sort.Strings(resources)
+
+	o.Kustomization = kustomizes.LazyCreate(o.Kustomization)
+	o.Kustomization.Namespace = o.Namespace
+	o.Kustomization.Resources = resources
+	err = kustomizes.SaveKustomization(o.Kustomization, o.OutDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save the kustomization in %s", o.OutDir)
+	}
+	log.Logger().Infof("generated a kustomize base with %d resources in %s", len(resources), termcolor.ColorInfo(o.OutDir))
+	return nil
+}
@@ -0,0 +1,76 @@
+package helm_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/kustomizes"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmToKustomize(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+	outDir := filepath.Join(tmpDir, "base")
+
+	name := "mychart"
+	newOptions := func() *helm.ToKustomizeOptions {
+		_, o := helm.NewCmdHelmToKustomize()
+		o.HelmBinary = "helm"
+		o.ReleaseName = name
+		o.Namespace = "jx"
+		o.Chart = filepath.Join("test_data", name)
+		o.OutDir = outDir
+		o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+			// lets fake out helm template by generating resources and a sub chart resource for the release
+			require.Equal(t, "template", c.Args[0], "command %s", c.CLI())
+			dir := filepath.Join(c.Args[2], name)
+			generated := map[string]string{
+				filepath.Join("templates", "resources.yaml"):              "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: " + name + "\n---\napiVersion: v1\nkind: Service\nmetadata:\n  name: " + name + "\n",
+				filepath.Join("charts", "redis", "templates", "svc.yaml"): "apiVersion: v1\nkind: Service\nmetadata:\n  name: redis\n",
+			}
+			for path, text := range generated {
+				path = filepath.Join(dir, path)
+				err := os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions)
+				if err != nil {
+					return "", err
+				}
+				err = ioutil.WriteFile(path, []byte(text), files.DefaultFileWritePermissions)
+				if err != nil {
+					return "", err
+				}
+			}
+			return "", nil
+		}
+		return o
+	}
+
+	o := newOptions()
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	kustomization, err := kustomizes.LoadKustomization(outDir)
+	require.NoError(t, err, "failed to load kustomization in %s", outDir)
+	assert.Equal(t, "Kustomization", kustomization.Kind, "kind")
+	assert.Equal(t, "jx", kustomization.Namespace, "namespace")
+	assert.Equal(t, []string{"redis/svc.yaml", "resources.yaml", "resources2.yaml"}, kustomization.Resources, "resources")
+	for _, r := range kustomization.Resources {
+		assert.FileExists(t, filepath.Join(outDir, filepath.FromSlash(r)), "resource %s", r)
+	}
+
+	// lets check we don't overwrite an existing base by default
+	o = newOptions()
+	err = o.Run()
+	require.Error(t, err, "should fail if the output dir is not empty")
+
+	o = newOptions()
+	o.Overwrite = true
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command with --overwrite")
+}
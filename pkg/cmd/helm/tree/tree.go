@@ -0,0 +1,301 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/workloads"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Renders a helm chart and displays the tree of resources grouped by kind
+
+The relationships between resources are inferred from the Service selectors and the Ingress backends.
+`)
+
+	cmdExample = templates.Examples(`
+		# displays the resources of a local chart
+		%s helm tree --chart charts/myapp
+
+		# displays the resources of a remote chart
+		%s helm tree --repository https://charts.jenkins.io --chart lighthouse --version 1.0.0
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Chart         string
+	ReleaseName   string
+	Namespace     string
+	Repository    string
+	Version       string
+	ValuesFiles   []string
+	HelmBinary    string
+	Tree          *Tree
+	CommandRunner cmdrunner.CommandRunner
+}
+
+// Tree the resources of a chart grouped by kind
+type Tree struct {
+	Kinds []*KindGroup
+}
+
+// KindGroup the resources of a kind
+type KindGroup struct {
+	Kind      string
+	Resources []*Resource
+}
+
+// Resource a resource along with the resources it refers to
+type Resource struct {
+	Kind   string
+	Name   string
+	Refers []string
+}
+
+// NewCmdHelmTree creates a command object for the command
+func NewCmdHelmTree() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "tree",
+		Short:   "Renders a helm chart and displays the tree of resources grouped by kind",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Chart, "chart", "c", "", "the chart to render")
+	cmd.Flags().StringVarP(&o.ReleaseName, "name", "n", "", "the name of the helm release. Defaults to the chart name")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "", "", "the namespace used to render the chart")
+	cmd.Flags().StringVarP(&o.Repository, "repository", "r", "", "the helm chart repository to locate the chart")
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "the version of the helm chart to use. If not specified then the latest one is used")
+	cmd.Flags().StringArrayVarP(&o.ValuesFiles, "values", "f", nil, "the helm values.yaml files used to render the chart")
+	cmd.Flags().StringVarP(&o.HelmBinary, "helm-binary", "", "", "specifies the helm binary location to use. If not specified defaults to using the downloaded helm plugin")
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.Chart == "" {
+		return options.MissingOption("chart")
+	}
+	if o.ReleaseName == "" {
+		paths := strings.Split(strings.TrimSuffix(o.Chart, "/"), "/")
+		o.ReleaseName = paths[len(paths)-1]
+	}
+	var err error
+	if o.HelmBinary == "" {
+		o.HelmBinary, err = plugins.GetHelmBinary(plugins.HelmVersion)
+		if err != nil {
+			return errors.Wrapf(err, "failed to download helm plugin")
+		}
+	}
+	if o.CommandRunner == nil {
+		o.CommandRunner = cmdrunner.QuietCommandRunner
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+
+	args := []string{"template", o.ReleaseName, o.Chart}
+	if o.Namespace != "" {
+		args = append(args, "--namespace", o.Namespace)
+	}
+	if o.Repository != "" {
+		args = append(args, "--repo", o.Repository)
+	}
+	if o.Version != "" {
+		args = append(args, "--version", o.Version)
+	}
+	for _, f := range o.ValuesFiles {
+		args = append(args, "--values", f)
+	}
+	c := &cmdrunner.Command{
+		Name: o.HelmBinary,
+		Args: args,
+	}
+	text, err := o.CommandRunner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to run %s", c.CLI())
+	}
+
+	o.Tree, err = NewTree([]byte(text))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create tree of resources")
+	}
+	log.Logger().Info(o.Tree.String())
+	return nil
+}
+
+// NewTree creates the tree of the resources in the given YAML documents
+func NewTree(data []byte) (*Tree, error) {
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewReader(data), OmitReaderAnnotations: true}).Read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse YAML")
+	}
+
+	tree := &Tree{}
+	kinds := map[string]*KindGroup{}
+	var services []*yaml.RNode
+	podLabels := map[*Resource]map[string]string{}
+	resources := map[*yaml.RNode]*Resource{}
+	for _, node := range nodes {
+		kind := kyamls.GetKind(node, "")
+		name := kyamls.GetName(node, "")
+		if kind == "" || name == "" {
+			continue
+		}
+		r := &Resource{Kind: kind, Name: name}
+		resources[node] = r
+		group := kinds[kind]
+		if group == nil {
+			group = &KindGroup{Kind: kind}
+			kinds[kind] = group
+			tree.Kinds = append(tree.Kinds, group)
+		}
+		group.Resources = append(group.Resources, r)
+
+		labels, err := workloads.GetPodLabels(node, kind)
+		if err != nil {
+			return nil, err
+		}
+		if labels != nil {
+			podLabels[r] = labels
+		}
+		if kind == "Service" {
+			services = append(services, node)
+		}
+	}
+
+	// services refer to the workloads whose pods match their selector
+	for _, node := range services {
+		r := resources[node]
+		selector := map[string]string{}
+		selectorNode, err := node.Pipe(yaml.Lookup("spec", "selector"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find selector of Service %s", r.Name)
+		}
+		if selectorNode == nil {
+			continue
+		}
+		err = selectorNode.VisitFields(func(n *yaml.MapNode) error {
+			selector[n.Key.YNode().Value] = n.Value.YNode().Value
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read selector of Service %s", r.Name)
+		}
+		if len(selector) == 0 {
+			continue
+		}
+		for w, labels := range podLabels {
+			if matchesSelector(selector, labels) {
+				r.Refers = append(r.Refers, w.Kind+"/"+w.Name)
+			}
+		}
+	}
+
+	// ingresses refer to the services of their backends
+	for _, node := range nodes {
+		r := resources[node]
+		if r == nil || r.Kind != "Ingress" {
+			continue
+		}
+		forEachScalar(node.YNode(), nil, func(path []string, value string) {
+			if isIngressServiceName(path) {
+				ref := "Service/" + value
+				if stringhelpers.StringArrayIndex(r.Refers, ref) < 0 {
+					r.Refers = append(r.Refers, ref)
+				}
+			}
+		})
+	}
+
+	sort.Slice(tree.Kinds, func(i, j int) bool {
+		return tree.Kinds[i].Kind < tree.Kinds[j].Kind
+	})
+	for _, g := range tree.Kinds {
+		sort.Slice(g.Resources, func(i, j int) bool {
+			return g.Resources[i].Name < g.Resources[j].Name
+		})
+		for _, r := range g.Resources {
+			sort.Strings(r.Refers)
+		}
+	}
+	return tree, nil
+}
+
+// String returns the tree as text
+func (t *Tree) String() string {
+	buf := strings.Builder{}
+	for _, g := range t.Kinds {
+		buf.WriteString(g.Kind + "\n")
+		for _, r := range g.Resources {
+			buf.WriteString("  " + r.Name + "\n")
+			for _, ref := range r.Refers {
+				buf.WriteString("    -> " + ref + "\n")
+			}
+		}
+	}
+	return buf.String()
+}
+
+// forEachScalar invokes the function with the path of map keys and the value of each scalar in the node
+func forEachScalar(node *yaml.Node, path []string, fn func(path []string, value string)) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			forEachScalar(node.Content[i+1], append(path, node.Content[i].Value), fn)
+		}
+	case yaml.ScalarNode:
+		fn(path, node.Value)
+	default:
+		for _, child := range node.Content {
+			forEachScalar(child, path, fn)
+		}
+	}
+}
+
+func matchesSelector(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// isIngressServiceName returns true if the path is the service name of an Ingress backend for
+// networking.k8s.io/v1 (backend.service.name) or v1beta1 (backend.serviceName)
+func isIngressServiceName(path []string) bool {
+	l := len(path)
+	if l >= 2 && path[l-1] == "serviceName" && path[l-2] == "backend" {
+		return true
+	}
+	return l >= 3 && path[l-1] == "name" && path[l-2] == "service" && path[l-3] == "backend"
+}
@@ -0,0 +1,64 @@
+package tree_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/tree"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmTree(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("test_data", "rendered.yaml"))
+	require.NoError(t, err, "failed to load rendered chart")
+
+	var commands []*cmdrunner.Command
+	_, o := tree.NewCmdHelmTree()
+	o.Chart = "charts/myapp"
+	o.HelmBinary = "helm"
+	o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+		commands = append(commands, c)
+		return string(data), nil
+	}
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	require.Len(t, commands, 1, "commands")
+	assert.Equal(t, "helm template myapp charts/myapp", commands[0].CLI(), "helm command")
+
+	require.NotNil(t, o.Tree, "should have created a tree")
+	var kinds []string
+	for _, g := range o.Tree.Kinds {
+		kinds = append(kinds, g.Kind)
+	}
+	assert.Equal(t, []string{"Deployment", "Ingress", "Service"}, kinds, "kinds")
+
+	deployments := o.Tree.Kinds[0].Resources
+	require.Len(t, deployments, 2, "deployments")
+	assert.Equal(t, "myapp", deployments[0].Name)
+	assert.Equal(t, "myapp-worker", deployments[1].Name)
+
+	ingresses := o.Tree.Kinds[1].Resources
+	require.Len(t, ingresses, 1, "ingresses")
+	assert.Equal(t, []string{"Service/myapp"}, ingresses[0].Refers, "ingress refers to the service")
+
+	services := o.Tree.Kinds[2].Resources
+	require.Len(t, services, 1, "services")
+	assert.Equal(t, []string{"Deployment/myapp"}, services[0].Refers, "service refers to the matching deployment only")
+
+	expected := `Deployment
+  myapp
+  myapp-worker
+Ingress
+  myapp
+    -> Service/myapp
+Service
+  myapp
+    -> Deployment/myapp
+`
+	assert.Equal(t, expected, o.Tree.String(), "tree text")
+}
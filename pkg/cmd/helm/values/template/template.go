@@ -0,0 +1,142 @@
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/jxtmpl/templater"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// TemplateSuffix the file suffix of values files which are go templates
+	TemplateSuffix = ".gotmpl"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Renders go template values files using environment variables and context values so they can be passed into helm template
+
+The template can refer to environment variables via {{ .Env.NAME }} and any context values via {{ .Values.name }}.
+The output file is the template file name without the .gotmpl suffix.
+`)
+
+	cmdExample = templates.Examples(`
+		# renders all the *.gotmpl files in the current directory
+		%s helm values template
+
+		# renders a specific values file using some context values
+		%s helm values template --values context.yaml --set domain=acme.com values.yaml.gotmpl
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir         string
+	OutDir      string
+	Files       []string
+	ValuesFiles []string
+	Sets        []string
+	Env         map[string]string
+}
+
+// NewCmdValuesTemplate creates a command object for the command
+func NewCmdValuesTemplate() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "template",
+		Short:   "Renders go template values files using environment variables and context values so they can be passed into helm template",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Files = append(o.Files, args...)
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to look for *.gotmpl files if no files are specified as arguments")
+	cmd.Flags().StringVarP(&o.OutDir, "output-dir", "o", "", "the directory to write the rendered values files. Defaults to the directory of each template")
+	cmd.Flags().StringArrayVarP(&o.ValuesFiles, "values", "f", nil, "the YAML files used to populate the context {{ .Values }} of the templates")
+	cmd.Flags().StringArrayVarP(&o.Sets, "set", "", nil, "set context values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	return cmd, o
+}
+
+// Validate verifies the options are valid
+func (o *Options) Validate() error {
+	if o.Env == nil {
+		o.Env = map[string]string{}
+		for _, e := range os.Environ() {
+			paths := strings.SplitN(e, "=", 2)
+			if len(paths) == 2 {
+				o.Env[paths[0]] = paths[1]
+			}
+		}
+	}
+	if len(o.Files) == 0 {
+		fileNames, err := filepath.Glob(filepath.Join(o.Dir, "*"+TemplateSuffix))
+		if err != nil {
+			return errors.Wrapf(err, "failed to find *%s files in dir %s", TemplateSuffix, o.Dir)
+		}
+		o.Files = fileNames
+	}
+	if o.OutDir != "" {
+		err := os.MkdirAll(o.OutDir, files.DefaultDirWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create output dir %s", o.OutDir)
+		}
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	if len(o.Files) == 0 {
+		log.Logger().Infof("no *%s files found in dir %s", TemplateSuffix, o.Dir)
+		return nil
+	}
+
+	t := &templater.Templater{
+		ValuesFiles: o.ValuesFiles,
+		Sets:        o.Sets,
+		Env:         o.Env,
+	}
+	for _, path := range o.Files {
+		outFile := o.outputFileName(path)
+		data, err := t.Render(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to render %s", path)
+		}
+		err = ioutil.WriteFile(outFile, data, files.DefaultFileWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", outFile)
+		}
+		log.Logger().Infof("rendered %s to %s", info(path), info(outFile))
+	}
+	return nil
+}
+
+func (o *Options) outputFileName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), TemplateSuffix)
+	dir := o.OutDir
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+	return filepath.Join(dir, name)
+}
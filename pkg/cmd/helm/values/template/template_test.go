@@ -0,0 +1,44 @@
+package template_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/values/template"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValuesTemplate(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "could not create temp dir")
+
+	_, o := template.NewCmdValuesTemplate()
+	o.Dir = "test_data"
+	o.OutDir = tmpDir
+	o.ValuesFiles = []string{filepath.Join("test_data", "context.yaml")}
+	o.Sets = []string{"domain=acme.com", "replicas=3"}
+	o.Env = map[string]string{
+		"VERSION": "1.2.3",
+	}
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run")
+
+	testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", "expected.yaml"), filepath.Join(tmpDir, "values.yaml"), "rendered values file")
+}
+
+func TestValuesTemplateMissingEnv(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "could not create temp dir")
+
+	_, o := template.NewCmdValuesTemplate()
+	o.Files = []string{filepath.Join("test_data", "values.yaml.gotmpl")}
+	o.OutDir = tmpDir
+	o.ValuesFiles = []string{filepath.Join("test_data", "context.yaml")}
+	o.Env = map[string]string{}
+
+	err = o.Run()
+	require.Error(t, err, "should fail when an environment variable is missing")
+}
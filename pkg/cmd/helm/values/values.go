@@ -0,0 +1,24 @@
+package values
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/values/template"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdValues creates the new command
+func NewCmdValues() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "values",
+		Short: "Commands for working with helm values files",
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(template.NewCmdValuesTemplate()))
+	return command
+}
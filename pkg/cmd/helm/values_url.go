@@ -0,0 +1,92 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/httphelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// IsValuesURL returns true if the values file is a http or https URL
+func IsValuesURL(valuesFile string) bool {
+	return strings.HasPrefix(valuesFile, "http://") || strings.HasPrefix(valuesFile, "https://")
+}
+
+// resolveValuesFiles downloads any values files which are URLs returning the local file names to pass to helm.
+// Each URL is only downloaded once and the files are cached in the given directory
+func (o *TemplateOptions) resolveValuesFiles(cacheDir string) ([]string, error) {
+	var answer []string
+	for _, valuesFile := range o.ValuesFiles {
+		if !IsValuesURL(valuesFile) {
+			answer = append(answer, valuesFile)
+			continue
+		}
+		path := filepath.Join(cacheDir, fmt.Sprintf("%x.yaml", sha256.Sum256([]byte(valuesFile))))
+		exists, err := files.FileExists(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+		}
+		if !exists {
+			err = o.downloadValuesFile(valuesFile, path)
+			if err != nil {
+				return nil, err
+			}
+		}
+		answer = append(answer, path)
+	}
+	return answer, nil
+}
+
+func (o *TemplateOptions) downloadValuesFile(u, path string) error {
+	client := httphelpers.GetClient()
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create http request for %s", u)
+	}
+	if o.ValuesAuthHeader != "" {
+		paths := strings.SplitN(o.ValuesAuthHeader, ":", 2)
+		if len(paths) != 2 {
+			return errors.Errorf("invalid values auth header should be of the form 'name: value'")
+		}
+		req.Header.Set(strings.TrimSpace(paths[0]), strings.TrimSpace(paths[1]))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to GET values file %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("failed to GET values file %s with status %s", u, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read response from %s", u)
+	}
+
+	values := map[string]interface{}{}
+	err = yaml.Unmarshal(body, &values)
+	if err != nil {
+		return errors.Wrapf(err, "values file %s is not valid YAML", u)
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", filepath.Dir(path))
+	}
+	err = ioutil.WriteFile(path, body, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", path)
+	}
+	log.Logger().Debugf("downloaded values file %s to %s", u, path)
+	return nil
+}
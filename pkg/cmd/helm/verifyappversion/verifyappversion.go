@@ -0,0 +1,217 @@
+package verifyappversion
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/images"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Verifies that the image tags of the workloads rendered by each helm chart match the appVersion of the chart
+
+A leading 'v' is ignored when comparing the tag with the appVersion. Images which are deployed at a different version such as sidecars can be excluded with --ignore
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the charts in the charts dir
+		%s helm verify-appversion
+
+		# ignores the images of some sidecars
+		%s helm verify-appversion --ignore gcr.io/cloudsql-docker/* --ignore busybox
+	`)
+
+	// WorkloadKinds the kinds of resource whose images are verified
+	WorkloadKinds = []string{"CronJob", "DaemonSet", "Deployment", "Job", "Pod", "ReplicaSet", "StatefulSet"}
+)
+
+// Options the options for the command
+type Options struct {
+	UseHelmPlugin bool
+	HelmBinary    string
+	ChartsDir     string
+	Ignore        []string
+	Failures      []verifiers.Failure
+	CommandRunner cmdrunner.CommandRunner
+}
+
+// NewCmdHelmVerifyAppVersion creates a command object for the command
+func NewCmdHelmVerifyAppVersion() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "verify-appversion",
+		Short:   "Verifies that the image tags of the workloads rendered by each helm chart match the appVersion of the chart",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.ChartsDir, "charts-dir", "c", "charts", "the directory to look for helm charts to verify")
+	cmd.Flags().StringArrayVarP(&o.Ignore, "ignore", "i", nil, "the image names which are not verified. Supports a trailing '*' wildcard")
+	cmd.Flags().StringVarP(&o.HelmBinary, "binary", "n", "", "specifies the helm binary location to use. If not specified defaults to 'helm' on the $PATH")
+	cmd.Flags().BoolVarP(&o.UseHelmPlugin, "use-helm-plugin", "", false, "uses the jx binary plugin for helm rather than whatever helm is on the $PATH")
+	return cmd, o
+}
+
+// Validate verifies the options
+func (o *Options) Validate() error {
+	if o.CommandRunner == nil {
+		o.CommandRunner = cmdrunner.DefaultCommandRunner
+	}
+	var err error
+	if o.HelmBinary == "" {
+		if o.UseHelmPlugin {
+			o.HelmBinary, err = plugins.GetHelmBinary(plugins.HelmVersion)
+			if err != nil {
+				return err
+			}
+		}
+		if o.HelmBinary == "" {
+			o.HelmBinary = "helm"
+		}
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	o.Failures = nil
+	dir := o.ChartsDir
+	exists, err := files.DirExists(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if charts dir exists %s", dir)
+	}
+	if !exists {
+		log.Logger().Infof("no charts dir: %s", dir)
+		return nil
+	}
+
+	fileSlice, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read dir %s", dir)
+	}
+	for _, f := range fileSlice {
+		if !f.IsDir() {
+			continue
+		}
+		name := f.Name()
+		chartDir := filepath.Join(dir, name)
+		chartFile := filepath.Join(chartDir, "Chart.yaml")
+		exists, err := files.FileExists(chartFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check file exists %s", chartFile)
+		}
+		if !exists {
+			continue
+		}
+		err = o.verifyChart(name, chartDir, chartFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to verify chart %s", name)
+		}
+	}
+	return verifiers.Report(o.Failures, "images which do not match the chart appVersion")
+}
+
+func (o *Options) verifyChart(name, chartDir, chartFile string) error {
+	chart, err := chartutil.LoadChartfile(chartFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load %s", chartFile)
+	}
+	if chart.AppVersion == "" {
+		o.Failures = append(o.Failures, verifiers.Failure{
+			Path:    chartFile,
+			Kind:    "Chart",
+			Name:    name,
+			Message: "has no appVersion",
+		})
+		return nil
+	}
+
+	log.Logger().Infof("verifying chart %s has appVersion %s", info(name), info(chart.AppVersion))
+
+	c := &cmdrunner.Command{
+		Dir:  chartDir,
+		Name: o.HelmBinary,
+		Args: []string{"template", name, "."},
+	}
+	text, err := o.CommandRunner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to template chart")
+	}
+	reader := &kio.ByteReader{Reader: strings.NewReader(text), OmitReaderAnnotations: true}
+	nodes, err := reader.Read()
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse the output of helm template")
+	}
+	appVersion := strings.TrimPrefix(chart.AppVersion, "v")
+	for _, node := range nodes {
+		if stringhelpers.StringArrayIndex(WorkloadKinds, kyamls.GetKind(node, chartDir)) < 0 {
+			continue
+		}
+		path := sourcePath(node, chartDir)
+		images.ForEachImage(node.YNode(), func(container, image string) {
+			if image == "" || o.isIgnored(image) {
+				return
+			}
+			_, tag := images.SplitImageTag(strings.Split(image, "@")[0])
+			if strings.TrimPrefix(tag, "v") == appVersion {
+				return
+			}
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "container %s image %s does not match the chart appVersion %s", container, image, chart.AppVersion))
+		})
+	}
+	return nil
+}
+
+func (o *Options) isIgnored(image string) bool {
+	name, _ := images.SplitImageTag(strings.Split(image, "@")[0])
+	for _, pattern := range o.Ignore {
+		if stringhelpers.StringMatchesPattern(image, pattern) || stringhelpers.StringMatchesPattern(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourcePath returns the template file the resource was rendered from using the comment added by helm template
+func sourcePath(node *yaml.RNode, chartDir string) string {
+	// the comment is attached to the first field of the resource
+	content := node.YNode().Content
+	if len(content) == 0 {
+		return chartDir
+	}
+	for _, line := range strings.Split(content[0].HeadComment, "\n") {
+		if strings.HasPrefix(line, "# Source: ") {
+			return filepath.Join(filepath.Dir(chartDir), strings.TrimSpace(strings.TrimPrefix(line, "# Source: ")))
+		}
+	}
+	return chartDir
+}
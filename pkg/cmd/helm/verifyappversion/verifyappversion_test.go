@@ -0,0 +1,55 @@
+package verifyappversion_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helm/verifyappversion"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner/fakerunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmVerifyAppVersion(t *testing.T) {
+	chartsDir := filepath.Join("test_data", "charts")
+
+	runner := &fakerunner.FakeRunner{
+		CommandRunner: func(c *cmdrunner.Command) (string, error) {
+			data, err := ioutil.ReadFile(filepath.Join(c.Dir, "rendered.yaml"))
+			return string(data), err
+		},
+	}
+
+	_, o := verifyappversion.NewCmdHelmVerifyAppVersion()
+	o.HelmBinary = "helm"
+	o.CommandRunner = runner.Run
+	o.ChartsDir = chartsDir
+	o.Ignore = []string{"busybox", "gcr.io/cloudsql-docker/*"}
+
+	err := o.Run()
+	require.Error(t, err, "should fail for the mismatching chart")
+
+	runner.ExpectResults(t,
+		fakerunner.FakeResult{CLI: "helm template matching .", Dir: filepath.Join(chartsDir, "matching")},
+		fakerunner.FakeResult{CLI: "helm template mismatch .", Dir: filepath.Join(chartsDir, "mismatch")},
+	)
+
+	require.Len(t, o.Failures, 1, "failures")
+	f := o.Failures[0]
+	assert.Equal(t, "Deployment", f.Kind)
+	assert.Equal(t, "mismatch", f.Name)
+	assert.Equal(t, filepath.Join(chartsDir, "mismatch", "templates", "deployment.yaml"), f.Path)
+	assert.Equal(t, "container app image gcr.io/myorg/mismatch:1.9.0 does not match the chart appVersion 2.0.0", f.Message)
+
+	o.ChartsDir = filepath.Join(chartsDir, "..", "does-not-exist")
+	err = o.Run()
+	require.NoError(t, err, "should ignore a missing charts dir")
+
+	o.ChartsDir = chartsDir
+	o.Ignore = nil
+	err = o.Run()
+	require.Error(t, err)
+	assert.Len(t, o.Failures, 3, "sidecar images should fail without --ignore")
+}
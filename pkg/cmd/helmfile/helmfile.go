@@ -3,11 +3,17 @@ package helmfile
 import (
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/add"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/move"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/prunerepos"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/report"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/resolve"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/setversion"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/status"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/structure"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/template"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/validate"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/verifyenv"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/verifynames"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/verifynamespaces"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/spf13/cobra"
@@ -28,10 +34,16 @@ func NewCmdHelmfile() *cobra.Command {
 	}
 	command.AddCommand(cobras.SplitCommand(add.NewCmdHelmfileAdd()))
 	command.AddCommand(cobras.SplitCommand(move.NewCmdHelmfileMove()))
+	command.AddCommand(cobras.SplitCommand(prunerepos.NewCmdHelmfilePruneRepos()))
 	command.AddCommand(cobras.SplitCommand(report.NewCmdHelmfileReport()))
 	command.AddCommand(cobras.SplitCommand(resolve.NewCmdHelmfileResolve()))
+	command.AddCommand(cobras.SplitCommand(setversion.NewCmdHelmfileSetVersion()))
 	command.AddCommand(cobras.SplitCommand(status.NewCmdHelmfileStatus()))
 	command.AddCommand(cobras.SplitCommand(structure.NewCmdHelmfileStructure()))
+	command.AddCommand(cobras.SplitCommand(template.NewCmdHelmfileTemplate()))
 	command.AddCommand(cobras.SplitCommand(validate.NewCmdHelmfileValidate()))
+	command.AddCommand(cobras.SplitCommand(verifyenv.NewCmdHelmfileVerifyEnv()))
+	command.AddCommand(cobras.SplitCommand(verifynames.NewCmdHelmfileVerifyNames()))
+	command.AddCommand(cobras.SplitCommand(verifynamespaces.NewCmdHelmfileVerifyNamespaces()))
 	return command
 }
@@ -6,13 +6,16 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
 
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/split"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/helmhelpers"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/yamls"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
@@ -26,6 +29,21 @@ import (
 
 const (
 	pathSeparator = string(os.PathSeparator)
+
+	// GroupByNamespace moves resources into the cluster and namespaces directories
+	GroupByNamespace = "namespace"
+
+	// GroupByAPIGroup moves resources into a directory per API group
+	GroupByAPIGroup = "apigroup"
+
+	// CoreAPIGroupDir the directory used for resources in the core API group which has no name
+	CoreAPIGroupDir = "core"
+
+	// OnCollisionError fails if two releases are moved into the same directory
+	OnCollisionError = "error"
+
+	// OnCollisionRename suffixes the directory of a colliding release with the index of the release
+	OnCollisionRename = "rename"
 )
 
 var (
@@ -36,27 +54,73 @@ The output of 'helmfile template' ignores the namespace specified in the 'helmfi
 
 So this command applies the namespace to all the generated resources and then moves the namespaced resources into the config-root/namespaces/$ns/$releaseName directory
 and then moves any CRDs or cluster level resources into 'config-root/cluster/$releaseName'
+
+Files containing multiple resources are split into a file per resource in the order the resources appear using the same file names as 'jx gitops split' so the output is stable
+
+If '--group-by apigroup' is specified then the resources are moved into a directory per API group instead such as 'config-root/apps/$ns/$releaseName' with resources in the core API group moved into 'config-root/core/$ns/$releaseName'
+
+If '--path-template' is specified then it is used instead of '--group-by' as the Go template of the path of each resource relative to the output dir. The template can use the variables .Namespace, .ReleaseName, .ChartName, .PathName, .APIVersion, .APIGroup, .Kind, .Name, .File, .ClusterScoped and .CustomResourceDefinition along with the sprig functions. The default is:
+
+    ` + DefaultPathTemplate + `
+
+If '--dir-includes-release-name' is specified then two releases in a namespace may resolve to the same directory such as the release 'lighthouse-jx' and the release 'jx' of the 'lighthouse' chart. By default this is an error but if '--on-collision rename' is specified then the directory of the later release is suffixed with the index of the release instead
+
+If '--include-kind' or '--exclude-kind' are specified then each resource is filtered by its kind before it is written. The include kinds are applied first and then any of the remaining resources matching the exclude kinds are skipped
+
+If '--annotate-chart' is specified then each resource is annotated with the chart and chart version it was generated from
+
+If '--move-log' is specified then a JSON file is written recording the source file, destination file and identity of each moved resource
 `)
 
 	namespaceExample = templates.Examples(`
 		# moves the generated files in 'tmp' to the config root dir
 		%s helmfile move --dir config-root --from tmp
+
+		# annotates the moved resources with the chart and version of their release in the helmfile
+		%s helmfile move --dir config-root --from tmp --annotate-chart --helmfile helmfile.yaml
+
+		# moves the generated files in 'tmp' into a directory per API group such as 'apps' or 'networking.k8s.io'
+		%s helmfile move --dir config-root --from tmp --group-by apigroup
+
+		# moves the resources into a directory per namespace and kind
+		%s helmfile move --dir config-root --from tmp --path-template '{{ .Namespace }}/{{ .Kind | lower }}/{{ .Name }}.yaml'
+
+		# does not move any Secrets as they are processed by another tool
+		%s helmfile move --dir config-root --from tmp --exclude-kind Secret
+
+		# records where each resource was moved to in a JSON file
+		%s helmfile move --dir config-root --from tmp --move-log moves.json
+
+		# moves releases which resolve to the same directory into separate directories
+		%s helmfile move --dir config-root --from tmp --dir-includes-release-name --on-collision rename
 	`)
 )
 
 // NamespaceOptions the options for the command
 type Options struct {
 	kyamls.Filter
-	Dir                          string
-	OutputDir                    string
-	DirIncludesReleaseName       bool
-	ClusterDir                   string
-	ClusterNamespacesDir         string
-	ClusterResourcesDir          string
-	CustomResourceDefinitionsDir string
-	NamespacesDir                string
-	SingleNamespace              string
-	HelmState                    *state.HelmState
+	Dir                         string
+	OutputDir                   string
+	DirIncludesReleaseName      bool
+	OnCollision                 string
+	ClusterDir                  string
+	ClusterNamespacesDir        string
+	ClusterResourcesDir         string
+	GroupBy                     string
+	SingleNamespace             string
+	IncludeKinds                []string
+	ExcludeKinds                []string
+	StripOwnerRefs              bool
+	CrossNamespaceOwnerRefsOnly bool
+	AnnotateChart               bool
+	Helmfile                    string
+	MoveLog                     string
+	PathTemplate                string
+	Moves                       []MovedResource
+	HelmState                   *state.HelmState
+	releases                    map[string]*state.ReleaseSpec
+	kindFilter                  func(node *yaml.RNode, path string) (bool, error)
+	pathTemplate                *template.Template
 }
 
 // NewCmdHelmfileMove creates a command object for the command
@@ -68,7 +132,7 @@ func NewCmdHelmfileMove() (*cobra.Command, *Options) {
 		Aliases: []string{"mv"},
 		Short:   "Moves the generated template files from 'helmfile template' into the right gitops directory",
 		Long:    namespaceLong,
-		Example: fmt.Sprintf(namespaceExample, rootcmd.BinaryName),
+		Example: fmt.Sprintf(namespaceExample, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName),
 		Run: func(cmd *cobra.Command, args []string) {
 			err := o.Run()
 			helper.CheckErr(err)
@@ -76,7 +140,17 @@ func NewCmdHelmfileMove() (*cobra.Command, *Options) {
 	}
 	cmd.Flags().StringVarP(&o.Dir, "dir", "", "", "the directory containing the generated resources")
 	cmd.Flags().StringVarP(&o.OutputDir, "output-dir", "o", "config-root", "the output directory")
+	cmd.Flags().StringVarP(&o.GroupBy, "group-by", "", GroupByNamespace, "how the resources are organised in the output directory. Values: "+GroupByNamespace+", "+GroupByAPIGroup)
 	cmd.Flags().BoolVarP(&o.DirIncludesReleaseName, "dir-includes-release-name", "", false, "the directory containing the generated resources has a path segment that is the release name")
+	cmd.Flags().StringVarP(&o.OnCollision, "on-collision", "", OnCollisionError, "what to do when two releases resolve to the same directory with --dir-includes-release-name. Values: "+OnCollisionError+", "+OnCollisionRename)
+	cmd.Flags().BoolVarP(&o.StripOwnerRefs, "strip-owner-refs", "", false, "removes the metadata.ownerReferences from the moved resources as they are not valid once the resources are relocated")
+	cmd.Flags().BoolVarP(&o.CrossNamespaceOwnerRefsOnly, "cross-namespace-owner-refs-only", "", false, "when used with --strip-owner-refs only removes the metadata.ownerReferences of resources which are moved to a different namespace")
+	cmd.Flags().BoolVarP(&o.AnnotateChart, "annotate-chart", "", false, "adds the "+ChartAnnotation+" and "+ChartVersionAnnotation+" annotations to the moved resources")
+	cmd.Flags().StringVarP(&o.Helmfile, "helmfile", "", "", "the helmfile used to find the chart and version of each release for --annotate-chart. If not specified the chart directory name and the "+HelmChartLabel+" label are used")
+	cmd.Flags().StringVarP(&o.PathTemplate, "path-template", "", "", "the Go template of the path of each moved resource relative to the output dir. Defaults to the layout of --group-by")
+	cmd.Flags().StringVarP(&o.MoveLog, "move-log", "", "", "the file to write a JSON log of the source file, destination file and identity of each moved resource to")
+	cmd.Flags().StringArrayVarP(&o.IncludeKinds, "include-kind", "", nil, "only moves resources of these kinds. Can be specified multiple times. Supports 'apiVersion/kind' expressions")
+	cmd.Flags().StringArrayVarP(&o.ExcludeKinds, "exclude-kind", "", nil, "does not move resources of these kinds. Applied after --include-kind. Can be specified multiple times. Supports 'apiVersion/kind' expressions")
 
 	o.Filter.AddFlags(cmd)
 	return cmd, o
@@ -84,13 +158,32 @@ func NewCmdHelmfileMove() (*cobra.Command, *Options) {
 
 // Run implements the command
 func (o *Options) Run() error {
+	switch o.GroupBy {
+	case "":
+		o.GroupBy = GroupByNamespace
+	case GroupByNamespace:
+	case GroupByAPIGroup:
+		if o.ClusterNamespacesDir == "" {
+			o.ClusterNamespacesDir = filepath.Join(o.OutputDir, CoreAPIGroupDir, "namespaces")
+			err := os.MkdirAll(o.ClusterNamespacesDir, files.DefaultDirWritePermissions)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create cluster namespaces dir %s", o.ClusterNamespacesDir)
+			}
+		}
+	default:
+		return options.InvalidOption("group-by", o.GroupBy, []string{GroupByNamespace, GroupByAPIGroup})
+	}
+	switch o.OnCollision {
+	case "":
+		o.OnCollision = OnCollisionError
+	case OnCollisionError, OnCollisionRename:
+	default:
+		return options.InvalidOption("on-collision", o.OnCollision, []string{OnCollisionError, OnCollisionRename})
+	}
 	if o.ClusterDir == "" {
 		o.ClusterDir = filepath.Join(o.OutputDir, "cluster")
 	}
-	if o.NamespacesDir == "" {
-		o.NamespacesDir = filepath.Join(o.OutputDir, "namespaces")
-	}
-	if o.ClusterResourcesDir == "" {
+	if o.ClusterResourcesDir == "" && o.GroupBy == GroupByNamespace {
 		o.ClusterResourcesDir = filepath.Join(o.ClusterDir, "resources")
 		err := os.MkdirAll(o.ClusterResourcesDir, files.DefaultDirWritePermissions)
 		if err != nil {
@@ -104,8 +197,23 @@ func (o *Options) Run() error {
 			return errors.Wrapf(err, "failed to create cluster namespaces dir %s", o.ClusterNamespacesDir)
 		}
 	}
-	if o.CustomResourceDefinitionsDir == "" {
-		o.CustomResourceDefinitionsDir = filepath.Join(o.OutputDir, "customresourcedefinitions")
+	err := o.parsePathTemplate()
+	if err != nil {
+		return err
+	}
+	filter := kyamls.Filter{
+		Kinds:       o.IncludeKinds,
+		KindsIgnore: o.ExcludeKinds,
+	}
+	o.kindFilter, err = filter.ToFilterFn()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create kind filter")
+	}
+	if o.AnnotateChart {
+		err = o.loadReleases()
+		if err != nil {
+			return errors.Wrapf(err, "failed to load the helmfile releases")
+		}
 	}
 
 	globPattern := "*/*"
@@ -113,13 +221,16 @@ func (o *Options) Run() error {
 		globPattern = "*/*/*"
 	}
 	g := filepath.Join(o.Dir, globPattern)
-	fileNames, err := filepath.Glob(g)
+	var fileNames []string
+	fileNames, err = filepath.Glob(g)
 	if err != nil {
 		return errors.Wrapf(err, "failed to glob files %s", g)
 	}
 
+	o.Moves = nil
 	var namespaces []string
-	for _, dir := range fileNames {
+	releasePaths := map[string]string{}
+	for i, dir := range fileNames {
 		log.Logger().Debugf("processing chart dir %s", dir)
 
 		exists, err := files.DirExists(dir)
@@ -145,13 +256,49 @@ func (o *Options) Run() error {
 		}
 		namespaces = append(namespaces, ns)
 
-		err = o.moveFilesToClusterOrNamespacesFolder(dir, ns, releaseName, chartName)
+		// pathName is always prefixed with chartName but lets also remove any duplication
+		var pathName string
+		if chartName == releaseName {
+			pathName = chartName
+		} else if strings.HasPrefix(releaseName, chartName) {
+			pathName = releaseName
+		} else {
+			pathName = fmt.Sprintf("%s-%s", chartName, releaseName)
+		}
+
+		// lets check if another release in the namespace has already been moved into the same directory
+		key := ns + "/" + pathName
+		if other, ok := releasePaths[key]; ok {
+			if o.OnCollision != OnCollisionRename {
+				return errors.Errorf("releases %s and %s in namespace %s both resolve to the directory %s. Use --on-collision %s to move them into separate directories", other, releaseName, ns, pathName, OnCollisionRename)
+			}
+			pathName = fmt.Sprintf("%s-%d", pathName, i+1)
+			key = ns + "/" + pathName
+			if renamed, ok := releasePaths[key]; ok {
+				return errors.Errorf("releases %s and %s in namespace %s both resolve to the directory %s", renamed, releaseName, ns, pathName)
+			}
+			log.Logger().Infof("moving release %s in namespace %s to the directory %s as it collides with release %s", termcolor.ColorInfo(releaseName), ns, termcolor.ColorInfo(pathName), other)
+		}
+		releasePaths[key] = releaseName
+
+		err = o.moveFilesToClusterOrNamespacesFolder(dir, ns, releaseName, chartName, pathName)
 		if err != nil {
 			return errors.Wrapf(err, "failed to ")
 		}
 	}
+	err = o.writeMoveLog()
+	if err != nil {
+		return errors.Wrapf(err, "failed to write the move log")
+	}
 
 	// now lets lazy create any namespace resources which don't exist in the cluster dir
+	includeNamespaces, err := o.includesKind("v1", "Namespace")
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if Namespace resources are included")
+	}
+	if !includeNamespaces {
+		return nil
+	}
 	for _, ns := range namespaces {
 		err = o.lazyCreateNamespaceResource(ns)
 		if err != nil {
@@ -161,6 +308,61 @@ func (o *Options) Run() error {
 	return nil
 }
 
+// includesKind returns true if resources of the given apiVersion and kind pass the include/exclude kind filters
+func (o *Options) includesKind(apiVersion, kind string) (bool, error) {
+	if o.kindFilter == nil {
+		return true, nil
+	}
+	node := yaml.NewRNode(&yaml.Node{Kind: yaml.MappingNode})
+	err := node.PipeE(yaml.SetField("apiVersion", yaml.NewScalarRNode(apiVersion)))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to set apiVersion")
+	}
+	err = node.PipeE(yaml.SetField("kind", yaml.NewScalarRNode(kind)))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to set kind")
+	}
+	return o.kindFilter(node, "")
+}
+
+// stripOwnerReferences removes the owner references of the resource if enabled. If only cross namespace
+// owner references are stripped then they are only removed if the resource is moved to a different namespace
+func (o *Options) stripOwnerReferences(node *yaml.RNode, path, ns string) error {
+	if !o.StripOwnerRefs {
+		return nil
+	}
+	if o.CrossNamespaceOwnerRefsOnly {
+		currentNs := kyamls.GetNamespace(node, path)
+		if currentNs == "" || currentNs == ns {
+			return nil
+		}
+	}
+	metadata, err := node.Pipe(yaml.Get("metadata"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get metadata of %s", path)
+	}
+	if metadata == nil {
+		return nil
+	}
+	removed, err := metadata.Pipe(yaml.Clear("ownerReferences"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove metadata.ownerReferences of %s", path)
+	}
+	if removed != nil {
+		log.Logger().Debugf("removed the metadata.ownerReferences of %s", path)
+	}
+	return nil
+}
+
+// apiGroupDir returns the directory name for the API group of the given apiVersion
+func apiGroupDir(apiVersion string) string {
+	i := strings.LastIndex(apiVersion, "/")
+	if i <= 0 {
+		return CoreAPIGroupDir
+	}
+	return apiVersion[0:i]
+}
+
 func (o *Options) lazyCreateNamespaceResource(ns string) error {
 	dir := filepath.Dir(o.ClusterNamespacesDir)
 
@@ -211,7 +413,7 @@ func (o *Options) lazyCreateNamespaceResource(ns string) error {
 	return nil
 }
 
-func (o *Options) moveFilesToClusterOrNamespacesFolder(dir string, ns string, releaseName string, chartName string) error {
+func (o *Options) moveFilesToClusterOrNamespacesFolder(dir, ns, releaseName, chartName, pathName string) error {
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if info == nil || info.IsDir() {
 			return nil
@@ -244,49 +446,70 @@ func (o *Options) moveFilesToClusterOrNamespacesFolder(dir string, ns string, re
 			return nil
 		}
 
-		node, err := yaml.ReadFile(path)
-		if err != nil {
-			return errors.Wrapf(err, "failed to load YAML file %s", path)
-		}
-
-		// pathName is always prefixed with chartName but lets also remove any duplication
-		var pathName string
-		if chartName == releaseName {
-			pathName = chartName
-		} else if strings.HasPrefix(releaseName, chartName) {
-			pathName = releaseName
-		} else {
-			pathName = fmt.Sprintf("%s-%s", chartName, releaseName)
-		}
-
-		kind := kyamls.GetKind(node, path)
-		outDir := filepath.Join(o.ClusterResourcesDir, ns, pathName)
-
-		if kyamls.IsCustomResourceDefinition(kind) {
-			outDir = filepath.Join(o.CustomResourceDefinitionsDir, ns, pathName)
-		} else if !kyamls.IsClusterKind(kind) {
-			err := node.PipeE(yaml.LookupCreate(yaml.ScalarNode, "metadata", "namespace"), yaml.FieldSetter{StringValue: ns})
+		// lets move each resource of the file in the order they appear using the same file names as the split command
+		for i, doc := range split.Documents(string(data)) {
+			node, err := yaml.Parse(doc)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse resource %d of YAML file %s", i+1, path)
+			}
+			err = o.moveResource(node, path, split.DocumentFileName(rel, i), ns, releaseName, chartName, pathName)
 			if err != nil {
-				return errors.Wrapf(err, "failed to set metadata.namespace to %s for path %s", ns, path)
+				return err
 			}
-			outDir = filepath.Join(o.NamespacesDir, ns, pathName)
 		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to modify namespace to %s for release %s in dir %s", ns, releaseName, dir)
+	}
+	return nil
+}
 
-		outFile := filepath.Join(outDir, rel)
-		parentDir := filepath.Dir(outFile)
-		err = os.MkdirAll(parentDir, files.DefaultDirWritePermissions)
+// moveResource moves the resource from the file at the path to the relative file name in the output dir of its kind
+func (o *Options) moveResource(node *yaml.RNode, path, rel, ns, releaseName, chartName, pathName string) error {
+	if o.kindFilter != nil {
+		include, err := o.kindFilter(node, path)
 		if err != nil {
-			return errors.Wrapf(err, "failed to create dir %s", parentDir)
+			return errors.Wrapf(err, "failed to evaluate kind filter on %s", path)
 		}
+		if !include {
+			log.Logger().Debugf("ignoring resource %s in file %s as its kind is filtered out", kyamls.GetName(node, path), path)
+			return nil
+		}
+	}
+
+	err := o.stripOwnerReferences(node, path, ns)
+	if err != nil {
+		return err
+	}
+
+	err = o.annotateChart(node, path, ns, releaseName, chartName)
+	if err != nil {
+		return err
+	}
 
-		err = yaml.WriteFile(node, outFile)
+	kind := kyamls.GetKind(node, path)
+	if !kyamls.IsCustomResourceDefinition(kind) && !kyamls.IsClusterKind(kind) {
+		err := node.PipeE(yaml.LookupCreate(yaml.ScalarNode, "metadata", "namespace"), yaml.FieldSetter{StringValue: ns})
 		if err != nil {
-			return errors.Wrapf(err, "failed to save %s", outFile)
+			return errors.Wrapf(err, "failed to set metadata.namespace to %s for path %s", ns, path)
 		}
-		return nil
-	})
+	}
+
+	outFile, err := o.outputFile(node, path, rel, ns, releaseName, chartName, pathName)
 	if err != nil {
-		return errors.Wrapf(err, "failed to modify namespace to %s for release %s in dir %s", ns, releaseName, dir)
+		return err
+	}
+	parentDir := filepath.Dir(outFile)
+	err = os.MkdirAll(parentDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", parentDir)
+	}
+
+	err = yaml.WriteFile(node, outFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save %s", outFile)
 	}
+	o.recordMove(node, path, outFile)
 	return nil
 }
@@ -1,14 +1,18 @@
 package move_test
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/move"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 func TestUpdateNamespaceInYamlFiles(t *testing.T) {
@@ -66,3 +70,445 @@ func TestUpdateNamespaceInYamlFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestHelmfileMoveKindFilters(t *testing.T) {
+	tests := []struct {
+		name          string
+		includeKinds  []string
+		excludeKinds  []string
+		expectedFiles []string
+		missingFiles  []string
+	}{
+		{
+			name:         "include",
+			includeKinds: []string{"Deployment"},
+			expectedFiles: []string{
+				"namespaces/jx/lighthouse/lighthouse-foghorn-deploy.yaml",
+			},
+			missingFiles: []string{
+				"customresourcedefinitions/jx/lighthouse/lighthousejobs.lighthouse.jenkins.io-crd.yaml",
+				"cluster/resources/nginx/nginx-ingress/nginx-ingress-clusterrole.yaml",
+				"cluster/namespaces/jx.yaml",
+			},
+		},
+		{
+			name:         "exclude",
+			excludeKinds: []string{"ClusterRole", "apiextensions.k8s.io/CustomResourceDefinition"},
+			expectedFiles: []string{
+				"namespaces/jx/lighthouse/lighthouse-foghorn-deploy.yaml",
+				"cluster/namespaces/jx.yaml",
+			},
+			missingFiles: []string{
+				"customresourcedefinitions/jx/lighthouse/lighthousejobs.lighthouse.jenkins.io-crd.yaml",
+				"cluster/resources/nginx/nginx-ingress/nginx-ingress-clusterrole.yaml",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "could not create temp dir")
+
+		_, o := move.NewCmdHelmfileMove()
+
+		o.Dir = filepath.Join("test_data", "output")
+		o.OutputDir = tmpDir
+		o.IncludeKinds = test.includeKinds
+		o.ExcludeKinds = test.excludeKinds
+
+		err = o.Run()
+		require.NoError(t, err, "failed to run helmfile move for %s", test.name)
+
+		for _, efn := range test.expectedFiles {
+			ef := filepath.Join(append([]string{tmpDir}, strings.Split(efn, "/")...)...)
+			assert.FileExists(t, ef, "for test %s", test.name)
+		}
+		for _, efn := range test.missingFiles {
+			ef := filepath.Join(append([]string{tmpDir}, strings.Split(efn, "/")...)...)
+			assert.NoFileExists(t, ef, "for test %s", test.name)
+		}
+	}
+}
+
+func TestHelmfileMoveStripOwnerRefs(t *testing.T) {
+	tests := []struct {
+		name                        string
+		stripOwnerRefs              bool
+		crossNamespaceOwnerRefsOnly bool
+		expectedOwnerRefs           map[string]bool
+	}{
+		{
+			name: "keep",
+			expectedOwnerRefs: map[string]bool{
+				"same-ns":  true,
+				"other-ns": true,
+				"no-ns":    true,
+			},
+		},
+		{
+			name:           "strip",
+			stripOwnerRefs: true,
+			expectedOwnerRefs: map[string]bool{
+				"same-ns":  false,
+				"other-ns": false,
+				"no-ns":    false,
+			},
+		},
+		{
+			name:                        "cross-namespace",
+			stripOwnerRefs:              true,
+			crossNamespaceOwnerRefsOnly: true,
+			expectedOwnerRefs: map[string]bool{
+				"same-ns":  true,
+				"other-ns": false,
+				"no-ns":    true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "could not create temp dir")
+
+		_, o := move.NewCmdHelmfileMove()
+		o.Dir = filepath.Join("test_data", "ownerRefs")
+		o.OutputDir = tmpDir
+		o.StripOwnerRefs = test.stripOwnerRefs
+		o.CrossNamespaceOwnerRefsOnly = test.crossNamespaceOwnerRefsOnly
+
+		err = o.Run()
+		require.NoError(t, err, "failed to run helmfile move for %s", test.name)
+
+		for name, expected := range test.expectedOwnerRefs {
+			path := filepath.Join(tmpDir, "namespaces", "jx", "chart", name+".yaml")
+			require.FileExists(t, path)
+			data, err := ioutil.ReadFile(path)
+			require.NoError(t, err, "failed to read %s", path)
+			text := string(data)
+			assert.Contains(t, text, "namespace: jx", "namespace of %s for %s", name, test.name)
+			assert.Equal(t, expected, strings.Contains(text, "ownerReferences"), "ownerReferences of %s for %s", name, test.name)
+		}
+	}
+}
+
+func TestHelmfileMoveGroupByAPIGroup(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "could not create temp dir")
+
+	_, o := move.NewCmdHelmfileMove()
+
+	o.Dir = filepath.Join("test_data", "output")
+	o.OutputDir = tmpDir
+	o.GroupBy = move.GroupByAPIGroup
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run helmfile move")
+
+	expectedFiles := []string{
+		"apps/jx/lighthouse/lighthouse-foghorn-deploy.yaml",
+		"apiextensions.k8s.io/jx/lighthouse/lighthousejobs.lighthouse.jenkins.io-crd.yaml",
+		"rbac.authorization.k8s.io/nginx/nginx-ingress/nginx-ingress-clusterrole.yaml",
+		"core/namespaces/jx.yaml",
+		"core/namespaces/nginx.yaml",
+	}
+	for _, efn := range expectedFiles {
+		ef := filepath.Join(append([]string{tmpDir}, strings.Split(efn, "/")...)...)
+		assert.FileExists(t, ef)
+	}
+	for _, dir := range []string{"cluster", "namespaces", "customresourcedefinitions"} {
+		assert.NoDirExists(t, filepath.Join(tmpDir, dir))
+	}
+
+	_, o = move.NewCmdHelmfileMove()
+	o.Dir = filepath.Join("test_data", "output")
+	o.OutputDir = tmpDir
+	o.GroupBy = "cheese"
+	err = o.Run()
+	require.Error(t, err, "should fail for an invalid --group-by")
+}
+
+func TestHelmfileMoveAnnotateChart(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "could not create temp dir")
+
+	_, o := move.NewCmdHelmfileMove()
+
+	o.Dir = filepath.Join("test_data", "provenance", "output")
+	o.OutputDir = tmpDir
+	o.AnnotateChart = true
+	o.Helmfile = filepath.Join("test_data", "provenance", "helmfile.yaml")
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run helmfile move")
+
+	testCases := []struct {
+		file        string
+		chart       string
+		version     string
+		description string
+	}{
+		{
+			file:        filepath.Join(tmpDir, "namespaces", "jx", "lighthouse", "lighthouse-foghorn-deploy.yaml"),
+			chart:       "jx3/lighthouse",
+			version:     "1.1.0",
+			description: "release in the helmfile",
+		},
+		{
+			file:        filepath.Join(tmpDir, "cluster", "resources", "nginx", "ingress-nginx", "ingress-nginx-clusterrole.yaml"),
+			chart:       "ingress-nginx",
+			version:     "3.29.0",
+			description: "release not in the helmfile with a helm.sh/chart label",
+		},
+	}
+	for _, tc := range testCases {
+		require.FileExists(t, tc.file, tc.description)
+		node, err := yaml.ReadFile(tc.file)
+		require.NoError(t, err, "failed to load %s", tc.file)
+
+		assert.Equal(t, tc.chart, kyamls.GetStringField(node, tc.file, "metadata", "annotations", move.ChartAnnotation), "chart annotation for %s", tc.description)
+		assert.Equal(t, tc.version, kyamls.GetStringField(node, tc.file, "metadata", "annotations", move.ChartVersionAnnotation), "chart version annotation for %s", tc.description)
+	}
+}
+
+func TestHelmfileMoveLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	moveLog := filepath.Join(tmpDir, "logs", "moves.json")
+
+	_, o := move.NewCmdHelmfileMove()
+	o.Dir = filepath.Join("test_data", "output")
+	o.OutputDir = tmpDir
+	o.MoveLog = moveLog
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run helmfile move")
+
+	data, err := ioutil.ReadFile(moveLog)
+	require.NoError(t, err, "failed to read %s", moveLog)
+	var moves []move.MovedResource
+	err = json.Unmarshal(data, &moves)
+	require.NoError(t, err, "failed to parse %s", moveLog)
+
+	sourceDir := filepath.ToSlash(o.Dir)
+	outDir := filepath.ToSlash(tmpDir)
+	expected := []move.MovedResource{
+		{
+			Source:      sourceDir + "/jx/lighthouse/templates/lighthouse-foghorn-deploy.yaml",
+			Destination: outDir + "/namespaces/jx/lighthouse/lighthouse-foghorn-deploy.yaml",
+			Resource: move.ResourceID{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Namespace:  "jx",
+				Name:       "lighthouse-foghorn",
+			},
+		},
+		{
+			Source:      sourceDir + "/jx/lighthouse/templates/lighthousejobs.lighthouse.jenkins.io-crd.yaml",
+			Destination: outDir + "/customresourcedefinitions/jx/lighthouse/lighthousejobs.lighthouse.jenkins.io-crd.yaml",
+			Resource: move.ResourceID{
+				APIVersion: "apiextensions.k8s.io/v1beta1",
+				Kind:       "CustomResourceDefinition",
+				Name:       "lighthousejobs.lighthouse.jenkins.io",
+			},
+		},
+		{
+			Source:      sourceDir + "/nginx/nginx-ingress/templates/nginx-ingress-clusterrole.yaml",
+			Destination: outDir + "/cluster/resources/nginx/nginx-ingress/nginx-ingress-clusterrole.yaml",
+			Resource: move.ResourceID{
+				APIVersion: "rbac.authorization.k8s.io/v1",
+				Kind:       "ClusterRole",
+				Name:       "nginx-ingress",
+			},
+		},
+	}
+	assert.Equal(t, expected, moves, "move log %s", moveLog)
+}
+
+func TestHelmfileMoveMultipleDocuments(t *testing.T) {
+	expected := []struct {
+		file string
+		kind string
+	}{
+		{file: "namespaces/jx/myapp/all.yaml", kind: "Service"},
+		{file: "namespaces/jx/myapp/all2.yaml", kind: "ConfigMap"},
+		{file: "cluster/resources/jx/myapp/all3.yaml", kind: "ClusterRole"},
+		{file: "namespaces/jx/myapp/all4.yaml", kind: "Deployment"},
+	}
+
+	var outputs []map[string]string
+	for run := 0; run < 2; run++ {
+		tmpDir := t.TempDir()
+
+		_, o := move.NewCmdHelmfileMove()
+		o.Dir = filepath.Join("test_data", "multidoc")
+		o.OutputDir = tmpDir
+		o.MoveLog = filepath.Join(tmpDir, "moves.json")
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run helmfile move %d", run)
+
+		output := map[string]string{}
+		for _, e := range expected {
+			path := filepath.Join(append([]string{tmpDir}, strings.Split(e.file, "/")...)...)
+			require.FileExists(t, path, "run %d", run)
+			node, err := yaml.ReadFile(path)
+			require.NoError(t, err, "failed to load %s", path)
+			assert.Equal(t, e.kind, kyamls.GetKind(node, path), "kind of %s for run %d", e.file, run)
+
+			data, err := ioutil.ReadFile(path)
+			require.NoError(t, err, "failed to read %s", path)
+			output[e.file] = string(data)
+		}
+		outputs = append(outputs, output)
+
+		var kinds []string
+		for _, m := range o.Moves {
+			kinds = append(kinds, m.Resource.Kind)
+		}
+		assert.Equal(t, []string{"Service", "ConfigMap", "ClusterRole", "Deployment"}, kinds, "move log order for run %d", run)
+	}
+	assert.Equal(t, outputs[0], outputs[1], "the output should be the same for each run")
+}
+
+func TestHelmfileMovePathTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_, o := move.NewCmdHelmfileMove()
+	o.Dir = filepath.Join("test_data", "output")
+	o.OutputDir = tmpDir
+	o.PathTemplate = `{{ if .ClusterScoped }}cluster{{ else }}{{ .Namespace }}{{ end }}/{{ .ReleaseName }}/{{ .Kind | lower }}-{{ .Name }}.yaml`
+
+	err := o.Run()
+	require.NoError(t, err, "failed to run helmfile move")
+
+	expectedFiles := []string{
+		"jx/lighthouse/deployment-lighthouse-foghorn.yaml",
+		"cluster/lighthouse/customresourcedefinition-lighthousejobs.lighthouse.jenkins.io.yaml",
+		"cluster/nginx-ingress/clusterrole-nginx-ingress.yaml",
+		"cluster/namespaces/jx.yaml",
+	}
+	for _, efn := range expectedFiles {
+		ef := filepath.Join(append([]string{tmpDir}, strings.Split(efn, "/")...)...)
+		assert.FileExists(t, ef)
+	}
+	assert.NoDirExists(t, filepath.Join(tmpDir, "namespaces"))
+
+	for _, pathTemplate := range []string{"{{ .Cheese }}", "{{ .Name", "../{{ .Name }}.yaml"} {
+		_, o = move.NewCmdHelmfileMove()
+		o.Dir = filepath.Join("test_data", "output")
+		o.OutputDir = t.TempDir()
+		o.PathTemplate = pathTemplate
+		err = o.Run()
+		require.Error(t, err, "should fail for the path template %s", pathTemplate)
+	}
+}
+
+func TestHelmfileMoveKindFiltersMultipleDocuments(t *testing.T) {
+	tests := []struct {
+		name         string
+		includeKinds []string
+		excludeKinds []string
+		expected     []string
+	}{
+		{
+			name:     "all",
+			expected: []string{"Secret", "Deployment", "Service"},
+		},
+		{
+			name:         "exclude-secret",
+			excludeKinds: []string{"Secret"},
+			expected:     []string{"Deployment", "Service"},
+		},
+		{
+			name:         "include-secret",
+			includeKinds: []string{"Secret"},
+			expected:     []string{"Secret"},
+		},
+		{
+			name:         "include-then-exclude",
+			includeKinds: []string{"v1/Secret", "Deployment"},
+			excludeKinds: []string{"Secret"},
+			expected:     []string{"Deployment"},
+		},
+	}
+
+	files := map[string]string{
+		"Secret":     "namespaces/jx/myapp/myapp.yaml",
+		"Deployment": "namespaces/jx/myapp/myapp2.yaml",
+		"Service":    "namespaces/jx/myapp/myapp3.yaml",
+	}
+	for _, test := range tests {
+		tmpDir := t.TempDir()
+
+		_, o := move.NewCmdHelmfileMove()
+		o.Dir = filepath.Join("test_data", "secrets")
+		o.OutputDir = tmpDir
+		o.IncludeKinds = test.includeKinds
+		o.ExcludeKinds = test.excludeKinds
+		o.MoveLog = filepath.Join(tmpDir, "moves.json")
+
+		err := o.Run()
+		require.NoError(t, err, "failed to run helmfile move for %s", test.name)
+
+		var kinds []string
+		for _, m := range o.Moves {
+			kinds = append(kinds, m.Resource.Kind)
+		}
+		assert.Equal(t, test.expected, kinds, "moved kinds for %s", test.name)
+
+		for kind, efn := range files {
+			ef := filepath.Join(append([]string{tmpDir}, strings.Split(efn, "/")...)...)
+			if stringhelpers.StringArrayIndex(test.expected, kind) < 0 {
+				assert.NoFileExists(t, ef, "%s for %s", kind, test.name)
+				continue
+			}
+			require.FileExists(t, ef, "%s for %s", kind, test.name)
+			node, err := yaml.ReadFile(ef)
+			require.NoError(t, err, "failed to load %s", ef)
+			assert.Equal(t, kind, kyamls.GetKind(node, ef), "kind of %s for %s", efn, test.name)
+		}
+	}
+}
+
+func TestHelmfileMoveOnCollision(t *testing.T) {
+	// the 'jx' and 'lighthouse-jx' releases of the lighthouse chart both resolve to the 'lighthouse-jx' directory
+	tmpDir := t.TempDir()
+
+	_, o := move.NewCmdHelmfileMove()
+	o.Dir = filepath.Join("test_data", "collision")
+	o.OutputDir = tmpDir
+	o.DirIncludesReleaseName = true
+
+	err := o.Run()
+	require.Error(t, err, "should fail by default when releases collide")
+	assert.Contains(t, err.Error(), "releases jx and lighthouse-jx in namespace jx both resolve to the directory lighthouse-jx", "error")
+
+	tmpDir = t.TempDir()
+
+	_, o = move.NewCmdHelmfileMove()
+	o.Dir = filepath.Join("test_data", "collision")
+	o.OutputDir = tmpDir
+	o.DirIncludesReleaseName = true
+	o.OnCollision = move.OnCollisionRename
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run helmfile move")
+
+	expected := map[string]string{
+		"namespaces/jx/lighthouse-jx/lighthouse-config-cm.yaml":   "jx",
+		"namespaces/jx/lighthouse-jx-2/lighthouse-config-cm.yaml": "lighthouse-jx",
+	}
+	for efn, release := range expected {
+		ef := filepath.Join(append([]string{tmpDir}, strings.Split(efn, "/")...)...)
+		require.FileExists(t, ef, "file for release %s", release)
+		node, err := yaml.ReadFile(ef)
+		require.NoError(t, err, "failed to load %s", ef)
+		assert.Equal(t, release, kyamls.GetStringField(node, ef, "data", "release"), "release of %s", efn)
+	}
+
+	_, o = move.NewCmdHelmfileMove()
+	o.Dir = filepath.Join("test_data", "collision")
+	o.OutputDir = t.TempDir()
+	o.OnCollision = "ignore"
+	err = o.Run()
+	require.Error(t, err, "should fail for an invalid --on-collision")
+}
@@ -0,0 +1,76 @@
+package move
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ResourceID the identity of a moved resource
+type ResourceID struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// MovedResource records the source file of a resource and the destination file it was moved to
+type MovedResource struct {
+	Source      string     `json:"source"`
+	Destination string     `json:"destination"`
+	Resource    ResourceID `json:"resource"`
+}
+
+// recordMove records the move of the resource for the --move-log
+func (o *Options) recordMove(node *yaml.RNode, source, destination string) {
+	if o.MoveLog == "" {
+		return
+	}
+	o.Moves = append(o.Moves, MovedResource{
+		Source:      filepath.ToSlash(source),
+		Destination: filepath.ToSlash(destination),
+		Resource: ResourceID{
+			APIVersion: kyamls.GetAPIVersion(node, source),
+			Kind:       kyamls.GetKind(node, source),
+			Namespace:  kyamls.GetNamespace(node, source),
+			Name:       kyamls.GetName(node, source),
+		},
+	})
+}
+
+// writeMoveLog writes the moved resources sorted by their source file as JSON to the --move-log file
+func (o *Options) writeMoveLog() error {
+	if o.MoveLog == "" {
+		return nil
+	}
+	moves := o.Moves
+	if moves == nil {
+		moves = []MovedResource{}
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Source < moves[j].Source
+	})
+	data, err := json.MarshalIndent(moves, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the move log to JSON")
+	}
+	dir := filepath.Dir(o.MoveLog)
+	err = os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	err = ioutil.WriteFile(o.MoveLog, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", o.MoveLog)
+	}
+	log.Logger().Debugf("wrote the log of %d moved resources to %s", len(moves), o.MoveLog)
+	return nil
+}
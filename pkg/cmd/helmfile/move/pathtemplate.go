@@ -0,0 +1,106 @@
+package move
+
+import (
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// DefaultPathTemplate the path template of the namespace layout used by '--group-by namespace'
+	DefaultPathTemplate = `{{ if .CustomResourceDefinition }}customresourcedefinitions{{ else if .ClusterScoped }}cluster/resources{{ else }}namespaces{{ end }}/{{ .Namespace }}/{{ .PathName }}/{{ .File }}`
+
+	// APIGroupPathTemplate the path template of the API group layout used by '--group-by apigroup'
+	APIGroupPathTemplate = `{{ .APIGroup }}/{{ .Namespace }}/{{ .PathName }}/{{ .File }}`
+)
+
+// PathTemplateData the variables available to the --path-template
+type PathTemplateData struct {
+	// Namespace the namespace of the release
+	Namespace string
+
+	// ReleaseName the name of the release
+	ReleaseName string
+
+	// ChartName the name of the chart
+	ChartName string
+
+	// PathName the chart name prefixed release name used in the default layout
+	PathName string
+
+	// APIVersion the apiVersion of the resource
+	APIVersion string
+
+	// APIGroup the API group of the resource or core for the core API group
+	APIGroup string
+
+	// Kind the kind of the resource
+	Kind string
+
+	// Name the name of the resource
+	Name string
+
+	// File the path of the file of the resource relative to the chart templates directory
+	File string
+
+	// ClusterScoped true if the resource is not namespaced
+	ClusterScoped bool
+
+	// CustomResourceDefinition true if the resource is a CustomResourceDefinition
+	CustomResourceDefinition bool
+}
+
+// parsePathTemplate parses the --path-template defaulting it from the --group-by
+func (o *Options) parsePathTemplate() error {
+	text := o.PathTemplate
+	if text == "" {
+		text = DefaultPathTemplate
+		if o.GroupBy == GroupByAPIGroup {
+			text = APIGroupPathTemplate
+		}
+	}
+	var err error
+	o.pathTemplate, err = template.New("path").Option("missingkey=error").Funcs(sprig.TxtFuncMap()).Parse(text)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse --path-template %s", text)
+	}
+	return nil
+}
+
+// outputFile returns the file in the output dir to move the resource to by evaluating the path template
+func (o *Options) outputFile(node *yaml.RNode, path, rel, ns, releaseName, chartName, pathName string) (string, error) {
+	apiVersion := kyamls.GetAPIVersion(node, path)
+	kind := kyamls.GetKind(node, path)
+	data := &PathTemplateData{
+		Namespace:                ns,
+		ReleaseName:              releaseName,
+		ChartName:                chartName,
+		PathName:                 pathName,
+		APIVersion:               apiVersion,
+		APIGroup:                 apiGroupDir(apiVersion),
+		Kind:                     kind,
+		Name:                     kyamls.GetName(node, path),
+		File:                     filepath.ToSlash(rel),
+		ClusterScoped:            kyamls.IsClusterKind(kind),
+		CustomResourceDefinition: kyamls.IsCustomResourceDefinition(kind),
+	}
+	buf := &strings.Builder{}
+	err := o.pathTemplate.Execute(buf, data)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to evaluate the path template for %s", path)
+	}
+	file := strings.TrimSpace(buf.String())
+	if file == "" {
+		return "", errors.Errorf("the path template evaluated to an empty path for %s", path)
+	}
+	file = filepath.Clean(filepath.FromSlash(file))
+	if filepath.IsAbs(file) || file == ".." || strings.HasPrefix(file, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("the path template evaluated to %s for %s which is outside of the output dir", file, path)
+	}
+	return filepath.Join(o.OutputDir, file), nil
+}
@@ -0,0 +1,96 @@
+package move
+
+import (
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/helmfiles"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/pkg/errors"
+	"github.com/roboll/helmfile/pkg/state"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// ChartAnnotation the annotation added by --annotate-chart for the chart the resource was generated from
+	ChartAnnotation = "jx.io/chart"
+
+	// ChartVersionAnnotation the annotation added by --annotate-chart for the version of the chart the resource was generated from
+	ChartVersionAnnotation = "jx.io/chart-version"
+
+	// HelmChartLabel the standard label added by charts containing the chart name and version
+	HelmChartLabel = "helm.sh/chart"
+)
+
+// loadReleases loads the releases of the helmfile and any nested helmfiles indexed by namespace and release name
+func (o *Options) loadReleases() error {
+	o.releases = map[string]*state.ReleaseSpec{}
+	if o.HelmState != nil {
+		o.addReleases(o.HelmState)
+	}
+	if o.Helmfile == "" {
+		return nil
+	}
+	hfs, err := helmfiles.GatherHelmfiles(o.Helmfile, "")
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather nested helmfiles")
+	}
+	for _, hf := range hfs {
+		helmState := &state.HelmState{}
+		err = yaml2s.LoadFile(hf.Filepath, helmState)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load helmfile %s", hf.Filepath)
+		}
+		o.addReleases(helmState)
+	}
+	return nil
+}
+
+func (o *Options) addReleases(helmState *state.HelmState) {
+	for i := range helmState.Releases {
+		release := &helmState.Releases[i]
+		ns := release.Namespace
+		if ns == "" {
+			ns = helmState.OverrideNamespace
+		}
+		o.releases[ns+"/"+release.Name] = release
+	}
+}
+
+// annotateChart adds the chart and chart version annotations to the resource if --annotate-chart is enabled.
+// The chart and version come from the release in the helmfile or else the chart directory and the standard helm.sh/chart label
+func (o *Options) annotateChart(node *yaml.RNode, path, ns, releaseName, chartName string) error {
+	if !o.AnnotateChart {
+		return nil
+	}
+	chart := chartName
+	version := ""
+	release := o.releases[ns+"/"+releaseName]
+	if release != nil {
+		chart = release.Chart
+		version = release.Version
+	}
+	if version == "" {
+		labels, err := kyamls.GetLabels(node, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get labels of %s", path)
+		}
+		label := labels[HelmChartLabel]
+		if strings.HasPrefix(label, chartName+"-") {
+			version = strings.TrimPrefix(label, chartName+"-")
+		}
+	}
+
+	err := node.PipeE(yaml.SetAnnotation(ChartAnnotation, chart))
+	if err != nil {
+		return errors.Wrapf(err, "failed to set annotation %s on %s", ChartAnnotation, path)
+	}
+	if version == "" {
+		return nil
+	}
+	err = node.PipeE(yaml.SetAnnotation(ChartVersionAnnotation, version))
+	if err != nil {
+		return errors.Wrapf(err, "failed to set annotation %s on %s", ChartVersionAnnotation, path)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package prunerepos
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/helmfiles"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/roboll/helmfile/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Removes the repositories from the helmfile and any nested helmfiles which are not used by the chart of any release in the same helmfile
+`)
+
+	cmdExample = templates.Examples(`
+		# removes the unused repositories
+		%s helmfile prune-repos
+
+		# displays the repositories which would be removed
+		%s helmfile prune-repos --dry-run
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir      string
+	Helmfile string
+	DryRun   bool
+	Removed  []string
+}
+
+// NewCmdHelmfilePruneRepos creates a command object for the command
+func NewCmdHelmfilePruneRepos() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "prune-repos",
+		Short:   "Removes the repositories from the helmfile and any nested helmfiles which are not used by any release",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory that contains the helmfile")
+	cmd.Flags().StringVarP(&o.Helmfile, "helmfile", "", "", "the helmfile to prune. If not specified defaults to 'helmfile.yaml' in the dir")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "if enabled just log the repositories which would be removed without modifying the helmfiles")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	if o.Helmfile == "" {
+		o.Helmfile = "helmfile.yaml"
+	}
+
+	hfs, err := helmfiles.GatherHelmfiles(o.Helmfile, o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather nested helmfiles")
+	}
+
+	prefix := ""
+	if o.DryRun {
+		prefix = "would have "
+	}
+	processed := map[string]bool{}
+	for _, hf := range hfs {
+		path := hf.Filepath
+		if processed[path] {
+			continue
+		}
+		processed[path] = true
+
+		helmState := state.HelmState{}
+		err = yaml2s.LoadFile(path, &helmState)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load helmfile %s", path)
+		}
+
+		used := map[string]bool{}
+		for i := range helmState.Releases {
+			repo := ChartRepository(helmState.Releases[i].Chart)
+			if repo != "" {
+				used[repo] = true
+			}
+		}
+
+		var repositories []state.RepositorySpec
+		for _, repo := range helmState.Repositories {
+			if used[repo.Name] {
+				repositories = append(repositories, repo)
+				continue
+			}
+			log.Logger().Infof("%sremoved unused repository %s from %s", prefix, info(repo.Name), info(path))
+			o.Removed = append(o.Removed, filepath.Join(path, repo.Name))
+		}
+		if len(repositories) == len(helmState.Repositories) || o.DryRun {
+			continue
+		}
+		helmState.Repositories = repositories
+		err = yaml2s.SaveFile(helmState, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", path)
+		}
+	}
+	if len(o.Removed) == 0 {
+		log.Logger().Infof("no unused repositories found")
+	}
+	return nil
+}
+
+// ChartRepository returns the name of the repository of the chart or an empty string if the chart is a local path
+func ChartRepository(chart string) string {
+	if strings.HasPrefix(chart, ".") || strings.HasPrefix(chart, "/") {
+		return ""
+	}
+	i := strings.Index(chart, "/")
+	if i <= 0 {
+		return ""
+	}
+	return chart[0:i]
+}
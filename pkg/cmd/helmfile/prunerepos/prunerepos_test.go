@@ -0,0 +1,72 @@
+package prunerepos_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/prunerepos"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmfilePruneRepos(t *testing.T) {
+	helmfiles := []string{
+		filepath.Join("helmfiles", "jx", "helmfile.yaml"),
+		filepath.Join("helmfiles", "tekton", "helmfile.yaml"),
+	}
+
+	testCases := []struct {
+		name        string
+		dryRun      bool
+		expectedDir string
+	}{
+		{
+			name:        "prune",
+			expectedDir: "expected",
+		},
+		{
+			name:        "dry-run",
+			dryRun:      true,
+			expectedDir: "input",
+		},
+	}
+
+	for _, tc := range testCases {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "failed to create tmp dir")
+
+		err = files.CopyDirOverwrite(filepath.Join("test_data", "input"), tmpDir)
+		require.NoError(t, err, "failed to copy test data for %s", tc.name)
+
+		_, o := prunerepos.NewCmdHelmfilePruneRepos()
+		o.Dir = tmpDir
+		o.DryRun = tc.dryRun
+
+		err = o.Run()
+		require.NoError(t, err, "failed to run for %s", tc.name)
+		assert.Equal(t, []string{
+			filepath.Join(tmpDir, "helmfiles", "jx", "helmfile.yaml", "bitnami"),
+			filepath.Join(tmpDir, "helmfiles", "jx", "helmfile.yaml", "stable"),
+		}, o.Removed, "removed repositories for %s", tc.name)
+
+		for _, h := range helmfiles {
+			testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", tc.expectedDir, h), filepath.Join(tmpDir, h), tc.name+" "+h)
+		}
+	}
+}
+
+func TestChartRepository(t *testing.T) {
+	testCases := map[string]string{
+		"jx3/lighthouse":  "jx3",
+		"./charts/local":  "",
+		"../charts/local": "",
+		"/charts/local":   "",
+		"lighthouse":      "",
+	}
+	for chart, expected := range testCases {
+		assert.Equal(t, expected, prunerepos.ChartRepository(chart), "for chart %s", chart)
+	}
+}
@@ -0,0 +1,143 @@
+package setversion
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/helmfiles"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/roboll/helmfile/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Updates the version of a chart in every release of the helmfile and any nested helmfiles
+`)
+
+	cmdExample = templates.Examples(`
+		# updates every release of the chart to the given version
+		%s helmfile set-version --chart jx3/jx-pipelines-visualizer --version 1.2.3
+
+		# displays the releases which would be updated
+		%s helmfile set-version --chart jx3/jx-pipelines-visualizer --version 1.2.3 --dry-run
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir      string
+	Helmfile string
+	Chart    string
+	Version  string
+	DryRun   bool
+	Updated  []string
+}
+
+// NewCmdHelmfileSetVersion creates a command object for the command
+func NewCmdHelmfileSetVersion() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "set-version",
+		Short:   "Updates the version of a chart in every release of the helmfile and any nested helmfiles",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory that contains the helmfile")
+	cmd.Flags().StringVarP(&o.Helmfile, "helmfile", "", "", "the helmfile to update. If not specified defaults to 'helmfile.yaml' in the dir")
+	cmd.Flags().StringVarP(&o.Chart, "chart", "c", "", "the name of the chart to update. If the name has no repository prefix then the chart in any repository is updated")
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "the new version of the chart")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "if enabled just log the releases which would be updated without modifying the helmfiles")
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.Chart == "" {
+		return options.MissingOption("chart")
+	}
+	if o.Version == "" {
+		return options.MissingOption("version")
+	}
+	if o.Helmfile == "" {
+		o.Helmfile = "helmfile.yaml"
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+
+	hfs, err := helmfiles.GatherHelmfiles(o.Helmfile, o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather nested helmfiles")
+	}
+
+	prefix := ""
+	if o.DryRun {
+		prefix = "would have "
+	}
+	processed := map[string]bool{}
+	for _, hf := range hfs {
+		path := hf.Filepath
+		if processed[path] {
+			continue
+		}
+		processed[path] = true
+
+		helmState := state.HelmState{}
+		err = yaml2s.LoadFile(path, &helmState)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load helmfile %s", path)
+		}
+
+		modified := false
+		for i := range helmState.Releases {
+			release := &helmState.Releases[i]
+			if !o.matchesChart(release.Chart) || release.Version == o.Version {
+				continue
+			}
+			log.Logger().Infof("%supdated release %s in %s from version %s to %s", prefix, info(release.Name), info(path), info(release.Version), info(o.Version))
+			release.Version = o.Version
+			o.Updated = append(o.Updated, filepath.Join(path, release.Name))
+			modified = true
+		}
+		if !modified || o.DryRun {
+			continue
+		}
+		err = yaml2s.SaveFile(helmState, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", path)
+		}
+	}
+	if len(o.Updated) == 0 {
+		log.Logger().Infof("no releases of chart %s needed to be updated to version %s", info(o.Chart), info(o.Version))
+	}
+	return nil
+}
+
+func (o *Options) matchesChart(chart string) bool {
+	if chart == o.Chart {
+		return true
+	}
+	return !strings.Contains(o.Chart, "/") && strings.HasSuffix(chart, "/"+o.Chart)
+}
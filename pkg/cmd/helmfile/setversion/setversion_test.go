@@ -0,0 +1,75 @@
+package setversion_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/setversion"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmfileSetVersion(t *testing.T) {
+	helmfiles := []string{
+		filepath.Join("helmfiles", "jx", "helmfile.yaml"),
+		filepath.Join("helmfiles", "tekton", "helmfile.yaml"),
+	}
+
+	testCases := []struct {
+		name        string
+		chart       string
+		dryRun      bool
+		expectedDir string
+		updated     int
+	}{
+		{
+			name:        "prefixed-chart",
+			chart:       "jx3/jx-pipelines-visualizer",
+			expectedDir: "expected",
+			updated:     3,
+		},
+		{
+			name:        "chart-without-prefix",
+			chart:       "jx-pipelines-visualizer",
+			expectedDir: "expected",
+			updated:     3,
+		},
+		{
+			name:        "dry-run",
+			chart:       "jx3/jx-pipelines-visualizer",
+			dryRun:      true,
+			expectedDir: "input",
+			updated:     3,
+		},
+		{
+			name:        "other-repository",
+			chart:       "another/jx-pipelines-visualizer",
+			expectedDir: "input",
+		},
+	}
+
+	for _, tc := range testCases {
+		tmpDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "failed to create tmp dir")
+
+		err = files.CopyDirOverwrite(filepath.Join("test_data", "input"), tmpDir)
+		require.NoError(t, err, "failed to copy test data for %s", tc.name)
+
+		_, o := setversion.NewCmdHelmfileSetVersion()
+		o.Dir = tmpDir
+		o.Chart = tc.chart
+		o.Version = "1.2.3"
+		o.DryRun = tc.dryRun
+
+		err = o.Run()
+		require.NoError(t, err, "failed to run for %s", tc.name)
+		assert.Len(t, o.Updated, tc.updated, "updated releases for %s", tc.name)
+
+		for _, h := range helmfiles {
+			testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", tc.expectedDir, h), filepath.Join(tmpDir, h), tc.name+" "+h)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/helmfiles"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/roboll/helmfile/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Renders the helmfile and any nested helmfiles via 'helmfile template'
+
+If --concurrency is greater than 1 then each nested helmfile containing releases is rendered in parallel.
+The helm repositories are synchronised once up front and the dependencies of the releases are not built by the workers.
+Rendering concurrently is not supported if a parent helmfile uses environments, bases or passes selectors or values to its nested helmfiles.
+
+If --decrypt is specified then any SOPS encrypted secrets files referenced by the releases are decrypted before rendering.
+The decryption requires the SOPS key material to be available via one of the environment variables: ` + strings.Join(SopsKeyEnvVars, ", ") + `
+`)
+
+	cmdExample = templates.Examples(`
+		# renders the helmfile.yaml in the current directory
+		%s helmfile template --output-dir /tmp/generate
+
+		# renders the nested helmfiles in parallel
+		%s helmfile template --concurrency 4 --output-dir /tmp/generate
+
+		# renders the helmfile decrypting any SOPS encrypted secrets files first
+		%s helmfile template --decrypt --output-dir /tmp/generate
+	`)
+
+	// SopsKeyEnvVars the environment variables which provide the key material for SOPS decryption
+	SopsKeyEnvVars = []string{
+		"SOPS_AGE_KEY",
+		"SOPS_AGE_KEY_FILE",
+		"SOPS_PGP_FP",
+		"SOPS_KMS_ARN",
+		"SOPS_GCP_KMS_IDS",
+		"SOPS_AZURE_KEYVAULT_URLS",
+		"SOPS_VAULT_URIS",
+	}
+)
+
+// Options the options for the command
+type Options struct {
+	Dir            string
+	Helmfile       string
+	OutputDir      string
+	HelmfileBinary string
+	SopsBinary     string
+	Args           []string
+	Concurrency    int
+	Decrypt        bool
+	CommandRunner  cmdrunner.CommandRunner
+}
+
+// NewCmdHelmfileTemplate creates a command object for the command
+func NewCmdHelmfileTemplate() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "template",
+		Short:   "Renders the helmfile and any nested helmfiles via 'helmfile template'",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Args = append(o.Args, args...)
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory that contains the helmfile")
+	cmd.Flags().StringVarP(&o.Helmfile, "helmfile", "", "", "the helmfile to template. Defaults to 'helmfile.yaml' in the directory")
+	cmd.Flags().StringVarP(&o.OutputDir, "output-dir", "o", "", "the directory to write the generated resources")
+	cmd.Flags().StringVarP(&o.HelmfileBinary, "helmfile-binary", "", "", "specifies the helmfile binary location to use. If not specified defaults to using the downloaded helmfile plugin")
+	cmd.Flags().StringVarP(&o.SopsBinary, "sops-binary", "", "sops", "specifies the sops binary used to decrypt secrets files")
+	cmd.Flags().StringArrayVarP(&o.Args, "args", "", nil, "additional arguments passed to 'helmfile template'")
+	cmd.Flags().IntVarP(&o.Concurrency, "concurrency", "", 1, "the number of nested helmfiles to render in parallel. If greater than 1 each nested helmfile is rendered separately")
+	cmd.Flags().BoolVarP(&o.Decrypt, "decrypt", "", false, "decrypts any SOPS encrypted secrets files of the releases before rendering")
+	return cmd, o
+}
+
+// Validate validates the options and populates any missing values
+func (o *Options) Validate() error {
+	var err error
+	if o.Helmfile == "" {
+		o.Helmfile = "helmfile.yaml"
+	}
+	if o.HelmfileBinary == "" {
+		o.HelmfileBinary, err = plugins.GetHelmfileBinary(plugins.HelmfileVersion)
+		if err != nil {
+			return errors.Wrapf(err, "failed to download helmfile plugin")
+		}
+	}
+	if o.OutputDir != "" {
+		o.OutputDir, err = filepath.Abs(o.OutputDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find absolute path of output dir %s", o.OutputDir)
+		}
+	}
+	if o.Decrypt {
+		if !hasSopsKeyMaterial() {
+			return errors.Errorf("cannot --decrypt as no SOPS key material was found. Please specify one of the environment variables: %s", strings.Join(SopsKeyEnvVars, ", "))
+		}
+	}
+	if o.CommandRunner == nil {
+		o.CommandRunner = cmdrunner.DefaultCommandRunner
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+
+	dir := o.Dir
+	if o.Decrypt {
+		// lets decrypt in a temporary copy so that we never leave decrypted secrets in the source tree
+		dir, err = ioutil.TempDir("", "jx-helmfile-template-")
+		if err != nil {
+			return errors.Wrapf(err, "failed to create temporary directory")
+		}
+		defer os.RemoveAll(dir)
+
+		err = copyHelmfileTree(o.Dir, dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy %s to %s", o.Dir, dir)
+		}
+		err = o.decryptSecrets(dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decrypt secrets")
+		}
+	}
+
+	if o.Concurrency > 1 {
+		return o.renderConcurrently(dir)
+	}
+	return o.render(dir, o.Helmfile)
+}
+
+func (o *Options) render(dir, helmfile string, extraArgs ...string) error {
+	args := []string{"--file", helmfile, "template"}
+	args = append(args, extraArgs...)
+	if o.OutputDir != "" {
+		args = append(args, "--output-dir", o.OutputDir)
+	}
+	args = append(args, o.Args...)
+	c := &cmdrunner.Command{
+		Dir:  dir,
+		Name: o.HelmfileBinary,
+		Args: args,
+	}
+	_, err := o.CommandRunner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to run command %s in dir %s", c.CLI(), dir)
+	}
+	return nil
+}
+
+// renderConcurrently renders each nested helmfile using a pool of workers.
+// The results are reported in the order of the helmfiles so that the output is deterministic
+func (o *Options) renderConcurrently(dir string) error {
+	paths, err := o.findReleaseHelmfiles(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find helmfiles")
+	}
+
+	// lets add and update the helm repositories once so that the workers do not race on the helm repository cache
+	c := &cmdrunner.Command{
+		Dir:  dir,
+		Name: o.HelmfileBinary,
+		Args: []string{"--file", o.Helmfile, "repos"},
+	}
+	_, err = o.CommandRunner(c)
+	if err != nil {
+		return errors.Wrapf(err, "failed to run command %s in dir %s", c.CLI(), dir)
+	}
+
+	results := make([]error, len(paths))
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < o.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = o.render(dir, paths[i], "--skip-deps")
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, path := range paths {
+		if results[i] != nil {
+			return errors.Wrapf(results[i], "failed to render helmfile %s", path)
+		}
+		log.Logger().Infof("rendered helmfile %s", info(path))
+	}
+	return nil
+}
+
+// findReleaseHelmfiles returns the paths relative to the dir of the helmfiles which contain releases
+func (o *Options) findReleaseHelmfiles(dir string) ([]string, error) {
+	hfs, err := helmfiles.GatherHelmfiles(o.Helmfile, dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to gather nested helmfiles")
+	}
+	var answer []string
+	for _, hf := range hfs {
+		path, err := filepath.Rel(dir, hf.Filepath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find relative path of %s", hf.Filepath)
+		}
+		if stringhelpers.StringArrayIndex(answer, path) >= 0 {
+			continue
+		}
+		helmState := state.HelmState{}
+		err = yaml2s.LoadFile(hf.Filepath, &helmState)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load helmfile %s", hf.Filepath)
+		}
+		err = verifyCanRenderSeparately(path, &helmState)
+		if err != nil {
+			return nil, err
+		}
+		if len(helmState.Releases) == 0 {
+			continue
+		}
+		if len(helmState.Helmfiles) > 0 {
+			return nil, errors.Errorf("cannot render helmfile %s concurrently as it contains both releases and nested helmfiles", path)
+		}
+		answer = append(answer, path)
+	}
+	return answer, nil
+}
+
+// verifyCanRenderSeparately returns an error if the helmfile uses settings which would be lost
+// when rendering its nested helmfiles separately
+func verifyCanRenderSeparately(path string, helmState *state.HelmState) error {
+	if len(helmState.Environments) > 0 {
+		return errors.Errorf("cannot render helmfile %s concurrently as it uses environments", path)
+	}
+	if len(helmState.Bases) > 0 {
+		return errors.Errorf("cannot render helmfile %s concurrently as it uses bases", path)
+	}
+	for _, nested := range helmState.Helmfiles {
+		if len(nested.Selectors) > 0 || nested.SelectorsInherited {
+			return errors.Errorf("cannot render helmfile %s concurrently as it passes selectors to the nested helmfile %s", path, nested.Path)
+		}
+		if len(nested.Environment.OverrideValues) > 0 {
+			return errors.Errorf("cannot render helmfile %s concurrently as it passes values to the nested helmfile %s", path, nested.Path)
+		}
+	}
+	return nil
+}
+
+// decryptSecrets decrypts the secrets files of each release in place and
+// moves them into the values so that helmfile does not try to decrypt them again
+func (o *Options) decryptSecrets(dir string) error {
+	hfs, err := helmfiles.GatherHelmfiles(o.Helmfile, dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather nested helmfiles")
+	}
+	decrypted := map[string]bool{}
+	for _, hf := range hfs {
+		path := hf.Filepath
+		helmState := state.HelmState{}
+		err = yaml2s.LoadFile(path, &helmState)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load helmfile %s", path)
+		}
+
+		modified := false
+		for i := range helmState.Releases {
+			release := &helmState.Releases[i]
+			for _, s := range release.Secrets {
+				secretFile, ok := s.(string)
+				if !ok {
+					return errors.Errorf("unsupported secrets entry %v for release %s in helmfile %s", s, release.Name, path)
+				}
+				fileName, err := filepath.Abs(filepath.Join(filepath.Dir(path), secretFile))
+				if err != nil {
+					return errors.Wrapf(err, "failed to find absolute path of %s", secretFile)
+				}
+
+				// secrets files can be shared by releases so lets only decrypt them once
+				if !decrypted[fileName] {
+					c := &cmdrunner.Command{
+						Dir:  filepath.Dir(path),
+						Name: o.SopsBinary,
+						Args: []string{"--decrypt", "--in-place", fileName},
+					}
+					_, err = o.CommandRunner(c)
+					if err != nil {
+						return errors.Wrapf(err, "failed to decrypt %s", secretFile)
+					}
+					decrypted[fileName] = true
+					log.Logger().Debugf("decrypted secrets file %s for release %s", info(secretFile), info(release.Name))
+				}
+
+				release.Values = append(release.Values, secretFile)
+				modified = true
+			}
+			release.Secrets = nil
+		}
+		if !modified {
+			continue
+		}
+		err = yaml2s.SaveFile(helmState, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", path)
+		}
+	}
+	return nil
+}
+
+// copyHelmfileTree copies the helmfiles and their values files to the destination ignoring the git repository metadata
+func copyHelmfileTree(src, dst string) error {
+	fileSlice, err := ioutil.ReadDir(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read dir %s", src)
+	}
+	for _, f := range fileSlice {
+		name := f.Name()
+		if name == ".git" {
+			continue
+		}
+		err = files.CopyFileOrDir(filepath.Join(src, name), filepath.Join(dst, name), true)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy %s", name)
+		}
+	}
+	return nil
+}
+
+func hasSopsKeyMaterial() bool {
+	for _, e := range SopsKeyEnvVars {
+		if os.Getenv(e) != "" {
+			return true
+		}
+	}
+	return false
+}
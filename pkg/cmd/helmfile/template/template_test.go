@@ -0,0 +1,261 @@
+package template_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/template"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner/fakerunner"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/roboll/helmfile/pkg/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmfileTemplateDecrypt(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	srcDir := filepath.Join("test_data", "secrets")
+	err = files.CopyDirOverwrite(srcDir, tmpDir)
+	require.NoError(t, err, "failed to copy %s to %s", srcDir, tmpDir)
+
+	os.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(tmpDir, "key.txt"))
+	defer os.Unsetenv("SOPS_AGE_KEY_FILE")
+
+	var renderedState *state.HelmState
+	var decryptedSecrets string
+	runner := &fakerunner.FakeRunner{
+		CommandRunner: func(c *cmdrunner.Command) (string, error) {
+			t.Logf("running command %s in dir %s\n", c.CLI(), c.Dir)
+			switch c.Name {
+			case "sops":
+				// lets fake the decryption
+				path := c.Args[len(c.Args)-1]
+				return "", ioutil.WriteFile(path, []byte("password: secret\n"), files.DefaultFileWritePermissions)
+			case "helmfile":
+				// lets capture the rendered files before the temporary directory is removed
+				data, err := ioutil.ReadFile(filepath.Join(c.Dir, "helmfiles", "jx", "secrets.yaml"))
+				require.NoError(t, err, "failed to read decrypted secrets")
+				decryptedSecrets = string(data)
+
+				renderedState = &state.HelmState{}
+				err = yaml2s.LoadFile(filepath.Join(c.Dir, "helmfiles", "jx", "helmfile.yaml"), renderedState)
+				require.NoError(t, err, "failed to load nested helmfile")
+			}
+			return "", nil
+		},
+	}
+
+	_, o := template.NewCmdHelmfileTemplate()
+	o.Dir = tmpDir
+	o.OutputDir = filepath.Join(tmpDir, "output")
+	o.HelmfileBinary = "helmfile"
+	o.Decrypt = true
+	o.CommandRunner = runner.Run
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	require.Len(t, runner.OrderedCommands, 2, "commands")
+	assert.Equal(t, "sops", runner.OrderedCommands[0].Name)
+	helmfileCmd := runner.OrderedCommands[1]
+	assert.Equal(t, "helmfile --file helmfile.yaml template --output-dir "+o.OutputDir, helmfileCmd.CLI())
+	assert.NotEqual(t, tmpDir, helmfileCmd.Dir, "should render from a temporary copy of the dir")
+
+	assert.Equal(t, "password: secret\n", decryptedSecrets, "decrypted secrets")
+	require.NotNil(t, renderedState, "should have rendered the helmfile")
+	require.Len(t, renderedState.Releases, 1, "releases")
+	release := renderedState.Releases[0]
+	assert.Empty(t, release.Secrets, "secrets should be moved to values")
+	assert.Equal(t, []interface{}{"values.yaml", "secrets.yaml"}, release.Values, "values")
+
+	// lets verify we did not decrypt the source files
+	data, err := ioutil.ReadFile(filepath.Join(tmpDir, "helmfiles", "jx", "secrets.yaml"))
+	require.NoError(t, err, "failed to read source secrets")
+	assert.Contains(t, string(data), "ENC[", "source secrets should remain encrypted")
+	assert.NoDirExists(t, helmfileCmd.Dir, "should have removed the temporary dir")
+}
+
+func TestHelmfileTemplateDecryptMissingKeys(t *testing.T) {
+	for _, e := range template.SopsKeyEnvVars {
+		if os.Getenv(e) != "" {
+			t.Skipf("skipping test as $%s is set", e)
+		}
+	}
+
+	runner := &fakerunner.FakeRunner{}
+	_, o := template.NewCmdHelmfileTemplate()
+	o.Dir = filepath.Join("test_data", "secrets")
+	o.HelmfileBinary = "helmfile"
+	o.Decrypt = true
+	o.CommandRunner = runner.Run
+
+	err := o.Run()
+	require.Error(t, err, "should fail without SOPS key material")
+	assert.Empty(t, runner.OrderedCommands, "should not have run any commands")
+}
+
+func TestHelmfileTemplateConcurrency(t *testing.T) {
+	outDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	delays := map[string]time.Duration{
+		"helmfiles/jx/helmfile.yaml":               300 * time.Millisecond,
+		"helmfiles/nginx/helmfile.yaml":            100 * time.Millisecond,
+		"helmfiles/tekton-pipelines/helmfile.yaml": 0,
+	}
+
+	lock := sync.Mutex{}
+	runner := &fakerunner.FakeRunner{
+		CommandRunner: func(c *cmdrunner.Command) (string, error) {
+			if c.Args[2] == "repos" {
+				return "", nil
+			}
+
+			// lets render the nested helmfiles out of order
+			path := c.Args[1]
+			time.Sleep(delays[path])
+
+			ns := filepath.Base(filepath.Dir(path))
+			err := os.MkdirAll(filepath.Join(outDir, ns), files.DefaultDirWritePermissions)
+			if err != nil {
+				return "", err
+			}
+			return "", ioutil.WriteFile(filepath.Join(outDir, ns, "rendered.yaml"), []byte(path), files.DefaultFileWritePermissions)
+		},
+	}
+
+	_, o := template.NewCmdHelmfileTemplate()
+	o.Dir = filepath.Join("test_data", "nested")
+	o.OutputDir = outDir
+	o.HelmfileBinary = "helmfile"
+	o.Concurrency = 3
+	o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+		lock.Lock()
+		runner.Commands = append(runner.Commands, c)
+		lock.Unlock()
+		return runner.CommandRunner(c)
+	}
+
+	output := log.CaptureOutput(func() {
+		err = o.Run()
+	})
+	require.NoError(t, err, "failed to run the command")
+
+	require.NotEmpty(t, runner.Commands, "should have run commands")
+	assert.Equal(t, "helmfile --file helmfile.yaml repos", runner.Commands[0].CLI(), "should sync the repositories before rendering")
+
+	runner.ExpectResults(t,
+		fakerunner.FakeResult{CLI: "helmfile --file helmfile.yaml repos"},
+		fakerunner.FakeResult{CLI: "helmfile --file helmfiles/jx/helmfile.yaml template --skip-deps --output-dir " + outDir},
+		fakerunner.FakeResult{CLI: "helmfile --file helmfiles/nginx/helmfile.yaml template --skip-deps --output-dir " + outDir},
+		fakerunner.FakeResult{CLI: "helmfile --file helmfiles/tekton-pipelines/helmfile.yaml template --skip-deps --output-dir " + outDir},
+	)
+
+	for path := range delays {
+		ns := filepath.Base(filepath.Dir(path))
+		data, err := ioutil.ReadFile(filepath.Join(outDir, ns, "rendered.yaml"))
+		require.NoError(t, err, "failed to read rendered output for %s", path)
+		assert.Equal(t, path, string(data), "rendered output for %s", path)
+	}
+
+	// lets verify the results are reported in the order of the helmfiles
+	jxIdx := strings.Index(output, "helmfiles/jx/helmfile.yaml")
+	nginxIdx := strings.Index(output, "helmfiles/nginx/helmfile.yaml")
+	tektonIdx := strings.Index(output, "helmfiles/tekton-pipelines/helmfile.yaml")
+	assert.True(t, jxIdx >= 0 && jxIdx < nginxIdx && nginxIdx < tektonIdx, "should report helmfiles in order but got: %s", output)
+}
+
+func TestHelmfileTemplateConcurrencyUnsupportedParent(t *testing.T) {
+	testCases := []struct {
+		dir      string
+		expected string
+	}{
+		{
+			dir:      "nested-selectors",
+			expected: "passes selectors to the nested helmfile helmfiles/jx/helmfile.yaml",
+		},
+		{
+			dir:      "nested-values",
+			expected: "passes values to the nested helmfile helmfiles/nginx/helmfile.yaml",
+		},
+	}
+
+	for _, tc := range testCases {
+		runner := &fakerunner.FakeRunner{}
+
+		_, o := template.NewCmdHelmfileTemplate()
+		o.Dir = filepath.Join("test_data", tc.dir)
+		o.HelmfileBinary = "helmfile"
+		o.Concurrency = 2
+		o.CommandRunner = runner.Run
+
+		err := o.Run()
+		require.Error(t, err, "should fail for %s", tc.dir)
+		assert.Contains(t, err.Error(), tc.expected, "error for %s", tc.dir)
+		assert.Empty(t, runner.OrderedCommands, "should not have run any commands for %s", tc.dir)
+	}
+}
+
+func TestHelmfileTemplateDecryptSharedSecrets(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	srcDir := filepath.Join("test_data", "shared")
+	err = files.CopyDirOverwrite(srcDir, tmpDir)
+	require.NoError(t, err, "failed to copy %s to %s", srcDir, tmpDir)
+
+	// lets make sure we don't copy the git repository
+	err = os.MkdirAll(filepath.Join(tmpDir, ".git"), files.DefaultDirWritePermissions)
+	require.NoError(t, err, "failed to create .git dir")
+
+	os.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(tmpDir, "key.txt"))
+	defer os.Unsetenv("SOPS_AGE_KEY_FILE")
+
+	runner := &fakerunner.FakeRunner{
+		CommandRunner: func(c *cmdrunner.Command) (string, error) {
+			switch c.Name {
+			case "sops":
+				path := c.Args[len(c.Args)-1]
+				data, err := ioutil.ReadFile(path)
+				if err != nil {
+					return "", err
+				}
+				if !strings.Contains(string(data), "ENC[") {
+					return "", errors.Errorf("sops metadata not found")
+				}
+				return "", ioutil.WriteFile(path, []byte("password: secret\n"), files.DefaultFileWritePermissions)
+			case "helmfile":
+				assert.NoDirExists(t, filepath.Join(c.Dir, ".git"), "should not copy the .git dir")
+			}
+			return "", nil
+		},
+	}
+
+	_, o := template.NewCmdHelmfileTemplate()
+	o.Dir = tmpDir
+	o.HelmfileBinary = "helmfile"
+	o.Decrypt = true
+	o.CommandRunner = runner.Run
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	var sopsCommands []*cmdrunner.Command
+	for _, c := range runner.OrderedCommands {
+		if c.Name == "sops" {
+			sopsCommands = append(sopsCommands, c)
+		}
+	}
+	require.Len(t, sopsCommands, 1, "should only decrypt the shared secrets file once")
+}
@@ -0,0 +1,149 @@
+package verifyenv
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/helmfiles"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/roboll/helmfile/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that the values and secrets files referenced by the environments in the helmfile and any nested helmfiles exist
+
+Relative paths are resolved from the directory of the helmfile. Templated and remote paths are ignored.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the environment values files of the helmfile and any nested helmfiles
+		%s helmfile verify-env
+
+		# verifies the environment values files of a specific helmfile
+		%s helmfile verify-env --helmfile helmfiles/jx/helmfile.yaml
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir      string
+	Helmfile string
+	Failures []verifiers.Failure
+}
+
+// NewCmdHelmfileVerifyEnv creates a command object for the command
+func NewCmdHelmfileVerifyEnv() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "verify-env",
+		Short:   "Verifies that the values files referenced by the environments in the helmfile exist",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory that contains the helmfile")
+	cmd.Flags().StringVarP(&o.Helmfile, "helmfile", "", "", "the helmfile to verify. If not specified defaults to 'helmfile.yaml' in the dir")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	if o.Helmfile == "" {
+		o.Helmfile = "helmfile.yaml"
+	}
+	o.Failures = nil
+
+	hfs, err := helmfiles.GatherHelmfiles(o.Helmfile, o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather nested helmfiles")
+	}
+
+	processed := map[string]bool{}
+	for _, hf := range hfs {
+		path := hf.Filepath
+		if processed[path] {
+			continue
+		}
+		processed[path] = true
+
+		helmState := state.HelmState{}
+		err = yaml2s.LoadFile(path, &helmState)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load helmfile %s", path)
+		}
+
+		var names []string
+		for name := range helmState.Environments {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		dir := filepath.Dir(path)
+		for _, name := range names {
+			env := helmState.Environments[name]
+			var valuesFiles []string
+			for _, v := range env.Values {
+				// inline values are maps so only strings are file paths
+				s, ok := v.(string)
+				if ok {
+					valuesFiles = append(valuesFiles, s)
+				}
+			}
+			err = o.verifyFiles(path, dir, name, "values", valuesFiles)
+			if err != nil {
+				return err
+			}
+			err = o.verifyFiles(path, dir, name, "secrets", env.Secrets)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return verifiers.Report(o.Failures, "missing environment values files")
+}
+
+func (o *Options) verifyFiles(path, dir, envName, kind string, fileNames []string) error {
+	for _, fileName := range fileNames {
+		if IsDynamicPath(fileName) {
+			log.Logger().Debugf("ignoring %s file %s of environment %s in %s", kind, fileName, envName, path)
+			continue
+		}
+		valuesPath := fileName
+		if !filepath.IsAbs(valuesPath) {
+			valuesPath = filepath.Join(dir, fileName)
+		}
+		exists, err := files.FileExists(valuesPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if file exists %s", valuesPath)
+		}
+		if !exists {
+			o.Failures = append(o.Failures, verifiers.Failure{
+				Path:    path,
+				Kind:    "Environment",
+				Name:    envName,
+				Message: fmt.Sprintf("missing %s file %s", kind, fileName),
+			})
+		}
+	}
+	return nil
+}
+
+// IsDynamicPath returns true if the path is a go template or a remote URL so cannot be checked locally
+func IsDynamicPath(path string) bool {
+	return path == "" || strings.Contains(path, "{{") || strings.Contains(path, "::") || strings.Contains(path, "://")
+}
@@ -0,0 +1,55 @@
+package verifyenv_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/verifyenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmfileVerifyEnv(t *testing.T) {
+	testCases := []struct {
+		name     string
+		failures []string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "missing",
+			failures: []string{
+				"default: missing values file versionStream/src/fake-secrets.yaml.gotmpl",
+				"staging: missing values file staging-values.yaml",
+				"staging: missing secrets file staging-secrets.yaml",
+				"default: missing values file values.yaml",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, o := verifyenv.NewCmdHelmfileVerifyEnv()
+		o.Dir = filepath.Join("test_data", tc.name)
+
+		err := o.Run()
+		if len(tc.failures) == 0 {
+			require.NoError(t, err, "should not have failed for %s", tc.name)
+			assert.Empty(t, o.Failures, "failures for %s", tc.name)
+			continue
+		}
+		require.Error(t, err, "should have failed for %s", tc.name)
+
+		var failures []string
+		for _, f := range o.Failures {
+			failures = append(failures, f.Name+": "+f.Message)
+		}
+		assert.Equal(t, tc.failures, failures, "failures for %s", tc.name)
+	}
+}
+
+func TestIsDynamicPath(t *testing.T) {
+	assert.True(t, verifyenv.IsDynamicPath("values-{{ .Environment.Name }}.yaml"))
+	assert.True(t, verifyenv.IsDynamicPath("git::https://github.com/org/repo.git@values.yaml"))
+	assert.False(t, verifyenv.IsDynamicPath("../../jx-values.yaml"))
+}
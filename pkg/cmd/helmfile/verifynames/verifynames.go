@@ -0,0 +1,142 @@
+package verifynames
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/helmfiles"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/pkg/errors"
+	"github.com/roboll/helmfile/pkg/state"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// DefaultMaxLength the maximum length of a release name supported by helm
+	DefaultMaxLength = 53
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that the release names in the helmfile and any nested helmfiles are valid DNS-1123 labels and are not too long
+
+Release names are used to create the names of the generated resources so they must be valid kubernetes names.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the release names in the helmfile and any nested helmfiles
+		%s helmfile verify-names
+
+		# verifies the release names are no longer than 40 characters
+		%s helmfile verify-names --max-length 40
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir       string
+	Helmfile  string
+	MaxLength int
+	Failures  []verifiers.Failure
+}
+
+// NewCmdHelmfileVerifyNames creates a command object for the command
+func NewCmdHelmfileVerifyNames() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "verify-names",
+		Short:   "Verifies that the release names in the helmfile are valid DNS-1123 labels and are not too long",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory that contains the helmfile")
+	cmd.Flags().StringVarP(&o.Helmfile, "helmfile", "", "", "the helmfile to verify. If not specified defaults to 'helmfile.yaml' in the dir")
+	cmd.Flags().IntVarP(&o.MaxLength, "max-length", "", DefaultMaxLength, "the maximum length of a release name")
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.Helmfile == "" {
+		o.Helmfile = "helmfile.yaml"
+	}
+	if o.MaxLength <= 0 {
+		o.MaxLength = DefaultMaxLength
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	o.Failures = nil
+
+	hfs, err := helmfiles.GatherHelmfiles(o.Helmfile, o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather nested helmfiles")
+	}
+
+	processed := map[string]bool{}
+	for _, hf := range hfs {
+		path := hf.Filepath
+		if processed[path] {
+			continue
+		}
+		processed[path] = true
+
+		helmState := state.HelmState{}
+		err = yaml2s.LoadFile(path, &helmState)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load helmfile %s", path)
+		}
+		for i := range helmState.Releases {
+			release := &helmState.Releases[i]
+			ns := release.Namespace
+			if ns == "" {
+				ns = helmState.OverrideNamespace
+			}
+			for _, message := range o.verifyName(release.Name) {
+				o.Failures = append(o.Failures, verifiers.Failure{
+					Path:      path,
+					Kind:      "Release",
+					Name:      release.Name,
+					Namespace: ns,
+					Message:   message,
+				})
+			}
+		}
+	}
+	return verifiers.Report(o.Failures, "invalid release names")
+}
+
+// verifyName returns the reasons why the release name is invalid
+func (o *Options) verifyName(name string) []string {
+	if name == "" {
+		return []string{"missing release name"}
+	}
+	var messages []string
+	if len(name) > o.MaxLength {
+		messages = append(messages, fmt.Sprintf("must be no more than %d characters but was %d", o.MaxLength, len(name)))
+	}
+	for _, message := range validation.IsDNS1123Label(name) {
+		// the length is reported above using the configured maximum
+		if strings.HasPrefix(message, "must be no more than") {
+			continue
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
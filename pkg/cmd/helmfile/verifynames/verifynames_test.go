@@ -0,0 +1,57 @@
+package verifynames_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/verifynames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmfileVerifyNames(t *testing.T) {
+	testCases := []struct {
+		name      string
+		maxLength int
+		failures  []string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name:     "invalid",
+			failures: []string{"Lighthouse_Bot", "jx-build-controller-with-a-very-long-release-name-that-is-too-long"},
+		},
+		{
+			name:      "valid",
+			maxLength: 20,
+			failures:  []string{"jx-pipelines-visualizer"},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, o := verifynames.NewCmdHelmfileVerifyNames()
+		o.Dir = filepath.Join("test_data", tc.name)
+		if tc.maxLength > 0 {
+			o.MaxLength = tc.maxLength
+		}
+
+		err := o.Run()
+		if len(tc.failures) == 0 {
+			require.NoError(t, err, "should not have failed for %s", tc.name)
+			assert.Empty(t, o.Failures, "failures for %s", tc.name)
+			continue
+		}
+		require.Error(t, err, "should have failed for %s", tc.name)
+
+		var names []string
+		for _, f := range o.Failures {
+			assert.Equal(t, "jx", f.Namespace, "failure namespace for %s", f.Name)
+			t.Logf("%s: %s\n", f.Name, f.Message)
+			if len(names) == 0 || names[len(names)-1] != f.Name {
+				names = append(names, f.Name)
+			}
+		}
+		assert.Equal(t, tc.failures, names, "failed release names for %s with max length %d", tc.name, o.MaxLength)
+	}
+}
@@ -0,0 +1,108 @@
+package verifynamespaces
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/helmfiles"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/yaml2s"
+	"github.com/pkg/errors"
+	"github.com/roboll/helmfile/pkg/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that the releases in the helmfile and any nested helmfiles declare a namespace
+
+Releases without a namespace are installed into the default namespace of the helmfile or the current namespace which is often unexpected.
+Use --default to accept releases without a namespace if their helmfile has a top level default namespace.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the releases in the helmfile and any nested helmfiles declare a namespace
+		%s helmfile verify-namespaces
+
+		# accepts releases without a namespace in helmfiles with a default namespace
+		%s helmfile verify-namespaces --default
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir      string
+	Helmfile string
+	Default  bool
+	Failures []verifiers.Failure
+}
+
+// NewCmdHelmfileVerifyNamespaces creates a command object for the command
+func NewCmdHelmfileVerifyNamespaces() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "verify-namespaces",
+		Short:   "Verifies that the releases in the helmfile declare a namespace",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory that contains the helmfile")
+	cmd.Flags().StringVarP(&o.Helmfile, "helmfile", "", "", "the helmfile to verify. If not specified defaults to 'helmfile.yaml' in the dir")
+	cmd.Flags().BoolVarP(&o.Default, "default", "", false, "accept releases without a namespace if their helmfile has a top level default namespace")
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	if o.Helmfile == "" {
+		o.Helmfile = "helmfile.yaml"
+	}
+	o.Failures = nil
+
+	hfs, err := helmfiles.GatherHelmfiles(o.Helmfile, o.Dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to gather nested helmfiles")
+	}
+
+	processed := map[string]bool{}
+	for _, hf := range hfs {
+		path := hf.Filepath
+		if processed[path] {
+			continue
+		}
+		processed[path] = true
+
+		helmState := state.HelmState{}
+		err = yaml2s.LoadFile(path, &helmState)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load helmfile %s", path)
+		}
+		for i := range helmState.Releases {
+			release := &helmState.Releases[i]
+			if release.Namespace != "" {
+				continue
+			}
+			if o.Default && helmState.OverrideNamespace != "" {
+				continue
+			}
+			message := "missing namespace"
+			if helmState.OverrideNamespace != "" {
+				message = fmt.Sprintf("missing namespace so the helmfile default %s is used", helmState.OverrideNamespace)
+			}
+			o.Failures = append(o.Failures, verifiers.Failure{
+				Path:    path,
+				Kind:    "Release",
+				Name:    release.Name,
+				Message: message,
+			})
+		}
+	}
+	return verifiers.Report(o.Failures, "releases without a namespace")
+}
@@ -0,0 +1,50 @@
+package verifynamespaces_test
+
+import (
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/helmfile/verifynamespaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmfileVerifyNamespaces(t *testing.T) {
+	testCases := []struct {
+		name       string
+		useDefault bool
+		failures   []string
+	}{
+		{
+			name:     "strict",
+			failures: []string{"jx-build-controller", "tekton-dashboard"},
+		},
+		{
+			name:       "default",
+			useDefault: true,
+			failures:   []string{"tekton-dashboard"},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, o := verifynamespaces.NewCmdHelmfileVerifyNamespaces()
+		o.Dir = "test_data"
+		o.Default = tc.useDefault
+
+		err := o.Run()
+		require.Error(t, err, "should have failed for %s", tc.name)
+
+		var names []string
+		for _, f := range o.Failures {
+			t.Logf("%s: %s\n", f.Name, f.Message)
+			names = append(names, f.Name)
+		}
+		assert.Equal(t, tc.failures, names, "releases without a namespace for %s", tc.name)
+	}
+
+	_, o := verifynamespaces.NewCmdHelmfileVerifyNamespaces()
+	o.Dir = "test_data"
+	o.Helmfile = "helmfiles/jx/helmfile.yaml"
+	o.Default = true
+	err := o.Run()
+	require.NoError(t, err, "should not fail for a helmfile with a default namespace")
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/image/normalize"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/image/verifydigests"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/versionstreamer"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/giturl"
@@ -91,6 +94,9 @@ func NewCmdUpdateImage() (*cobra.Command, *Options) {
 	cmd.Flags().StringVarP(&o.SourceDir, "source-dir", "s", "content-root", "the directory to recursively look for the *.yaml files to modify")
 	o.Filter.AddFlags(cmd)
 	o.VersionStreamer.AddFlags(cmd)
+
+	cmd.AddCommand(cobras.SplitCommand(normalize.NewCmdImageNormalize()))
+	cmd.AddCommand(cobras.SplitCommand(verifydigests.NewCmdVerifyDigests()))
 	return cmd, o
 }
 
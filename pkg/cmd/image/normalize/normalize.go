@@ -0,0 +1,157 @@
+package normalize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/images"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// DockerHubRegistry the canonical name of the Docker Hub registry
+	DockerHubRegistry = "docker.io"
+
+	// DockerHubLibrary the repository of the official images on Docker Hub
+	DockerHubLibrary = "library"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Normalizes all the container image references in the kubernetes resources to include an explicit registry, repository and tag
+
+Every 'image' field is normalized so images in workloads, Tekton steps and sidecars and custom resources are all modified.
+
+For example 'nginx' becomes 'docker.io/library/nginx:latest' and 'myorg/myapp:1.0.0' becomes 'docker.io/myorg/myapp:1.0.0'. Images pinned to a digest are not given a tag and images using parameters or template expressions are not modified.
+`)
+
+	cmdExample = templates.Examples(`
+		# normalizes the image references in the config-root dir
+		%s image normalize --dir config-root
+
+		# normalizes the image references using a mirror as the default registry without adding a tag
+		%s image normalize --dir config-root --default-registry mirror.example.com --default-tag ""
+	`)
+
+	// registryAliases the alternative names of registries which are replaced by the canonical name
+	registryAliases = map[string]string{
+		"index.docker.io":      DockerHubRegistry,
+		"registry-1.docker.io": DockerHubRegistry,
+	}
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir             string
+	DefaultRegistry string
+	DefaultTag      string
+	Normalized      int
+}
+
+// NewCmdImageNormalize creates a command object for the command
+func NewCmdImageNormalize() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "normalize",
+		Short:   "Normalizes all the container image references in the kubernetes resources to include an explicit registry, repository and tag",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringVarP(&o.DefaultRegistry, "default-registry", "", DockerHubRegistry, "the registry of images which do not specify a registry")
+	cmd.Flags().StringVarP(&o.DefaultTag, "default-tag", "", "latest", "the tag of images which specify neither a tag nor a digest. If empty no tag is added")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	if o.DefaultRegistry == "" {
+		return options.MissingOption("default-registry")
+	}
+	o.Normalized = 0
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		modified := false
+		images.ForEachImageNode(node.YNode(), func(name string, imageNode *yaml.Node) {
+			image := imageNode.Value
+			normalized := NormalizeImage(image, o.DefaultRegistry, o.DefaultTag)
+			if normalized == image {
+				return
+			}
+			imageNode.Value = normalized
+			modified = true
+			o.Normalized++
+			log.Logger().Infof("normalized image %s to %s in file %s", info(image), info(normalized), path)
+		})
+		return modified, nil
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to normalize files in dir %s", o.Dir)
+	}
+	log.Logger().Infof("normalized %d image references", o.Normalized)
+	return nil
+}
+
+// NormalizeImage returns the image reference with an explicit registry, repository and tag. Images without a registry
+// use the default registry and official Docker Hub images use the library repository. The default tag is only added
+// if the image has neither a tag nor a digest. Empty images or images using parameters or template expressions are
+// returned unchanged
+func NormalizeImage(image, defaultRegistry, defaultTag string) string {
+	image = strings.TrimSpace(image)
+	if image == "" || strings.ContainsAny(image, "$({} ") {
+		return image
+	}
+	name := image
+	digest := ""
+	idx := strings.Index(name, "@")
+	if idx >= 0 {
+		digest = name[idx:]
+		name = name[:idx]
+	}
+	name, tag := images.SplitImageTag(name)
+
+	registry := defaultRegistry
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && IsRegistry(parts[0]) {
+		registry = parts[0]
+		name = parts[1]
+	}
+	if alias, ok := registryAliases[registry]; ok {
+		registry = alias
+	}
+	if registry == DockerHubRegistry && !strings.Contains(name, "/") {
+		name = DockerHubLibrary + "/" + name
+	}
+
+	answer := registry + "/" + name
+	if tag == "" && digest == "" {
+		tag = defaultTag
+	}
+	if tag != "" {
+		answer += ":" + tag
+	}
+	return answer + digest
+}
+
+// IsRegistry returns true if the first path segment of an image is a registry host rather than a repository
+func IsRegistry(segment string) bool {
+	return strings.ContainsAny(segment, ".:") || segment == "localhost"
+}
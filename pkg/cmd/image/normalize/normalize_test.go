@@ -0,0 +1,80 @@
+package normalize_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/image/normalize"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestNormalizeImage(t *testing.T) {
+	digest := "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	testCases := map[string]string{
+		"nginx":                               "docker.io/library/nginx:latest",
+		"nginx:1.19":                          "docker.io/library/nginx:1.19",
+		"nginx@" + digest:                     "docker.io/library/nginx@" + digest,
+		"myorg/myapp":                         "docker.io/myorg/myapp:latest",
+		"myorg/myapp:1.0.0":                   "docker.io/myorg/myapp:1.0.0",
+		"index.docker.io/myorg/myapp:1.0.0":   "docker.io/myorg/myapp:1.0.0",
+		"docker.io/nginx":                     "docker.io/library/nginx:latest",
+		"docker.io/library/nginx:1.19":        "docker.io/library/nginx:1.19",
+		"ghcr.io/myorg/myapp":                 "ghcr.io/myorg/myapp:latest",
+		"ghcr.io/myorg/myapp:1.0.0@" + digest: "ghcr.io/myorg/myapp:1.0.0@" + digest,
+		"localhost/myapp":                     "localhost/myapp:latest",
+		"localhost:5000/myorg/myapp:1.0.0":    "localhost:5000/myorg/myapp:1.0.0",
+		"gcr.io/jenkinsxio/jx-cli:3.1.0":      "gcr.io/jenkinsxio/jx-cli:3.1.0",
+		"$(params.builder)":                   "$(params.builder)",
+		"{{ .Values.image.repository }}":      "{{ .Values.image.repository }}",
+		"":                                    "",
+	}
+	for image, expected := range testCases {
+		got := normalize.NormalizeImage(image, normalize.DockerHubRegistry, "latest")
+		assert.Equal(t, expected, got, "normalized image %s", image)
+	}
+
+	assert.Equal(t, "mirror.example.com/nginx", normalize.NormalizeImage("nginx", "mirror.example.com", ""), "normalized image with a mirror and no default tag")
+	assert.Equal(t, "ghcr.io/myorg/myapp", normalize.NormalizeImage("ghcr.io/myorg/myapp", normalize.DockerHubRegistry, ""), "normalized image with no default tag")
+}
+
+func TestImageNormalize(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := files.CopyDirOverwrite("test_data", tmpDir)
+	require.NoError(t, err, "failed to copy test data to %s", tmpDir)
+
+	_, o := normalize.NewCmdImageNormalize()
+	o.Dir = tmpDir
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+	assert.Equal(t, 3, o.Normalized, "normalized images")
+
+	expected := map[string]map[string][]string{
+		"deployment.yaml": {
+			"docker.io/library/busybox:latest": {"spec", "template", "spec", "initContainers", "[name=init]", "image"},
+			"docker.io/myorg/app:1.2.3":        {"spec", "template", "spec", "containers", "[name=app]", "image"},
+			"ghcr.io/myorg/proxy:2.0.0":        {"spec", "template", "spec", "containers", "[name=proxy]", "image"},
+			"docker.io/library/nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000": {"spec", "template", "spec", "containers", "[name=pinned]", "image"},
+		},
+		"task.yaml": {
+			"$(params.builder)":             {"spec", "steps", "[name=build]", "image"},
+			"docker.io/library/golang:1.15": {"spec", "steps", "[name=test]", "image"},
+		},
+	}
+	for name, images := range expected {
+		path := filepath.Join(tmpDir, name)
+		node, err := yaml.ReadFile(path)
+		require.NoError(t, err, "failed to load %s", path)
+		for image, fields := range images {
+			assert.Equal(t, image, kyamls.GetStringField(node, path, fields...), "image in %s", name)
+		}
+	}
+
+	// lets check normalizing is idempotent
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command again")
+	assert.Equal(t, 0, o.Normalized, "normalized images on the second run")
+}
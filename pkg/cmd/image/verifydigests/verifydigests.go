@@ -0,0 +1,133 @@
+package verifydigests
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/images"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Verifies that all the container images in the kubernetes resources are pinned to a digest so deployments are reproducible
+
+Every 'image' field is checked so images in workloads, Tekton steps and sidecars and custom resources are all verified.
+
+Use --fix to resolve the digest of each image from its registry and pin the image to it. The tag is kept so the image remains readable
+`)
+
+	cmdExample = templates.Examples(`
+		# reports the images which are not pinned to a digest
+		%s image verify-digests --dir config-root
+
+		# pins the images to the digests of their tags
+		%s image verify-digests --dir config-root --fix
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir            string
+	Fix            bool
+	Ignore         []string
+	DigestResolver func(image string) (string, error)
+	Failures       []verifiers.Failure
+	Pinned         int
+}
+
+// NewCmdVerifyDigests creates a command object for the command
+func NewCmdVerifyDigests() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "verify-digests",
+		Short:   "Verifies that all the container images in the kubernetes resources are pinned to a digest",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.Fix, "fix", "", false, "resolves the digest of each image which is not pinned from its registry and pins the image to it")
+	cmd.Flags().StringArrayVarP(&o.Ignore, "ignore", "i", nil, "the image names which are not verified. Supports a trailing '*' wildcard")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	if o.DigestResolver == nil {
+		o.DigestResolver = ResolveDigest
+	}
+	o.Failures = nil
+	o.Pinned = 0
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		modified := false
+		var err error
+		images.ForEachImageNode(node.YNode(), func(name string, imageNode *yaml.Node) {
+			image := imageNode.Value
+			if err != nil || image == "" || strings.Contains(image, "@") || o.isIgnored(image) {
+				return
+			}
+			if !o.Fix {
+				if name != "" {
+					o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "container %s image %s is not pinned to a digest", name, image))
+					return
+				}
+				o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "image %s is not pinned to a digest", image))
+				return
+			}
+			digest, resolveErr := o.DigestResolver(image)
+			if resolveErr != nil {
+				err = errors.Wrapf(resolveErr, "failed to resolve the digest of image %s in file %s", image, path)
+				return
+			}
+			imageNode.Value = image + "@" + digest
+			modified = true
+			o.Pinned++
+			log.Logger().Infof("pinned image %s to %s in file %s", info(image), info(digest), path)
+		})
+		return modified, err
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	if o.Fix {
+		log.Logger().Infof("pinned %d images to their digests", o.Pinned)
+		return nil
+	}
+	return verifiers.Report(o.Failures, "images not pinned to a digest")
+}
+
+func (o *Options) isIgnored(image string) bool {
+	name, _ := images.SplitImageTag(image)
+	for _, pattern := range o.Ignore {
+		if stringhelpers.StringMatchesPattern(image, pattern) || stringhelpers.StringMatchesPattern(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveDigest resolves the digest of the image from its registry using the default docker credentials
+func ResolveDigest(image string) (string, error) {
+	return crane.Digest(image)
+}
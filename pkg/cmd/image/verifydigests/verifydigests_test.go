@@ -0,0 +1,119 @@
+package verifydigests_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/image/verifydigests"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRegistry starts an in memory registry with the images of the test data returning its host and the image digests
+func setupRegistry(t *testing.T, images ...string) (string, map[string]string) {
+	server := httptest.NewServer(registry.New())
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err, "failed to parse registry URL %s", server.URL)
+	host := u.Host
+
+	digests := map[string]string{}
+	for _, image := range images {
+		img, err := random.Image(1024, 1)
+		require.NoError(t, err, "failed to create random image")
+		ref := host + "/" + image
+		err = crane.Push(img, ref)
+		require.NoError(t, err, "failed to push image %s", ref)
+		digest, err := img.Digest()
+		require.NoError(t, err, "failed to get the digest of image %s", ref)
+		digests[ref] = digest.String()
+	}
+	return host, digests
+}
+
+// setupDir copies the test data to a temp dir using the host of the registry in the images
+func setupDir(t *testing.T, host string) string {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	data, err := ioutil.ReadFile(filepath.Join("test_data", "deployment.yaml"))
+	require.NoError(t, err, "failed to read test data")
+	text := strings.ReplaceAll(string(data), "REGISTRY", host)
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "deployment.yaml"), []byte(text), files.DefaultFileWritePermissions)
+	require.NoError(t, err, "failed to write test data")
+	return tmpDir
+}
+
+func TestVerifyDigests(t *testing.T) {
+	host, _ := setupRegistry(t)
+	dir := setupDir(t, host)
+
+	_, o := verifydigests.NewCmdVerifyDigests()
+	o.Dir = dir
+
+	err := o.Run()
+	require.Error(t, err, "should fail for images without a digest")
+
+	var messages []string
+	for _, f := range o.Failures {
+		assert.Equal(t, "Deployment", f.Kind)
+		assert.Equal(t, "myapp", f.Name)
+		messages = append(messages, f.Message)
+	}
+	assert.Equal(t, []string{
+		"container init image " + host + "/myorg/init:1.0.0 is not pinned to a digest",
+		"container app image " + host + "/myorg/app:1.2.3 is not pinned to a digest",
+	}, messages, "failures")
+
+	o.Ignore = []string{host + "/myorg/*"}
+	err = o.Run()
+	require.NoError(t, err, "should ignore the images")
+}
+
+func TestVerifyDigestsFix(t *testing.T) {
+	host, digests := setupRegistry(t, "myorg/init:1.0.0", "myorg/app:1.2.3")
+	dir := setupDir(t, host)
+
+	_, o := verifydigests.NewCmdVerifyDigests()
+	o.Dir = dir
+	o.Fix = true
+
+	err := o.Run()
+	require.NoError(t, err, "failed to pin the images")
+	assert.Equal(t, 2, o.Pinned, "pinned images")
+
+	path := filepath.Join(dir, "deployment.yaml")
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "failed to read %s", path)
+	text := string(data)
+	for image, digest := range digests {
+		assert.Contains(t, text, "image: "+image+"@"+digest, "should pin image %s", image)
+	}
+	assert.Contains(t, text, "image: "+host+"/myorg/sidecar:2.0.0@sha256:0000000000000000000000000000000000000000000000000000000000000000", "should not modify the pinned image")
+
+	// the images are now all pinned
+	o.Fix = false
+	err = o.Run()
+	require.NoError(t, err, "should pass once the images are pinned")
+}
+
+func TestVerifyDigestsFixMissingImage(t *testing.T) {
+	host, _ := setupRegistry(t, "myorg/init:1.0.0")
+	dir := setupDir(t, host)
+
+	_, o := verifydigests.NewCmdVerifyDigests()
+	o.Dir = dir
+	o.Fix = true
+
+	err := o.Run()
+	require.Error(t, err, "should fail if an image does not exist in the registry")
+	assert.Contains(t, err.Error(), host+"/myorg/app:1.2.3")
+}
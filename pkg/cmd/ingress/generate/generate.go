@@ -0,0 +1,235 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	nv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Generates an Ingress resource from a simplified list of host=service:port mappings
+
+Each mapping is of the form 'host=service:port' or 'host/path=service:port'. The port can be a number or a named port.
+Each path uses the 'Prefix' path type.
+`)
+
+	cmdExample = templates.Examples(`
+		# generates an Ingress for a single host
+		%s ingress generate --name myapp myapp.acme.com=myapp:8080
+
+		# generates an Ingress with multiple paths and TLS into a file
+		%s ingress generate --name myapp --tls-secret myapp-tls --file ingress.yaml myapp.acme.com=myapp:http myapp.acme.com/api=myapp-api:8080
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Name         string
+	Namespace    string
+	IngressClass string
+	TLSSecret    string
+	OutFile      string
+	Mappings     []string
+	Annotations  []string
+	Ingress      *nv1.Ingress
+}
+
+// NewCmdIngressGenerate creates a command object for the command
+func NewCmdIngressGenerate() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "generate",
+		Short:   "Generates an Ingress resource from a simplified list of host=service:port mappings",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			o.Mappings = append(o.Mappings, args...)
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Name, "name", "n", "", "the name of the Ingress")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "", "", "the namespace of the Ingress")
+	cmd.Flags().StringVarP(&o.IngressClass, "ingress-class", "", "", "the ingress class of the Ingress")
+	cmd.Flags().StringVarP(&o.TLSSecret, "tls-secret", "", "", "the name of the TLS Secret. If specified TLS is enabled for all the hosts")
+	cmd.Flags().StringVarP(&o.OutFile, "file", "f", "", "the file to write the Ingress to. If not specified the Ingress is written to the console")
+	cmd.Flags().StringArrayVarP(&o.Mappings, "rule", "r", nil, "the host=service:port mappings of the Ingress")
+	cmd.Flags().StringArrayVarP(&o.Annotations, "annotation", "a", nil, "the name=value annotations of the Ingress")
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.Name == "" {
+		return options.MissingOption("name")
+	}
+	if len(o.Mappings) == 0 {
+		return errors.Errorf("no host=service:port mappings specified")
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+
+	o.Ingress, err = o.createIngress()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create Ingress")
+	}
+
+	data, err := ToYAML(o.Ingress)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal Ingress to YAML")
+	}
+	if o.OutFile == "" {
+		fmt.Fprint(os.Stdout, string(data))
+		return nil
+	}
+
+	dir := filepath.Dir(o.OutFile)
+	err = os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	err = ioutil.WriteFile(o.OutFile, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", o.OutFile)
+	}
+	log.Logger().Infof("generated Ingress %s to %s", termcolor.ColorInfo(o.Name), termcolor.ColorInfo(o.OutFile))
+	return nil
+}
+
+func (o *Options) createIngress() (*nv1.Ingress, error) {
+	ing := &nv1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "networking.k8s.io/v1",
+			Kind:       "Ingress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      o.Name,
+			Namespace: o.Namespace,
+		},
+	}
+	for _, a := range o.Annotations {
+		paths := strings.SplitN(a, "=", 2)
+		if len(paths) != 2 || paths[0] == "" {
+			return nil, errors.Errorf("invalid annotation %s should be of the form name=value", a)
+		}
+		if ing.Annotations == nil {
+			ing.Annotations = map[string]string{}
+		}
+		ing.Annotations[paths[0]] = paths[1]
+	}
+	if o.IngressClass != "" {
+		ing.Spec.IngressClassName = &o.IngressClass
+	}
+
+	var hosts []string
+	for _, m := range o.Mappings {
+		host, path, backend, err := ParseMapping(m)
+		if err != nil {
+			return nil, err
+		}
+		pathType := nv1.PathTypePrefix
+		rule := findOrCreateRule(ing, host)
+		rule.HTTP.Paths = append(rule.HTTP.Paths, nv1.HTTPIngressPath{
+			Path:     path,
+			PathType: &pathType,
+			Backend:  backend,
+		})
+		if stringhelpers.StringArrayIndex(hosts, host) < 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	if o.TLSSecret != "" {
+		ing.Spec.TLS = []nv1.IngressTLS{
+			{
+				Hosts:      hosts,
+				SecretName: o.TLSSecret,
+			},
+		}
+	}
+	return ing, nil
+}
+
+// ParseMapping parses a mapping of the form 'host[/path]=service:port'
+func ParseMapping(mapping string) (string, string, nv1.IngressBackend, error) {
+	backend := nv1.IngressBackend{}
+	paths := strings.SplitN(mapping, "=", 2)
+	if len(paths) != 2 {
+		return "", "", backend, errors.Errorf("invalid mapping %s should be of the form host=service:port", mapping)
+	}
+	host := paths[0]
+	path := "/"
+	idx := strings.Index(host, "/")
+	if idx >= 0 {
+		path = host[idx:]
+		host = host[:idx]
+	}
+	servicePort := strings.SplitN(paths[1], ":", 2)
+	if host == "" || len(servicePort) != 2 || servicePort[0] == "" || servicePort[1] == "" {
+		return "", "", backend, errors.Errorf("invalid mapping %s should be of the form host=service:port", mapping)
+	}
+	service := &nv1.IngressServiceBackend{
+		Name: servicePort[0],
+	}
+	port, err := strconv.Atoi(servicePort[1])
+	if err == nil {
+		service.Port.Number = int32(port)
+	} else {
+		service.Port.Name = servicePort[1]
+	}
+	backend.Service = service
+	return host, path, backend, nil
+}
+
+// ToYAML marshals the Ingress to YAML omitting the empty status and creation timestamp
+func ToYAML(ing *nv1.Ingress) ([]byte, error) {
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ing)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to convert Ingress to unstructured")
+	}
+	unstructured.RemoveNestedField(m, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(m, "status")
+	return yaml.Marshal(m)
+}
+
+func findOrCreateRule(ing *nv1.Ingress, host string) *nv1.IngressRule {
+	for i := range ing.Spec.Rules {
+		if ing.Spec.Rules[i].Host == host {
+			return &ing.Spec.Rules[i]
+		}
+	}
+	ing.Spec.Rules = append(ing.Spec.Rules, nv1.IngressRule{
+		Host: host,
+		IngressRuleValue: nv1.IngressRuleValue{
+			HTTP: &nv1.HTTPIngressRuleValue{},
+		},
+	})
+	return &ing.Spec.Rules[len(ing.Spec.Rules)-1]
+}
@@ -0,0 +1,65 @@
+package generate_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/ingress/generate"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngressGenerate(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	testCases := []struct {
+		name string
+		opts generate.Options
+	}{
+		{
+			name: "simple",
+			opts: generate.Options{
+				Name:     "hook",
+				Mappings: []string{"hook.acme.com=hook:80"},
+			},
+		},
+		{
+			name: "tls",
+			opts: generate.Options{
+				Name:         "myapp",
+				Namespace:    "jx",
+				IngressClass: "nginx",
+				TLSSecret:    "myapp-tls",
+				Annotations:  []string{"nginx.ingress.kubernetes.io/proxy-body-size=10m"},
+				Mappings: []string{
+					"myapp.acme.com=myapp:http",
+					"docs.acme.com=docs:80",
+					"myapp.acme.com/api=myapp-api:8080",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		o := tc.opts
+		o.OutFile = filepath.Join(tmpDir, tc.name+".yaml")
+		err = o.Run()
+		require.NoError(t, err, "failed to ingress generate for %s", tc.name)
+
+		testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", tc.name+".yaml"), o.OutFile, "generated Ingress for "+tc.name)
+	}
+}
+
+func TestIngressGenerateInvalidMappings(t *testing.T) {
+	for _, m := range []string{"acme.com", "acme.com=svc", "acme.com=:80", "=svc:80", "acme.com=svc:"} {
+		_, _, _, err := generate.ParseMapping(m)
+		assert.Error(t, err, "should fail to parse mapping %s", m)
+	}
+
+	o := &generate.Options{Mappings: []string{"acme.com=svc:80"}}
+	err := o.Run()
+	assert.Error(t, err, "should fail without a name")
+}
@@ -20,6 +20,7 @@ var (
 	cmdLong = templates.LongDesc(`
 		Display the binary plugins
 
+The 'list' alias of this command has been replaced by the 'plugin list' command which also displays the installed versions and paths of the plugins. Use 'plugin get' or its 'ls' alias for this output
 `)
 
 	cmdExample = templates.Examples(`
@@ -37,7 +38,7 @@ func NewCmdPluginGet() (*cobra.Command, *Options) {
 		Short:   "Display the binary plugins",
 		Long:    cmdLong,
 		Example: cmdExample,
-		Aliases: []string{"list", "ls"},
+		Aliases: []string{"ls"},
 		Run: func(cmd *cobra.Command, args []string) {
 			err := o.Run()
 			helper.CheckErr(err)
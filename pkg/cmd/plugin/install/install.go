@@ -0,0 +1,177 @@
+package install
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Installs the helm, helmfile, kpt and kubectl binary plugins
+
+Use --dry-run to display the URL each plugin would be downloaded from and the path it would be installed to without downloading anything
+
+Set $JX_PLUGIN_MIRROR to the base URL of a mirror such as an internal Artifactory to download the plugins from it rather than upstream. The mirror must have the same path layout as upstream
+
+Set $GITHUB_TOKEN to authenticate the downloads from github.com to avoid the rate limits of anonymous requests. The token is never sent to other hosts
+`)
+
+	cmdExample = templates.Examples(`
+		# installs the binary plugins
+		%s plugins install
+
+		# displays where the binary plugins would be downloaded from and installed to
+		%s plugins install --dry-run
+	`)
+)
+
+// Install a plugin and the dir it is installed into
+type Install struct {
+	Plugin jenkinsv1.Plugin
+	BinDir string
+}
+
+// Options the options for the command
+type Options struct {
+	DryRun   bool
+	Out      io.Writer
+	Installs []Install
+}
+
+// NewCmdPluginInstall creates a command object for the command
+func NewCmdPluginInstall() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "install",
+		Short:   "Installs the helm, helmfile, kpt and kubectl binary plugins",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "displays the download URL and install path of each plugin without downloading it")
+	return cmd, o
+}
+
+// Validate verifies the options and defaults the plugins to install
+func (o *Options) Validate() error {
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if len(o.Installs) > 0 {
+		return nil
+	}
+	var err error
+	o.Installs, err = DefaultInstalls()
+	return err
+}
+
+// DefaultInstalls returns the helm, helmfile, kpt and kubectl plugins at their default versions and the dirs they are installed into
+func DefaultInstalls() ([]Install, error) {
+	return PluginInstalls(
+		plugins.CreateHelmPlugin(plugins.HelmVersion),
+		plugins.CreateHelmfilePlugin(plugins.HelmfileVersion),
+		plugins.CreateKptPlugin(plugins.KptVersion),
+		plugins.CreateKubectlPlugin(plugins.KubectlVersion),
+	)
+}
+
+// AllInstalls returns the default installs followed by the installs of any other plugins in plugins.Plugins
+func AllInstalls() ([]Install, error) {
+	answer, err := DefaultInstalls()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plugins.Plugins {
+		found := false
+		for i := range answer {
+			if answer[i].Plugin.Name == p.Name {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		installs, err := PluginInstalls(p)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, installs...)
+	}
+	return answer, nil
+}
+
+// PluginInstalls returns the installs of the plugins into the dirs their binaries are resolved from
+func PluginInstalls(ps ...jenkinsv1.Plugin) ([]Install, error) {
+	var answer []Install
+	for _, p := range ps {
+		binDir, err := plugins.BinDirFor(p.Spec.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find plugin home dir")
+		}
+		answer = append(answer, Install{Plugin: p, BinDir: binDir})
+	}
+	return answer, nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	if o.DryRun {
+		return o.displayInstalls()
+	}
+	for i := range o.Installs {
+		p := o.Installs[i].Plugin
+		path, err := plugins.EnsurePluginInstalled(p, o.Installs[i].BinDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to ensure plugin is installed %s", p.Name)
+		}
+		log.Logger().Infof("installed plugin %s version %s at %s", termcolor.ColorInfo(p.Name), termcolor.ColorInfo(p.Spec.Version), termcolor.ColorInfo(path))
+	}
+	return nil
+}
+
+func (o *Options) displayInstalls() error {
+	t := table.CreateTable(o.Out)
+	t.AddRow("NAME", "VERSION", "URL", "PATH")
+	for i := range o.Installs {
+		p := o.Installs[i].Plugin
+		u, err := extensions.FindPluginUrl(p.Spec)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the download URL of plugin %s", p.Name)
+		}
+		t.AddRow(p.Name, p.Spec.Version, u, InstallPath(p, o.Installs[i].BinDir))
+	}
+	t.Render()
+	return nil
+}
+
+// InstallPath returns the path the plugin is installed to in the bin dir
+func InstallPath(plugin jenkinsv1.Plugin, binDir string) string {
+	name := fmt.Sprintf("%s-%s", plugin.Spec.Name, plugin.Spec.Version)
+	if plugins.CompressPluginsFunc(os.Getenv) {
+		name += plugins.CompressedExtension
+	}
+	return filepath.Join(binDir, name)
+}
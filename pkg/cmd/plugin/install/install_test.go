@@ -0,0 +1,70 @@
+package install_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/install"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginInstallDryRun(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create temp dir")
+	defer os.RemoveAll(tmpDir)
+
+	oldHome := os.Getenv("JX_GITOPS_HOME")
+	os.Setenv("JX_GITOPS_HOME", tmpDir)
+	defer os.Setenv("JX_GITOPS_HOME", oldHome)
+
+	binDir, err := plugins.PluginBinDir()
+	require.NoError(t, err)
+	gitopsBinDir, err := plugins.GitopsPluginBinDir()
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	_, o := install.NewCmdPluginInstall()
+	o.DryRun = true
+	o.Out = out
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	text := out.String()
+	t.Logf("%s\n", text)
+
+	expected := []struct {
+		plugin jenkinsv1.Plugin
+		binDir string
+	}{
+		{plugin: plugins.CreateHelmPlugin(plugins.HelmVersion), binDir: binDir},
+		{plugin: plugins.CreateHelmfilePlugin(plugins.HelmfileVersion), binDir: binDir},
+		{plugin: plugins.CreateKptPlugin(plugins.KptVersion), binDir: gitopsBinDir},
+		{plugin: plugins.CreateKubectlPlugin(plugins.KubectlVersion), binDir: gitopsBinDir},
+	}
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	require.Len(t, lines, len(expected)+1, "should display a header and a line per plugin")
+	for i, e := range expected {
+		u, err := extensions.FindPluginUrl(e.plugin.Spec)
+		require.NoError(t, err, "failed to find URL of plugin %s", e.plugin.Name)
+
+		fields := strings.Fields(lines[i+1])
+		require.Len(t, fields, 4, "fields of line %s", lines[i+1])
+		assert.Equal(t, e.plugin.Name, fields[0], "name")
+		assert.Equal(t, e.plugin.Spec.Version, fields[1], "version of %s", e.plugin.Name)
+		assert.Equal(t, u, fields[2], "URL of %s", e.plugin.Name)
+		assert.Equal(t, filepath.Join(e.binDir, e.plugin.Spec.Name+"-"+e.plugin.Spec.Version), fields[3], "path of %s", e.plugin.Name)
+	}
+
+	files, err := ioutil.ReadDir(binDir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "should not download any plugins")
+}
@@ -0,0 +1,166 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/install"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// OutputJSON writes the plugins to the output as JSON
+	OutputJSON = "json"
+)
+
+var (
+	// Outputs the supported output formats
+	Outputs = []string{OutputJSON}
+
+	cmdLong = templates.LongDesc(`
+		Lists the binary plugins with their default version, the versions installed on disk and the path of the default version
+
+This command replaces the 'list' alias of the 'plugin get' command. It lists every plugin displayed by 'plugin get' and its NAME and VERSION columns come first so scripts which parse them are unaffected. Use 'plugin get' for the previous output
+`)
+
+	cmdExample = templates.Examples(`
+		# lists the binary plugins and their installed versions
+		%s plugins list
+
+		# lists the binary plugins as JSON
+		%s plugins list --output json
+	`)
+)
+
+// PluginInfo the default and installed versions of a plugin
+type PluginInfo struct {
+	Name      string   `json:"name"`
+	Version   string   `json:"version"`
+	Installed []string `json:"installed"`
+	Path      string   `json:"path"`
+}
+
+// Options the options for the command
+type Options struct {
+	Output   string
+	Out      io.Writer
+	Installs []install.Install
+	Results  []PluginInfo
+}
+
+// NewCmdPluginList creates a command object for the command
+func NewCmdPluginList() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Lists the binary plugins with their default and installed versions",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "the output format. Values: "+strings.Join(Outputs, ", ")+". Defaults to a table")
+	return cmd, o
+}
+
+// Validate verifies the options and defaults the plugins to list
+func (o *Options) Validate() error {
+	if o.Output != "" && o.Output != OutputJSON {
+		return options.InvalidOption("output", o.Output, Outputs)
+	}
+	if o.Out == nil {
+		o.Out = os.Stdout
+	}
+	if len(o.Installs) > 0 {
+		return nil
+	}
+	var err error
+	o.Installs, err = install.AllInstalls()
+	return err
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	o.Results = nil
+	for i := range o.Installs {
+		p := o.Installs[i].Plugin
+		binDir := o.Installs[i].BinDir
+		installed, err := InstalledVersions(p.Spec.Name, binDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find the installed versions of plugin %s", p.Name)
+		}
+		o.Results = append(o.Results, PluginInfo{
+			Name:      p.Name,
+			Version:   p.Spec.Version,
+			Installed: installed,
+			Path:      install.InstallPath(p, binDir),
+		})
+	}
+
+	if o.Output == OutputJSON {
+		data, err := json.MarshalIndent(o.Results, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal the plugins to JSON")
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		return err
+	}
+
+	t := table.CreateTable(o.Out)
+	t.AddRow("NAME", "VERSION", "INSTALLED", "PATH")
+	for _, r := range o.Results {
+		installed := strings.Join(r.Installed, ",")
+		if installed == "" {
+			installed = "-"
+		}
+		t.AddRow(r.Name, r.Version, installed, r.Path)
+	}
+	t.Render()
+	return nil
+}
+
+// InstalledVersions returns the sorted versions of the plugin installed in the bin dir including any compressed binaries
+func InstalledVersions(name, binDir string) ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(binDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read dir %s", binDir)
+	}
+	prefix := name + "-"
+	versions := []string{}
+	for _, f := range fileInfos {
+		fileName := f.Name()
+		if f.IsDir() || !strings.HasPrefix(fileName, prefix) {
+			continue
+		}
+		version := strings.TrimSuffix(strings.TrimPrefix(fileName, prefix), plugins.CompressedExtension)
+		if version == "" || strings.Contains(version, ".tmp-") || stringhelpers.StringArrayIndex(versions, version) >= 0 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
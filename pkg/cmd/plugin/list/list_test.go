@@ -0,0 +1,116 @@
+package list_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/install"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/list"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginList(t *testing.T) {
+	binDir := t.TempDir()
+	gitopsBinDir := t.TempDir()
+
+	for _, name := range []string{"helm-3.4.0", "helm-" + plugins.HelmVersion, "helmfile-0.1.0.gz", "kubectl-1.16.15.tmp-123"} {
+		err := ioutil.WriteFile(filepath.Join(binDir, name), []byte("binary"), 0755)
+		require.NoError(t, err, "failed to write %s", name)
+	}
+	installs := []install.Install{
+		{Plugin: plugins.CreateHelmPlugin(plugins.HelmVersion), BinDir: binDir},
+		{Plugin: plugins.CreateHelmfilePlugin(plugins.HelmfileVersion), BinDir: binDir},
+		{Plugin: plugins.CreateKubectlPlugin(plugins.KubectlVersion), BinDir: gitopsBinDir},
+	}
+	expected := []list.PluginInfo{
+		{
+			Name:      plugins.HelmPluginName,
+			Version:   plugins.HelmVersion,
+			Installed: []string{"3.4.0", plugins.HelmVersion},
+			Path:      install.InstallPath(installs[0].Plugin, binDir),
+		},
+		{
+			Name:      plugins.HelmfilePluginName,
+			Version:   plugins.HelmfileVersion,
+			Installed: []string{"0.1.0"},
+			Path:      install.InstallPath(installs[1].Plugin, binDir),
+		},
+		{
+			Name:      plugins.KubectlPluginName,
+			Version:   plugins.KubectlVersion,
+			Installed: []string{},
+			Path:      install.InstallPath(installs[2].Plugin, gitopsBinDir),
+		},
+	}
+
+	out := &bytes.Buffer{}
+	_, o := list.NewCmdPluginList()
+	o.Out = out
+	o.Installs = installs
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+	assert.Equal(t, expected, o.Results, "results")
+
+	text := out.String()
+	t.Logf("%s\n", text)
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	require.Len(t, lines, len(installs)+1, "should display a header and a line per plugin")
+	assert.Equal(t, []string{plugins.HelmPluginName, plugins.HelmVersion, "3.4.0," + plugins.HelmVersion, expected[0].Path}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{plugins.KubectlPluginName, plugins.KubectlVersion, "-", expected[2].Path}, strings.Fields(lines[3]))
+
+	out.Reset()
+	o.Output = list.OutputJSON
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command with JSON output")
+
+	var results []list.PluginInfo
+	err = json.Unmarshal(out.Bytes(), &results)
+	require.NoError(t, err, "failed to parse JSON output %s", out.String())
+	assert.Equal(t, expected, results, "JSON results")
+}
+
+func TestPluginListInvalidOutput(t *testing.T) {
+	_, o := list.NewCmdPluginList()
+	o.Output = "yaml"
+	o.Installs = []install.Install{{Plugin: plugins.CreateHelmPlugin(plugins.HelmVersion), BinDir: os.TempDir()}}
+	err := o.Run()
+	require.Error(t, err, "should fail for an invalid output")
+}
+
+func TestPluginListIncludesGetPlugins(t *testing.T) {
+	old, hasOld := os.LookupEnv("JX_GITOPS_HOME")
+	defer func() {
+		if hasOld {
+			os.Setenv("JX_GITOPS_HOME", old)
+		} else {
+			os.Unsetenv("JX_GITOPS_HOME")
+		}
+	}()
+	os.Setenv("JX_GITOPS_HOME", t.TempDir())
+
+	out := &bytes.Buffer{}
+	_, o := list.NewCmdPluginList()
+	o.Out = out
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	// lets check every plugin displayed by 'plugin get' is listed with the same NAME and VERSION columns
+	rows := map[string]string{}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		require.True(t, len(fields) >= 2, "invalid line %s", line)
+		rows[fields[0]] = fields[1]
+	}
+	for _, p := range plugins.Plugins {
+		assert.Equal(t, p.Spec.Version, rows[p.Name], "version of plugin %s", p.Name)
+	}
+	assert.Equal(t, plugins.KustomizeVersion, rows[plugins.KustomizePluginName], "version of plugin %s", plugins.KustomizePluginName)
+}
@@ -2,6 +2,8 @@ package plugin
 
 import (
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/get"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/install"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/list"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/upgrade"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
@@ -11,8 +13,9 @@ import (
 // NewCmdPlugin creates the new command
 func NewCmdPlugin() *cobra.Command {
 	command := &cobra.Command{
-		Use:   "plugin",
-		Short: "Commands for working with plugins",
+		Use:     "plugin",
+		Aliases: []string{"plugins"},
+		Short:   "Commands for working with plugins",
 		Run: func(command *cobra.Command, args []string) {
 			err := command.Help()
 			if err != nil {
@@ -21,6 +24,8 @@ func NewCmdPlugin() *cobra.Command {
 		},
 	}
 	command.AddCommand(cobras.SplitCommand(get.NewCmdPluginGet()))
+	command.AddCommand(cobras.SplitCommand(install.NewCmdPluginInstall()))
+	command.AddCommand(cobras.SplitCommand(list.NewCmdPluginList()))
 	command.AddCommand(cobras.SplitCommand(upgrade.NewCmdUpgradePlugins()))
 	return command
 }
@@ -9,12 +9,13 @@ import (
 
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
-	"github.com/jenkins-x/jx-helpers/v3/pkg/homedir"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
@@ -24,11 +25,16 @@ import (
 var (
 	cmdPluginsLong = templates.LongDesc(`
 		Upgrades the binary plugins for this plugin
+
+Each plugin is downloaded again even if it is already cached so that any stale binary is replaced
 `)
 
 	cmdPluginsExample = templates.Examples(`
 		# upgrades your plugin binaries for gitops
 		%s plugins upgrade
+
+		# upgrades a single plugin to a specific version
+		%s plugins upgrade --plugin helm --version 3.5.4
 	`)
 )
 
@@ -36,6 +42,9 @@ var (
 type Options struct {
 	CommandRunner cmdrunner.CommandRunner
 	Path          string
+	Plugin        string
+	Version       string
+	Installer     plugins.Installer
 }
 
 // NewCmdUpgrade creates a command object for the command
@@ -46,25 +55,56 @@ func NewCmdUpgradePlugins() (*cobra.Command, *Options) {
 		Use:     "upgrade",
 		Short:   "Upgrades the binary plugins for this plugin",
 		Long:    cmdPluginsLong,
-		Example: fmt.Sprintf(cmdPluginsExample, rootcmd.BinaryName),
+		Example: fmt.Sprintf(cmdPluginsExample, rootcmd.BinaryName, rootcmd.BinaryName),
 		Run: func(cmd *cobra.Command, args []string) {
 			err := o.Run()
 			helper.CheckErr(err)
 		},
 	}
 	cmd.Flags().StringVarP(&o.Path, "path", "", "", "creates a symlink to the binary plugins in this bin path dir")
+	cmd.Flags().StringVarP(&o.Plugin, "plugin", "", "", "the name of a single plugin to upgrade. Values: "+strings.Join(plugins.PluginNames, ", "))
+	cmd.Flags().StringVarP(&o.Version, "version", "", "", "the version of the plugin to upgrade to. Requires --plugin. Defaults to the default version of the plugin")
 	return cmd, o
 }
 
+// Validate verifies the options
+func (o *Options) Validate() error {
+	if o.Version != "" && o.Plugin == "" {
+		return options.MissingOption("plugin")
+	}
+	if o.Plugin != "" && stringhelpers.StringArrayIndex(plugins.PluginNames, o.Plugin) < 0 {
+		return options.InvalidOption("plugin", o.Plugin, plugins.PluginNames)
+	}
+	if o.CommandRunner == nil {
+		o.CommandRunner = cmdrunner.DefaultCommandRunner
+	}
+	if o.Installer == nil {
+		o.Installer = plugins.ReinstallPlugin
+	}
+	return nil
+}
+
+// Plugins returns the plugins to upgrade
+func (o *Options) Plugins() ([]jenkinsv1.Plugin, error) {
+	if o.Plugin == "" {
+		return plugins.Plugins, nil
+	}
+	p, err := plugins.CreatePlugin(o.Plugin, o.Version)
+	if err != nil {
+		return nil, err
+	}
+	return []jenkinsv1.Plugin{p}, nil
+}
+
 // Run implements the command
 func (o *Options) Run() error {
-	pluginBinDir, err := homedir.DefaultPluginBinDir()
+	err := o.Validate()
 	if err != nil {
-		return errors.Wrap(err, "failed to find plugin bin directory")
+		return errors.Wrapf(err, "failed to validate options")
 	}
-
-	if o.CommandRunner == nil {
-		o.CommandRunner = cmdrunner.DefaultCommandRunner
+	upgrades, err := o.Plugins()
+	if err != nil {
+		return err
 	}
 	if o.Path != "" {
 		err = os.MkdirAll(o.Path, files.DefaultDirWritePermissions)
@@ -73,12 +113,17 @@ func (o *Options) Run() error {
 		}
 	}
 
-	for k := range plugins.Plugins {
-		p := plugins.Plugins[k]
-		log.Logger().Infof("checking binary jx plugin %s version %s is installed", termcolor.ColorInfo(p.Name), termcolor.ColorInfo(p.Spec.Version))
-		fileName, err := extensions.EnsurePluginInstalled(p, pluginBinDir)
+	for k := range upgrades {
+		p := upgrades[k]
+		// lets replace the binary in the dir it is resolved from when jx-gitops runs the plugin
+		pluginBinDir, err := plugins.BinDirFor(p.Spec.Name)
+		if err != nil {
+			return errors.Wrap(err, "failed to find plugin bin directory")
+		}
+		log.Logger().Infof("upgrading binary jx plugin %s to version %s", termcolor.ColorInfo(p.Name), termcolor.ColorInfo(p.Spec.Version))
+		fileName, err := o.Installer(p, pluginBinDir)
 		if err != nil {
-			return errors.Wrapf(err, "failed to ensure plugin is installed %s", p.Name)
+			return errors.Wrapf(err, "failed to upgrade plugin %s", p.Name)
 		}
 
 		if o.Path != "" {
@@ -0,0 +1,90 @@
+package upgrade_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin/upgrade"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cmdrunner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgradeSinglePlugin(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldHome := os.Getenv("JX_GITOPS_HOME")
+	os.Setenv("JX_GITOPS_HOME", tmpDir)
+	defer os.Setenv("JX_GITOPS_HOME", oldHome)
+
+	var upgraded []jenkinsv1.Plugin
+	_, o := upgrade.NewCmdUpgradePlugins()
+	o.Plugin = plugins.KubectlPluginName
+	o.Version = "1.20.0"
+	o.Installer = func(p jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+		upgraded = append(upgraded, p)
+		return filepath.Join(pluginBinDir, p.Spec.Name+"-"+p.Spec.Version), nil
+	}
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	require.Len(t, upgraded, 1, "upgraded plugins")
+	assert.Equal(t, plugins.KubectlPluginName, upgraded[0].Name, "name")
+	assert.Equal(t, "1.20.0", upgraded[0].Spec.Version, "version")
+}
+
+func TestUpgradeReplacesResolvedBinaries(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldHome := os.Getenv("JX_GITOPS_HOME")
+	os.Setenv("JX_GITOPS_HOME", tmpDir)
+	defer os.Setenv("JX_GITOPS_HOME", oldHome)
+
+	_, o := upgrade.NewCmdUpgradePlugins()
+	o.CommandRunner = func(c *cmdrunner.Command) (string, error) {
+		return "", nil
+	}
+	o.Installer = func(p jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+		path := filepath.Join(pluginBinDir, p.Spec.Name+"-"+p.Spec.Version)
+		return path, ioutil.WriteFile(path, []byte("upgraded"), 0755)
+	}
+	err := o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	// lets check the binaries jx-gitops runs are the ones which were upgraded
+	binaries := map[string]func(string) (string, error){
+		plugins.HelmPluginName:      plugins.GetHelmBinary,
+		plugins.HelmfilePluginName:  plugins.GetHelmfileBinary,
+		plugins.KustomizePluginName: plugins.GetKustomizeBinary,
+		plugins.KubectlPluginName:   plugins.GetKubectlBinary,
+		plugins.KappPluginName:      plugins.GetKappBinary,
+	}
+	for name, fn := range binaries {
+		path, err := fn("")
+		require.NoError(t, err, "failed to get the binary of plugin %s", name)
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err, "failed to read %s", path)
+		assert.Equal(t, "upgraded", string(data), "binary of plugin %s at %s", name, path)
+	}
+}
+
+func TestUpgradeInvalidOptions(t *testing.T) {
+	_, o := upgrade.NewCmdUpgradePlugins()
+	o.Version = "1.20.0"
+	err := o.Run()
+	require.Error(t, err, "should fail for --version without --plugin")
+
+	_, o = upgrade.NewCmdUpgradePlugins()
+	o.Plugin = "cheese"
+	err = o.Run()
+	require.Error(t, err, "should fail for an unknown plugin")
+}
+
+func TestUpgradePluginsDefaults(t *testing.T) {
+	_, o := upgrade.NewCmdUpgradePlugins()
+	results, err := o.Plugins()
+	require.NoError(t, err, "failed to find the plugins")
+	assert.Equal(t, plugins.Plugins, results, "should upgrade the default plugins")
+}
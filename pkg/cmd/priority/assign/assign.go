@@ -0,0 +1,189 @@
+package assign
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Assigns a PriorityClass to the workloads in a dir by setting the priorityClassName of their pod template
+
+The workloads are the Deployment, StatefulSet, DaemonSet, ReplicaSet, Job and CronJob resources which match the filters.
+
+Use --generate to also generate the PriorityClass resource into the output dir as $name-priorityclass.yaml
+`)
+
+	cmdExample = templates.Examples(`
+		# assigns the high priority class to the workloads with a label
+		%s priority assign --dir config-root --selector tier=critical --class high
+
+		# assigns the priority class and generates the PriorityClass resource
+		%s priority assign --dir config-root --selector tier=critical --class high --generate --value 100000
+	`)
+
+	// podSpecPaths the path to the pod spec of each workload kind
+	podSpecPaths = map[string][]string{
+		"Deployment":  {"spec", "template", "spec"},
+		"StatefulSet": {"spec", "template", "spec"},
+		"DaemonSet":   {"spec", "template", "spec"},
+		"ReplicaSet":  {"spec", "template", "spec"},
+		"Job":         {"spec", "template", "spec"},
+		"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+	}
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir           string
+	ClassName     string
+	Generate      bool
+	OutDir        string
+	Value         int32
+	GlobalDefault bool
+	Description   string
+	Assigned      []string
+	Generated     string
+}
+
+// NewCmdPriorityAssign creates a command object for the command
+func NewCmdPriorityAssign() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "assign",
+		Short:   "Assigns a PriorityClass to the workloads in a dir",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringVarP(&o.ClassName, "class", "c", "", "the name of the PriorityClass to assign to the workloads")
+	cmd.Flags().BoolVarP(&o.Generate, "generate", "", false, "if enabled the PriorityClass resource is generated too")
+	cmd.Flags().StringVarP(&o.OutDir, "output-dir", "o", filepath.Join("config-root", "cluster"), "the dir to write the generated PriorityClass to")
+	cmd.Flags().Int32VarP(&o.Value, "value", "", 0, "the value of the generated PriorityClass. Higher values are scheduled first")
+	cmd.Flags().BoolVarP(&o.GlobalDefault, "global-default", "", false, "if enabled the generated PriorityClass is used for pods without a priorityClassName")
+	cmd.Flags().StringVarP(&o.Description, "description", "", "", "the description of the generated PriorityClass")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.ClassName == "" {
+		return options.MissingOption("class")
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	o.Assigned = nil
+	o.Generated = ""
+
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		specPath := podSpecPaths[kind]
+		if specPath == nil {
+			return false, nil
+		}
+		name := kyamls.GetName(node, path)
+		podSpec, err := node.Pipe(yaml.LookupCreate(yaml.MappingNode, specPath...))
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to find the pod spec of %s %s in file %s", kind, name, path)
+		}
+		current := kyamls.GetStringField(podSpec, path, "priorityClassName")
+		if current == o.ClassName {
+			return false, nil
+		}
+		err = podSpec.PipeE(yaml.SetField("priorityClassName", yaml.NewScalarRNode(o.ClassName)))
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to set the priorityClassName of %s %s in file %s", kind, name, path)
+		}
+		log.Logger().Infof("assigned priority class %s to %s %s in file %s", info(o.ClassName), kind, info(name), path)
+		o.Assigned = append(o.Assigned, kind+"/"+name)
+		return true, nil
+	}
+	err = kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to assign priority class in dir %s", o.Dir)
+	}
+	if len(o.Assigned) == 0 {
+		log.Logger().Infof("no workloads found to assign the priority class %s to", info(o.ClassName))
+	}
+	if !o.Generate {
+		return nil
+	}
+	return o.generatePriorityClass()
+}
+
+// CreatePriorityClass creates the PriorityClass resource
+func CreatePriorityClass(name string, value int32, globalDefault bool, description string) *schedulingv1.PriorityClass {
+	return &schedulingv1.PriorityClass{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "scheduling.k8s.io/v1",
+			Kind:       "PriorityClass",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Value:         value,
+		GlobalDefault: globalDefault,
+		Description:   description,
+	}
+}
+
+func (o *Options) generatePriorityClass() error {
+	pc := CreatePriorityClass(o.ClassName, o.Value, o.GlobalDefault, o.Description)
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert PriorityClass to unstructured")
+	}
+	unstructured.RemoveNestedField(m, "metadata", "creationTimestamp")
+	data, err := sigsyaml.Marshal(m)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal PriorityClass to YAML")
+	}
+
+	err = os.MkdirAll(o.OutDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", o.OutDir)
+	}
+	path := filepath.Join(o.OutDir, o.ClassName+"-priorityclass.yaml")
+	err = ioutil.WriteFile(path, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", path)
+	}
+	log.Logger().Infof("generated %s", info(path))
+	o.Generated = path
+	return nil
+}
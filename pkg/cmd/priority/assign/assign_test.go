@@ -0,0 +1,74 @@
+package assign_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/priority/assign"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+func TestPriorityAssign(t *testing.T) {
+	tmpDir := t.TempDir()
+	outDir := t.TempDir()
+
+	err := files.CopyDirOverwrite(filepath.Join("test_data", "source"), tmpDir)
+	require.NoError(t, err, "failed to copy source files to %s", tmpDir)
+
+	_, o := assign.NewCmdPriorityAssign()
+	o.Dir = tmpDir
+	o.ClassName = "high"
+	o.Selector = map[string]string{"tier": "critical"}
+	o.Generate = true
+	o.OutDir = outDir
+	o.Value = 100000
+	o.Description = "critical workloads"
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	sort.Strings(o.Assigned)
+	assert.Equal(t, []string{"CronJob/backup", "Deployment/api"}, o.Assigned, "assigned workloads")
+
+	expected := map[string][]string{
+		"deployment.yaml": {"spec", "template", "spec", "priorityClassName"},
+		"cronjob.yaml":    {"spec", "jobTemplate", "spec", "template", "spec", "priorityClassName"},
+	}
+	for name, fields := range expected {
+		path := filepath.Join(tmpDir, name)
+		node, err := yaml.ReadFile(path)
+		require.NoError(t, err, "failed to load %s", path)
+		assert.Equal(t, "high", kyamls.GetStringField(node, path, fields...), "priorityClassName in %s", name)
+	}
+
+	path := filepath.Join(tmpDir, "other.yaml")
+	node, err := yaml.ReadFile(path)
+	require.NoError(t, err, "failed to load %s", path)
+	assert.Equal(t, "", kyamls.GetStringField(node, path, "spec", "template", "spec", "priorityClassName"), "should not assign to a workload which does not match the selector")
+
+	require.Equal(t, filepath.Join(outDir, "high-priorityclass.yaml"), o.Generated, "generated file")
+	data, err := ioutil.ReadFile(o.Generated)
+	require.NoError(t, err, "failed to load %s", o.Generated)
+	pc := &schedulingv1.PriorityClass{}
+	err = sigsyaml.Unmarshal(data, pc)
+	require.NoError(t, err, "failed to parse %s", o.Generated)
+	assert.Equal(t, "PriorityClass", pc.Kind, "kind")
+	assert.Equal(t, "high", pc.Name, "name")
+	assert.Equal(t, int32(100000), pc.Value, "value")
+	assert.Equal(t, "critical workloads", pc.Description, "description")
+	assert.False(t, pc.GlobalDefault, "globalDefault")
+}
+
+func TestPriorityAssignMissingClass(t *testing.T) {
+	_, o := assign.NewCmdPriorityAssign()
+	o.Dir = filepath.Join("test_data", "source")
+	err := o.Run()
+	require.Error(t, err, "should fail without --class")
+}
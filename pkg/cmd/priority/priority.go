@@ -0,0 +1,24 @@
+package priority
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/priority/assign"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPriority creates the new command
+func NewCmdPriority() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "priority",
+		Short: "Commands for working with PriorityClass resources and the priority of workloads",
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(assign.NewCmdPriorityAssign()))
+	return command
+}
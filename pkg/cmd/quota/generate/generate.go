@@ -0,0 +1,329 @@
+package generate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Generates a ResourceQuota and an optional LimitRange for each namespace in a policy file
+
+The policy file contains the default limits and the limits of each namespace which override the defaults:
+
+    defaults:
+      cpu: "4"
+      memory: 8Gi
+      pods: 20
+    namespaces:
+      team-a:
+        cpu: "8"
+        memory: 16Gi
+        limitRange:
+          defaultCPU: 500m
+          defaultMemory: 512Mi
+      team-b: {}
+
+The resources are written to the output dir as $namespace/$name-resourcequota.yaml and $namespace/$name-limitrange.yaml
+`)
+
+	cmdExample = templates.Examples(`
+		# generates the quotas for each namespace in the policy
+		%s quota generate --policy quota-policy.yaml
+
+		# generates the quotas into a different dir
+		%s quota generate --policy quota-policy.yaml --output-dir config-root/namespaces
+	`)
+)
+
+// Policy the quota policy of the namespaces
+type Policy struct {
+	// Defaults the default limits of each namespace
+	Defaults Limits `json:"defaults,omitempty"`
+
+	// Namespaces the limits of each namespace which override the defaults
+	Namespaces map[string]Limits `json:"namespaces,omitempty"`
+}
+
+// Limits the limits of a namespace
+type Limits struct {
+	// CPU the total CPU requests of the namespace
+	CPU string `json:"cpu,omitempty"`
+
+	// Memory the total memory requests of the namespace
+	Memory string `json:"memory,omitempty"`
+
+	// LimitsCPU the total CPU limits of the namespace
+	LimitsCPU string `json:"limitsCPU,omitempty"`
+
+	// LimitsMemory the total memory limits of the namespace
+	LimitsMemory string `json:"limitsMemory,omitempty"`
+
+	// Pods the maximum number of pods in the namespace
+	Pods int `json:"pods,omitempty"`
+
+	// LimitRange the optional default container requests and limits of the namespace
+	LimitRange *LimitRange `json:"limitRange,omitempty"`
+}
+
+// LimitRange the default container requests and limits
+type LimitRange struct {
+	DefaultCPU           string `json:"defaultCPU,omitempty"`
+	DefaultMemory        string `json:"defaultMemory,omitempty"`
+	DefaultRequestCPU    string `json:"defaultRequestCPU,omitempty"`
+	DefaultRequestMemory string `json:"defaultRequestMemory,omitempty"`
+}
+
+// Options the options for the command
+type Options struct {
+	PolicyFile string
+	OutDir     string
+	Name       string
+	Policy     *Policy
+	Generated  []string
+}
+
+// NewCmdQuotaGenerate creates a command object for the command
+func NewCmdQuotaGenerate() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "generate",
+		Short:   "Generates a ResourceQuota and an optional LimitRange for each namespace in a policy file",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.PolicyFile, "policy", "p", "", "the YAML file containing the quota policy")
+	cmd.Flags().StringVarP(&o.OutDir, "output-dir", "o", filepath.Join("config-root", "namespaces"), "the dir to write the resources to in a sub directory for each namespace")
+	cmd.Flags().StringVarP(&o.Name, "name", "n", "default", "the name of the generated ResourceQuota and LimitRange resources")
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.Name == "" {
+		o.Name = "default"
+	}
+	if o.Policy != nil {
+		return nil
+	}
+	if o.PolicyFile == "" {
+		return options.MissingOption("policy")
+	}
+	data, err := ioutil.ReadFile(o.PolicyFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", o.PolicyFile)
+	}
+	o.Policy = &Policy{}
+	err = yaml.Unmarshal(data, o.Policy)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse quota policy %s", o.PolicyFile)
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	o.Generated = nil
+
+	var namespaces []string
+	for ns := range o.Policy.Namespaces {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
+		limits := o.Policy.Namespaces[ns].Merge(o.Policy.Defaults)
+		quota, err := CreateResourceQuota(o.Name, ns, limits)
+		if err != nil {
+			return errors.Wrapf(err, "invalid quota for namespace %s", ns)
+		}
+		err = o.save(ns, "resourcequota", quota)
+		if err != nil {
+			return err
+		}
+		if limits.LimitRange == nil {
+			continue
+		}
+		limitRange, err := CreateLimitRange(o.Name, ns, limits.LimitRange)
+		if err != nil {
+			return errors.Wrapf(err, "invalid limit range for namespace %s", ns)
+		}
+		err = o.save(ns, "limitrange", limitRange)
+		if err != nil {
+			return err
+		}
+	}
+	if len(o.Generated) == 0 {
+		log.Logger().Infof("no namespaces found in the quota policy")
+	}
+	return nil
+}
+
+// Merge returns the limits using the default values for any missing values
+func (l Limits) Merge(defaults Limits) Limits {
+	if l.CPU == "" {
+		l.CPU = defaults.CPU
+	}
+	if l.Memory == "" {
+		l.Memory = defaults.Memory
+	}
+	if l.LimitsCPU == "" {
+		l.LimitsCPU = defaults.LimitsCPU
+	}
+	if l.LimitsMemory == "" {
+		l.LimitsMemory = defaults.LimitsMemory
+	}
+	if l.Pods == 0 {
+		l.Pods = defaults.Pods
+	}
+	if l.LimitRange == nil {
+		l.LimitRange = defaults.LimitRange
+	}
+	return l
+}
+
+// CreateResourceQuota creates the ResourceQuota for the limits
+func CreateResourceQuota(name, ns string, limits Limits) (*corev1.ResourceQuota, error) {
+	hard := corev1.ResourceList{}
+	err := addQuantities(hard, map[corev1.ResourceName]string{
+		corev1.ResourceRequestsCPU:    limits.CPU,
+		corev1.ResourceRequestsMemory: limits.Memory,
+		corev1.ResourceLimitsCPU:      limits.LimitsCPU,
+		corev1.ResourceLimitsMemory:   limits.LimitsMemory,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if limits.Pods > 0 {
+		hard[corev1.ResourcePods] = *resource.NewQuantity(int64(limits.Pods), resource.DecimalSI)
+	}
+	if len(hard) == 0 {
+		return nil, errors.Errorf("no cpu, memory or pods limits specified")
+	}
+	return &corev1.ResourceQuota{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ResourceQuota",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: hard,
+		},
+	}, nil
+}
+
+// CreateLimitRange creates the LimitRange of the default container requests and limits
+func CreateLimitRange(name, ns string, lr *LimitRange) (*corev1.LimitRange, error) {
+	item := corev1.LimitRangeItem{
+		Type: corev1.LimitTypeContainer,
+	}
+	defaults := corev1.ResourceList{}
+	err := addQuantities(defaults, map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    lr.DefaultCPU,
+		corev1.ResourceMemory: lr.DefaultMemory,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defaultRequests := corev1.ResourceList{}
+	err = addQuantities(defaultRequests, map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    lr.DefaultRequestCPU,
+		corev1.ResourceMemory: lr.DefaultRequestMemory,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(defaults) > 0 {
+		item.Default = defaults
+	}
+	if len(defaultRequests) > 0 {
+		item.DefaultRequest = defaultRequests
+	}
+	return &corev1.LimitRange{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "LimitRange",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{item},
+		},
+	}, nil
+}
+
+func addQuantities(list corev1.ResourceList, values map[corev1.ResourceName]string) error {
+	for name, value := range values {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s quantity %s", name, value)
+		}
+		list[name] = q
+	}
+	return nil
+}
+
+func (o *Options) save(ns, kind string, obj runtime.Object) error {
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert %s to unstructured", kind)
+	}
+	unstructured.RemoveNestedField(m, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(m, "status")
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s to YAML", kind)
+	}
+
+	dir := filepath.Join(o.OutDir, ns)
+	err = os.MkdirAll(dir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create dir %s", dir)
+	}
+	path := filepath.Join(dir, o.Name+"-"+kind+".yaml")
+	err = ioutil.WriteFile(path, data, files.DefaultFileWritePermissions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to save file %s", path)
+	}
+	log.Logger().Infof("generated %s", info(path))
+	o.Generated = append(o.Generated, path)
+	return nil
+}
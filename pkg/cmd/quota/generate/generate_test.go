@@ -0,0 +1,52 @@
+package generate_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/quota/generate"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaGenerate(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	_, o := generate.NewCmdQuotaGenerate()
+	o.PolicyFile = filepath.Join("test_data", "policy.yaml")
+	o.OutDir = tmpDir
+
+	err = o.Run()
+	require.NoError(t, err, "failed to generate quotas")
+
+	expected := []string{
+		filepath.Join("team-a", "default-resourcequota.yaml"),
+		filepath.Join("team-a", "default-limitrange.yaml"),
+		filepath.Join("team-b", "default-resourcequota.yaml"),
+	}
+	require.Len(t, o.Generated, len(expected), "generated files")
+	for i, name := range expected {
+		assert.Equal(t, filepath.Join(tmpDir, name), o.Generated[i], "generated file %d", i)
+		testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", "expected", name), filepath.Join(tmpDir, name), "generated "+name)
+	}
+}
+
+func TestQuotaGenerateInvalid(t *testing.T) {
+	_, o := generate.NewCmdQuotaGenerate()
+	err := o.Run()
+	require.Error(t, err, "should fail without a --policy")
+
+	_, o = generate.NewCmdQuotaGenerate()
+	o.Policy = &generate.Policy{
+		Namespaces: map[string]generate.Limits{
+			"team-a": {CPU: "lots"},
+		},
+	}
+	o.OutDir, err = ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+	err = o.Run()
+	require.Error(t, err, "should fail for an invalid quantity")
+}
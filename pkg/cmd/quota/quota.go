@@ -0,0 +1,25 @@
+package quota
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/quota/generate"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdQuota creates the new command
+func NewCmdQuota() *cobra.Command {
+	command := &cobra.Command{
+		Use:     "quota",
+		Short:   "Commands for working with ResourceQuota and LimitRange resources",
+		Aliases: []string{"quotas"},
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(generate.NewCmdQuotaGenerate()))
+	return command
+}
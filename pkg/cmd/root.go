@@ -3,7 +3,11 @@ package cmd
 import (
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/annotate"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/apply"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/bundle"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/canonicalize"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/commonannotations"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/condition"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/conftest"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/copy"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/gc"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/git"
@@ -21,17 +25,24 @@ import (
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/plugin"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/postprocess"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/pr"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/priority"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/quota"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/rename"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/repository"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/requirement"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/sa"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/scheduler"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/secret"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/security"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/split"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/upgrade"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/variables"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/vars"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/version"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/versionstream"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/webhook"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/workload"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/yset"
 	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
 	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
@@ -59,14 +70,25 @@ func Main() *cobra.Command {
 	cmd.AddCommand(kpt.NewCmdKpt())
 	cmd.AddCommand(plugin.NewCmdPlugin())
 	cmd.AddCommand(pr.NewCmdPR())
+	cmd.AddCommand(priority.NewCmdPriority())
+	cmd.AddCommand(quota.NewCmdQuota())
 	cmd.AddCommand(requirement.NewCmdRequirement())
 	cmd.AddCommand(repository.NewCmdRepository())
 	cmd.AddCommand(sa.NewCmdServiceAccount())
+	cmd.AddCommand(secret.NewCmdSecret())
+	cmd.AddCommand(security.NewCmdSecurity())
+	cmd.AddCommand(vars.NewCmdVars())
+	cmd.AddCommand(verify.NewCmdVerify())
 	cmd.AddCommand(webhook.NewCmdWebhook())
+	cmd.AddCommand(workload.NewCmdWorkload())
 
 	cmd.AddCommand(cobras.SplitCommand(annotate.NewCmdUpdateAnnotate()))
 	cmd.AddCommand(cobras.SplitCommand(apply.NewCmdApply()))
+	cmd.AddCommand(cobras.SplitCommand(bundle.NewCmdBundle()))
+	cmd.AddCommand(cobras.SplitCommand(canonicalize.NewCmdCanonicalize()))
+	cmd.AddCommand(cobras.SplitCommand(commonannotations.NewCmdCommonAnnotations()))
 	cmd.AddCommand(cobras.SplitCommand(condition.NewCmdCondition()))
+	cmd.AddCommand(cobras.SplitCommand(conftest.NewCmdConftest()))
 	cmd.AddCommand(cobras.SplitCommand(copy.NewCmdCopy()))
 	cmd.AddCommand(cobras.SplitCommand(hash.NewCmdHashAnnotate()))
 	cmd.AddCommand(cobras.SplitCommand(image.NewCmdUpdateImage()))
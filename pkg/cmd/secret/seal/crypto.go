@@ -0,0 +1,48 @@
+package seal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const sessionKeyBytes = 32
+
+// HybridEncrypt encrypts the plaintext in the same format as the sealed-secrets controller.
+// A random AES-GCM session key encrypts the plaintext and the session key is encrypted with RSA-OAEP
+// using the label. The result is the 2 byte length of the encrypted session key, the encrypted session key
+// and then the encrypted plaintext
+func HybridEncrypt(pubKey *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, sessionKeyBytes)
+	_, err := rand.Read(sessionKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate session key")
+	}
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create cipher")
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create GCM")
+	}
+
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pubKey, sessionKey, label)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encrypt session key")
+	}
+
+	ciphertext := make([]byte, 2)
+	binary.BigEndian.PutUint16(ciphertext, uint16(len(rsaCiphertext)))
+	ciphertext = append(ciphertext, rsaCiphertext...)
+
+	// the session key is only used once so a zero nonce is safe
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Seal(ciphertext, zeroNonce, plaintext, nil), nil
+}
@@ -0,0 +1,258 @@
+package seal
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/httphelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Converts Secret resources into SealedSecret resources for the sealed-secrets controller
+
+The secrets are sealed with the strict scope so that they can only be decrypted with the same name and namespace.
+The public certificate of the controller can be a local file or a URL such as the one exposed by the controller at /v1/cert.pem
+`)
+
+	cmdExample = templates.Examples(`
+		# replaces every Secret in the directory with a SealedSecret
+		%s secret seal --dir config-root --cert pub-cert.pem
+
+		# writes the SealedSecrets to a separate directory using the certificate of the controller
+		%s secret seal --dir secrets --output-dir sealed --cert http://sealed-secrets-controller.kube-system:8080/v1/cert.pem
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir       string
+	OutputDir string
+	Cert      string
+	Namespace string
+	PublicKey *rsa.PublicKey
+	Sealed    []string
+}
+
+// SealedSecret the SealedSecret resource of the sealed-secrets controller
+type SealedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              SealedSecretSpec `json:"spec"`
+}
+
+// SealedSecretSpec the spec of a SealedSecret
+type SealedSecretSpec struct {
+	EncryptedData map[string]string `json:"encryptedData"`
+	Template      SecretTemplate    `json:"template"`
+}
+
+// SecretTemplate the template of the Secret created by the controller
+type SecretTemplate struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Type              corev1.SecretType `json:"type,omitempty"`
+}
+
+// NewCmdSecretSeal creates a command object for the command
+func NewCmdSecretSeal() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "seal",
+		Short:   "Converts Secret resources into SealedSecret resources for the sealed-secrets controller",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for Secret resources")
+	cmd.Flags().StringVarP(&o.OutputDir, "output-dir", "o", "", "the directory to write the SealedSecret resources to. If not specified the Secret files are replaced")
+	cmd.Flags().StringVarP(&o.Cert, "cert", "c", "", "the file or URL of the public certificate of the sealed-secrets controller")
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", "", "the namespace of any Secret which does not specify a namespace")
+	return cmd, o
+}
+
+// Validate validates the options and loads the public key
+func (o *Options) Validate() error {
+	if o.PublicKey != nil {
+		return nil
+	}
+	if o.Cert == "" {
+		return options.MissingOption("cert")
+	}
+	data, err := o.loadCert()
+	if err != nil {
+		return errors.Wrapf(err, "failed to load certificate %s", o.Cert)
+	}
+	o.PublicKey, err = ParsePublicKey(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse certificate %s", o.Cert)
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+
+	o.Sealed = nil
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		if kyamls.GetKind(node, path) != "Secret" || kyamls.GetAPIVersion(node, path) != "v1" {
+			return false, nil
+		}
+		text, err := node.String()
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to convert node to string")
+		}
+		secret := &corev1.Secret{}
+		err = sigsyaml.Unmarshal([]byte(text), secret)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to unmarshal Secret")
+		}
+		if secret.Namespace == "" {
+			secret.Namespace = o.Namespace
+		}
+		if secret.Namespace == "" {
+			return false, errors.Errorf("Secret %s has no namespace so please specify --namespace", secret.Name)
+		}
+
+		sealed, err := o.Seal(secret)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to seal Secret %s", secret.Name)
+		}
+		data, err := sigsyaml.Marshal(sealed)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to marshal SealedSecret %s", secret.Name)
+		}
+
+		outFile := path
+		if o.OutputDir != "" {
+			rel, err := filepath.Rel(o.Dir, path)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to find relative path of %s", path)
+			}
+			outFile = filepath.Join(o.OutputDir, rel)
+			err = os.MkdirAll(filepath.Dir(outFile), files.DefaultDirWritePermissions)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to create dir %s", filepath.Dir(outFile))
+			}
+		}
+		err = ioutil.WriteFile(outFile, data, files.DefaultFileWritePermissions)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to save file %s", outFile)
+		}
+		o.Sealed = append(o.Sealed, outFile)
+		log.Logger().Infof("sealed Secret %s to %s", info(secret.Name), info(outFile))
+		return false, nil
+	}
+	err = kyamls.ModifyFiles(o.Dir, modifyFn, kyamls.Filter{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to seal secrets in dir %s", o.Dir)
+	}
+	return nil
+}
+
+// Seal creates the SealedSecret for the Secret using the strict scope
+func (o *Options) Seal(secret *corev1.Secret) (*SealedSecret, error) {
+	label := []byte(secret.Namespace + "/" + secret.Name)
+	values := map[string][]byte{}
+	for k, v := range secret.Data {
+		values[k] = v
+	}
+	for k, v := range secret.StringData {
+		values[k] = []byte(v)
+	}
+
+	sealed := &SealedSecret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "bitnami.com/v1alpha1",
+			Kind:       "SealedSecret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+		},
+		Spec: SealedSecretSpec{
+			EncryptedData: map[string]string{},
+			Template: SecretTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        secret.Name,
+					Namespace:   secret.Namespace,
+					Labels:      secret.Labels,
+					Annotations: secret.Annotations,
+				},
+				Type: secret.Type,
+			},
+		},
+	}
+	for k, v := range values {
+		ciphertext, err := HybridEncrypt(o.PublicKey, v, label)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encrypt key %s", k)
+		}
+		sealed.Spec.EncryptedData[k] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	return sealed, nil
+}
+
+func (o *Options) loadCert() ([]byte, error) {
+	if !strings.HasPrefix(o.Cert, "http://") && !strings.HasPrefix(o.Cert, "https://") {
+		return ioutil.ReadFile(o.Cert)
+	}
+	client := httphelpers.GetClient()
+	resp, err := client.Get(o.Cert)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to GET %s", o.Cert)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to GET %s with status %s", o.Cert, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// ParsePublicKey parses the RSA public key from the PEM encoded certificate
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("no PEM data found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse certificate")
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("certificate does not contain an RSA public key")
+	}
+	return key, nil
+}
@@ -0,0 +1,123 @@
+package seal_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/secret/seal"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSecretSeal(t *testing.T) {
+	key, certPEM := createTestKey(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(certPEM)
+	}))
+	defer server.Close()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	certFile := filepath.Join(tmpDir, "cert.pem")
+	err = ioutil.WriteFile(certFile, certPEM, files.DefaultFileWritePermissions)
+	require.NoError(t, err, "failed to save cert")
+
+	for _, cert := range []string{certFile, server.URL + "/v1/cert.pem"} {
+		outDir, err := ioutil.TempDir("", "")
+		require.NoError(t, err, "failed to create tmp dir")
+
+		_, o := seal.NewCmdSecretSeal()
+		o.Dir = filepath.Join("test_data", "secrets")
+		o.OutputDir = outDir
+		o.Cert = cert
+
+		err = o.Run()
+		require.NoError(t, err, "failed to seal with cert %s", cert)
+		require.Len(t, o.Sealed, 1, "sealed files with cert %s", cert)
+		assert.NoFileExists(t, filepath.Join(outDir, "configmap.yaml"), "should not seal a ConfigMap")
+
+		data, err := ioutil.ReadFile(filepath.Join(outDir, "secret.yaml"))
+		require.NoError(t, err, "failed to load SealedSecret")
+		t.Logf("generated SealedSecret:\n%s\n", string(data))
+
+		sealed := &seal.SealedSecret{}
+		err = yaml.Unmarshal(data, sealed)
+		require.NoError(t, err, "failed to unmarshal SealedSecret")
+
+		assert.Equal(t, "SealedSecret", sealed.Kind)
+		assert.Equal(t, "bitnami.com/v1alpha1", sealed.APIVersion)
+		assert.Equal(t, "mysecret", sealed.Name)
+		assert.Equal(t, "jx", sealed.Namespace)
+		assert.Equal(t, "myapp", sealed.Spec.Template.Labels["app"], "template labels")
+		assert.Equal(t, "Opaque", string(sealed.Spec.Template.Type), "template type")
+
+		expected := map[string]string{
+			"username": "admin",
+			"password": "s3cr3t",
+			"token":    "mytoken",
+		}
+		require.Len(t, sealed.Spec.EncryptedData, len(expected), "encrypted keys")
+		for k, v := range expected {
+			ciphertext, err := base64.StdEncoding.DecodeString(sealed.Spec.EncryptedData[k])
+			require.NoError(t, err, "failed to decode key %s", k)
+
+			plaintext, err := hybridDecrypt(key, ciphertext, []byte("jx/mysecret"))
+			require.NoError(t, err, "failed to decrypt key %s", k)
+			assert.Equal(t, v, string(plaintext), "decrypted key %s", k)
+
+			_, err = hybridDecrypt(key, ciphertext, []byte("default/mysecret"))
+			assert.Error(t, err, "should not decrypt key %s in another namespace", k)
+		}
+	}
+}
+
+func createTestKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err, "failed to generate key")
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sealed-secret"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err, "failed to create certificate")
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func hybridDecrypt(key *rsa.PrivateKey, ciphertext, label []byte) ([]byte, error) {
+	l := int(binary.BigEndian.Uint16(ciphertext))
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, ciphertext[2:2+l], label)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return aed.Open(nil, make([]byte, aed.NonceSize()), ciphertext[2+l:], nil)
+}
@@ -0,0 +1,25 @@
+package secret
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/secret/seal"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdSecret creates the new command
+func NewCmdSecret() *cobra.Command {
+	command := &cobra.Command{
+		Use:     "secret",
+		Short:   "Commands for working with Secret resources",
+		Aliases: []string{"secrets"},
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(seal.NewCmdSecretSeal()))
+	return command
+}
@@ -0,0 +1,211 @@
+package harden
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/workloads"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Sets the securityContext fields of the pods and containers of the workloads in a dir to meet a security baseline
+
+The workloads are the Deployment, StatefulSet, DaemonSet, ReplicaSet, Job, CronJob and Pod resources which match the filters. The pod fields are runAsNonRoot, runAsUser and seccompProfile and the container fields of the containers and init containers are readOnlyRootFilesystem, allowPrivilegeEscalation and capabilities.drop
+
+Fields which are already set are preserved unless --overwrite is specified
+`)
+
+	cmdExample = templates.Examples(`
+		# sets runAsNonRoot, readOnlyRootFilesystem, allowPrivilegeEscalation, dropped capabilities and the seccomp profile
+		%s security harden --dir config-root
+
+		# also sets the user the pods run as
+		%s security harden --dir config-root --run-as-user 1000
+
+		# only hardens the workloads with a label without using a read only root filesystem
+		%s security harden --dir config-root --selector app=api --read-only-root-filesystem=false
+
+		# replaces any existing settings of the workloads
+		%s security harden --dir config-root --overwrite
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir                         string
+	RunAsNonRoot                bool
+	RunAsUser                   int64
+	SeccompProfile              string
+	ReadOnlyRootFilesystem      bool
+	DisallowPrivilegeEscalation bool
+	DropCapabilities            []string
+	Overwrite                   bool
+	Modified                    []string
+}
+
+// NewCmdSecurityHarden creates a command object for the command
+func NewCmdSecurityHarden() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "harden",
+		Short:   "Sets the securityContext fields of the workloads in a dir to meet a security baseline",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.RunAsNonRoot, "run-as-non-root", "", true, "if enabled sets runAsNonRoot to true in the pod securityContext")
+	cmd.Flags().Int64VarP(&o.RunAsUser, "run-as-user", "", 0, "the runAsUser of the pod securityContext. Not set if 0")
+	cmd.Flags().StringVarP(&o.SeccompProfile, "seccomp-profile", "", "RuntimeDefault", "the seccompProfile type of the pod securityContext. Not set if empty")
+	cmd.Flags().BoolVarP(&o.ReadOnlyRootFilesystem, "read-only-root-filesystem", "", true, "if enabled sets readOnlyRootFilesystem to true in the container securityContext")
+	cmd.Flags().BoolVarP(&o.DisallowPrivilegeEscalation, "disallow-privilege-escalation", "", true, "if enabled sets allowPrivilegeEscalation to false in the container securityContext")
+	cmd.Flags().StringArrayVarP(&o.DropCapabilities, "drop-capabilities", "", []string{"ALL"}, "the capabilities to drop in the container securityContext. Not set if empty")
+	cmd.Flags().BoolVarP(&o.Overwrite, "overwrite", "", false, "if enabled any existing values of the fields are replaced")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Modified = nil
+
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		podSpec, err := workloads.GetPodSpec(node, kind)
+		if err != nil || podSpec == nil {
+			return false, err
+		}
+		name := kyamls.GetName(node, path)
+		modified, err := o.hardenPod(podSpec)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to harden the pod of %s %s in file %s", kind, name, path)
+		}
+		containers, err := workloads.GetContainers(podSpec, true)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get the containers of %s %s in file %s", kind, name, path)
+		}
+		for _, c := range containers {
+			flag, err := o.hardenContainer(c)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to harden container %s of %s %s in file %s", workloads.GetContainerName(c), kind, name, path)
+			}
+			if flag {
+				modified = true
+			}
+		}
+		if modified {
+			log.Logger().Infof("hardened the securityContext of %s %s in file %s", kind, info(name), path)
+			o.Modified = append(o.Modified, kind+"/"+name)
+		}
+		return modified, nil
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to harden the workloads in dir %s", o.Dir)
+	}
+	if len(o.Modified) == 0 {
+		log.Logger().Infof("no workloads needed hardening in dir %s", info(o.Dir))
+	}
+	return nil
+}
+
+func (o *Options) hardenPod(podSpec *yaml.RNode) (bool, error) {
+	modified := false
+	if o.RunAsNonRoot {
+		flag, err := o.setField(podSpec, yaml.NewScalarRNode("true"), "securityContext", "runAsNonRoot")
+		if err != nil {
+			return false, err
+		}
+		modified = modified || flag
+	}
+	if o.RunAsUser > 0 {
+		flag, err := o.setField(podSpec, yaml.NewScalarRNode(strconv.FormatInt(o.RunAsUser, 10)), "securityContext", "runAsUser")
+		if err != nil {
+			return false, err
+		}
+		modified = modified || flag
+	}
+	if o.SeccompProfile != "" {
+		flag, err := o.setField(podSpec, yaml.NewScalarRNode(o.SeccompProfile), "securityContext", "seccompProfile", "type")
+		if err != nil {
+			return false, err
+		}
+		modified = modified || flag
+	}
+	return modified, nil
+}
+
+func (o *Options) hardenContainer(container *yaml.RNode) (bool, error) {
+	modified := false
+	if o.ReadOnlyRootFilesystem {
+		flag, err := o.setField(container, yaml.NewScalarRNode("true"), "securityContext", "readOnlyRootFilesystem")
+		if err != nil {
+			return false, err
+		}
+		modified = modified || flag
+	}
+	if o.DisallowPrivilegeEscalation {
+		flag, err := o.setField(container, yaml.NewScalarRNode("false"), "securityContext", "allowPrivilegeEscalation")
+		if err != nil {
+			return false, err
+		}
+		modified = modified || flag
+	}
+	if len(o.DropCapabilities) > 0 {
+		flag, err := o.setField(container, yaml.NewListRNode(o.DropCapabilities...), "securityContext", "capabilities", "drop")
+		if err != nil {
+			return false, err
+		}
+		modified = modified || flag
+	}
+	return modified, nil
+}
+
+// setField sets the field at the path to the value returning true if it was modified. Existing values are preserved
+// unless --overwrite is specified
+func (o *Options) setField(node, value *yaml.RNode, path ...string) (bool, error) {
+	last := len(path) - 1
+	parent, err := node.Pipe(yaml.LookupCreate(yaml.MappingNode, path[:last]...))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to find %v", path[:last])
+	}
+	existing := parent.Field(path[last])
+	if existing != nil && !yaml.IsMissingOrNull(existing.Value) {
+		if !o.Overwrite {
+			return false, nil
+		}
+		current, err := existing.Value.String()
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to marshal %v", path)
+		}
+		expected, err := value.String()
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to marshal the value of %v", path)
+		}
+		if current == expected {
+			return false, nil
+		}
+	}
+	err = parent.PipeE(yaml.SetField(path[last], value))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to set %v", path)
+	}
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package harden_test
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/security/harden"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestSecurityHarden(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := files.CopyDirOverwrite(filepath.Join("test_data", "source"), tmpDir)
+	require.NoError(t, err, "failed to copy source files to %s", tmpDir)
+
+	_, o := harden.NewCmdSecurityHarden()
+	o.Dir = tmpDir
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	sort.Strings(o.Modified)
+	assert.Equal(t, []string{"Deployment/api", "StatefulSet/db"}, o.Modified, "modified workloads")
+
+	podSpec := []string{"spec", "template", "spec"}
+	deployment := filepath.Join(tmpDir, "deployment.yaml")
+	assertField(t, deployment, "true", podSpec, "securityContext", "runAsNonRoot")
+	assertField(t, deployment, "RuntimeDefault", podSpec, "securityContext", "seccompProfile", "type")
+	assertField(t, deployment, "", podSpec, "securityContext", "runAsUser")
+	for _, c := range []string{"initContainers/migrate", "containers/api"} {
+		parts := strings.Split(c, "/")
+		container := append(append([]string{}, podSpec...), parts[0], "[name="+parts[1]+"]")
+		assertField(t, deployment, "true", container, "securityContext", "readOnlyRootFilesystem")
+		assertField(t, deployment, "false", container, "securityContext", "allowPrivilegeEscalation")
+		assertField(t, deployment, "[ALL]", container, "securityContext", "capabilities", "drop")
+	}
+
+	statefulSet := filepath.Join(tmpDir, "statefulset.yaml")
+	container := append(append([]string{}, podSpec...), "containers", "[name=db]")
+	assertField(t, statefulSet, "true", podSpec, "securityContext", "runAsNonRoot")
+	assertField(t, statefulSet, "999", podSpec, "securityContext", "runAsUser")
+	assertField(t, statefulSet, "Localhost", podSpec, "securityContext", "seccompProfile", "type")
+	assertField(t, statefulSet, "false", container, "securityContext", "readOnlyRootFilesystem")
+	assertField(t, statefulSet, "false", container, "securityContext", "allowPrivilegeEscalation")
+	assertField(t, statefulSet, "[NET_RAW]", container, "securityContext", "capabilities", "drop")
+
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command again")
+	assert.Empty(t, o.Modified, "should not modify hardened workloads")
+
+	o.Overwrite = true
+	o.RunAsUser = 1000
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command with --overwrite")
+	sort.Strings(o.Modified)
+	assert.Equal(t, []string{"Deployment/api", "StatefulSet/db"}, o.Modified, "modified workloads with --overwrite")
+
+	assertField(t, deployment, "1000", podSpec, "securityContext", "runAsUser")
+	assertField(t, statefulSet, "1000", podSpec, "securityContext", "runAsUser")
+	assertField(t, statefulSet, "RuntimeDefault", podSpec, "securityContext", "seccompProfile", "type")
+	assertField(t, statefulSet, "true", container, "securityContext", "readOnlyRootFilesystem")
+	assertField(t, statefulSet, "[ALL]", container, "securityContext", "capabilities", "drop")
+}
+
+func assertField(t *testing.T, path, expected string, prefix []string, fields ...string) {
+	node, err := yaml.ReadFile(path)
+	require.NoError(t, err, "failed to load %s", path)
+	fieldPath := append(append([]string{}, prefix...), fields...)
+	value, err := node.Pipe(yaml.Lookup(fieldPath...))
+	require.NoError(t, err, "failed to lookup %v in %s", fieldPath, path)
+	actual := ""
+	if value != nil {
+		actual = value.YNode().Value
+		if value.YNode().Kind == yaml.SequenceNode {
+			var values []string
+			for _, n := range value.YNode().Content {
+				values = append(values, n.Value)
+			}
+			actual = "[" + strings.Join(values, ",") + "]"
+		}
+	}
+	assert.Equal(t, expected, actual, "%v in %s", fieldPath, filepath.Base(path))
+}
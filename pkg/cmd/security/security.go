@@ -0,0 +1,24 @@
+package security
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/security/harden"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdSecurity creates the new command
+func NewCmdSecurity() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "security",
+		Short: "Commands for working with the security settings of workloads",
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(harden.NewCmdSecurityHarden()))
+	return command
+}
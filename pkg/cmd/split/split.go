@@ -75,42 +75,11 @@ func ProcessYamlFiles(dir string) error {
 			return errors.Wrapf(err, "failed to load file %s", path)
 		}
 
-		input := string(data)
-		if strings.HasPrefix(input, resourcesSeparator) {
-			input = "\n" + input
-		}
-		sections := strings.Split(input, "\n"+resourcesSeparator)
-
-		count := 0
-		var fileNames []string
-		buf := strings.Builder{}
-		for _, section := range sections {
-			if buf.Len() > 0 {
-				buf.WriteString(resourcesSeparator)
-			}
-			buf.WriteString(section)
-			if !helmhelpers.IsWhitespaceOrComments(section) {
-				count++
-
-				text := buf.String()
-				// remove all newline prefixes
-				for {
-					if !strings.HasPrefix(text, "\n") {
-						break
-					}
-					text = strings.TrimPrefix(text, "\n")
-				}
-				fileNames = append(fileNames, text)
-				buf.Reset()
-			}
-		}
+		fileNames := Documents(string(data))
+		count := len(fileNames)
 		if count >= 1 {
 			for i, text := range fileNames {
-				name := path
-				if i > 0 {
-					ex := filepath.Ext(path)
-					name = strings.TrimSuffix(path, ex) + strconv.Itoa(i+1) + ex
-				}
+				name := DocumentFileName(path, i)
 
 				// lets remove empty files
 				if helmhelpers.IsWhitespaceOrComments(text) {
@@ -154,3 +123,44 @@ func ProcessYamlFiles(dir string) error {
 	}
 	return nil
 }
+
+// Documents splits the YAML text into the text of each resource in the order they appear. Any sections which only
+// contain whitespace or comments are kept with the following resource
+func Documents(input string) []string {
+	if strings.HasPrefix(input, resourcesSeparator) {
+		input = "\n" + input
+	}
+	sections := strings.Split(input, "\n"+resourcesSeparator)
+
+	var answer []string
+	buf := strings.Builder{}
+	for _, section := range sections {
+		if buf.Len() > 0 {
+			buf.WriteString(resourcesSeparator)
+		}
+		buf.WriteString(section)
+		if !helmhelpers.IsWhitespaceOrComments(section) {
+			text := buf.String()
+			// remove all newline prefixes
+			for {
+				if !strings.HasPrefix(text, "\n") {
+					break
+				}
+				text = strings.TrimPrefix(text, "\n")
+			}
+			answer = append(answer, text)
+			buf.Reset()
+		}
+	}
+	return answer
+}
+
+// DocumentFileName returns the name of the file of the resource at the index of a file with multiple resources. The
+// first resource keeps the name of the file and the others have their 1 based index appended
+func DocumentFileName(path string, i int) string {
+	if i == 0 {
+		return path
+	}
+	ex := filepath.Ext(path)
+	return strings.TrimSuffix(path, ex) + strconv.Itoa(i+1) + ex
+}
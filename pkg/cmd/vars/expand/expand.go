@@ -0,0 +1,194 @@
+package expand
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Expands the Kustomize style $(NAME) variable references in the kubernetes resources in the given directory tree
+
+The variables are loaded from a YAML file of names to values and optionally the environment variables. Values in the file take precedence.
+Any references to undefined variables fail the command unless --allow-undefined is specified in which case they are left as they are.
+`)
+
+	cmdExample = templates.Examples(`
+		# expands the variables from a file in the current directory
+		%s vars expand --file vars.yaml
+
+		# expands the variables from the environment leaving any undefined references
+		%s vars expand --dir config-root --env --allow-undefined
+	`)
+
+	// VarRegex the regular expression of a $(NAME) variable reference
+	VarRegex = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_]*)\)`)
+)
+
+// Options the options for the command
+type Options struct {
+	Dir            string
+	File           string
+	Env            bool
+	AllowUndefined bool
+	Vars           map[string]string
+	Undefined      []string
+	GetEnv         func(string) string
+}
+
+// NewCmdVarsExpand creates a command object for the command
+func NewCmdVarsExpand() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "expand",
+		Short:   "Expands the $(NAME) variable references in the kubernetes resources in the given directory tree",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringVarP(&o.File, "file", "f", "", "the YAML file of variable names to values")
+	cmd.Flags().BoolVarP(&o.Env, "env", "e", false, "resolve variables which are not in the file from the environment variables")
+	cmd.Flags().BoolVarP(&o.AllowUndefined, "allow-undefined", "", false, "leave references to undefined variables rather than failing")
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.GetEnv == nil {
+		o.GetEnv = os.Getenv
+	}
+	if o.Vars == nil {
+		o.Vars = map[string]string{}
+	}
+	if o.File == "" {
+		if !o.Env && len(o.Vars) == 0 {
+			return errors.Errorf("no variables specified via --file or --env")
+		}
+		return nil
+	}
+	data, err := ioutil.ReadFile(o.File)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load file %s", o.File)
+	}
+	values := map[string]string{}
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse vars file %s", o.File)
+	}
+	for k, v := range values {
+		o.Vars[k] = v
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	o.Undefined = nil
+
+	undefined := map[string][]string{}
+	expanded := map[string][]byte{}
+	err = filepath.Walk(o.Dir, func(path string, info os.FileInfo, err error) error {
+		if info == nil || info.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to load file %s", path)
+		}
+		text := string(data)
+		result := VarRegex.ReplaceAllStringFunc(text, func(ref string) string {
+			name := VarRegex.FindStringSubmatch(ref)[1]
+			value, ok := o.lookup(name)
+			if !ok {
+				undefined[name] = append(undefined[name], path)
+				return ref
+			}
+			return value
+		})
+		if result != text {
+			expanded[path] = []byte(result)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to expand variables in dir %s", o.Dir)
+	}
+
+	for name := range undefined {
+		o.Undefined = append(o.Undefined, name)
+	}
+	sort.Strings(o.Undefined)
+	for _, name := range o.Undefined {
+		log.Logger().Warnf("undefined variable %s referenced in %s", info(name), strings.Join(unique(undefined[name]), ", "))
+	}
+	if len(o.Undefined) > 0 && !o.AllowUndefined {
+		return errors.Errorf("found %d undefined variables: %s", len(o.Undefined), strings.Join(o.Undefined, ", "))
+	}
+
+	var paths []string
+	for path := range expanded {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		err = ioutil.WriteFile(path, expanded[path], files.DefaultFileWritePermissions)
+		if err != nil {
+			return errors.Wrapf(err, "failed to save file %s", path)
+		}
+		log.Logger().Debugf("expanded variables in %s", path)
+	}
+	return nil
+}
+
+func (o *Options) lookup(name string) (string, bool) {
+	value, ok := o.Vars[name]
+	if ok {
+		return value, true
+	}
+	if o.Env {
+		value = o.GetEnv(name)
+		if value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func unique(values []string) []string {
+	var answer []string
+	for _, v := range values {
+		if len(answer) == 0 || answer[len(answer)-1] != v {
+			answer = append(answer, v)
+		}
+	}
+	return answer
+}
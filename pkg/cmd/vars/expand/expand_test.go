@@ -0,0 +1,74 @@
+package expand_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/vars/expand"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/testhelpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarsExpand(t *testing.T) {
+	tmpDir := copySource(t)
+
+	_, o := expand.NewCmdVarsExpand()
+	o.Dir = tmpDir
+	o.File = filepath.Join("test_data", "vars.yaml")
+	o.Env = true
+	o.GetEnv = func(name string) string {
+		if name == "BASE_PATH" {
+			return "api"
+		}
+		// the file takes precedence
+		if name == "DOMAIN" {
+			return "wrong.com"
+		}
+		return ""
+	}
+	err := o.Run()
+	require.NoError(t, err, "failed to expand vars")
+	assert.Empty(t, o.Undefined, "undefined vars")
+
+	for _, name := range []string{"deployment.yaml", filepath.Join("nested", "ingress.yaml")} {
+		testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", "expected", name), filepath.Join(tmpDir, name), "expanded "+name)
+	}
+}
+
+func TestVarsExpandUndefined(t *testing.T) {
+	tmpDir := copySource(t)
+
+	_, o := expand.NewCmdVarsExpand()
+	o.Dir = tmpDir
+	o.File = filepath.Join("test_data", "vars.yaml")
+	err := o.Run()
+	require.Error(t, err, "should fail for undefined vars")
+	assert.Equal(t, []string{"BASE_PATH"}, o.Undefined, "undefined vars")
+
+	// nothing is modified when failing
+	testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", "source", "deployment.yaml"), filepath.Join(tmpDir, "deployment.yaml"), "unmodified deployment.yaml")
+
+	_, o = expand.NewCmdVarsExpand()
+	o.Dir = tmpDir
+	o.File = filepath.Join("test_data", "vars.yaml")
+	o.AllowUndefined = true
+	err = o.Run()
+	require.NoError(t, err, "should not fail for undefined vars with --allow-undefined")
+	assert.Equal(t, []string{"BASE_PATH"}, o.Undefined, "undefined vars")
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpDir, "deployment.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "value: https://myapp.acme.com/$(BASE_PATH)", "should leave the undefined reference")
+	assert.Contains(t, string(data), "replicas: 3", "should expand the defined references")
+}
+
+func copySource(t *testing.T) string {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create temp dir")
+	err = files.CopyDirOverwrite(filepath.Join("test_data", "source"), tmpDir)
+	require.NoError(t, err, "failed to copy source files")
+	return tmpDir
+}
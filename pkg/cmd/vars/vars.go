@@ -0,0 +1,24 @@
+package vars
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/vars/expand"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdVars creates the new command
+func NewCmdVars() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "vars",
+		Short: "Commands for working with $(NAME) variable references in kubernetes resources",
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(expand.NewCmdVarsExpand()))
+	return command
+}
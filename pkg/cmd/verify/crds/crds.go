@@ -0,0 +1,213 @@
+package crds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that there is a CustomResourceDefinition for every custom resource in the directory tree
+
+Any resource whose kind is not a built in kubernetes kind must have a CustomResourceDefinition in the directory tree which defines its group, kind and version.
+If --cluster is specified then CustomResourceDefinitions which are already installed in the cluster are also used.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the CRDs of all the custom resources are in the directory tree
+		%s verify crds --dir config-root
+
+		# verifies the CRDs of all the custom resources are in the directory tree or the current cluster
+		%s verify crds --dir config-root --cluster
+	`)
+)
+
+// BuiltInGroups the API groups served by kubernetes which are not registered in the client-go scheme
+var BuiltInGroups = map[string]bool{
+	"apiextensions.k8s.io":   true,
+	"apiregistration.k8s.io": true,
+	"metrics.k8s.io":         true,
+}
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir        string
+	Cluster    bool
+	KubeClient kubernetes.Interface
+	Failures   []verifiers.Failure
+
+	clusterGroupVersions map[string]bool
+}
+
+type customResource struct {
+	node *yaml.RNode
+	path string
+	gvk  schema.GroupVersionKind
+}
+
+// NewCmdVerifyCRDs creates a command object for the command
+func NewCmdVerifyCRDs() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "crds",
+		Short:   "Verifies that there is a CustomResourceDefinition for every custom resource in the directory tree",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.Cluster, "cluster", "", false, "also use the CustomResourceDefinitions installed in the current cluster")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	o.clusterGroupVersions = nil
+	if o.Cluster {
+		var err error
+		o.KubeClient, err = kube.LazyCreateKubeClient(o.KubeClient)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create kube client")
+		}
+	}
+
+	// the versions of each CRD indexed by group and kind
+	definitions := map[schema.GroupKind][]string{}
+	var resources []customResource
+
+	// lets find the CRDs in the whole tree even if the filter excludes them
+	err := kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		if kyamls.GetKind(node, path) != "CustomResourceDefinition" {
+			return false, nil
+		}
+		gk := schema.GroupKind{
+			Group: kyamls.GetStringField(node, path, "spec", "group"),
+			Kind:  kyamls.GetStringField(node, path, "spec", "names", "kind"),
+		}
+		definitions[gk] = append(definitions[gk], crdVersions(node)...)
+		return false, nil
+	}, kyamls.Filter{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find CRDs in dir %s", o.Dir)
+	}
+
+	err = kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		apiVersion := kyamls.GetAPIVersion(node, path)
+		if kind == "" || apiVersion == "" {
+			return false, nil
+		}
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "has an invalid apiVersion %s", apiVersion))
+			return false, nil
+		}
+		gvk := gv.WithKind(kind)
+		if scheme.Scheme.Recognizes(gvk) || BuiltInGroups[gv.Group] {
+			return false, nil
+		}
+		resources = append(resources, customResource{node: node, path: path, gvk: gvk})
+		return false, nil
+	}, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+
+	for _, r := range resources {
+		gvk := r.gvk
+		versions, found := definitions[gvk.GroupKind()]
+		if found && stringhelpers.StringArrayIndex(versions, gvk.Version) >= 0 {
+			continue
+		}
+		if o.Cluster {
+			inCluster, err := o.clusterHasKind(gvk)
+			if err != nil {
+				return errors.Wrapf(err, "failed to check if the cluster has %s", gvk.String())
+			}
+			if inCluster {
+				continue
+			}
+		}
+		if found {
+			o.Failures = append(o.Failures, verifiers.NewFailure(r.node, r.path, "the CustomResourceDefinition for %s does not define version %s only: %s", gvk.GroupKind().String(), gvk.Version, strings.Join(versions, ", ")))
+			continue
+		}
+		o.Failures = append(o.Failures, verifiers.NewFailure(r.node, r.path, "missing CustomResourceDefinition for %s", gvk.GroupKind().String()))
+	}
+	return verifiers.Report(o.Failures, "custom resources without a CustomResourceDefinition")
+}
+
+// clusterHasKind returns true if the cluster serves the kind for the group and version
+func (o *Options) clusterHasKind(gvk schema.GroupVersionKind) (bool, error) {
+	if o.clusterGroupVersions == nil {
+		groups, err := o.KubeClient.Discovery().ServerGroups()
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to discover the API groups of the cluster")
+		}
+		o.clusterGroupVersions = map[string]bool{}
+		for _, g := range groups.Groups {
+			for _, v := range g.Versions {
+				o.clusterGroupVersions[v.GroupVersion] = true
+			}
+		}
+	}
+	gv := gvk.GroupVersion().String()
+	if !o.clusterGroupVersions[gv] {
+		return false, nil
+	}
+	list, err := o.KubeClient.Discovery().ServerResourcesForGroupVersion(gv)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to discover the resources of %s", gv)
+	}
+	for _, r := range list.APIResources {
+		if r.Kind == gvk.Kind {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// crdVersions returns the versions defined by the CRD supporting both the v1 'versions' and v1beta1 'version' fields
+func crdVersions(node *yaml.RNode) []string {
+	var answer []string
+	version := kyamls.GetStringField(node, "", "spec", "version")
+	if version != "" {
+		answer = append(answer, version)
+	}
+	versions, err := node.Pipe(yaml.Lookup("spec", "versions"))
+	if err != nil || versions == nil {
+		return answer
+	}
+	elements, err := versions.Elements()
+	if err != nil {
+		return answer
+	}
+	for _, e := range elements {
+		name := kyamls.GetStringField(e, "", "name")
+		if name != "" && stringhelpers.StringArrayIndex(answer, name) < 0 {
+			answer = append(answer, name)
+		}
+	}
+	return answer
+}
@@ -0,0 +1,60 @@
+package crds_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/crds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVerifyCRDs(t *testing.T) {
+	_, o := crds.NewCmdVerifyCRDs()
+	o.Dir = filepath.Join("test_data", "present")
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = crds.NewCmdVerifyCRDs()
+	o.Dir = filepath.Join("test_data", "missing")
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var messages []string
+	for _, f := range o.Failures {
+		messages = append(messages, f.Kind+"/"+f.Name+": "+f.Message)
+	}
+	assert.Equal(t, []string{
+		"Gadget/my-gadget: the CustomResourceDefinition for Gadget.acme.com does not define version v1 only: v1alpha1",
+		"Widget/my-widget: missing CustomResourceDefinition for Widget.acme.com",
+	}, messages, "failure messages")
+}
+
+func TestVerifyCRDsInCluster(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "acme.com/v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name: "widgets",
+					Kind: "Widget",
+				},
+			},
+		},
+	}
+
+	_, o := crds.NewCmdVerifyCRDs()
+	o.Dir = filepath.Join("test_data", "missing")
+	o.Cluster = true
+	o.KubeClient = kubeClient
+	err := o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	require.Len(t, o.Failures, 1, "failures")
+	assert.Equal(t, "Gadget", o.Failures[0].Kind, "the Widget CRD is in the cluster")
+}
@@ -0,0 +1,197 @@
+package envsecrets
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/workloads"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that the environment variables of the workloads do not contain hardcoded secrets
+
+An environment variable literal 'value' is reported if the name of the variable looks like a secret, such as *_PASSWORD, *_TOKEN or *_KEY,
+if the value is a long high entropy string such as a generated token or if the value is a URL containing a password. Secrets should be referenced via 'valueFrom.secretKeyRef' instead.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies there are no secrets in the environment variables of the workloads
+		%s verify env-secrets --dir config-root
+
+		# ignore an environment variable which is known not to be a secret
+		%s verify env-secrets --allow CACHE_KEY_PREFIX
+	`)
+
+	// SecretNameWords the words in environment variable names which indicate the value is a secret
+	SecretNameWords = []string{"PASSWORD", "PASSWD", "PWD", "SECRET", "TOKEN", "KEY", "APIKEY", "CREDENTIAL", "CREDENTIALS"}
+
+	nameSplitRegex  = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	envRefRegex     = regexp.MustCompile(`^\$\([A-Za-z0-9_]+\)$`)
+	whitespaceRegex = regexp.MustCompile(`\s`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir              string
+	Allow            []string
+	EntropyThreshold float64
+	MinLength        int
+	Failures         []verifiers.Failure
+}
+
+// NewCmdVerifyEnvSecrets creates a command object for the command
+func NewCmdVerifyEnvSecrets() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "env-secrets",
+		Short:   "Verifies that the environment variables of the workloads do not contain hardcoded secrets",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringArrayVarP(&o.Allow, "allow", "a", nil, "the names of environment variables which are allowed to have literal values. Supports a trailing '*' wildcard")
+	cmd.Flags().Float64VarP(&o.EntropyThreshold, "entropy-threshold", "", 4.0, "the Shannon entropy in bits per character above which a value is considered to be a secret")
+	cmd.Flags().IntVarP(&o.MinLength, "min-length", "", 20, "the minimum length of a value to check its entropy")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		err := workloads.ForEachContainer(node, kind, true, func(container *yaml.RNode) error {
+			containerName := workloads.GetContainerName(container)
+			env, err := container.Pipe(yaml.Lookup("env"))
+			if err != nil {
+				return errors.Wrapf(err, "failed to get env of container %s", containerName)
+			}
+			if env == nil {
+				return nil
+			}
+			elements, err := env.Elements()
+			if err != nil {
+				return errors.Wrapf(err, "failed to get env elements of container %s", containerName)
+			}
+			for _, e := range elements {
+				name := kyamls.GetStringField(e, path, "name")
+				value := kyamls.GetStringField(e, path, "value")
+				if o.isAllowed(name) {
+					continue
+				}
+				reason := o.SecretReason(name, value)
+				if reason != "" {
+					o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "container %s env %s %s", containerName, name, reason))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to check containers")
+		}
+		return false, nil
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "secrets in environment variable literals")
+}
+
+func (o *Options) isAllowed(name string) bool {
+	for _, pattern := range o.Allow {
+		if stringhelpers.StringMatchesPattern(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretReason returns the reason why the environment variable literal looks like a secret or an empty string if it does not
+func (o *Options) SecretReason(name, value string) string {
+	if isPlaceholder(value) {
+		return ""
+	}
+	if IsSecretName(name) {
+		return "has a literal value but its name looks like a secret"
+	}
+	u, err := url.Parse(value)
+	if err == nil && u.Scheme != "" && u.Host != "" {
+		// URLs are naturally high entropy so only report any embedded credentials
+		if _, ok := u.User.Password(); ok {
+			return "has a URL literal value containing a password"
+		}
+		return ""
+	}
+	if len(value) >= o.MinLength && !whitespaceRegex.MatchString(value) {
+		entropy := Entropy(value)
+		if entropy >= o.EntropyThreshold {
+			return fmt.Sprintf("has a high entropy literal value (%.2f bits per character) which looks like a secret", entropy)
+		}
+	}
+	return ""
+}
+
+// IsSecretName returns true if any of the words of the environment variable name indicate it is a secret
+func IsSecretName(name string) bool {
+	for _, word := range nameSplitRegex.Split(strings.ToUpper(name), -1) {
+		if stringhelpers.StringArrayIndex(SecretNameWords, word) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlaceholder returns true if the value cannot be a secret such as an empty value, a boolean, a number or a reference to another variable
+func isPlaceholder(value string) bool {
+	if value == "" || envRefRegex.MatchString(value) {
+		return true
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return true
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return true
+	}
+	return false
+}
+
+// Entropy returns the Shannon entropy of the text in bits per character
+func Entropy(text string) float64 {
+	if text == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range text {
+		counts[r]++
+		total++
+	}
+	answer := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(total)
+		answer -= p * math.Log2(p)
+	}
+	return answer
+}
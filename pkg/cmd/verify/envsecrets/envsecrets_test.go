@@ -0,0 +1,56 @@
+package envsecrets_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/envsecrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEnvSecrets(t *testing.T) {
+	_, o := envsecrets.NewCmdVerifyEnvSecrets()
+	o.Dir = filepath.Join("test_data", "benign")
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = envsecrets.NewCmdVerifyEnvSecrets()
+	o.Dir = filepath.Join("test_data", "secrets")
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var messages []string
+	for _, f := range o.Failures {
+		messages = append(messages, f.Kind+"/"+f.Name+": "+f.Message)
+	}
+	require.Len(t, messages, 4, "failure messages %#v", messages)
+	assert.Equal(t, "Deployment/secrets: container init env DB_PASSWORD has a literal value but its name looks like a secret", messages[0])
+	assert.Contains(t, messages[1], "container app env UPSTREAM_CONFIG has a high entropy literal value", "high entropy message")
+	assert.Equal(t, "Deployment/secrets: container app env CACHE_KEY has a literal value but its name looks like a secret", messages[2])
+	assert.Equal(t, "Deployment/secrets: container app env DATABASE_URL has a URL literal value containing a password", messages[3])
+
+	_, o = envsecrets.NewCmdVerifyEnvSecrets()
+	o.Dir = filepath.Join("test_data", "secrets")
+	o.Allow = []string{"CACHE_*", "UPSTREAM_CONFIG"}
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+	assert.Len(t, o.Failures, 2, "failures with allowed variables")
+}
+
+func TestIsSecretName(t *testing.T) {
+	testCases := map[string]bool{
+		"PASSWORD":          true,
+		"DB_PASSWORD":       true,
+		"github-token":      true,
+		"AWS_SECRET_ACCESS": true,
+		"API_KEY":           true,
+		"LOG_LEVEL":         false,
+		"KEYCLOAK_URL":      false,
+		"TOKENIZER":         false,
+	}
+	for name, expected := range testCases {
+		assert.Equal(t, expected, envsecrets.IsSecretName(name), "secret name %s", name)
+	}
+}
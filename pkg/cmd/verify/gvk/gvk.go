@@ -0,0 +1,173 @@
+package gvk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that the group, version and kind of every resource in the directory tree is served by the current cluster
+
+Resources whose kind is not served are usually missing a CustomResourceDefinition or use an API version which is not supported by the version of kubernetes of the cluster.
+Custom resources whose CustomResourceDefinition is in the directory tree are accepted as the kind is served once the tree is applied unless --dir-crds=false is specified.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the kinds of the resources are served by the current cluster
+		%s verify gvk --dir config-root
+
+		# verifies the kinds even if their CustomResourceDefinition is in the directory tree
+		%s verify gvk --dir config-root --dir-crds=false
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir        string
+	DirCRDs    bool
+	KubeClient kubernetes.Interface
+	Failures   []verifiers.Failure
+}
+
+// NewCmdVerifyGVK creates a command object for the command
+func NewCmdVerifyGVK() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "gvk",
+		Short:   "Verifies that the group, version and kind of every resource in the directory tree is served by the current cluster",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.DirCRDs, "dir-crds", "", true, "accept custom resources whose CustomResourceDefinition is in the directory tree")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	var err error
+	o.KubeClient, err = kube.LazyCreateKubeClient(o.KubeClient)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create kube client")
+	}
+
+	// the kinds served by each group version and the versions served by each group
+	served := map[schema.GroupVersionKind]bool{}
+	groupVersions := map[string][]string{}
+	_, lists, err := o.KubeClient.Discovery().ServerGroupsAndResources()
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return errors.Wrapf(err, "failed to discover the API resources of the cluster")
+		}
+		log.Logger().Warnf("failed to discover some of the API resources of the cluster: %s", err.Error())
+	}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return errors.Wrapf(err, "invalid group version %s", list.GroupVersion)
+		}
+		groupVersions[gv.Group] = append(groupVersions[gv.Group], gv.Version)
+		for _, r := range list.APIResources {
+			served[gv.WithKind(r.Kind)] = true
+		}
+	}
+
+	// lets find the CRDs in the whole tree even if the filter excludes them
+	if o.DirCRDs {
+		err = kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+			if !kyamls.IsCustomResourceDefinition(kyamls.GetKind(node, path)) {
+				return false, nil
+			}
+			group := kyamls.GetStringField(node, path, "spec", "group")
+			kind := kyamls.GetStringField(node, path, "spec", "names", "kind")
+			for _, v := range crdVersions(node) {
+				served[schema.GroupVersionKind{Group: group, Version: v, Kind: kind}] = true
+			}
+			return false, nil
+		}, kyamls.Filter{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to find CRDs in dir %s", o.Dir)
+		}
+	}
+
+	err = kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		apiVersion := kyamls.GetAPIVersion(node, path)
+		if kind == "" || apiVersion == "" {
+			return false, nil
+		}
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "has an invalid apiVersion %s", apiVersion))
+			return false, nil
+		}
+		gvk := gv.WithKind(kind)
+		if served[gvk] {
+			return false, nil
+		}
+		versions := groupVersions[gv.Group]
+		switch {
+		case len(versions) == 0:
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "the cluster does not serve the API group of %s", gvk.GroupKind().String()))
+		case stringhelpers.StringArrayIndex(versions, gv.Version) < 0:
+			sort.Strings(versions)
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "the cluster does not serve version %s of %s only: %s", gv.Version, gvk.GroupKind().String(), strings.Join(versions, ", ")))
+		default:
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "the cluster does not serve the kind %s in %s", kind, apiVersion))
+		}
+		return false, nil
+	}, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "resources whose kind is not served by the cluster")
+}
+
+// crdVersions returns the versions defined by the CRD supporting both the v1 'versions' and v1beta1 'version' fields
+func crdVersions(node *yaml.RNode) []string {
+	var answer []string
+	version := kyamls.GetStringField(node, "", "spec", "version")
+	if version != "" {
+		answer = append(answer, version)
+	}
+	versions, err := node.Pipe(yaml.Lookup("spec", "versions"))
+	if err != nil || versions == nil {
+		return answer
+	}
+	elements, err := versions.Elements()
+	if err != nil {
+		return answer
+	}
+	for _, e := range elements {
+		name := kyamls.GetStringField(e, "", "name")
+		if name != "" {
+			answer = append(answer, name)
+		}
+	}
+	return answer
+}
@@ -0,0 +1,93 @@
+package gvk_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/gvk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVerifyGVK(t *testing.T) {
+	newKubeClient := func() *fake.Clientset {
+		kubeClient := fake.NewSimpleClientset()
+		kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Kind: "Deployment", Namespaced: true},
+				},
+			},
+			{
+				GroupVersion: "batch/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "jobs", Kind: "Job", Namespaced: true},
+				},
+			},
+			{
+				GroupVersion: "networking.k8s.io/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "ingresses", Kind: "Ingress", Namespaced: true},
+				},
+			},
+			{
+				GroupVersion: "apiextensions.k8s.io/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "customresourcedefinitions", Kind: "CustomResourceDefinition"},
+				},
+			},
+		}
+		return kubeClient
+	}
+
+	testCases := []struct {
+		name     string
+		dirCRDs  bool
+		expected []string
+	}{
+		{
+			name:    "dir-crds",
+			dirCRDs: true,
+			expected: []string{
+				"CronJob/backup: the cluster does not serve the kind CronJob in batch/v1",
+				"Ingress/myapp: the cluster does not serve version v1beta1 of Ingress.networking.k8s.io only: v1",
+				"Widget/my-widget: the cluster does not serve the API group of Widget.acme.com",
+			},
+		},
+		{
+			name: "cluster-only",
+			expected: []string{
+				"CronJob/backup: the cluster does not serve the kind CronJob in batch/v1",
+				"Gadget/my-gadget: the cluster does not serve the API group of Gadget.acme.com",
+				"Ingress/myapp: the cluster does not serve version v1beta1 of Ingress.networking.k8s.io only: v1",
+				"Widget/my-widget: the cluster does not serve the API group of Widget.acme.com",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		_, o := gvk.NewCmdVerifyGVK()
+		o.Dir = filepath.Join("test_data", "source")
+		o.DirCRDs = tc.dirCRDs
+		o.KubeClient = newKubeClient()
+		err := o.Run()
+		require.Error(t, err, "should have failed to verify dir %s for %s", o.Dir, tc.name)
+
+		var messages []string
+		for _, f := range o.Failures {
+			messages = append(messages, f.Kind+"/"+f.Name+": "+f.Message)
+		}
+		assert.Equal(t, tc.expected, messages, "failure messages for %s", tc.name)
+	}
+
+	_, o := gvk.NewCmdVerifyGVK()
+	o.Dir = filepath.Join("test_data", "source")
+	o.KubeClient = newKubeClient()
+	o.Filter.Kinds = []string{"Deployment", "Gadget"}
+	err := o.Run()
+	require.NoError(t, err, "should verify the filtered kinds")
+	assert.Empty(t, o.Failures, "failures")
+}
@@ -0,0 +1,165 @@
+package images
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that all the container images in the kubernetes resources use immutable references
+
+Every 'image' field is checked so images in workloads, Tekton steps and sidecars and custom resources are all verified.
+
+An image is considered immutable if it is referenced by digest or by a semantic version tag.
+Images using the 'latest' tag, no tag or any other tag are reported unless they match the --allow patterns.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies all images use a digest or semantic version tag
+		%s verify images --dir config-root
+
+		# allows any images from a registry to use mutable tags
+		%s verify images --allow gcr.io/my-project/*
+	`)
+
+	semverTagRegex = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir      string
+	Allow    []string
+	Failures []verifiers.Failure
+}
+
+// NewCmdVerifyImages creates a command object for the command
+func NewCmdVerifyImages() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "images",
+		Short:   "Verifies that all the container images in the kubernetes resources use immutable references",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringArrayVarP(&o.Allow, "allow", "a", nil, "the image names which are allowed to use mutable tags. Supports a trailing '*' wildcard")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		ForEachImage(node.YNode(), func(name, image string) {
+			if image == "" || o.isAllowed(image) {
+				return
+			}
+			reason := MutableReason(image)
+			if reason == "" {
+				return
+			}
+			if name != "" {
+				o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "container %s image %s %s", name, image, reason))
+				return
+			}
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "image %s %s", image, reason))
+		})
+		return false, nil
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "mutable image references")
+}
+
+// ForEachImage invokes the function on every image field in the node tree along with the name of the enclosing object
+// so that images in any kind of resource are found such as pod specs, Tekton steps and sidecars or custom resources
+func ForEachImage(node *yaml.Node, fn func(name, image string)) {
+	ForEachImageNode(node, func(name string, image *yaml.Node) {
+		fn(name, image.Value)
+	})
+}
+
+// ForEachImageNode invokes the function on the value node of every image field in the node tree along with the name
+// of the enclosing object so that the image can be modified
+func ForEachImageNode(node *yaml.Node, fn func(name string, image *yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			ForEachImageNode(child, fn)
+		}
+	case yaml.MappingNode:
+		name := ""
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "name" && value.Kind == yaml.ScalarNode {
+				name = value.Value
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "image" && value.Kind == yaml.ScalarNode {
+				fn(name, value)
+				continue
+			}
+			ForEachImageNode(value, fn)
+		}
+	}
+}
+
+func (o *Options) isAllowed(image string) bool {
+	name, _ := SplitImageTag(image)
+	for _, pattern := range o.Allow {
+		if stringhelpers.StringMatchesPattern(image, pattern) || stringhelpers.StringMatchesPattern(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// MutableReason returns the reason why the image reference is mutable or an empty string if it is immutable
+func MutableReason(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	_, tag := SplitImageTag(image)
+	switch {
+	case tag == "":
+		return "has no tag"
+	case tag == "latest":
+		return "uses the latest tag"
+	case !semverTagRegex.MatchString(tag):
+		return "does not use a digest or semantic version tag"
+	default:
+		return ""
+	}
+}
+
+// SplitImageTag splits the image into the name and tag ignoring any registry port
+func SplitImageTag(image string) (string, string) {
+	idx := strings.LastIndex(image, ":")
+	if idx < 0 || strings.Contains(image[idx:], "/") {
+		return image, ""
+	}
+	return image[:idx], image[idx+1:]
+}
@@ -0,0 +1,55 @@
+package images_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/images"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyImages(t *testing.T) {
+	_, o := images.NewCmdVerifyImages()
+	o.Dir = filepath.Join("test_data", "immutable")
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = images.NewCmdVerifyImages()
+	o.Dir = filepath.Join("test_data", "mutable")
+	o.Allow = []string{"gcr.io/my-project/*"}
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var messages []string
+	for _, f := range o.Failures {
+		messages = append(messages, f.Kind+"/"+f.Name+": "+f.Message)
+	}
+	assert.Equal(t, []string{
+		"Deployment/mutable: container latest image nginx:latest uses the latest tag",
+		"Deployment/mutable: container notag image localhost:5000/nginx has no tag",
+		"Deployment/mutable: container branch image gcr.io/jenkinsxio/builder-go:main does not use a digest or semantic version tag",
+		"Task/release: container build image gcr.io/kaniko-project/executor:debug does not use a digest or semantic version tag",
+		"Task/release: container docker image docker:dind does not use a digest or semantic version tag",
+	}, messages, "failure messages")
+}
+
+func TestMutableReason(t *testing.T) {
+	testCases := map[string]bool{
+		"nginx":                        true,
+		"nginx:latest":                 true,
+		"nginx:stable":                 true,
+		"localhost:5000/nginx":         true,
+		"nginx:1.19.6":                 false,
+		"nginx:v1.19.6":                false,
+		"localhost:5000/nginx:1.0.0":   false,
+		"nginx@sha256:abc123":          false,
+		"nginx:latest@sha256:abc123":   false,
+		"gcr.io/jx/app:0.0.1-SNAPSHOT": false,
+	}
+	for image, expected := range testCases {
+		reason := images.MutableReason(image)
+		assert.Equal(t, expected, reason != "", "mutable for image %s with reason %s", image, reason)
+	}
+}
@@ -0,0 +1,169 @@
+package ingresstls
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that the TLS secrets referenced by the Ingress resources exist
+
+Each spec.tls[].secretName of an Ingress must resolve to a Secret in the same namespace. A Secret is found if the directory tree contains a Secret or an ExternalSecret of that name
+or a cert-manager Certificate whose spec.secretName is that name.
+
+If --cluster is specified then any secrets which are not in the directory tree are looked up in the current cluster too.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the TLS secrets of the ingresses are in the directory tree
+		%s verify ingress-tls --dir config-root
+
+		# verifies the TLS secrets of the ingresses are in the directory tree or the current cluster
+		%s verify ingress-tls --dir config-root --cluster
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir        string
+	Cluster    bool
+	KubeClient kubernetes.Interface
+	Failures   []verifiers.Failure
+}
+
+// NewCmdVerifyIngressTLS creates a command object for the command
+func NewCmdVerifyIngressTLS() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "ingress-tls",
+		Short:   "Verifies that the TLS secrets referenced by the Ingress resources exist",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.Cluster, "cluster", "", false, "also look for the secrets which are not in the directory tree in the current cluster")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+
+	// lets find the secrets in the whole tree even if the filter excludes them
+	secrets := map[string]bool{}
+	err := kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		name := SecretName(node, path)
+		if name != "" {
+			secrets[secretKey(kyamls.GetNamespace(node, path), name)] = true
+		}
+		return false, nil
+	}, kyamls.Filter{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find secrets in dir %s", o.Dir)
+	}
+
+	if o.Cluster {
+		o.KubeClient, err = kube.LazyCreateKubeClient(o.KubeClient)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create kube client")
+		}
+	}
+
+	ctx := context.Background()
+	err = kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		if kyamls.GetKind(node, path) != "Ingress" {
+			return false, nil
+		}
+		tls, err := node.Pipe(yaml.Lookup("spec", "tls"))
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get spec.tls")
+		}
+		if tls == nil {
+			return false, nil
+		}
+		elements, err := tls.Elements()
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get spec.tls elements")
+		}
+		ns := kyamls.GetNamespace(node, path)
+		for _, e := range elements {
+			secretName := kyamls.GetStringField(e, path, "secretName")
+			if secretName == "" || secrets[secretKey(ns, secretName)] {
+				continue
+			}
+			if o.Cluster {
+				found, err := o.clusterHasSecret(ctx, ns, secretName)
+				if err != nil {
+					return false, err
+				}
+				if found {
+					continue
+				}
+			}
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "the TLS secret %s does not exist", secretName))
+		}
+		return false, nil
+	}, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "ingresses with missing TLS secrets")
+}
+
+// SecretName returns the name of the Secret defined by the resource if it is a Secret, an ExternalSecret or a
+// cert-manager Certificate otherwise returns an empty string
+func SecretName(node *yaml.RNode, path string) string {
+	switch kyamls.GetKind(node, path) {
+	case "Secret":
+		return kyamls.GetName(node, path)
+	case "ExternalSecret":
+		name := kyamls.GetStringField(node, path, "spec", "target", "name")
+		if name != "" {
+			return name
+		}
+		return kyamls.GetName(node, path)
+	case "Certificate":
+		return kyamls.GetStringField(node, path, "spec", "secretName")
+	default:
+		return ""
+	}
+}
+
+func (o *Options) clusterHasSecret(ctx context.Context, ns, name string) (bool, error) {
+	if ns == "" {
+		ns = "default"
+	}
+	_, err := o.KubeClient.CoreV1().Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to get Secret %s in namespace %s", name, ns)
+	}
+	return true, nil
+}
+
+func secretKey(ns, name string) string {
+	return ns + "/" + name
+}
@@ -0,0 +1,54 @@
+package ingresstls_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/ingresstls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVerifyIngressTLS(t *testing.T) {
+	_, o := ingresstls.NewCmdVerifyIngressTLS()
+	o.Dir = filepath.Join("test_data", "valid")
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = ingresstls.NewCmdVerifyIngressTLS()
+	o.Dir = filepath.Join("test_data", "dangling")
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var messages []string
+	for _, f := range o.Failures {
+		messages = append(messages, f.Kind+"/"+f.Name+": "+f.Message)
+	}
+	assert.Equal(t, []string{
+		"Ingress/app: the TLS secret api-tls does not exist",
+		"Ingress/app: the TLS secret cluster-tls does not exist",
+	}, messages, "failures for dir %s", o.Dir)
+}
+
+func TestVerifyIngressTLSInCluster(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-tls",
+			Namespace: "jx",
+		},
+	})
+
+	_, o := ingresstls.NewCmdVerifyIngressTLS()
+	o.Dir = filepath.Join("test_data", "dangling")
+	o.Cluster = true
+	o.KubeClient = kubeClient
+	err := o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	require.Len(t, o.Failures, 1, "failures for dir %s", o.Dir)
+	assert.Equal(t, "the TLS secret api-tls does not exist", o.Failures[0].Message)
+}
@@ -0,0 +1,183 @@
+package namespaces
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kube"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that all the namespaced kubernetes resources declare their metadata.namespace
+
+Otherwise the resources are applied to the 'default' namespace or the namespace of the current context.
+
+The scope of each kind is found from the CustomResourceDefinitions in the directory tree and the built in kubernetes kinds.
+If --cluster is specified then the API resources of the current cluster are used too.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the namespaced resources have a namespace
+		%s verify namespaces --dir config-root
+
+		# verifies the namespaced resources have a namespace using the scope of the kinds in the current cluster
+		%s verify namespaces --dir config-root --cluster
+	`)
+
+	// ClusterScopedKinds the built in kubernetes kinds which are not namespaced
+	ClusterScopedKinds = map[schema.GroupKind]bool{
+		{Group: "", Kind: "ComponentStatus"}:                                            true,
+		{Group: "", Kind: "Namespace"}:                                                  true,
+		{Group: "", Kind: "Node"}:                                                       true,
+		{Group: "", Kind: "PersistentVolume"}:                                           true,
+		{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:   true,
+		{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: true,
+		{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:               true,
+		{Group: "apiregistration.k8s.io", Kind: "APIService"}:                           true,
+		{Group: "authentication.k8s.io", Kind: "TokenReview"}:                           true,
+		{Group: "authorization.k8s.io", Kind: "SelfSubjectAccessReview"}:                true,
+		{Group: "authorization.k8s.io", Kind: "SelfSubjectRulesReview"}:                 true,
+		{Group: "authorization.k8s.io", Kind: "SubjectAccessReview"}:                    true,
+		{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}:               true,
+		{Group: "flowcontrol.apiserver.k8s.io", Kind: "FlowSchema"}:                     true,
+		{Group: "flowcontrol.apiserver.k8s.io", Kind: "PriorityLevelConfiguration"}:     true,
+		{Group: "networking.k8s.io", Kind: "IngressClass"}:                              true,
+		{Group: "node.k8s.io", Kind: "RuntimeClass"}:                                    true,
+		{Group: "policy", Kind: "PodSecurityPolicy"}:                                    true,
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                       true,
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                true,
+		{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                             true,
+		{Group: "storage.k8s.io", Kind: "CSIDriver"}:                                    true,
+		{Group: "storage.k8s.io", Kind: "CSINode"}:                                      true,
+		{Group: "storage.k8s.io", Kind: "StorageClass"}:                                 true,
+		{Group: "storage.k8s.io", Kind: "VolumeAttachment"}:                             true,
+	}
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir        string
+	Cluster    bool
+	KubeClient kubernetes.Interface
+	Failures   []verifiers.Failure
+}
+
+// NewCmdVerifyNamespaces creates a command object for the command
+func NewCmdVerifyNamespaces() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "namespaces",
+		Short:   "Verifies that all the namespaced kubernetes resources declare their metadata.namespace",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.Cluster, "cluster", "", false, "also use the scope of the API resources in the current cluster")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+
+	// the scope of each kind indexed by group and kind where true means namespaced
+	scopes := map[schema.GroupKind]bool{}
+	for gk := range ClusterScopedKinds {
+		scopes[gk] = false
+	}
+
+	// lets find the CRDs in the whole tree even if the filter excludes them
+	err := kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		if !kyamls.IsCustomResourceDefinition(kyamls.GetKind(node, path)) {
+			return false, nil
+		}
+		gk := schema.GroupKind{
+			Group: kyamls.GetStringField(node, path, "spec", "group"),
+			Kind:  kyamls.GetStringField(node, path, "spec", "names", "kind"),
+		}
+		scopes[gk] = kyamls.GetStringField(node, path, "spec", "scope") != "Cluster"
+		return false, nil
+	}, kyamls.Filter{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find CRDs in dir %s", o.Dir)
+	}
+
+	if o.Cluster {
+		err = o.addClusterScopes(scopes)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		apiVersion := kyamls.GetAPIVersion(node, path)
+		if kind == "" || apiVersion == "" || kind == "List" {
+			return false, nil
+		}
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "has an invalid apiVersion %s", apiVersion))
+			return false, nil
+		}
+		gk := gv.WithKind(kind).GroupKind()
+		namespaced, found := scopes[gk]
+		if !found {
+			// lets assume any unknown kind is namespaced unless its using the naming convention for cluster kinds
+			namespaced = !kyamls.IsClusterKind(kind)
+		}
+		if namespaced && kyamls.GetNamespace(node, path) == "" {
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "the namespaced %s is missing metadata.namespace", gk.String()))
+		}
+		return false, nil
+	}, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "namespaced resources without a namespace")
+}
+
+// addClusterScopes adds the scopes of the API resources of the current cluster
+func (o *Options) addClusterScopes(scopes map[schema.GroupKind]bool) error {
+	var err error
+	o.KubeClient, err = kube.LazyCreateKubeClient(o.KubeClient)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create kube client")
+	}
+	_, lists, err := o.KubeClient.Discovery().ServerGroupsAndResources()
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return errors.Wrapf(err, "failed to discover the API resources of the cluster")
+		}
+		log.Logger().Warnf("failed to discover some of the API resources of the cluster: %s", err.Error())
+	}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return errors.Wrapf(err, "invalid group version %s", list.GroupVersion)
+		}
+		for _, r := range list.APIResources {
+			scopes[gv.WithKind(r.Kind).GroupKind()] = r.Namespaced
+		}
+	}
+	return nil
+}
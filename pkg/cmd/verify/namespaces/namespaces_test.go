@@ -0,0 +1,65 @@
+package namespaces_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/namespaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVerifyNamespaces(t *testing.T) {
+	_, o := namespaces.NewCmdVerifyNamespaces()
+	o.Dir = filepath.Join("test_data", "valid")
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = namespaces.NewCmdVerifyNamespaces()
+	o.Dir = filepath.Join("test_data", "invalid")
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var messages []string
+	for _, f := range o.Failures {
+		messages = append(messages, f.Kind+"/"+f.Name+": "+f.Message)
+	}
+	assert.Equal(t, []string{
+		"Deployment/app: the namespaced Deployment.apps is missing metadata.namespace",
+		"Gadget/gadget: the namespaced Gadget.acme.com is missing metadata.namespace",
+		"Widget/widget: the namespaced Widget.acme.com is missing metadata.namespace",
+	}, messages, "failures for dir %s", o.Dir)
+}
+
+func TestVerifyNamespacesInCluster(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "acme.com/v1",
+			APIResources: []metav1.APIResource{
+				{
+					Name:       "gadgets",
+					Kind:       "Gadget",
+					Namespaced: false,
+				},
+			},
+		},
+	}
+
+	_, o := namespaces.NewCmdVerifyNamespaces()
+	o.Dir = filepath.Join("test_data", "invalid")
+	o.Cluster = true
+	o.KubeClient = kubeClient
+	err := o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var names []string
+	for _, f := range o.Failures {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"app", "widget"}, names, "the Gadget kind is cluster scoped in the cluster")
+}
@@ -0,0 +1,140 @@
+package probes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/workloads"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// SkipAnnotation the annotation on a workload to opt out of the probe verification. The value is either 'true'
+// or a comma separated list of the names of the containers which do not need probes
+const SkipAnnotation = "jenkins-x.io/skip-probes"
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that all the containers of the Deployments, StatefulSets and DaemonSets declare liveness and readiness probes
+
+A workload can opt out by using the annotation '` + SkipAnnotation + `' with the value 'true' or a comma separated list of container names.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies all containers have liveness and readiness probes
+		%s verify probes --dir config-root
+
+		# only verify the readiness probes of Deployments
+		%s verify probes --liveness=false --statefulsets=false --daemonsets=false
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir          string
+	Liveness     bool
+	Readiness    bool
+	Deployments  bool
+	StatefulSets bool
+	DaemonSets   bool
+	Failures     []verifiers.Failure
+}
+
+// NewCmdVerifyProbes creates a command object for the command
+func NewCmdVerifyProbes() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "probes",
+		Short:   "Verifies that all the containers of the workloads declare liveness and readiness probes",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.Liveness, "liveness", "", true, "verifies that containers declare a liveness probe")
+	cmd.Flags().BoolVarP(&o.Readiness, "readiness", "", true, "verifies that containers declare a readiness probe")
+	cmd.Flags().BoolVarP(&o.Deployments, "deployments", "", true, "verifies the containers of Deployments")
+	cmd.Flags().BoolVarP(&o.StatefulSets, "statefulsets", "", true, "verifies the containers of StatefulSets")
+	cmd.Flags().BoolVarP(&o.DaemonSets, "daemonsets", "", true, "verifies the containers of DaemonSets")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	kinds := map[string]bool{
+		"Deployment":  o.Deployments,
+		"StatefulSet": o.StatefulSets,
+		"DaemonSet":   o.DaemonSets,
+	}
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		if !kinds[kind] {
+			return false, nil
+		}
+		skip := kyamls.GetStringField(node, path, "metadata", "annotations", SkipAnnotation)
+		if skip == "true" {
+			return false, nil
+		}
+		err := workloads.ForEachContainer(node, kind, false, func(container *yaml.RNode) error {
+			if !skipContainer(skip, workloads.GetContainerName(container)) {
+				o.verifyContainer(node, path, container)
+			}
+			return nil
+		})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to verify containers in %s", path)
+		}
+		return false, nil
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "containers missing liveness or readiness probes")
+}
+
+func (o *Options) verifyContainer(node *yaml.RNode, path string, container *yaml.RNode) {
+	checks := []struct {
+		enabled bool
+		field   string
+	}{
+		{o.Liveness, "livenessProbe"},
+		{o.Readiness, "readinessProbe"},
+	}
+	var missing []string
+	for _, c := range checks {
+		if !c.enabled {
+			continue
+		}
+		probe, err := container.Pipe(yaml.Lookup(c.field))
+		if err != nil || probe == nil {
+			missing = append(missing, c.field)
+		}
+	}
+	if len(missing) > 0 {
+		o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "container %s is missing %v", workloads.GetContainerName(container), missing))
+	}
+}
+
+// skipContainer returns true if the container name is in the comma separated names of the skip annotation value
+func skipContainer(skip, name string) bool {
+	for _, s := range strings.Split(skip, ",") {
+		if strings.TrimSpace(s) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package probes_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/probes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyProbes(t *testing.T) {
+	_, o := probes.NewCmdVerifyProbes()
+	o.Dir = filepath.Join("test_data", "probed")
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = probes.NewCmdVerifyProbes()
+	o.Dir = filepath.Join("test_data", "unprobed")
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	messages := map[string][]string{}
+	for _, f := range o.Failures {
+		messages[f.Name] = append(messages[f.Name], f.Message)
+	}
+	assert.Equal(t, map[string][]string{
+		"missing-liveness": {
+			"container app is missing [livenessProbe]",
+			"container sidecar is missing [livenessProbe readinessProbe]",
+		},
+		"missing-probes": {
+			"container db is missing [livenessProbe readinessProbe]",
+		},
+		"missing-readiness": {
+			"container agent is missing [readinessProbe]",
+		},
+	}, messages, "failures for dir %s", o.Dir)
+}
+
+func TestVerifyProbesToggles(t *testing.T) {
+	_, o := probes.NewCmdVerifyProbes()
+	o.Dir = filepath.Join("test_data", "unprobed")
+	o.Liveness = false
+	o.StatefulSets = false
+	err := o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	require.Len(t, o.Failures, 2, "failures for dir %s", o.Dir)
+	for _, f := range o.Failures {
+		assert.NotEqual(t, "StatefulSet", f.Kind, "should not verify StatefulSets")
+		assert.NotContains(t, f.Message, "livenessProbe", "should not verify liveness probes")
+	}
+
+	_, o = probes.NewCmdVerifyProbes()
+	o.Dir = filepath.Join("test_data", "unprobed")
+	o.Readiness = false
+	o.Deployments = false
+	o.StatefulSets = false
+	err = o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+}
@@ -0,0 +1,150 @@
+package registries
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/images"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// DockerHubRegistry the registry used for images which do not specify a registry host
+	DockerHubRegistry = "docker.io"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that all the container images in the kubernetes resources are pulled from the approved registries
+
+Images which do not specify a registry host such as 'nginx' or 'bitnami/nginx' are pulled from 'docker.io'.
+Registries can use wildcards such as '*.gcr.io'.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies all images are pulled from gcr.io or ghcr.io
+		%s verify registries --dir config-root --registry gcr.io --registry ghcr.io
+
+		# verifies all images are pulled from docker hub or any regional gcr.io registry
+		%s verify registries --registry docker.io --registry *.gcr.io
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir        string
+	Registries []string
+	Failures   []verifiers.Failure
+}
+
+// NewCmdVerifyRegistries creates a command object for the command
+func NewCmdVerifyRegistries() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "registries",
+		Short:   "Verifies that all the container images in the kubernetes resources are pulled from the approved registries",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringArrayVarP(&o.Registries, "registry", "r", nil, "the approved registry hosts. Supports wildcards such as '*.gcr.io'")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if len(o.Registries) == 0 {
+		return options.MissingOption("registry")
+	}
+	for _, pattern := range o.Registries {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return errors.Wrapf(err, "invalid registry pattern %s", pattern)
+		}
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate")
+	}
+	o.Failures = nil
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		images.ForEachImage(node.YNode(), func(name, image string) {
+			if image == "" {
+				return
+			}
+			registry := ImageRegistry(image)
+			if o.isApproved(registry) {
+				return
+			}
+			if name != "" {
+				o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "container %s image %s uses the registry %s which is not approved", name, image, registry))
+				return
+			}
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "image %s uses the registry %s which is not approved", image, registry))
+		})
+		return false, nil
+	}
+	err = kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "images from unapproved registries")
+}
+
+func (o *Options) isApproved(registry string) bool {
+	for _, pattern := range o.Registries {
+		if NormalizeRegistry(pattern) == registry {
+			return true
+		}
+		matched, _ := path.Match(pattern, registry)
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageRegistry returns the registry host of the image. Images without a registry host use docker.io
+func ImageRegistry(image string) string {
+	idx := strings.Index(image, "/")
+	if idx < 0 {
+		return DockerHubRegistry
+	}
+	host := image[:idx]
+	if host != "localhost" && !strings.ContainsAny(host, ".:") {
+		// the first path segment is a docker hub organisation
+		return DockerHubRegistry
+	}
+	return NormalizeRegistry(host)
+}
+
+// NormalizeRegistry returns the canonical name of the registry host so that the aliases of docker hub are treated the same
+func NormalizeRegistry(registry string) string {
+	registry = strings.ToLower(registry)
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return DockerHubRegistry
+	}
+	return registry
+}
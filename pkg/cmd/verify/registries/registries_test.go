@@ -0,0 +1,58 @@
+package registries_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/registries"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyRegistries(t *testing.T) {
+	_, o := registries.NewCmdVerifyRegistries()
+	o.Dir = filepath.Join("test_data", "allowed")
+	o.Registries = []string{"docker.io", "gcr.io", "*.gcr.io"}
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = registries.NewCmdVerifyRegistries()
+	o.Dir = filepath.Join("test_data", "disallowed")
+	o.Registries = []string{"*.io"}
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var messages []string
+	for _, f := range o.Failures {
+		messages = append(messages, f.Kind+"/"+f.Name+": "+f.Message)
+	}
+	assert.Equal(t, []string{
+		"Deployment/disallowed: container local image localhost:5000/app:1.0.0 uses the registry localhost:5000 which is not approved",
+	}, messages, "failure messages")
+
+	_, o = registries.NewCmdVerifyRegistries()
+	o.Dir = filepath.Join("test_data", "disallowed")
+	o.Registries = []string{"gcr.io"}
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+	assert.Len(t, o.Failures, 3, "failures for dir %s", o.Dir)
+}
+
+func TestImageRegistry(t *testing.T) {
+	testCases := map[string]string{
+		"nginx":                            "docker.io",
+		"nginx:1.19.6":                     "docker.io",
+		"bitnami/nginx:1.19.6":             "docker.io",
+		"docker.io/bitnami/nginx":          "docker.io",
+		"index.docker.io/library/nginx":    "docker.io",
+		"gcr.io/jenkinsxio/builder-go":     "gcr.io",
+		"localhost/app":                    "localhost",
+		"localhost:5000/app:1.0.0":         "localhost:5000",
+		"GHCR.io/jenkins-x/jx-gitops":      "ghcr.io",
+		"myregistry:5000/org/app@sha256:1": "myregistry:5000",
+	}
+	for image, expected := range testCases {
+		assert.Equal(t, expected, registries.ImageRegistry(image), "registry for image %s", image)
+	}
+}
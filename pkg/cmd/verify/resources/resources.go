@@ -0,0 +1,108 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/workloads"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that all the containers in the kubernetes resources declare their CPU and memory requests and limits
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies all containers have requests and limits
+		%s verify resources --dir config-root
+
+		# only verify the memory requests and limits
+		%s verify resources --cpu-requests=false --cpu-limits=false
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir                  string
+	CPURequests          bool
+	CPULimits            bool
+	MemoryRequests       bool
+	MemoryLimits         bool
+	IgnoreInitContainers bool
+	Failures             []verifiers.Failure
+}
+
+// NewCmdVerifyResources creates a command object for the command
+func NewCmdVerifyResources() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "resources",
+		Short:   "Verifies that all the containers in the kubernetes resources declare their CPU and memory requests and limits",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.CPURequests, "cpu-requests", "", true, "verifies that containers declare CPU requests")
+	cmd.Flags().BoolVarP(&o.CPULimits, "cpu-limits", "", true, "verifies that containers declare CPU limits")
+	cmd.Flags().BoolVarP(&o.MemoryRequests, "memory-requests", "", true, "verifies that containers declare memory requests")
+	cmd.Flags().BoolVarP(&o.MemoryLimits, "memory-limits", "", true, "verifies that containers declare memory limits")
+	cmd.Flags().BoolVarP(&o.IgnoreInitContainers, "ignore-init-containers", "", false, "does not verify the init containers")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		err := workloads.ForEachContainer(node, kind, !o.IgnoreInitContainers, func(container *yaml.RNode) error {
+			o.verifyContainer(node, path, container)
+			return nil
+		})
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to verify containers in %s", path)
+		}
+		return false, nil
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "containers missing resource requests or limits")
+}
+
+func (o *Options) verifyContainer(node *yaml.RNode, path string, container *yaml.RNode) {
+	name := workloads.GetContainerName(container)
+	checks := []struct {
+		enabled bool
+		fields  []string
+	}{
+		{o.CPURequests, []string{"resources", "requests", "cpu"}},
+		{o.CPULimits, []string{"resources", "limits", "cpu"}},
+		{o.MemoryRequests, []string{"resources", "requests", "memory"}},
+		{o.MemoryLimits, []string{"resources", "limits", "memory"}},
+	}
+	var missing []string
+	for _, c := range checks {
+		if c.enabled && kyamls.GetStringField(container, path, c.fields...) == "" {
+			missing = append(missing, kyamls.JSONPath(c.fields...))
+		}
+	}
+	if len(missing) > 0 {
+		o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "container %s is missing %v", name, missing))
+	}
+}
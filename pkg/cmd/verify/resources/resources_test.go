@@ -0,0 +1,45 @@
+package resources_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyResources(t *testing.T) {
+	_, o := resources.NewCmdVerifyResources()
+	o.Dir = filepath.Join("test_data", "compliant")
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = resources.NewCmdVerifyResources()
+	o.Dir = filepath.Join("test_data", "noncompliant")
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+	require.Len(t, o.Failures, 2, "failures for dir %s", o.Dir)
+
+	names := map[string]string{}
+	for _, f := range o.Failures {
+		names[f.Name] = f.Message
+		t.Logf("%s\n", f.String())
+	}
+	assert.Contains(t, names["missing-limits"], "container app is missing")
+	assert.Contains(t, names["missing-limits"], "resources.limits.cpu")
+	assert.NotContains(t, names["missing-limits"], "resources.requests.cpu")
+	assert.Contains(t, names["missing-resources"], "container init is missing")
+}
+
+func TestVerifyResourcesDisableChecks(t *testing.T) {
+	_, o := resources.NewCmdVerifyResources()
+	o.Dir = filepath.Join("test_data", "noncompliant")
+	o.CPULimits = false
+	o.MemoryLimits = false
+	o.IgnoreInitContainers = true
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures)
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/workloads"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Verifies that the selector of every Service in the directory tree matches the pods of a workload in the same namespace
+
+Services whose selector matches no workload have no endpoints so confuse service discovery. Services without a selector and ExternalName Services are ignored as their endpoints are not managed by a selector.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the Services select the pods of a workload
+		%s verify services --dir config-root
+
+		# removes the files of the Services which select no workload
+		%s verify services --dir config-root --fix
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir      string
+	Fix      bool
+	Removed  []string
+	Failures []verifiers.Failure
+}
+
+type podLabels struct {
+	namespace string
+	labels    map[string]string
+}
+
+// NewCmdVerifyServices creates a command object for the command
+func NewCmdVerifyServices() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "services",
+		Short:   "Verifies that the selector of every Service in the directory tree matches the pods of a workload",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.Fix, "fix", "", false, "removes the files of the Services whose selector matches no workload")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	o.Removed = nil
+
+	// lets find the pod labels of all the workloads in the tree even if the filter excludes them
+	var pods []podLabels
+	err := kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		labels, err := workloads.GetPodLabels(node, kind)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get the pod labels of %s", path)
+		}
+		if labels != nil {
+			pods = append(pods, podLabels{namespace: kyamls.GetNamespace(node, path), labels: labels})
+		}
+		return false, nil
+	}, kyamls.Filter{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to find workloads in dir %s", o.Dir)
+	}
+
+	var orphans []verifiers.Failure
+	err = kyamls.ModifyFiles(o.Dir, func(node *yaml.RNode, path string) (bool, error) {
+		if kyamls.GetKind(node, path) != "Service" {
+			return false, nil
+		}
+		if kyamls.GetStringField(node, path, "spec", "type") == "ExternalName" {
+			return false, nil
+		}
+		selector, err := GetSelector(node)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get the selector of %s", path)
+		}
+		if len(selector) == 0 {
+			return false, nil
+		}
+		ns := kyamls.GetNamespace(node, path)
+		for _, p := range pods {
+			if p.namespace == ns && Matches(selector, p.labels) {
+				return false, nil
+			}
+		}
+		orphans = append(orphans, verifiers.NewFailure(node, path, "the selector %s matches no workload in the directory tree", SelectorString(selector)))
+		return false, nil
+	}, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+
+	if !o.Fix {
+		o.Failures = orphans
+		return verifiers.Report(o.Failures, "Services whose selector matches no workload")
+	}
+	for _, f := range orphans {
+		err = os.Remove(f.Path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove file %s", f.Path)
+		}
+		log.Logger().Infof("removed Service %s in file %s as %s", info(f.Name), f.Path, f.Message)
+		o.Removed = append(o.Removed, f.Path)
+	}
+	if len(o.Removed) == 0 {
+		log.Logger().Infof("no Services whose selector matches no workload found")
+	}
+	return nil
+}
+
+// GetSelector returns the selector of the Service
+func GetSelector(node *yaml.RNode) (map[string]string, error) {
+	selector, err := node.Pipe(yaml.Lookup("spec", "selector"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the selector")
+	}
+	answer := map[string]string{}
+	if selector == nil {
+		return answer, nil
+	}
+	err = selector.VisitFields(func(n *yaml.MapNode) error {
+		answer[n.Key.YNode().Value] = n.Value.YNode().Value
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the selector")
+	}
+	return answer, nil
+}
+
+// Matches returns true if all the labels of the selector have the same value in the labels
+func Matches(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectorString returns the selector as sorted comma separated key=value pairs
+func SelectorString(selector map[string]string) string {
+	var pairs []string
+	for k, v := range selector {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/services"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyServices(t *testing.T) {
+	_, o := services.NewCmdVerifyServices()
+	o.Dir = filepath.Join("test_data", "source")
+	err := o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var messages []string
+	for _, f := range o.Failures {
+		messages = append(messages, f.Namespace+"/"+f.Name+": "+f.Message)
+	}
+	sort.Strings(messages)
+	assert.Equal(t, []string{
+		"jx/old-api: the selector app=old-api matches no workload in the directory tree",
+		"staging/api: the selector app=api matches no workload in the directory tree",
+	}, messages, "failure messages")
+
+	_, o = services.NewCmdVerifyServices()
+	o.Dir = filepath.Join("test_data", "source")
+	o.Filter.Names = []string{"database", "legacy"}
+	err = o.Run()
+	require.NoError(t, err, "should ignore Services without a selector and ExternalName Services")
+	assert.Empty(t, o.Failures, "failures")
+}
+
+func TestVerifyServicesFix(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := files.CopyDirOverwrite(filepath.Join("test_data", "source"), tmpDir)
+	require.NoError(t, err, "failed to copy source files to %s", tmpDir)
+
+	_, o := services.NewCmdVerifyServices()
+	o.Dir = tmpDir
+	o.Fix = true
+	err = o.Run()
+	require.NoError(t, err, "failed to fix dir %s", tmpDir)
+
+	sort.Strings(o.Removed)
+	assert.Equal(t, []string{filepath.Join(tmpDir, "old-api-svc.yaml"), filepath.Join(tmpDir, "other-ns-svc.yaml")}, o.Removed, "removed files")
+	for _, name := range []string{"api-svc.yaml", "deployment.yaml", "external-svc.yaml", "manual-svc.yaml"} {
+		assert.FileExists(t, filepath.Join(tmpDir, name), "should keep %s", name)
+	}
+	for _, path := range o.Removed {
+		assert.NoFileExists(t, path, "should remove the orphaned Service")
+	}
+
+	_, o = services.NewCmdVerifyServices()
+	o.Dir = tmpDir
+	err = o.Run()
+	require.NoError(t, err, "should verify the fixed dir %s", tmpDir)
+}
@@ -0,0 +1,138 @@
+package spread
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/workloads"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// SkipAnnotation the annotation on a workload with the value 'true' to opt out of the spread verification
+const SkipAnnotation = "jenkins-x.io/skip-spread"
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that the Deployments with multiple replicas spread their pods across nodes using pod anti-affinity or topology spread constraints
+
+A workload can opt out by using the annotation '` + SkipAnnotation + `: "true"'.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies the Deployments with more than one replica spread their pods
+		%s verify spread --dir config-root
+
+		# also verifies StatefulSets and only the workloads with at least 3 replicas
+		%s verify spread --dir config-root --statefulsets --min-replicas 3
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir          string
+	MinReplicas  int
+	Deployments  bool
+	StatefulSets bool
+	Failures     []verifiers.Failure
+}
+
+// NewCmdVerifySpread creates a command object for the command
+func NewCmdVerifySpread() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "spread",
+		Short:   "Verifies that the workloads with multiple replicas use pod anti-affinity or topology spread constraints",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().IntVarP(&o.MinReplicas, "min-replicas", "", 2, "the minimum number of replicas of the workloads to verify")
+	cmd.Flags().BoolVarP(&o.Deployments, "deployments", "", true, "verifies Deployments")
+	cmd.Flags().BoolVarP(&o.StatefulSets, "statefulsets", "", false, "verifies StatefulSets")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	kinds := map[string]bool{
+		"Deployment":  o.Deployments,
+		"StatefulSet": o.StatefulSets,
+	}
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		if !kinds[kind] {
+			return false, nil
+		}
+		if kyamls.GetStringField(node, path, "metadata", "annotations", SkipAnnotation) == "true" {
+			return false, nil
+		}
+		replicas := Replicas(node, path)
+		if replicas < o.MinReplicas {
+			return false, nil
+		}
+		spread, err := IsSpread(node, kind)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to verify %s", path)
+		}
+		if !spread {
+			o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "has %d replicas but no pod anti-affinity or topology spread constraints", replicas))
+		}
+		return false, nil
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	return verifiers.Report(o.Failures, "workloads with multiple replicas which do not spread their pods across nodes")
+}
+
+// Replicas returns the replicas of the workload defaulting to 1 if it is not specified or is not a number
+func Replicas(node *yaml.RNode, path string) int {
+	text := kyamls.GetStringField(node, path, "spec", "replicas")
+	if text == "" {
+		return 1
+	}
+	replicas, err := strconv.Atoi(text)
+	if err != nil {
+		return 1
+	}
+	return replicas
+}
+
+// IsSpread returns true if the pod spec of the workload has pod anti-affinity rules or topology spread constraints
+func IsSpread(node *yaml.RNode, kind string) (bool, error) {
+	podSpec, err := workloads.GetPodSpec(node, kind)
+	if err != nil || podSpec == nil {
+		return false, err
+	}
+	paths := [][]string{
+		{"affinity", "podAntiAffinity", "requiredDuringSchedulingIgnoredDuringExecution"},
+		{"affinity", "podAntiAffinity", "preferredDuringSchedulingIgnoredDuringExecution"},
+		{"topologySpreadConstraints"},
+	}
+	for _, p := range paths {
+		value, err := podSpec.Pipe(yaml.Lookup(p...))
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to find %v", p)
+		}
+		if value != nil && len(value.YNode().Content) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
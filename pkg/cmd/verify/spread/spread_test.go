@@ -0,0 +1,47 @@
+package spread_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/spread"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySpread(t *testing.T) {
+	_, o := spread.NewCmdVerifySpread()
+	o.Dir = filepath.Join("test_data", "spread")
+	err := o.Run()
+	require.NoError(t, err, "failed to verify dir %s", o.Dir)
+	assert.Empty(t, o.Failures, "should have no failures for dir %s", o.Dir)
+
+	_, o = spread.NewCmdVerifySpread()
+	o.Dir = filepath.Join("test_data", "unspread")
+	err = o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	messages := map[string]string{}
+	for _, f := range o.Failures {
+		messages[f.Kind+"/"+f.Name] = f.Message
+	}
+	assert.Equal(t, map[string]string{
+		"Deployment/no-affinity":   "has 3 replicas but no pod anti-affinity or topology spread constraints",
+		"Deployment/node-affinity": "has 2 replicas but no pod anti-affinity or topology spread constraints",
+	}, messages, "failures for dir %s", o.Dir)
+}
+
+func TestVerifySpreadToggles(t *testing.T) {
+	_, o := spread.NewCmdVerifySpread()
+	o.Dir = filepath.Join("test_data", "unspread")
+	o.StatefulSets = true
+	o.MinReplicas = 3
+	err := o.Run()
+	require.Error(t, err, "should have failed to verify dir %s", o.Dir)
+
+	var names []string
+	for _, f := range o.Failures {
+		names = append(names, f.Kind+"/"+f.Name)
+	}
+	assert.ElementsMatch(t, []string{"Deployment/no-affinity", "StatefulSet/db"}, names, "failures for dir %s", o.Dir)
+}
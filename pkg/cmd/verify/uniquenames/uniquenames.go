@@ -0,0 +1,114 @@
+package uniquenames
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/verifiers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	cmdLong = templates.LongDesc(`
+		Verifies that no two resources share a name within a namespace
+
+Resources of the same kind with the same name in a namespace always fail verification.
+Resources of different kinds with the same name (such as a Deployment and its Service) are only reported as warnings unless --cross-kind is specified.
+`)
+
+	cmdExample = templates.Examples(`
+		# verifies there are no resources of the same kind with the same name in a namespace
+		%s verify unique-names --dir config-root
+
+		# also fail if resources of different kinds share a name in a namespace
+		%s verify unique-names --dir config-root --cross-kind
+	`)
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir       string
+	CrossKind bool
+	Failures  []verifiers.Failure
+	Warnings  []verifiers.Failure
+}
+
+type resource struct {
+	Kind string
+	Path string
+}
+
+// NewCmdVerifyUniqueNames creates a command object for the command
+func NewCmdVerifyUniqueNames() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "unique-names",
+		Short:   "Verifies that no two resources share a name within a namespace",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "d", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().BoolVarP(&o.CrossKind, "cross-kind", "", false, "fail verification if resources of different kinds share a name within a namespace")
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	o.Failures = nil
+	o.Warnings = nil
+
+	// resources indexed by namespace then name
+	resources := map[string]map[string][]resource{}
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		name := kyamls.GetName(node, path)
+		if kind == "" || name == "" {
+			return false, nil
+		}
+		ns := kyamls.GetNamespace(node, path)
+		if kyamls.IsClusterKind(kind) {
+			ns = ""
+		}
+		names := resources[ns]
+		if names == nil {
+			names = map[string][]resource{}
+			resources[ns] = names
+		}
+
+		for _, r := range names[name] {
+			if r.Kind == kind {
+				o.Failures = append(o.Failures, verifiers.NewFailure(node, path, "duplicate of the %s in file %s", r.Kind, r.Path))
+				continue
+			}
+			f := verifiers.NewFailure(node, path, "has the same name as the %s in file %s", r.Kind, r.Path)
+			if o.CrossKind {
+				o.Failures = append(o.Failures, f)
+			} else {
+				o.Warnings = append(o.Warnings, f)
+			}
+		}
+		names[name] = append(names[name], resource{Kind: kind, Path: path})
+		return false, nil
+	}
+	err := kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to verify files in dir %s", o.Dir)
+	}
+	for i := range o.Warnings {
+		log.Logger().Warn(o.Warnings[i].String())
+	}
+	return verifiers.Report(o.Failures, "resources with colliding names")
+}
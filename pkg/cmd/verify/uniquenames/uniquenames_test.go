@@ -0,0 +1,63 @@
+package uniquenames_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/uniquenames"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyUniqueNames(t *testing.T) {
+	testCases := []struct {
+		dir         string
+		crossKind   bool
+		expectError bool
+		failures    int
+		warnings    int
+	}{
+		{
+			dir: "distinct",
+		},
+		{
+			dir:       "distinct",
+			crossKind: true,
+		},
+		{
+			dir:         "duplicate",
+			expectError: true,
+			failures:    1,
+		},
+		{
+			dir:      "cross-kind",
+			warnings: 1,
+		},
+		{
+			dir:         "cross-kind",
+			crossKind:   true,
+			expectError: true,
+			failures:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		_, o := uniquenames.NewCmdVerifyUniqueNames()
+		o.Dir = filepath.Join("test_data", tc.dir)
+		o.CrossKind = tc.crossKind
+
+		err := o.Run()
+		if tc.expectError {
+			require.Error(t, err, "should fail for dir %s with cross kind %v", tc.dir, tc.crossKind)
+		} else {
+			require.NoError(t, err, "should not fail for dir %s with cross kind %v", tc.dir, tc.crossKind)
+		}
+		assert.Len(t, o.Failures, tc.failures, "failures for dir %s with cross kind %v", tc.dir, tc.crossKind)
+		assert.Len(t, o.Warnings, tc.warnings, "warnings for dir %s with cross kind %v", tc.dir, tc.crossKind)
+
+		for _, f := range append(o.Failures, o.Warnings...) {
+			t.Logf("%s\n", f.String())
+			assert.Equal(t, "jx", f.Namespace, "namespace of collision for dir %s", tc.dir)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package verify
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/crds"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/envsecrets"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/gvk"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/images"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/ingresstls"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/namespaces"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/probes"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/registries"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/resources"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/services"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/spread"
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/verify/uniquenames"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdVerify creates the new command
+func NewCmdVerify() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "verify",
+		Short: "Commands for verifying the kubernetes resources in a directory tree conform to policies",
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(crds.NewCmdVerifyCRDs()))
+	command.AddCommand(cobras.SplitCommand(envsecrets.NewCmdVerifyEnvSecrets()))
+	command.AddCommand(cobras.SplitCommand(gvk.NewCmdVerifyGVK()))
+	command.AddCommand(cobras.SplitCommand(images.NewCmdVerifyImages()))
+	command.AddCommand(cobras.SplitCommand(ingresstls.NewCmdVerifyIngressTLS()))
+	command.AddCommand(cobras.SplitCommand(namespaces.NewCmdVerifyNamespaces()))
+	command.AddCommand(cobras.SplitCommand(probes.NewCmdVerifyProbes()))
+	command.AddCommand(cobras.SplitCommand(registries.NewCmdVerifyRegistries()))
+	command.AddCommand(cobras.SplitCommand(resources.NewCmdVerifyResources()))
+	command.AddCommand(cobras.SplitCommand(services.NewCmdVerifyServices()))
+	command.AddCommand(cobras.SplitCommand(spread.NewCmdVerifySpread()))
+	command.AddCommand(cobras.SplitCommand(uniquenames.NewCmdVerifyUniqueNames()))
+	return command
+}
@@ -0,0 +1,246 @@
+package convert
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/rootcmd"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/helper"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras/templates"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/options"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/stringhelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// KindDeployment the Deployment kind
+	KindDeployment = "Deployment"
+
+	// KindStatefulSet the StatefulSet kind
+	KindStatefulSet = "StatefulSet"
+
+	// KindDaemonSet the DaemonSet kind
+	KindDaemonSet = "DaemonSet"
+
+	rollingUpdate = "RollingUpdate"
+)
+
+var (
+	info = termcolor.ColorInfo
+
+	cmdLong = templates.LongDesc(`
+		Converts workloads between the Deployment, StatefulSet and DaemonSet kinds
+
+The pod template and selector are preserved. The update strategy is converted where the target kind supports it and any spec fields which are not supported by the target kind such as replicas for a DaemonSet are removed.
+
+A StatefulSet uses the name of the workload as its serviceName if it does not have one.
+`)
+
+	cmdExample = templates.Examples(`
+		# converts all the Deployments in the dir to DaemonSets
+		%s workload convert --dir config-root --kind Deployment --to DaemonSet
+
+		# converts the workloads with a label to StatefulSets
+		%s workload convert --dir config-root --selector app=cheese --to StatefulSet
+	`)
+
+	// Kinds the workload kinds which can be converted
+	Kinds = []string{KindDeployment, KindStatefulSet, KindDaemonSet}
+
+	// specFields the fields of the spec of each workload kind
+	specFields = map[string][]string{
+		KindDeployment:  {"replicas", "selector", "template", "strategy", "minReadySeconds", "revisionHistoryLimit", "paused", "progressDeadlineSeconds"},
+		KindStatefulSet: {"replicas", "selector", "template", "serviceName", "podManagementPolicy", "updateStrategy", "volumeClaimTemplates", "revisionHistoryLimit", "minReadySeconds"},
+		KindDaemonSet:   {"selector", "template", "updateStrategy", "minReadySeconds", "revisionHistoryLimit"},
+	}
+)
+
+// Options the options for the command
+type Options struct {
+	kyamls.Filter
+	Dir string
+	To  string
+}
+
+// NewCmdWorkloadConvert creates a command object for the command
+func NewCmdWorkloadConvert() (*cobra.Command, *Options) {
+	o := &Options{}
+
+	cmd := &cobra.Command{
+		Use:     "convert",
+		Short:   "Converts workloads between the Deployment, StatefulSet and DaemonSet kinds",
+		Long:    cmdLong,
+		Example: fmt.Sprintf(cmdExample, rootcmd.BinaryName, rootcmd.BinaryName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := o.Run()
+			helper.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringVarP(&o.Dir, "dir", "", ".", "the directory to recursively look for the *.yaml or *.yml files")
+	cmd.Flags().StringVarP(&o.To, "to", "t", "", "the kind to convert the workloads to. Values: "+strings.Join(Kinds, ", "))
+	o.Filter.AddFlags(cmd)
+	return cmd, o
+}
+
+// Validate validates the options
+func (o *Options) Validate() error {
+	if o.To == "" {
+		return options.MissingOption("to")
+	}
+	if stringhelpers.StringArrayIndex(Kinds, o.To) < 0 {
+		return options.InvalidOption("to", o.To, Kinds)
+	}
+	return nil
+}
+
+// Run implements the command
+func (o *Options) Run() error {
+	err := o.Validate()
+	if err != nil {
+		return errors.Wrapf(err, "failed to validate options")
+	}
+	modifyFn := func(node *yaml.RNode, path string) (bool, error) {
+		kind := kyamls.GetKind(node, path)
+		if kind == o.To || stringhelpers.StringArrayIndex(Kinds, kind) < 0 {
+			return false, nil
+		}
+		err := Convert(node, path, o.To)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to convert %s in file %s", kind, path)
+		}
+		log.Logger().Infof("converted %s %s to a %s in file %s", kind, info(kyamls.GetName(node, path)), o.To, path)
+		return true, nil
+	}
+	err = kyamls.ModifyFiles(o.Dir, modifyFn, o.Filter)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert workloads in dir %s", o.Dir)
+	}
+	return nil
+}
+
+// Convert converts the workload node to the given kind
+func Convert(node *yaml.RNode, path, to string) error {
+	from := kyamls.GetKind(node, path)
+	name := kyamls.GetName(node, path)
+	err := node.PipeE(yaml.SetField("apiVersion", yaml.NewScalarRNode("apps/v1")))
+	if err != nil {
+		return errors.Wrapf(err, "failed to set apiVersion")
+	}
+	err = node.PipeE(yaml.SetField("kind", yaml.NewScalarRNode(to)))
+	if err != nil {
+		return errors.Wrapf(err, "failed to set kind")
+	}
+	_, err = node.Pipe(yaml.Clear("status"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove status")
+	}
+
+	spec, err := node.Pipe(yaml.LookupCreate(yaml.MappingNode, "spec"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to find spec")
+	}
+	err = convertStrategy(spec, from, to, name)
+	if err != nil {
+		return err
+	}
+
+	fields, err := spec.Fields()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the spec fields")
+	}
+	for _, field := range fields {
+		if stringhelpers.StringArrayIndex(specFields[to], field) >= 0 {
+			continue
+		}
+		if field == "volumeClaimTemplates" {
+			log.Logger().Warnf("removing the volumeClaimTemplates of %s %s as they are not supported by a %s", from, info(name), to)
+		}
+		_, err = spec.Pipe(yaml.Clear(field))
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove spec.%s", field)
+		}
+	}
+
+	if to == KindStatefulSet {
+		serviceName, err := spec.Pipe(yaml.Get("serviceName"))
+		if err != nil {
+			return errors.Wrapf(err, "failed to get spec.serviceName")
+		}
+		if serviceName == nil {
+			err = spec.PipeE(yaml.SetField("serviceName", yaml.NewScalarRNode(name)))
+			if err != nil {
+				return errors.Wrapf(err, "failed to set spec.serviceName")
+			}
+		}
+	}
+	return nil
+}
+
+// convertStrategy converts the update strategy of the source kind to the target kind keeping the
+// rolling update maxUnavailable where the target kind supports it
+func convertStrategy(spec *yaml.RNode, from, to, name string) error {
+	fromField := strategyField(from)
+	toField := strategyField(to)
+	strategy, err := spec.Pipe(yaml.Get(fromField))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get spec.%s", fromField)
+	}
+	if strategy == nil {
+		return nil
+	}
+	_, err = spec.Pipe(yaml.Clear(fromField))
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove spec.%s", fromField)
+	}
+
+	strategyType := ""
+	typeNode, err := strategy.Pipe(yaml.Get("type"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get spec.%s.type", fromField)
+	}
+	if typeNode != nil {
+		strategyType = typeNode.YNode().Value
+	}
+	if strategyType == "" {
+		strategyType = rollingUpdate
+	}
+	supported := strategyType == rollingUpdate ||
+		(strategyType == "Recreate" && to == KindDeployment) ||
+		(strategyType == "OnDelete" && to != KindDeployment)
+	if !supported {
+		log.Logger().Warnf("removing the %s update strategy of %s %s as it is not supported by a %s", strategyType, from, info(name), to)
+		return nil
+	}
+
+	converted, err := spec.Pipe(yaml.LookupCreate(yaml.MappingNode, toField))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create spec.%s", toField)
+	}
+	err = converted.PipeE(yaml.SetField("type", yaml.NewScalarRNode(strategyType)))
+	if err != nil {
+		return errors.Wrapf(err, "failed to set spec.%s.type", toField)
+	}
+	if strategyType != rollingUpdate || to == KindStatefulSet {
+		return nil
+	}
+	maxUnavailable, err := strategy.Pipe(yaml.Lookup("rollingUpdate", "maxUnavailable"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get spec.%s.rollingUpdate.maxUnavailable", fromField)
+	}
+	if maxUnavailable == nil {
+		return nil
+	}
+	return converted.PipeE(yaml.LookupCreate(yaml.MappingNode, "rollingUpdate"), yaml.SetField("maxUnavailable", maxUnavailable))
+}
+
+func strategyField(kind string) string {
+	if kind == KindDeployment {
+		return "strategy"
+	}
+	return "updateStrategy"
+}
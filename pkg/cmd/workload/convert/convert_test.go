@@ -0,0 +1,50 @@
+package convert_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/workload/convert"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkloadConvertDeploymentToDaemonSet(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "could not create temp dir")
+
+	err = files.CopyDirOverwrite(filepath.Join("test_data", "source"), tmpDir)
+	require.NoError(t, err, "failed to copy source files to %s", tmpDir)
+
+	_, o := convert.NewCmdWorkloadConvert()
+	o.Dir = tmpDir
+	o.To = convert.KindDaemonSet
+	err = o.Run()
+	require.NoError(t, err, "failed to run the command")
+
+	for _, name := range []string{"deployment.yaml", "service.yaml"} {
+		resultFile := filepath.Join(tmpDir, name)
+		expectedFile := filepath.Join("test_data", "expected", name)
+
+		result, err := ioutil.ReadFile(resultFile)
+		require.NoError(t, err, "failed to load %s", resultFile)
+		expected, err := ioutil.ReadFile(expectedFile)
+		require.NoError(t, err, "failed to load %s", expectedFile)
+
+		assert.Equal(t, strings.TrimSpace(string(expected)), strings.TrimSpace(string(result)), "file %s", name)
+	}
+}
+
+func TestWorkloadConvertInvalidKind(t *testing.T) {
+	_, o := convert.NewCmdWorkloadConvert()
+	o.Dir = filepath.Join("test_data", "source")
+	err := o.Run()
+	require.Error(t, err, "should fail without --to")
+
+	o.To = "ReplicaSet"
+	err = o.Run()
+	require.Error(t, err, "should fail for an unsupported kind")
+}
@@ -0,0 +1,24 @@
+package workload
+
+import (
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/cmd/workload/convert"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/cobras"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdWorkload creates the new command
+func NewCmdWorkload() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "workload",
+		Short: "Commands for working with workload resources such as Deployments, StatefulSets and DaemonSets",
+		Run: func(command *cobra.Command, args []string) {
+			err := command.Help()
+			if err != nil {
+				log.Logger().Errorf(err.Error())
+			}
+		},
+	}
+	command.AddCommand(cobras.SplitCommand(convert.NewCmdWorkloadConvert()))
+	return command
+}
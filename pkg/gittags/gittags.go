@@ -0,0 +1,40 @@
+package gittags
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/pkg/errors"
+)
+
+// HasTag returns true if the tag exists in the git repository in the dir
+func HasTag(g gitclient.Interface, dir, tag string) (bool, error) {
+	text, err := g.Command(dir, "tag", "--list", tag)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list the git tags in dir %s", dir)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == tag {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateAnnotatedTag creates an annotated tag of the current commit of the git repository in the dir
+func CreateAnnotatedTag(g gitclient.Interface, dir, tag, message string) error {
+	_, err := g.Command(dir, "tag", "--annotate", tag, "--message", message)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create git tag %s in dir %s", tag, dir)
+	}
+	return nil
+}
+
+// PushTag pushes the tag of the git repository in the dir to the remote
+func PushTag(g gitclient.Interface, dir, remote, tag string) error {
+	_, err := g.Command(dir, "push", remote, "refs/tags/"+tag)
+	if err != nil {
+		return errors.Wrapf(err, "failed to push git tag %s to remote %s", tag, remote)
+	}
+	return nil
+}
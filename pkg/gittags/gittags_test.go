@@ -0,0 +1,66 @@
+package gittags_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/gittags"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/gitclient/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndPushTag(t *testing.T) {
+	g := cli.NewCLIClient("", nil)
+
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create tmp dir")
+
+	remoteDir := filepath.Join(tmpDir, "remote.git")
+	_, err = g.Command(tmpDir, "init", "--bare", remoteDir)
+	require.NoError(t, err, "failed to create the bare remote repository")
+
+	dir := filepath.Join(tmpDir, "repo")
+	_, err = g.Command(tmpDir, "clone", remoteDir, dir)
+	require.NoError(t, err, "failed to clone the remote repository")
+	requireGit(t, g, dir, "config", "user.name", "jx-gitops")
+	requireGit(t, g, dir, "config", "user.email", "jx-gitops@example.com")
+
+	path := filepath.Join(dir, "README.md")
+	err = ioutil.WriteFile(path, []byte("hello"), files.DefaultFileWritePermissions)
+	require.NoError(t, err, "failed to write file %s", path)
+	_, err = gitclient.AddAndCommitFiles(g, dir, "initial commit")
+	require.NoError(t, err, "failed to commit")
+
+	tag := "v1.2.3"
+	exists, err := gittags.HasTag(g, dir, tag)
+	require.NoError(t, err, "failed to check for tag")
+	assert.False(t, exists, "tag %s should not exist yet", tag)
+
+	err = gittags.CreateAnnotatedTag(g, dir, tag, "release 1.2.3")
+	require.NoError(t, err, "failed to create tag")
+
+	exists, err = gittags.HasTag(g, dir, tag)
+	require.NoError(t, err, "failed to check for tag")
+	assert.True(t, exists, "tag %s should exist", tag)
+
+	tagType, err := g.Command(dir, "cat-file", "-t", tag)
+	require.NoError(t, err, "failed to get the type of tag %s", tag)
+	assert.Equal(t, "tag", strings.TrimSpace(tagType), "should be an annotated tag")
+
+	err = gittags.PushTag(g, dir, "origin", tag)
+	require.NoError(t, err, "failed to push tag")
+
+	exists, err = gittags.HasTag(g, remoteDir, tag)
+	require.NoError(t, err, "failed to check for tag in the remote")
+	assert.True(t, exists, "tag %s should have been pushed to the remote", tag)
+}
+
+func requireGit(t *testing.T, g gitclient.Interface, dir string, args ...string) {
+	_, err := g.Command(dir, args...)
+	require.NoError(t, err, "failed to perform git %s", strings.Join(args, " "))
+}
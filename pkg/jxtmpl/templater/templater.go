@@ -3,6 +3,7 @@ package templater
 import (
 	"bytes"
 	"io/ioutil"
+	"path/filepath"
 	"text/template"
 
 	"github.com/Masterminds/sprig"
@@ -12,12 +13,19 @@ import (
 	"github.com/jenkins-x/jx-logging/v3/pkg/log"
 	"github.com/pkg/errors"
 	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/strvals"
 )
 
 // Templater a templater of values yaml
 type Templater struct {
 	Requirements *jxcore.RequirementsConfig
 	ValuesFiles  []string
+
+	// Sets the helm style key=value pairs applied on top of the values files
+	Sets []string
+
+	// Env the environment variables available to the template via {{ .Env.NAME }}
+	Env map[string]string
 }
 
 // NewTemplater creates a new templater
@@ -35,17 +43,11 @@ func (o *Templater) createFuncMap(requirements *jxcore.RequirementsConfig) (temp
 
 // Generate generates the destination file from the given source template
 func (o *Templater) Generate(sourceFile string, destFile string) error {
-	requirements := o.Requirements
-	funcMap, err := o.createFuncMap(requirements)
+	data, err := o.Render(sourceFile)
 	if err != nil {
 		return err
 	}
 
-	data, err := o.renderTemplate(sourceFile, funcMap)
-	if err != nil {
-		return errors.Wrapf(err, "failed to render template file %s", sourceFile)
-	}
-
 	err = ioutil.WriteFile(destFile, data, files.DefaultFileWritePermissions)
 	if err != nil {
 		return errors.Wrapf(err, "failed to save output of template %s to %s", sourceFile, destFile)
@@ -53,6 +55,20 @@ func (o *Templater) Generate(sourceFile string, destFile string) error {
 	return nil
 }
 
+// Render evaluates the given source template and returns the output data
+func (o *Templater) Render(sourceFile string) ([]byte, error) {
+	funcMap, err := o.createFuncMap(o.Requirements)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := o.renderTemplate(sourceFile, funcMap)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render template file %s", sourceFile)
+	}
+	return data, nil
+}
+
 // NewFunctionMap creates a new function map for values.tmpl.yaml templating
 func NewFunctionMap() template.FuncMap {
 	funcMap := sprig.TxtFuncMap()
@@ -65,18 +81,18 @@ func NewFunctionMap() template.FuncMap {
 // RenderTemplate evaluates the given values.yaml file as a go template and returns the output data
 func (o *Templater) renderTemplate(templateFile string, funcMap template.FuncMap) ([]byte, error) {
 	requirements := o.Requirements
-	tmpl, err := template.New("values.yaml.gotmpl").Option("missingkey=error").Funcs(funcMap).ParseFiles(templateFile)
+	tmpl, err := template.New(filepath.Base(templateFile)).Option("missingkey=error").Funcs(funcMap).ParseFiles(templateFile)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse go template: %s", templateFile)
 	}
 
-	requirementsMap, err := requirements.ToMap()
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed turn requirements into a map: %v", requirements)
-	}
-
-	valuesMap := map[string]interface{}{
-		"jxRequirements": requirementsMap,
+	valuesMap := map[string]interface{}{}
+	if requirements != nil {
+		requirementsMap, err := requirements.ToMap()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed turn requirements into a map: %v", requirements)
+		}
+		valuesMap["jxRequirements"] = requirementsMap
 	}
 	for _, valuesFile := range o.ValuesFiles {
 		values := map[string]interface{}{}
@@ -89,6 +105,13 @@ func (o *Templater) renderTemplate(templateFile string, funcMap template.FuncMap
 		}
 	}
 
+	for _, s := range o.Sets {
+		err = strvals.ParseInto(s, valuesMap)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse --set value %s", s)
+		}
+	}
+
 	for k, v := range valuesMap {
 		log.Logger().Debugf("loaded value %s = %#v", k, v)
 	}
@@ -96,6 +119,9 @@ func (o *Templater) renderTemplate(templateFile string, funcMap template.FuncMap
 	templateData := map[string]interface{}{
 		"Values": chartutil.Values(valuesMap),
 	}
+	if o.Env != nil {
+		templateData["Env"] = o.Env
+	}
 	var buf bytes.Buffer
 	err = tmpl.Execute(&buf, templateData)
 	if err != nil {
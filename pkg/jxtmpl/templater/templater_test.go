@@ -32,3 +32,16 @@ func TestTemplater(t *testing.T) {
 
 	testhelpers.AssertTextFilesEqual(t, filepath.Join("test_data", "values.yaml"), tmpFileName, "generated file")
 }
+
+func TestTemplaterEnvAndSets(t *testing.T) {
+	tmpl := &templater.Templater{
+		Sets: []string{"replicas=3"},
+		Env: map[string]string{
+			"VERSION": "1.2.3",
+		},
+	}
+
+	data, err := tmpl.Render(filepath.Join("test_data", "env.yaml.gotmpl"))
+	require.NoError(t, err, "failed to render template")
+	require.Equal(t, "image:\n  tag: \"1.2.3\"\nreplicaCount: 3\n", string(data))
+}
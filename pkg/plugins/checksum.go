@@ -0,0 +1,270 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/httphelpers"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ChecksumAnnotationPrefix the prefix of the plugin annotations which store the SHA256 checksum of the binary
+	// of each platform as the plugin binaries have no checksum field
+	ChecksumAnnotationPrefix = "sha256.plugins.jenkins-x.io/"
+
+	// ChecksumExtension the extension of the files published alongside a release containing the SHA256 checksum of a binary
+	ChecksumExtension = ".sha256"
+)
+
+// PlatformKey returns the goos/goarch key of the checksum of a platform
+func PlatformKey(goos, goarch string) string {
+	return strings.ToLower(goos) + "/" + strings.ToLower(goarch)
+}
+
+// WithChecksums adds the SHA256 checksums keyed by goos/goarch to the plugin
+func WithChecksums(plugin jenkinsv1.Plugin, checksums ...map[string]string) jenkinsv1.Plugin {
+	for _, m := range checksums {
+		for k, v := range m {
+			if plugin.Annotations == nil {
+				plugin.Annotations = map[string]string{}
+			}
+			plugin.Annotations[ChecksumAnnotationPrefix+strings.ToLower(k)] = strings.ToLower(strings.TrimSpace(v))
+		}
+	}
+	return plugin
+}
+
+// Checksum returns the SHA256 checksum of the binary of the plugin for the platform or an empty string if there is none
+func Checksum(plugin jenkinsv1.Plugin, goos, goarch string) string {
+	return plugin.Annotations[ChecksumAnnotationPrefix+PlatformKey(goos, goarch)]
+}
+
+// FetchChecksums fetches the SHA256 checksums of the binaries of the plugin from the checksum files published alongside
+// each binary such as for helm releases
+func FetchChecksums(plugin jenkinsv1.Plugin) (map[string]string, error) {
+	m := map[string]string{}
+	for _, b := range plugin.Spec.Binaries {
+		u := b.URL + ChecksumExtension
+		checksum, err := FetchChecksum(u)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch checksum of plugin %s", plugin.Name)
+		}
+		m[PlatformKey(b.Goos, b.Goarch)] = checksum
+	}
+	return m, nil
+}
+
+// fetchInstallChecksums fetches the SHA256 checksum of the binary of the current platform from the checksum file
+// published alongside it so the download can be verified. Returns nil if the plugin is already installed
+func fetchInstallChecksums(plugin jenkinsv1.Plugin, pluginBinDir string) (map[string]string, error) {
+	name := fmt.Sprintf("%s-%s", plugin.Spec.Name, plugin.Spec.Version)
+	for _, path := range []string{filepath.Join(pluginBinDir, name), filepath.Join(pluginBinDir, name+CompressedExtension)} {
+		exists, err := files.FileExists(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check if file exists %s", path)
+		}
+		if exists {
+			return nil, nil
+		}
+	}
+	key := PlatformKey(runtime.GOOS, runtime.GOARCH)
+	for _, b := range plugin.Spec.Binaries {
+		if PlatformKey(b.Goos, b.Goarch) != key {
+			continue
+		}
+		checksum, err := FetchChecksum(b.URL + ChecksumExtension)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch checksum of plugin %s", plugin.Name)
+		}
+		return map[string]string{key: checksum}, nil
+	}
+	return nil, nil
+}
+
+// FetchChecksum fetches the SHA256 checksum from the URL of a checksum file which contains the hex digest optionally
+// followed by the file name
+func FetchChecksum(u string) (string, error) {
+	httpClient := httphelpers.GetClientWithTimeout(time.Minute)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create request for %s", u)
+	}
+	AddGitHubToken(req, os.Getenv(GitHubTokenEnv))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", errors.Errorf("status %s getting %s", resp.Status, u)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read %s", u)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", errors.Errorf("no checksum in %s", u)
+	}
+	checksum := strings.ToLower(fields[0])
+	_, err = hex.DecodeString(checksum)
+	if err != nil || len(checksum) != sha256.Size*2 {
+		return "", errors.Errorf("invalid SHA256 checksum %s in %s", checksum, u)
+	}
+	return checksum, nil
+}
+
+// installerFor returns the installer of the plugin which retries failed downloads up to $JX_GITOPS_PLUGIN_DOWNLOAD_ATTEMPTS times
+func installerFor(plugin jenkinsv1.Plugin) Installer {
+	return WithRetries(downloaderFor(plugin), DownloadAttemptsFunc(os.Getenv), DownloadBackoff)
+}
+
+// downloaderFor returns the installer of the plugin which verifies the download if the plugin has a checksum for the
+// current platform and authenticates the download if it is from GitHub and $GITHUB_TOKEN is set
+func downloaderFor(plugin jenkinsv1.Plugin) Installer {
+	if Checksum(plugin, runtime.GOOS, runtime.GOARCH) != "" {
+		return EnsureVerifiedPluginInstalled
+	}
+	if os.Getenv(GitHubTokenEnv) != "" && isGitHubPlugin(plugin) {
+		return EnsureDownloadedPluginInstalled
+	}
+	return extensions.EnsurePluginInstalled
+}
+
+// EnsureVerifiedPluginInstalled ensures the plugin is installed returning the path of the binary. The downloaded file is
+// verified against the checksum of the plugin for the current platform failing if it does not match
+func EnsureVerifiedPluginInstalled(plugin jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+	expected := Checksum(plugin, runtime.GOOS, runtime.GOARCH)
+	if expected == "" {
+		return "", errors.Errorf("plugin %s has no SHA256 checksum for platform %s", plugin.Name, PlatformKey(runtime.GOOS, runtime.GOARCH))
+	}
+	return downloadPlugin(plugin, pluginBinDir, expected)
+}
+
+// EnsureDownloadedPluginInstalled ensures the plugin is installed returning the path of the binary. Any downloads from
+// GitHub are authenticated with $GITHUB_TOKEN if it is set to avoid the rate limits of anonymous requests
+func EnsureDownloadedPluginInstalled(plugin jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+	return downloadPlugin(plugin, pluginBinDir, "")
+}
+
+// downloadPlugin ensures the plugin is installed returning the path of the binary. If the expected checksum is not
+// empty the downloaded file is verified against it
+func downloadPlugin(plugin jenkinsv1.Plugin, pluginBinDir, expected string) (string, error) {
+	pluginName := plugin.Spec.Name
+	path := filepath.Join(pluginBinDir, fmt.Sprintf("%s-%s", pluginName, plugin.Spec.Version))
+	exists, err := files.FileExists(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", path)
+	}
+	if exists {
+		return path, nil
+	}
+
+	u, err := extensions.FindPluginUrl(plugin.Spec)
+	if err != nil {
+		return "", err
+	}
+	log.Logger().Infof("Installing plugin %s version %s from %s into %s", termcolor.ColorInfo(pluginName),
+		termcolor.ColorInfo(plugin.Spec.Version), termcolor.ColorInfo(u), pluginBinDir)
+
+	tmpDir, err := ioutil.TempDir("", pluginName)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	filename := filepath.Base(strings.SplitN(u, "?", 2)[0])
+	downloadFile := filepath.Join(tmpDir, filename)
+	actual, err := downloadWithChecksum(u, downloadFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to download plugin %s", pluginName)
+	}
+	if expected != "" && actual != expected {
+		return "", errors.Errorf("SHA256 checksum mismatch for plugin %s version %s downloaded from %s: expected %s but got %s",
+			pluginName, plugin.Spec.Version, u, expected, actual)
+	}
+
+	binary := downloadFile
+	switch {
+	case strings.HasSuffix(filename, ".tar.gz"):
+		err = files.UnTargz(downloadFile, tmpDir, make([]string, 0))
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to extract %s", filename)
+		}
+		binary = filepath.Join(tmpDir, pluginName)
+	case strings.HasSuffix(filename, ".zip"):
+		err = files.Unzip(downloadFile, tmpDir)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to extract %s", filename)
+		}
+		binary = filepath.Join(tmpDir, pluginName)
+		if runtime.GOOS == "windows" {
+			binary += ".exe"
+		}
+	}
+
+	err = os.MkdirAll(pluginBinDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create dir %s", pluginBinDir)
+	}
+	in, err := os.Open(binary)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open %s", binary)
+	}
+	defer in.Close()
+	err = writeAtomically(path, 0755, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// downloadWithChecksum downloads the URL to the file returning the hex SHA256 checksum of its content
+func downloadWithChecksum(u, path string) (string, error) {
+	httpClient := httphelpers.GetClientWithTimeout(time.Minute * 20)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create request for %s", u)
+	}
+	req.Header.Add("Accept", "application/octet-stream")
+	AddGitHubToken(req, os.Getenv(GitHubTokenEnv))
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get %s", u)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", errors.Errorf("status %s getting %s", resp.Status, u)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create %s", path)
+	}
+	defer out.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, hash), resp.Body)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write %s", path)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,180 @@
+package plugins_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPluginChecksums(t *testing.T) {
+	checksums := map[string]string{"Linux/amd64": "ABC123", "darwin/amd64": "def456"}
+	plugin := plugins.CreateHelmPlugin(plugins.HelmVersion, checksums)
+
+	assert.Equal(t, "abc123", plugins.Checksum(plugin, "linux", "amd64"), "linux checksum")
+	assert.Equal(t, "def456", plugins.Checksum(plugin, "Darwin", "amd64"), "darwin checksum")
+	assert.Equal(t, "", plugins.Checksum(plugin, "windows", "amd64"), "windows checksum")
+	assert.Empty(t, plugins.CreateHelmPlugin(plugins.HelmVersion).Annotations, "annotations without checksums")
+}
+
+func TestFetchChecksums(t *testing.T) {
+	digest := sha256.Sum256([]byte(script))
+	checksum := hex.EncodeToString(digest[:])
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/myplugin.sha256" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(checksum + "  myplugin\n")) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	plugin := createChecksumPlugin(server.URL + "/myplugin")
+	m, err := plugins.FetchChecksums(plugin)
+	require.NoError(t, err, "failed to fetch checksums")
+	assert.Equal(t, map[string]string{plugins.PlatformKey(runtime.GOOS, runtime.GOARCH): checksum}, m)
+
+	plugin = createChecksumPlugin(server.URL + "/missing")
+	_, err = plugins.FetchChecksums(plugin)
+	require.Error(t, err, "should fail if there is no checksum file")
+}
+
+func TestEnsureVerifiedPluginInstalled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	digest := sha256.Sum256([]byte(script))
+	checksum := hex.EncodeToString(digest[:])
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(script)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	key := plugins.PlatformKey(runtime.GOOS, runtime.GOARCH)
+
+	binDir := t.TempDir()
+	plugin := plugins.WithChecksums(createChecksumPlugin(server.URL+"/myplugin"), map[string]string{key: checksum})
+	path, err := plugins.EnsurePluginInstalled(plugin, binDir)
+	require.NoError(t, err, "failed to install verified plugin")
+	assert.Equal(t, filepath.Join(binDir, "myplugin-1.2.3"), path)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "failed to read %s", path)
+	assert.Equal(t, script, string(data), "installed binary")
+
+	// lets not wait between the retries of the mismatched download
+	oldBackoff := plugins.DownloadBackoff
+	plugins.DownloadBackoff = 0
+	defer func() {
+		plugins.DownloadBackoff = oldBackoff
+	}()
+
+	binDir = t.TempDir()
+	plugin = plugins.WithChecksums(createChecksumPlugin(server.URL+"/myplugin"), map[string]string{key: "0000"})
+	_, err = plugins.EnsurePluginInstalled(plugin, binDir)
+	require.Error(t, err, "should fail on a checksum mismatch")
+	assert.Contains(t, err.Error(), "SHA256 checksum mismatch")
+	assert.NoFileExists(t, filepath.Join(binDir, "myplugin-1.2.3"), "should not install the binary on a checksum mismatch")
+}
+
+func TestGetHelmBinaryVerifiesChecksum(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	archive := createTarGz(t, runtime.GOOS+"-"+runtime.GOARCH+"/"+plugins.HelmPluginName, script)
+	digest := sha256.Sum256(archive)
+	checksum := hex.EncodeToString(digest[:])
+	checksumRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, plugins.ChecksumExtension) {
+			checksumRequests++
+			w.Write([]byte(checksum)) //nolint:errcheck
+			return
+		}
+		w.Write(archive) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	for _, e := range []string{plugins.MirrorEnv, "JX_GITOPS_HOME"} {
+		old, hasOld := os.LookupEnv(e)
+		defer func(e string) {
+			if hasOld {
+				os.Setenv(e, old)
+			} else {
+				os.Unsetenv(e)
+			}
+		}(e)
+	}
+	os.Setenv(plugins.MirrorEnv, server.URL)
+	os.Setenv("JX_GITOPS_HOME", t.TempDir())
+
+	path, err := plugins.GetHelmBinary("3.5.3")
+	require.NoError(t, err, "failed to get the helm binary")
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "failed to read %s", path)
+	assert.Equal(t, script, string(data), "installed binary")
+	assert.Equal(t, 1, checksumRequests, "should fetch the checksum of the download")
+
+	_, err = plugins.GetHelmBinary("3.5.3")
+	require.NoError(t, err, "failed to get the installed helm binary")
+	assert.Equal(t, 1, checksumRequests, "should not fetch the checksum of an installed binary")
+
+	// lets not wait between the retries of the mismatched download
+	oldBackoff := plugins.DownloadBackoff
+	plugins.DownloadBackoff = 0
+	defer func() {
+		plugins.DownloadBackoff = oldBackoff
+	}()
+
+	checksum = strings.Repeat("0", sha256.Size*2)
+	_, err = plugins.GetHelmBinary("3.6.0")
+	require.Error(t, err, "should fail on a checksum mismatch")
+	assert.Contains(t, err.Error(), "SHA256 checksum mismatch")
+	pluginBinDir, err := plugins.PluginBinDir()
+	require.NoError(t, err, "failed to find the plugin bin dir")
+	assert.NoFileExists(t, filepath.Join(pluginBinDir, "helm-3.6.0"), "should not install the binary on a checksum mismatch")
+}
+
+// createTarGz creates a tar.gz archive containing a single executable file
+func createTarGz(t *testing.T, name, content string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	require.NoError(t, err, "failed to write tar header")
+	_, err = tw.Write([]byte(content))
+	require.NoError(t, err, "failed to write tar content")
+	require.NoError(t, tw.Close(), "failed to close tar")
+	require.NoError(t, gw.Close(), "failed to close gzip")
+	return buf.Bytes()
+}
+
+func createChecksumPlugin(u string) jenkinsv1.Plugin {
+	return jenkinsv1.Plugin{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "myplugin",
+		},
+		Spec: jenkinsv1.PluginSpec{
+			SubCommand: "myplugin",
+			Binaries: []jenkinsv1.Binary{
+				{Goos: runtime.GOOS, Goarch: runtime.GOARCH, URL: u},
+			},
+			Name:    "myplugin",
+			Version: "1.2.3",
+		},
+	}
+}
@@ -0,0 +1,177 @@
+package plugins
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// CompressPluginsEnv the environment variable to enable keeping the plugin binaries compressed at rest
+	CompressPluginsEnv = "JX_GITOPS_COMPRESS_PLUGINS"
+
+	// CompressedExtension the file extension of compressed plugin binaries
+	CompressedExtension = ".gz"
+)
+
+// Installer installs the plugin into the bin dir returning the path of the binary
+type Installer func(plugin jenkinsv1.Plugin, pluginBinDir string) (string, error)
+
+// EnsurePluginInstalled ensures the plugin is installed returning the path of the binary. If $JX_GITOPS_COMPRESS_PLUGINS
+// is enabled the binary is kept compressed in the plugin bin dir and decompressed on demand into a temporary dir.
+// If the plugin has a checksum for the current platform the download is verified against it
+func EnsurePluginInstalled(plugin jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+	installer := installerFor(plugin)
+	if !CompressPluginsFunc(os.Getenv) {
+		return installer(plugin, pluginBinDir)
+	}
+	return EnsureCompressedPluginInstalled(plugin, pluginBinDir, PluginExecDir(), installer)
+}
+
+// CompressPluginsFunc returns true if the plugins should be compressed at rest using a function for looking up env vars for easier testing
+func CompressPluginsFunc(fn func(string) string) bool {
+	v := fn(CompressPluginsEnv)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Logger().Warnf("ignoring invalid $%s value %s", CompressPluginsEnv, v)
+		return false
+	}
+	return b
+}
+
+// PluginExecDir returns the temporary dir the compressed plugins are decompressed into
+func PluginExecDir() string {
+	return filepath.Join(os.TempDir(), "jx-gitops-plugins")
+}
+
+// EnsureCompressedPluginInstalled ensures the compressed plugin is in the bin dir installing it if required then
+// decompresses it into the exec dir if it is not already there returning the path of the executable binary
+func EnsureCompressedPluginInstalled(plugin jenkinsv1.Plugin, pluginBinDir, execDir string, installer Installer) (string, error) {
+	name := fmt.Sprintf("%s-%s", plugin.Spec.Name, plugin.Spec.Version)
+	compressedPath := filepath.Join(pluginBinDir, name+CompressedExtension)
+	execPath := filepath.Join(execDir, name)
+
+	err := os.MkdirAll(execDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create dir %s", execDir)
+	}
+
+	exists, err := files.FileExists(compressedPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", compressedPath)
+	}
+	if !exists {
+		// lets install into a temporary dir so that only the compressed binary is kept in the bin dir
+		tmpDir, err := ioutil.TempDir(execDir, "install-")
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to create temp dir")
+		}
+		defer os.RemoveAll(tmpDir)
+
+		path, err := installer(plugin, tmpDir)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to install plugin %s", name)
+		}
+		err = CompressFile(path, compressedPath)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to compress plugin %s", name)
+		}
+		err = os.Rename(path, execPath)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to move %s to %s", path, execPath)
+		}
+		return execPath, nil
+	}
+
+	exists, err = files.FileExists(execPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to check if file exists %s", execPath)
+	}
+	if exists {
+		return execPath, nil
+	}
+	err = DecompressFile(compressedPath, execPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decompress plugin %s", name)
+	}
+	return execPath, nil
+}
+
+// CompressFile gzips the source file into the destination file
+func CompressFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer in.Close()
+
+	return writeAtomically(dest, 0644, func(w io.Writer) error {
+		gz := gzip.NewWriter(w)
+		_, err := io.Copy(gz, in)
+		if err != nil {
+			return err
+		}
+		return gz.Close()
+	})
+}
+
+// DecompressFile gunzips the source file into an executable destination file
+func DecompressFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open %s", src)
+	}
+	defer in.Close()
+
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read compressed file %s", src)
+	}
+	defer gz.Close()
+
+	return writeAtomically(dest, 0755, func(w io.Writer) error {
+		_, err := io.Copy(w, gz)
+		return err
+	})
+}
+
+// writeAtomically writes to a temporary file which is renamed to the path so that concurrent processes never see a partial file
+func writeAtomically(path string, mode os.FileMode, fn func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temp file for %s", path)
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+
+	err = fn(f)
+	if err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to close %s", tmpPath)
+	}
+	err = os.Chmod(tmpPath, mode)
+	if err != nil {
+		return errors.Wrapf(err, "failed to chmod %s", tmpPath)
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rename %s to %s", tmpPath, path)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package plugins_test
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const script = "#!/bin/sh\necho hello from plugin\n"
+
+func TestCompressedPluginExecutes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts cannot be executed on windows")
+	}
+	plugin := createTestPlugin()
+
+	binDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create temp dir")
+	execDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create temp dir")
+
+	installs := 0
+	installer := func(p jenkinsv1.Plugin, dir string) (string, error) {
+		installs++
+		path := filepath.Join(dir, p.Spec.Name+"-"+p.Spec.Version)
+		err := ioutil.WriteFile(path, []byte(script), 0755)
+		return path, err
+	}
+
+	// the first install downloads and compresses the plugin
+	path, err := plugins.EnsureCompressedPluginInstalled(plugin, binDir, execDir, installer)
+	require.NoError(t, err, "failed to install plugin")
+	assertPluginExecutes(t, path)
+
+	fileNames, err := ioutil.ReadDir(binDir)
+	require.NoError(t, err, "failed to read dir %s", binDir)
+	require.Len(t, fileNames, 1, "should only have the compressed plugin in %s", binDir)
+	assert.Equal(t, "myplugin-1.2.3"+plugins.CompressedExtension, fileNames[0].Name())
+
+	// a new exec dir simulates a new process decompressing the cached plugin
+	execDir, err = ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create temp dir")
+
+	path, err = plugins.EnsureCompressedPluginInstalled(plugin, binDir, execDir, installer)
+	require.NoError(t, err, "failed to decompress plugin")
+	assert.Equal(t, filepath.Join(execDir, "myplugin-1.2.3"), path)
+	assertPluginExecutes(t, path)
+
+	// the decompressed plugin is reused
+	path, err = plugins.EnsureCompressedPluginInstalled(plugin, binDir, execDir, installer)
+	require.NoError(t, err, "failed to find plugin")
+	assertPluginExecutes(t, path)
+	assert.Equal(t, 1, installs, "should only have installed the plugin once")
+}
+
+func TestCompressDecompressFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err, "failed to create temp dir")
+
+	src := filepath.Join(tmpDir, "src")
+	compressed := filepath.Join(tmpDir, "src.gz")
+	dest := filepath.Join(tmpDir, "dest")
+	err = ioutil.WriteFile(src, []byte(script), 0644)
+	require.NoError(t, err, "failed to write %s", src)
+
+	err = plugins.CompressFile(src, compressed)
+	require.NoError(t, err, "failed to compress")
+	err = plugins.DecompressFile(compressed, dest)
+	require.NoError(t, err, "failed to decompress")
+
+	data, err := ioutil.ReadFile(dest)
+	require.NoError(t, err, "failed to read %s", dest)
+	assert.Equal(t, script, string(data))
+
+	exists, err := files.FileExists(src)
+	require.NoError(t, err)
+	assert.True(t, exists, "should not have removed the source file")
+}
+
+func TestCompressPluginsFunc(t *testing.T) {
+	testCases := map[string]bool{
+		"":       false,
+		"true":   true,
+		"1":      true,
+		"false":  false,
+		"cheese": false,
+	}
+	for value, expected := range testCases {
+		actual := plugins.CompressPluginsFunc(func(name string) string {
+			if name == plugins.CompressPluginsEnv {
+				return value
+			}
+			return ""
+		})
+		assert.Equal(t, expected, actual, "for $%s=%s", plugins.CompressPluginsEnv, value)
+	}
+}
+
+func createTestPlugin() jenkinsv1.Plugin {
+	return jenkinsv1.Plugin{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "myplugin",
+		},
+		Spec: jenkinsv1.PluginSpec{
+			Name:    "myplugin",
+			Version: "1.2.3",
+		},
+	}
+}
+
+func assertPluginExecutes(t *testing.T, path string) {
+	out, err := exec.Command(path).CombinedOutput()
+	require.NoError(t, err, "failed to execute %s", path)
+	assert.Equal(t, "hello from plugin", strings.TrimSpace(string(out)), "output of %s", path)
+}
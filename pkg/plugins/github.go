@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+)
+
+const (
+	// GitHubTokenEnv the environment variable of the token used to authenticate plugin downloads from GitHub so they
+	// get the higher rate limit of authenticated requests
+	GitHubTokenEnv = "GITHUB_TOKEN"
+)
+
+// gitHubHosts the hosts the GitHub token may be sent to
+var gitHubHosts = []string{"github.com", "api.github.com"}
+
+// IsGitHubURL returns true if the URL is on a GitHub host which the GitHub token may be sent to
+func IsGitHubURL(u *url.URL) bool {
+	if u == nil || u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range gitHubHosts {
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+// AddGitHubToken adds the token as the Authorization header of the request if it is to a GitHub host. The token is never
+// added to requests to other hosts such as get.helm.sh. Redirects to other hosts such as the storage of the release
+// assets do not include the header either as the http client drops it when following a redirect to a different domain
+func AddGitHubToken(req *http.Request, token string) {
+	if token == "" || !IsGitHubURL(req.URL) {
+		return
+	}
+	req.Header.Set("Authorization", "token "+token)
+}
+
+// isGitHubPlugin returns true if the plugin binary of the current platform is downloaded from GitHub
+func isGitHubPlugin(plugin jenkinsv1.Plugin) bool {
+	u, err := extensions.FindPluginUrl(plugin.Spec)
+	if err != nil {
+		return false
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	return IsGitHubURL(parsed)
+}
@@ -0,0 +1,92 @@
+package plugins_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddGitHubToken(t *testing.T) {
+	testCases := []struct {
+		url      string
+		token    string
+		expected string
+	}{
+		{
+			url:      "https://github.com/roboll/helmfile/releases/download/v0.138.7/helmfile_linux_amd64",
+			token:    "mytoken",
+			expected: "token mytoken",
+		},
+		{
+			url:      "https://api.github.com/repos/roboll/helmfile/releases",
+			token:    "mytoken",
+			expected: "token mytoken",
+		},
+		{
+			url: "https://github.com/roboll/helmfile/releases/download/v0.138.7/helmfile_linux_amd64",
+		},
+		{
+			url:   "https://get.helm.sh/helm-v3.5.3-linux-amd64.tar.gz",
+			token: "mytoken",
+		},
+		{
+			url:   "https://github.com.example.com/helmfile_linux_amd64",
+			token: "mytoken",
+		},
+		{
+			url:   "https://artifactory.example.com/plugins/roboll/helmfile/releases/download/v0.138.7/helmfile_linux_amd64",
+			token: "mytoken",
+		},
+		{
+			url:   "http://github.com/roboll/helmfile/releases/download/v0.138.7/helmfile_linux_amd64",
+			token: "mytoken",
+		},
+	}
+	for _, tc := range testCases {
+		req, err := http.NewRequest("GET", tc.url, nil)
+		require.NoError(t, err, "failed to create request for %s", tc.url)
+		plugins.AddGitHubToken(req, tc.token)
+		assert.Equal(t, tc.expected, req.Header.Get("Authorization"), "Authorization header for %s", tc.url)
+	}
+}
+
+func TestDownloadDoesNotSendGitHubTokenToOtherHosts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	old, hasOld := os.LookupEnv(plugins.GitHubTokenEnv)
+	defer func() {
+		if hasOld {
+			os.Setenv(plugins.GitHubTokenEnv, old)
+		} else {
+			os.Unsetenv(plugins.GitHubTokenEnv)
+		}
+	}()
+	os.Setenv(plugins.GitHubTokenEnv, "mytoken")
+
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		w.Write([]byte(script)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	binDir := t.TempDir()
+	path, err := plugins.EnsureDownloadedPluginInstalled(createChecksumPlugin(server.URL+"/myplugin"), binDir)
+	require.NoError(t, err, "failed to install plugin")
+	assert.Equal(t, filepath.Join(binDir, "myplugin-1.2.3"), path)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "failed to read %s", path)
+	assert.Equal(t, script, string(data), "installed binary")
+
+	require.Len(t, authorizations, 1, "requests")
+	assert.Equal(t, "", authorizations[0], "should not send the GitHub token to a non GitHub host")
+}
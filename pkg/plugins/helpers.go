@@ -12,7 +12,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// GetHelmBinary returns the path to the locally installed helm 3 extension
+// GetHelmBinary returns the path to the locally installed helm 3 extension. The download is verified against the
+// SHA256 checksum published alongside the binary
 func GetHelmBinary(version string) (string, error) {
 	if version == "" {
 		version = HelmVersion
@@ -21,8 +22,12 @@ func GetHelmBinary(version string) (string, error) {
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to find plugin home dir")
 	}
-	plugin := CreateHelmPlugin(version)
-	return extensions.EnsurePluginInstalled(plugin, pluginBinDir)
+	checksums, err := fetchInstallChecksums(CreateHelmPlugin(version), pluginBinDir)
+	if err != nil {
+		return "", err
+	}
+	plugin := CreateHelmPlugin(version, checksums)
+	return EnsurePluginInstalled(plugin, pluginBinDir)
 }
 
 // PluginBinDir returns the plugin dir
@@ -41,9 +46,25 @@ func PluginBinDirFunc(fn func(string) string) (string, error) {
 	return homedir.PluginBinDir("", ".jx")
 }
 
-// CreateHelmPlugin creates the helm 3 plugin
-func CreateHelmPlugin(version string) jenkinsv1.Plugin {
-	binaries := extensions.CreateBinaries(func(p extensions.Platform) string {
+// GitopsPluginBinDir returns the plugin dir of the kpt, kubectl and kapp plugins
+func GitopsPluginBinDir() (string, error) {
+	return homedir.PluginBinDir(os.Getenv("JX_GITOPS_HOME"), ".jx-gitops")
+}
+
+// BinDirFor returns the dir the binary of the plugin is resolved from. The helm and helmfile plugins use PluginBinDir
+// and the other plugins use GitopsPluginBinDir
+func BinDirFor(name string) (string, error) {
+	switch name {
+	case HelmPluginName, HelmfilePluginName:
+		return PluginBinDir()
+	default:
+		return GitopsPluginBinDir()
+	}
+}
+
+// CreateHelmPlugin creates the helm 3 plugin with the optional SHA256 checksums of its binaries keyed by goos/goarch
+func CreateHelmPlugin(version string, checksums ...map[string]string) jenkinsv1.Plugin {
+	binaries := createBinaries(func(p extensions.Platform) string {
 		return fmt.Sprintf("https://get.helm.sh/helm-v%s-%s-%s.%s", version, strings.ToLower(p.Goos), strings.ToLower(p.Goarch), p.Extension())
 	})
 
@@ -59,7 +80,7 @@ func CreateHelmPlugin(version string) jenkinsv1.Plugin {
 			Version:     version,
 		},
 	}
-	return plugin
+	return WithChecksums(plugin, checksums...)
 }
 
 // GetHelmfileBinary returns the path to the locally installed helmfile extension
@@ -72,17 +93,13 @@ func GetHelmfileBinary(version string) (string, error) {
 		return "", errors.Wrapf(err, "failed to find plugin home dir")
 	}
 	plugin := CreateHelmfilePlugin(version)
-	return extensions.EnsurePluginInstalled(plugin, pluginBinDir)
+	return EnsurePluginInstalled(plugin, pluginBinDir)
 }
 
-// CreateHelmfilePlugin creates the helmfile plugin
-func CreateHelmfilePlugin(version string) jenkinsv1.Plugin {
-	binaries := extensions.CreateBinaries(func(p extensions.Platform) string {
-		ext := ""
-		if p.IsWindows() {
-			ext = ".exe"
-		}
-		return fmt.Sprintf("https://github.com/roboll/helmfile/releases/download/v%s/helmfile_%s_%s%s", version, strings.ToLower(p.Goos), strings.ToLower(p.Goarch), ext)
+// CreateHelmfilePlugin creates the helmfile plugin with the optional SHA256 checksums of its binaries keyed by goos/goarch
+func CreateHelmfilePlugin(version string, checksums ...map[string]string) jenkinsv1.Plugin {
+	binaries := createPlatformBinaries(HelmfilePlatforms, func(p extensions.Platform) string {
+		return HelmfileURL(version, p)
 	})
 
 	plugin := jenkinsv1.Plugin{
@@ -97,7 +114,22 @@ func CreateHelmfilePlugin(version string) jenkinsv1.Plugin {
 			Version:     version,
 		},
 	}
-	return plugin
+	return WithChecksums(plugin, checksums...)
+}
+
+// HelmfileURL returns the download URL of the helmfile binary of the platform. Upstream publishes binaries for amd64,
+// arm64 and 386 so only 32-bit arm falls back to a fork
+func HelmfileURL(version string, p extensions.Platform) string {
+	goos := strings.ToLower(p.Goos)
+	goarch := strings.ToLower(p.Goarch)
+	if goarch == "arm" {
+		return fmt.Sprintf(HelmfileArmForkURL, version, goos)
+	}
+	ext := ""
+	if p.IsWindows() {
+		ext = ".exe"
+	}
+	return fmt.Sprintf("https://github.com/roboll/helmfile/releases/download/v%s/helmfile_%s_%s%s", version, goos, goarch, ext)
 }
 
 // GetKptBinary returns the path to the locally installed kpt 3 extension
@@ -105,17 +137,17 @@ func GetKptBinary(version string) (string, error) {
 	if version == "" {
 		version = KptVersion
 	}
-	pluginBinDir, err := homedir.PluginBinDir(os.Getenv("JX_GITOPS_HOME"), ".jx-gitops")
+	pluginBinDir, err := GitopsPluginBinDir()
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to find plugin home dir")
 	}
 	plugin := CreateKptPlugin(version)
-	return extensions.EnsurePluginInstalled(plugin, pluginBinDir)
+	return EnsurePluginInstalled(plugin, pluginBinDir)
 }
 
-// CreateKptPlugin creates the kpt 3 plugin
-func CreateKptPlugin(version string) jenkinsv1.Plugin {
-	binaries := extensions.CreateBinaries(func(p extensions.Platform) string {
+// CreateKptPlugin creates the kpt 3 plugin with the optional SHA256 checksums of its binaries keyed by goos/goarch
+func CreateKptPlugin(version string, checksums ...map[string]string) jenkinsv1.Plugin {
+	binaries := createBinaries(func(p extensions.Platform) string {
 		return fmt.Sprintf("https://github.com/GoogleContainerTools/kpt/releases/download/v%s/kpt_%s_%s-%s.tar.gz", version, strings.ToLower(p.Goos), strings.ToLower(p.Goarch), version)
 	})
 
@@ -131,25 +163,64 @@ func CreateKptPlugin(version string) jenkinsv1.Plugin {
 			Version:     version,
 		},
 	}
-	return plugin
+	return WithChecksums(plugin, checksums...)
+}
+
+// GetKustomizeBinary returns the path to the locally installed kustomize extension
+func GetKustomizeBinary(version string) (string, error) {
+	if version == "" {
+		version = KustomizeVersion
+	}
+	pluginBinDir, err := GitopsPluginBinDir()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find plugin home dir")
+	}
+	plugin := CreateKustomizePlugin(version)
+	return EnsurePluginInstalled(plugin, pluginBinDir)
+}
+
+// CreateKustomizePlugin creates the kustomize plugin with the optional SHA256 checksums of its binaries keyed by goos/goarch
+func CreateKustomizePlugin(version string, checksums ...map[string]string) jenkinsv1.Plugin {
+	binaries := createBinaries(func(p extensions.Platform) string {
+		return fmt.Sprintf("https://github.com/kubernetes-sigs/kustomize/releases/download/kustomize%%2Fv%s/kustomize_v%s_%s_%s.tar.gz", version, version, strings.ToLower(p.Goos), strings.ToLower(p.Goarch))
+	})
+
+	plugin := jenkinsv1.Plugin{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: KustomizePluginName,
+		},
+		Spec: jenkinsv1.PluginSpec{
+			SubCommand:  "kustomize",
+			Binaries:    binaries,
+			Description: "kustomize binary",
+			Name:        KustomizePluginName,
+			Version:     version,
+		},
+	}
+	return WithChecksums(plugin, checksums...)
 }
 
-// GetKubectlBinary returns the path to the locally installed kpt 3 extension
+// GetKubectlBinary returns the path to the locally installed kpt 3 extension. The download is verified against the
+// SHA256 checksum published alongside the binary
 func GetKubectlBinary(version string) (string, error) {
 	if version == "" {
 		version = KubectlVersion
 	}
-	pluginBinDir, err := homedir.PluginBinDir(os.Getenv("JX_GITOPS_HOME"), ".jx-gitops")
+	pluginBinDir, err := GitopsPluginBinDir()
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to find plugin home dir")
 	}
-	plugin := CreateKubectlPlugin(version)
-	return extensions.EnsurePluginInstalled(plugin, pluginBinDir)
+	checksums, err := fetchInstallChecksums(CreateKubectlPlugin(version), pluginBinDir)
+	if err != nil {
+		return "", err
+	}
+	plugin := CreateKubectlPlugin(version, checksums)
+	return EnsurePluginInstalled(plugin, pluginBinDir)
 }
 
-// CreateKubectlPlugin creates the kpt 3 plugin
-func CreateKubectlPlugin(version string) jenkinsv1.Plugin {
-	binaries := extensions.CreateBinaries(func(p extensions.Platform) string {
+// CreateKubectlPlugin creates the kpt 3 plugin with the optional SHA256 checksums of its binaries keyed by goos/goarch
+func CreateKubectlPlugin(version string, checksums ...map[string]string) jenkinsv1.Plugin {
+	binaries := createBinaries(func(p extensions.Platform) string {
 		return fmt.Sprintf("https://storage.googleapis.com/kubernetes-release/release/v%s/bin/%s/%s/kubectl", version, strings.ToLower(p.Goos), strings.ToLower(p.Goarch))
 	})
 
@@ -165,7 +236,7 @@ func CreateKubectlPlugin(version string) jenkinsv1.Plugin {
 			Version:     version,
 		},
 	}
-	return plugin
+	return WithChecksums(plugin, checksums...)
 }
 
 // GetKappBinary returns the path to the locally installed kpt 3 extension
@@ -173,18 +244,18 @@ func GetKappBinary(version string) (string, error) {
 	if version == "" {
 		version = KappVersion
 	}
-	pluginBinDir, err := homedir.PluginBinDir(os.Getenv("JX_GITOPS_HOME"), ".jx-gitops")
+	pluginBinDir, err := GitopsPluginBinDir()
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to find plugin home dir")
 	}
 	plugin := CreateKappPlugin(version)
-	return extensions.EnsurePluginInstalled(plugin, pluginBinDir)
+	return EnsurePluginInstalled(plugin, pluginBinDir)
 }
 
-// CreateKappPlugin creates the kpt 3 plugin
-func CreateKappPlugin(version string) jenkinsv1.Plugin {
+// CreateKappPlugin creates the kpt 3 plugin with the optional SHA256 checksums of its binaries keyed by goos/goarch
+func CreateKappPlugin(version string, checksums ...map[string]string) jenkinsv1.Plugin {
 	// TODO - Repoint this back to kapp repo once this has merged https://github.com/vmware-tanzu/carvel-kapp/pull/177
-	binaries := extensions.CreateBinaries(func(p extensions.Platform) string {
+	binaries := createBinaries(func(p extensions.Platform) string {
 		return fmt.Sprintf("https://github.com/chrismellard/carvel-kapp/releases/download/v%s/carvel-kapp_%s_%s_%s.tar.gz", version, version, p.Goos, p.Goarch)
 	})
 
@@ -200,5 +271,80 @@ func CreateKappPlugin(version string) jenkinsv1.Plugin {
 			Version:     version,
 		},
 	}
-	return plugin
+	return WithChecksums(plugin, checksums...)
+}
+
+// GetConftestBinary returns the path to the locally installed conftest extension
+func GetConftestBinary(version string) (string, error) {
+	if version == "" {
+		version = ConftestVersion
+	}
+	pluginBinDir, err := GitopsPluginBinDir()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find plugin home dir")
+	}
+	plugin := CreateConftestPlugin(version)
+	return EnsurePluginInstalled(plugin, pluginBinDir)
+}
+
+// CreateConftestPlugin creates the conftest plugin with the optional SHA256 checksums of its binaries keyed by goos/goarch
+func CreateConftestPlugin(version string, checksums ...map[string]string) jenkinsv1.Plugin {
+	binaries := createBinaries(func(p extensions.Platform) string {
+		return fmt.Sprintf("https://github.com/open-policy-agent/conftest/releases/download/v%s/conftest_%s_%s_%s.%s", version, version, p.Goos, conftestArch(p.Goarch), p.Extension())
+	})
+
+	plugin := jenkinsv1.Plugin{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ConftestPluginName,
+		},
+		Spec: jenkinsv1.PluginSpec{
+			SubCommand:  "conftest",
+			Binaries:    binaries,
+			Description: "conftest binary",
+			Name:        ConftestPluginName,
+			Version:     version,
+		},
+	}
+	return WithChecksums(plugin, checksums...)
+}
+
+// conftestArch returns the architecture name used in the conftest release archives
+func conftestArch(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return "x86_64"
+	case "386":
+		return "i386"
+	default:
+		return goarch
+	}
+}
+
+// CreatePlugin creates the plugin with the given name at the version or its default version if the version is blank
+func CreatePlugin(name, version string) (jenkinsv1.Plugin, error) {
+	switch name {
+	case HelmPluginName:
+		return CreateHelmPlugin(defaultVersion(version, HelmVersion)), nil
+	case HelmfilePluginName:
+		return CreateHelmfilePlugin(defaultVersion(version, HelmfileVersion)), nil
+	case KptPluginName:
+		return CreateKptPlugin(defaultVersion(version, KptVersion)), nil
+	case KustomizePluginName:
+		return CreateKustomizePlugin(defaultVersion(version, KustomizeVersion)), nil
+	case KubectlPluginName:
+		return CreateKubectlPlugin(defaultVersion(version, KubectlVersion)), nil
+	case KappPluginName:
+		return CreateKappPlugin(defaultVersion(version, KappVersion)), nil
+	case ConftestPluginName:
+		return CreateConftestPlugin(defaultVersion(version, ConftestVersion)), nil
+	default:
+		return jenkinsv1.Plugin{}, errors.Errorf("unknown plugin %s", name)
+	}
+}
+
+func defaultVersion(version, defaultValue string) string {
+	if version == "" {
+		return defaultValue
+	}
+	return version
 }
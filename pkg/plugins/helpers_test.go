@@ -199,6 +199,51 @@ func TestKubectlPlugin(t *testing.T) {
 	assert.True(t, foundWindows, "did not find a windows binary in the plugin %#v", plugin)
 }
 
+func TestConftestPlugin(t *testing.T) {
+	t.Parallel()
+
+	v := plugins.ConftestVersion
+	plugin := plugins.CreateConftestPlugin(v)
+
+	assert.Equal(t, plugins.ConftestPluginName, plugin.Name, "plugin.Name")
+	assert.Equal(t, plugins.ConftestPluginName, plugin.Spec.Name, "plugin.Spec.Name")
+
+	foundLinux := false
+	foundMac := false
+	foundWindows := false
+	foundArm := false
+	for _, b := range plugin.Spec.Binaries {
+		switch b.Goarch {
+		case "arm64":
+			if b.Goos == "Linux" {
+				foundArm = true
+				assert.Equal(t, "https://github.com/open-policy-agent/conftest/releases/download/v"+v+"/conftest_"+v+"_Linux_arm64.tar.gz", b.URL, "URL for linux arm binary")
+				t.Logf("found linux arm binary URL %s", b.URL)
+			}
+
+		case "amd64":
+			switch b.Goos {
+			case "Darwin":
+				foundMac = true
+				assert.Equal(t, "https://github.com/open-policy-agent/conftest/releases/download/v"+v+"/conftest_"+v+"_Darwin_x86_64.tar.gz", b.URL, "URL for mac binary")
+				t.Logf("found mac binary URL %s", b.URL)
+			case "Linux":
+				foundLinux = true
+				assert.Equal(t, "https://github.com/open-policy-agent/conftest/releases/download/v"+v+"/conftest_"+v+"_Linux_x86_64.tar.gz", b.URL, "URL for linux binary")
+				t.Logf("found linux binary URL %s", b.URL)
+			case "Windows":
+				foundWindows = true
+				assert.Equal(t, "https://github.com/open-policy-agent/conftest/releases/download/v"+v+"/conftest_"+v+"_Windows_x86_64.zip", b.URL, "URL for windows binary")
+				t.Logf("found windows binary URL %s", b.URL)
+			}
+		}
+	}
+	assert.True(t, foundArm, "did not find an arm linux binary in the plugin %#v", plugin)
+	assert.True(t, foundLinux, "did not find a linux binary in the plugin %#v", plugin)
+	assert.True(t, foundMac, "did not find a mac binary in the plugin %#v", plugin)
+	assert.True(t, foundWindows, "did not find a windows binary in the plugin %#v", plugin)
+}
+
 func TestPluginDir(t *testing.T) {
 	testCases := []struct {
 		env      map[string]string
@@ -238,3 +283,60 @@ func TestPluginDir(t *testing.T) {
 		assert.Equal(t, tc.expected, dir, "for env %v", tc.env)
 	}
 }
+
+func TestHelmfilePluginPlatforms(t *testing.T) {
+	t.Parallel()
+
+	prefix := "https://github.com/roboll/helmfile/releases/download/v0.138.7/"
+	testCases := []struct {
+		goos     string
+		goarch   string
+		expected string
+	}{
+		{goos: "Linux", goarch: "amd64", expected: prefix + "helmfile_linux_amd64"},
+		{goos: "Linux", goarch: "arm64", expected: prefix + "helmfile_linux_arm64"},
+		{goos: "Linux", goarch: "386", expected: prefix + "helmfile_linux_386"},
+		{goos: "Linux", goarch: "arm", expected: "https://github.com/jstrachan/helmfile/releases/download/v0.138.7/helmfile_linux_arm"},
+		{goos: "Darwin", goarch: "amd64", expected: prefix + "helmfile_darwin_amd64"},
+		{goos: "Darwin", goarch: "arm64", expected: prefix + "helmfile_darwin_arm64"},
+		{goos: "Windows", goarch: "amd64", expected: prefix + "helmfile_windows_amd64.exe"},
+	}
+
+	plugin := plugins.CreateHelmfilePlugin("0.138.7")
+	require.Len(t, plugin.Spec.Binaries, len(testCases), "binaries %#v", plugin.Spec.Binaries)
+	for _, tc := range testCases {
+		found := ""
+		for _, b := range plugin.Spec.Binaries {
+			if b.Goos == tc.goos && b.Goarch == tc.goarch {
+				found = b.URL
+			}
+		}
+		assert.Equal(t, tc.expected, found, "URL for %s/%s", tc.goos, tc.goarch)
+	}
+}
+
+func TestKustomizePlugin(t *testing.T) {
+	t.Parallel()
+
+	v := plugins.KustomizeVersion
+	plugin := plugins.CreateKustomizePlugin(v)
+
+	assert.Equal(t, plugins.KustomizePluginName, plugin.Name, "plugin.Name")
+	assert.Equal(t, plugins.KustomizePluginName, plugin.Spec.Name, "plugin.Spec.Name")
+
+	prefix := "https://github.com/kubernetes-sigs/kustomize/releases/download/kustomize%2Fv" + v + "/kustomize_v" + v
+	expected := map[string]string{
+		"Darwin/amd64":  prefix + "_darwin_amd64.tar.gz",
+		"Linux/amd64":   prefix + "_linux_amd64.tar.gz",
+		"Linux/arm64":   prefix + "_linux_arm64.tar.gz",
+		"Windows/amd64": prefix + "_windows_amd64.tar.gz",
+	}
+	found := map[string]string{}
+	for _, b := range plugin.Spec.Binaries {
+		key := b.Goos + "/" + b.Goarch
+		if _, ok := expected[key]; ok {
+			found[key] = b.URL
+		}
+	}
+	assert.Equal(t, expected, found, "binary URLs of the plugin %#v", plugin)
+}
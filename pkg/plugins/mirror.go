@@ -0,0 +1,76 @@
+package plugins
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+)
+
+// MirrorEnv the environment variable of the base URL of a mirror of the plugin downloads such as an internal
+// Artifactory. The scheme and host of each download URL are replaced by the base URL keeping the upstream path
+const MirrorEnv = "JX_PLUGIN_MIRROR"
+
+// MirrorURL returns the download URL rewritten to use the $JX_PLUGIN_MIRROR base URL if it is set
+func MirrorURL(u string) string {
+	return MirrorURLFunc(u, os.Getenv)
+}
+
+// MirrorURLFunc rewrites the download URL using a function for looking up env vars for easier testing
+func MirrorURLFunc(u string, fn func(string) string) string {
+	return RewriteURL(u, fn(MirrorEnv))
+}
+
+// RewriteURL replaces the scheme and host of the URL with the mirror base URL preserving the path so the mirror can
+// have the same layout as upstream. Returns the URL unchanged if the mirror is empty or either URL is invalid
+func RewriteURL(u, mirror string) string {
+	if mirror == "" {
+		return u
+	}
+	base, err := url.Parse(mirror)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		log.Logger().Warnf("ignoring invalid plugin mirror URL %s", mirror)
+		return u
+	}
+	original, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	// lets keep any escaped characters in the path such as the %2F in kustomize release tags
+	rawPath := strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.TrimPrefix(original.EscapedPath(), "/")
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return u
+	}
+	original.Scheme = base.Scheme
+	original.Host = base.Host
+	original.User = base.User
+	original.Path = path
+	original.RawPath = rawPath
+	return original.String()
+}
+
+// createBinaries creates the binaries of each default platform using any plugin mirror
+func createBinaries(fn func(p extensions.Platform) string) []jenkinsv1.Binary {
+	return createPlatformBinaries(extensions.DefaultPlatforms, fn)
+}
+
+// createPlatformBinaries creates the binaries of the platforms using any plugin mirror. Platforms without a URL are skipped
+func createPlatformBinaries(platforms []extensions.Platform, fn func(p extensions.Platform) string) []jenkinsv1.Binary {
+	var answer []jenkinsv1.Binary
+	for _, p := range platforms {
+		u := fn(p)
+		if u == "" {
+			continue
+		}
+		answer = append(answer, jenkinsv1.Binary{
+			Goarch: p.Goarch,
+			Goos:   p.Goos,
+			URL:    MirrorURL(u),
+		})
+	}
+	return answer
+}
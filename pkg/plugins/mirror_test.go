@@ -0,0 +1,113 @@
+package plugins_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteURL(t *testing.T) {
+	u := "https://get.helm.sh/helm-v3.5.3-linux-amd64.tar.gz"
+	testCases := []struct {
+		mirror   string
+		expected string
+	}{
+		{
+			expected: u,
+		},
+		{
+			mirror:   "https://artifactory.example.com",
+			expected: "https://artifactory.example.com/helm-v3.5.3-linux-amd64.tar.gz",
+		},
+		{
+			mirror:   "http://artifactory.example.com:8081/artifactory/plugins/",
+			expected: "http://artifactory.example.com:8081/artifactory/plugins/helm-v3.5.3-linux-amd64.tar.gz",
+		},
+		{
+			mirror:   "not a url",
+			expected: u,
+		},
+	}
+	for _, tc := range testCases {
+		got := plugins.MirrorURLFunc(u, func(name string) string {
+			if name == plugins.MirrorEnv {
+				return tc.mirror
+			}
+			return ""
+		})
+		assert.Equal(t, tc.expected, got, "URL for mirror %s", tc.mirror)
+	}
+}
+
+func TestPluginMirror(t *testing.T) {
+	old, hasOld := os.LookupEnv(plugins.MirrorEnv)
+	defer func() {
+		if hasOld {
+			os.Setenv(plugins.MirrorEnv, old)
+		} else {
+			os.Unsetenv(plugins.MirrorEnv)
+		}
+	}()
+
+	testCases := []struct {
+		mirror   string
+		plugin   func() jenkinsv1.Plugin
+		expected string
+	}{
+		{
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateHelmPlugin("3.5.3") },
+			expected: "https://get.helm.sh/helm-v3.5.3-linux-amd64.tar.gz",
+		},
+		{
+			mirror:   "https://artifactory.example.com/plugins",
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateHelmPlugin("3.5.3") },
+			expected: "https://artifactory.example.com/plugins/helm-v3.5.3-linux-amd64.tar.gz",
+		},
+		{
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateHelmfilePlugin("0.138.7") },
+			expected: "https://github.com/roboll/helmfile/releases/download/v0.138.7/helmfile_linux_amd64",
+		},
+		{
+			mirror:   "https://artifactory.example.com/plugins",
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateHelmfilePlugin("0.138.7") },
+			expected: "https://artifactory.example.com/plugins/roboll/helmfile/releases/download/v0.138.7/helmfile_linux_amd64",
+		},
+		{
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateKptPlugin("0.37.0") },
+			expected: "https://github.com/GoogleContainerTools/kpt/releases/download/v0.37.0/kpt_linux_amd64-0.37.0.tar.gz",
+		},
+		{
+			mirror:   "https://artifactory.example.com/plugins",
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateKptPlugin("0.37.0") },
+			expected: "https://artifactory.example.com/plugins/GoogleContainerTools/kpt/releases/download/v0.37.0/kpt_linux_amd64-0.37.0.tar.gz",
+		},
+		{
+			mirror:   "https://artifactory.example.com/plugins",
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateKustomizePlugin("4.0.5") },
+			expected: "https://artifactory.example.com/plugins/kubernetes-sigs/kustomize/releases/download/kustomize%2Fv4.0.5/kustomize_v4.0.5_linux_amd64.tar.gz",
+		},
+		{
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateKubectlPlugin("1.16.15") },
+			expected: "https://storage.googleapis.com/kubernetes-release/release/v1.16.15/bin/linux/amd64/kubectl",
+		},
+		{
+			mirror:   "https://artifactory.example.com/plugins",
+			plugin:   func() jenkinsv1.Plugin { return plugins.CreateKubectlPlugin("1.16.15") },
+			expected: "https://artifactory.example.com/plugins/kubernetes-release/release/v1.16.15/bin/linux/amd64/kubectl",
+		},
+	}
+	for _, tc := range testCases {
+		os.Setenv(plugins.MirrorEnv, tc.mirror)
+		plugin := tc.plugin()
+		got := ""
+		for _, b := range plugin.Spec.Binaries {
+			if b.Goos == "Linux" && b.Goarch == "amd64" {
+				got = b.URL
+			}
+		}
+		assert.Equal(t, tc.expected, got, "linux URL of plugin %s with mirror %s", plugin.Name, tc.mirror)
+	}
+}
@@ -0,0 +1,62 @@
+package plugins
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/files"
+	"github.com/pkg/errors"
+)
+
+// ReinstallPlugin downloads the plugin even if it is already installed replacing any existing binary of the same version
+// returning the path of the binary
+func ReinstallPlugin(plugin jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+	return ReinstallPluginWith(plugin, pluginBinDir, PluginExecDir(), CompressPluginsFunc(os.Getenv), installerFor(plugin))
+}
+
+// ReinstallPluginWith downloads the plugin with the installer ignoring any existing binary. The new binary is installed
+// into a temporary dir and then moved over the existing binary so that a failed download keeps the existing binary
+func ReinstallPluginWith(plugin jenkinsv1.Plugin, pluginBinDir, execDir string, compress bool, installer Installer) (string, error) {
+	name := fmt.Sprintf("%s-%s", plugin.Spec.Name, plugin.Spec.Version)
+	err := os.MkdirAll(pluginBinDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create dir %s", pluginBinDir)
+	}
+	tmpDir, err := ioutil.TempDir(pluginBinDir, "reinstall-")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create temp dir")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path, err := installer(plugin, tmpDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to install plugin %s", name)
+	}
+	if !compress {
+		dest := filepath.Join(pluginBinDir, name)
+		err = os.Rename(path, dest)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to move %s to %s", path, dest)
+		}
+		return dest, nil
+	}
+
+	err = os.MkdirAll(execDir, files.DefaultDirWritePermissions)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create dir %s", execDir)
+	}
+	compressedPath := filepath.Join(pluginBinDir, name+CompressedExtension)
+	err = CompressFile(path, compressedPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to compress plugin %s", name)
+	}
+	execPath := filepath.Join(execDir, name)
+	err = os.Rename(path, execPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to move %s to %s", path, execPath)
+	}
+	return execPath, nil
+}
@@ -0,0 +1,79 @@
+package plugins_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReinstallPlugin(t *testing.T) {
+	plugin := createTestPlugin()
+
+	for _, compress := range []bool{false, true} {
+		binDir := t.TempDir()
+		execDir := t.TempDir()
+
+		content := "old"
+		installer := func(p jenkinsv1.Plugin, dir string) (string, error) {
+			if content == "" {
+				return "", errors.New("download failed")
+			}
+			path := filepath.Join(dir, p.Spec.Name+"-"+p.Spec.Version)
+			err := ioutil.WriteFile(path, []byte(content), 0755)
+			return path, err
+		}
+
+		path, err := plugins.ReinstallPluginWith(plugin, binDir, execDir, compress, installer)
+		require.NoError(t, err, "failed to install plugin with compress %v", compress)
+		assertFileContent(t, path, "old")
+
+		content = "new"
+		path, err = plugins.ReinstallPluginWith(plugin, binDir, execDir, compress, installer)
+		require.NoError(t, err, "failed to reinstall plugin with compress %v", compress)
+		assertFileContent(t, path, "new")
+
+		expectedName := "myplugin-1.2.3"
+		if compress {
+			expectedName += plugins.CompressedExtension
+			assert.Equal(t, filepath.Join(execDir, "myplugin-1.2.3"), path, "path with compress %v", compress)
+		} else {
+			assert.Equal(t, filepath.Join(binDir, "myplugin-1.2.3"), path, "path with compress %v", compress)
+		}
+		fileNames, err := ioutil.ReadDir(binDir)
+		require.NoError(t, err, "failed to read dir %s", binDir)
+		require.Len(t, fileNames, 1, "should only have the plugin in %s with compress %v", binDir, compress)
+		assert.Equal(t, expectedName, fileNames[0].Name(), "file name with compress %v", compress)
+
+		// a failed download keeps the existing binary
+		content = ""
+		_, err = plugins.ReinstallPluginWith(plugin, binDir, execDir, compress, installer)
+		require.Error(t, err, "should fail if the download fails with compress %v", compress)
+		assertFileContent(t, path, "new")
+	}
+}
+
+func TestCreatePlugin(t *testing.T) {
+	p, err := plugins.CreatePlugin(plugins.HelmPluginName, "")
+	require.NoError(t, err, "failed to create helm plugin")
+	assert.Equal(t, plugins.HelmVersion, p.Spec.Version, "default version")
+
+	p, err = plugins.CreatePlugin(plugins.KptPluginName, "1.0.0")
+	require.NoError(t, err, "failed to create kpt plugin")
+	assert.Equal(t, plugins.KptPluginName, p.Name, "name")
+	assert.Equal(t, "1.0.0", p.Spec.Version, "version")
+
+	_, err = plugins.CreatePlugin("cheese", "")
+	require.Error(t, err, "should fail for an unknown plugin")
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "failed to read %s", path)
+	assert.Equal(t, expected, string(data), "content of %s", path)
+}
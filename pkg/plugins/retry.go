@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DownloadAttemptsEnv the environment variable of the maximum number of attempts to download a plugin
+	DownloadAttemptsEnv = "JX_GITOPS_PLUGIN_DOWNLOAD_ATTEMPTS"
+
+	// DefaultDownloadAttempts the default maximum number of attempts to download a plugin
+	DefaultDownloadAttempts = 3
+)
+
+// DownloadBackoff the delay before the second attempt to download a plugin which doubles for each following attempt
+var DownloadBackoff = 2 * time.Second
+
+// DownloadAttemptsFunc returns the maximum number of attempts to download a plugin using a function for looking up
+// env vars for easier testing
+func DownloadAttemptsFunc(fn func(string) string) int {
+	v := fn(DownloadAttemptsEnv)
+	if v == "" {
+		return DefaultDownloadAttempts
+	}
+	attempts, err := strconv.Atoi(v)
+	if err != nil || attempts < 1 {
+		log.Logger().Warnf("ignoring invalid $%s value %s", DownloadAttemptsEnv, v)
+		return DefaultDownloadAttempts
+	}
+	return attempts
+}
+
+// WithRetries returns an installer which retries the installer with exponential backoff until it succeeds or the
+// attempts are exhausted. Any partially downloaded binary is removed after a failed attempt so it is never cached
+func WithRetries(installer Installer, attempts int, backoff time.Duration) Installer {
+	return func(plugin jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+		path := filepath.Join(pluginBinDir, fmt.Sprintf("%s-%s", plugin.Spec.Name, plugin.Spec.Version))
+		delay := backoff
+		var err error
+		for i := 1; ; i++ {
+			var answer string
+			answer, err = installer(plugin, pluginBinDir)
+			if err == nil {
+				return answer, nil
+			}
+			removeErr := os.Remove(path)
+			if removeErr != nil && !os.IsNotExist(removeErr) {
+				return "", errors.Wrapf(removeErr, "failed to remove partially downloaded plugin %s", path)
+			}
+			if i >= attempts {
+				break
+			}
+			log.Logger().Warnf("attempt %d of %d to install plugin %s failed, retrying in %s: %s", i, attempts, plugin.Spec.Name, delay.String(), err.Error())
+			time.Sleep(delay)
+			delay *= 2
+		}
+		return "", errors.Wrapf(err, "failed to install plugin %s after %d attempts", plugin.Spec.Name, attempts)
+	}
+}
@@ -0,0 +1,127 @@
+package plugins_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jenkins-x-plugins/jx-gitops/pkg/plugins"
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetries(t *testing.T) {
+	plugin := createTestPlugin()
+
+	testCases := []struct {
+		name     string
+		failures int
+		attempts int
+		success  bool
+	}{
+		{
+			name:     "first-attempt",
+			attempts: 3,
+			success:  true,
+		},
+		{
+			name:     "transient-failures",
+			failures: 2,
+			attempts: 3,
+			success:  true,
+		},
+		{
+			name:     "exhausted",
+			failures: 3,
+			attempts: 3,
+		},
+	}
+	for _, tc := range testCases {
+		binDir := t.TempDir()
+		path := filepath.Join(binDir, "myplugin-1.2.3")
+
+		calls := 0
+		installer := func(p jenkinsv1.Plugin, pluginBinDir string) (string, error) {
+			calls++
+			assert.NoFileExists(t, path, "should have removed the partial download before attempt %d for %s", calls, tc.name)
+			if calls <= tc.failures {
+				err := ioutil.WriteFile(path, []byte("partial"), 0755)
+				require.NoError(t, err, "failed to write %s", path)
+				return "", errors.Errorf("connection reset")
+			}
+			err := ioutil.WriteFile(path, []byte(script), 0755)
+			require.NoError(t, err, "failed to write %s", path)
+			return path, nil
+		}
+
+		got, err := plugins.WithRetries(installer, tc.attempts, 0)(plugin, binDir)
+		if tc.success {
+			require.NoError(t, err, "failed to install for %s", tc.name)
+			assert.Equal(t, path, got, "path for %s", tc.name)
+			assert.Equal(t, tc.failures+1, calls, "attempts for %s", tc.name)
+			continue
+		}
+		require.Error(t, err, "should fail after exhausting the attempts for %s", tc.name)
+		assert.Contains(t, err.Error(), "after 3 attempts", "error for %s", tc.name)
+		assert.Equal(t, tc.attempts, calls, "attempts for %s", tc.name)
+		assert.NoFileExists(t, path, "should not cache a partial download for %s", tc.name)
+	}
+}
+
+func TestEnsurePluginInstalledRetriesDownload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	oldBackoff := plugins.DownloadBackoff
+	plugins.DownloadBackoff = 0
+	defer func() {
+		plugins.DownloadBackoff = oldBackoff
+	}()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(script)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	digest := sha256.Sum256([]byte(script))
+	key := plugins.PlatformKey(runtime.GOOS, runtime.GOARCH)
+	plugin := plugins.WithChecksums(createChecksumPlugin(server.URL+"/myplugin"), map[string]string{key: hex.EncodeToString(digest[:])})
+
+	binDir := t.TempDir()
+	path, err := plugins.EnsurePluginInstalled(plugin, binDir)
+	require.NoError(t, err, "failed to install plugin after a transient failure")
+	assert.Equal(t, 2, requests, "download requests")
+	assertPluginExecutes(t, path)
+}
+
+func TestDownloadAttemptsFunc(t *testing.T) {
+	testCases := map[string]int{
+		"":       plugins.DefaultDownloadAttempts,
+		"1":      1,
+		"5":      5,
+		"0":      plugins.DefaultDownloadAttempts,
+		"cheese": plugins.DefaultDownloadAttempts,
+	}
+	for value, expected := range testCases {
+		actual := plugins.DownloadAttemptsFunc(func(name string) string {
+			if name == plugins.DownloadAttemptsEnv {
+				return value
+			}
+			return ""
+		})
+		assert.Equal(t, expected, actual, "for $%s=%s", plugins.DownloadAttemptsEnv, value)
+	}
+}
@@ -1,6 +1,9 @@
 package plugins
 
-import jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+import (
+	jenkinsv1 "github.com/jenkins-x/jx-api/v4/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/extensions"
+)
 
 const (
 	// HelmPluginName the default name of the helm plugin
@@ -12,12 +15,18 @@ const (
 	// KptPluginName the default name of the kpt plugin
 	KptPluginName = "kpt"
 
+	// KustomizePluginName the default name of the kustomize plugin
+	KustomizePluginName = "kustomize"
+
 	// KubectlPluginName the default name of the kubectl plugin
 	KubectlPluginName = "kubectl"
 
 	// KappPluginName the default name of the kapp plugin
 	KappPluginName = "kapp"
 
+	// ConftestPluginName the default name of the conftest plugin
+	ConftestPluginName = "conftest"
+
 	// HelmVersion the default version of helm to use
 	HelmVersion = "3.5.3"
 
@@ -27,11 +36,20 @@ const (
 	// KptVersion the default version of kpt to use
 	KptVersion = "0.37.0"
 
+	// KustomizeVersion the default version of kustomize to use
+	KustomizeVersion = "4.0.5"
+
 	// KubectlVersion the default version of kpt to use
 	KubectlVersion = "1.16.15"
 
 	// KappVersion the default version of kapp to use
 	KappVersion = "0.35.1-cmfork"
+
+	// ConftestVersion the default version of conftest to use
+	ConftestVersion = "0.23.0"
+
+	// HelmfileArmForkURL the format of the download URL of helmfile for 32-bit arm which is not published upstream
+	HelmfileArmForkURL = "https://github.com/jstrachan/helmfile/releases/download/v%s/helmfile_%s_arm"
 )
 
 var (
@@ -41,7 +59,17 @@ var (
 		CreateHelmfilePlugin(HelmfileVersion),
 		// disable as no arm image yet
 		//CreateKptPlugin(KptVersion),
+		CreateKustomizePlugin(KustomizeVersion),
 		CreateKubectlPlugin(KubectlVersion),
 		CreateKappPlugin(KappVersion),
 	}
+
+	// HelmfilePlatforms the platforms helmfile binaries are available for
+	HelmfilePlatforms = append(append([]extensions.Platform{}, extensions.DefaultPlatforms...),
+		extensions.Platform{Goarch: "arm64", Goos: "Darwin"},
+		extensions.Platform{Goarch: "arm", Goos: "Linux"},
+	)
+
+	// PluginNames the names of the plugins which can be created with CreatePlugin
+	PluginNames = []string{HelmPluginName, HelmfilePluginName, KptPluginName, KustomizePluginName, KubectlPluginName, KappPluginName, ConftestPluginName}
 )
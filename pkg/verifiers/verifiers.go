@@ -0,0 +1,48 @@
+package verifiers
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/jenkins-x/jx-helpers/v3/pkg/termcolor"
+	"github.com/jenkins-x/jx-logging/v3/pkg/log"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Failure represents a resource which failed verification
+type Failure struct {
+	Path      string
+	Kind      string
+	Name      string
+	Namespace string
+	Message   string
+}
+
+// NewFailure creates a new failure for the given resource
+func NewFailure(node *yaml.RNode, path string, message string, args ...interface{}) Failure {
+	return Failure{
+		Path:      path,
+		Kind:      kyamls.GetKind(node, path),
+		Name:      kyamls.GetName(node, path),
+		Namespace: kyamls.GetNamespace(node, path),
+		Message:   fmt.Sprintf(message, args...),
+	}
+}
+
+// String returns a description of the failure
+func (f *Failure) String() string {
+	return fmt.Sprintf("%s %s in file %s: %s", f.Kind, termcolor.ColorInfo(f.Name), f.Path, f.Message)
+}
+
+// Report logs the failures and returns an error if there are any
+func Report(failures []Failure, description string) error {
+	for i := range failures {
+		log.Logger().Warn(failures[i].String())
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("found %d %s", len(failures), description)
+	}
+	log.Logger().Infof("no %s found", description)
+	return nil
+}
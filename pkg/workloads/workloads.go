@@ -0,0 +1,109 @@
+package workloads
+
+import (
+	"github.com/jenkins-x/jx-helpers/v3/pkg/kyamls"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	// PodSpecPaths the path to the pod spec for each kind of workload
+	PodSpecPaths = map[string][]string{
+		"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+		"DaemonSet":   {"spec", "template", "spec"},
+		"Deployment":  {"spec", "template", "spec"},
+		"Job":         {"spec", "template", "spec"},
+		"Pod":         {"spec"},
+		"ReplicaSet":  {"spec", "template", "spec"},
+		"StatefulSet": {"spec", "template", "spec"},
+	}
+)
+
+// GetPodSpec returns the pod spec of the workload or nil if the resource is not a workload
+func GetPodSpec(node *yaml.RNode, kind string) (*yaml.RNode, error) {
+	paths := PodSpecPaths[kind]
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	podSpec, err := node.Pipe(yaml.Lookup(paths...))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find pod spec of %s", kind)
+	}
+	return podSpec, nil
+}
+
+// GetContainers returns the containers of the pod spec along with the init containers if includeInit is true
+func GetContainers(podSpec *yaml.RNode, includeInit bool) ([]*yaml.RNode, error) {
+	var answer []*yaml.RNode
+	if podSpec == nil {
+		return answer, nil
+	}
+	names := []string{"containers"}
+	if includeInit {
+		names = []string{"initContainers", "containers"}
+	}
+	for _, name := range names {
+		containers, err := podSpec.Pipe(yaml.Lookup(name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find %s", name)
+		}
+		if containers == nil {
+			continue
+		}
+		elements, err := containers.Elements()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get elements of %s", name)
+		}
+		answer = append(answer, elements...)
+	}
+	return answer, nil
+}
+
+// ForEachContainer invokes the function on each container of the workload along with the init containers if includeInit is true
+func ForEachContainer(node *yaml.RNode, kind string, includeInit bool, fn func(container *yaml.RNode) error) error {
+	podSpec, err := GetPodSpec(node, kind)
+	if err != nil {
+		return err
+	}
+	containers, err := GetContainers(podSpec, includeInit)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get containers of %s", kind)
+	}
+	for _, c := range containers {
+		err = fn(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetContainerName returns the name of the container
+func GetContainerName(container *yaml.RNode) string {
+	return kyamls.GetStringField(container, "", "name")
+}
+
+// GetPodLabels returns the labels of the pods created by the workload or nil if the resource is not a workload
+func GetPodLabels(node *yaml.RNode, kind string) (map[string]string, error) {
+	paths := PodSpecPaths[kind]
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	labelPaths := append(append([]string{}, paths[:len(paths)-1]...), "metadata", "labels")
+	labels, err := node.Pipe(yaml.Lookup(labelPaths...))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find pod labels of %s", kind)
+	}
+	answer := map[string]string{}
+	if labels == nil {
+		return answer, nil
+	}
+	err = labels.VisitFields(func(n *yaml.MapNode) error {
+		answer[n.Key.YNode().Value] = n.Value.YNode().Value
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read pod labels of %s", kind)
+	}
+	return answer, nil
+}